@@ -8,52 +8,117 @@ import (
 	"log/slog"
 	"path"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/srerickson/ocfl-go"
+	ocflfs "github.com/srerickson/ocfl-go/fs"
 	"golang.org/x/sync/singleflight"
 )
 
-// min time between checking sidecar
+// default min time between checking sidecar (see WithRefreshInterval)
 const RefreshInterval = 20 * time.Second
 
+// number of objects between progress messages logged by IndexRoot
+const indexProgressInterval = 1000
+
 var ErrNotFound = errors.New("not found")
 
+// ErrForbidden is returned if the principal can read an object but isn't
+// allowed to change it.
+var ErrForbidden = errors.New("forbidden")
+
+// ErrCountsHidden is returned by Metrics and FixitySummary if the service has
+// an access policy: counts for the storage root would include objects that
+// the principal can't access.
+var ErrCountsHidden = errors.New("counts aren't available with an access policy")
+
 type Service struct {
 	root     *ocfl.Root
 	rootID   string
 	db       Database
 	inflight singleflight.Group
 	logger   *slog.Logger
+	policy   *Policy // access policy; nil allows all access
+
+	syncMode        SyncMode      // when accessed objects are checked (see WithSyncMode)
+	refreshInterval time.Duration // min time between checking an object's sidecar
+
+	policyMu       sync.Mutex
+	objectPolicies map[string]cachedObjectPolicy // by object ID, for objects with policy files
+
+	commitLocks keyedMutex // serializes commits by object ID
+}
+
+// ServiceOption is used to configure a Service created with NewService.
+type ServiceOption func(*Service)
+
+// WithPolicy sets the access policy used to check the principal's access to
+// objects. See Policy for details.
+func WithPolicy(pol *Policy) ServiceOption {
+	return func(s *Service) {
+		s.policy = pol
+	}
+}
+
+// WithRefreshInterval sets the min time between checking an indexed object's
+// inventory sidecar when the object is accessed, with the SyncInterval sync
+// mode. The default is RefreshInterval.
+func WithRefreshInterval(d time.Duration) ServiceOption {
+	return func(s *Service) {
+		s.refreshInterval = d
+	}
 }
 
 // NewServices initializes a new *Service for accessing an indexed OCFL storage
 // root.
-func NewService(root *ocfl.Root, db Database, rootID string, logger *slog.Logger) *Service {
+func NewService(root *ocfl.Root, db Database, rootID string, logger *slog.Logger, opts ...ServiceOption) *Service {
 	if logger == nil {
 		logger = slog.New(slog.DiscardHandler)
 	}
-	return &Service{
-		root:   root,
-		rootID: rootID,
-		db:     db,
-		logger: logger,
+	s := &Service{
+		root:            root,
+		rootID:          rootID,
+		db:              db,
+		logger:          logger,
+		refreshInterval: RefreshInterval,
+		objectPolicies:  map[string]cachedObjectPolicy{},
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 // SyncObject updates objID in the database if necessary and returns ObjectInfo. If
-// the object doesn't exist in the storage root, ErrNotFound is returned. If
-// RefreshInterval has not passed since the object was last indexed, the
-// existing index value is used. If the indexed object needs to be refreshed,
-// the OCFL object's inventory sidecar is compared to check if a full inventory
-// read is nessary.
+// the object doesn't exist in the storage root, ErrNotFound is returned.
+// Whether an indexed object is refreshed depends on the service's sync mode
+// (see WithSyncMode): by default, the existing index value is used if the
+// refresh interval has not passed since the object was last indexed. If the
+// indexed object needs to be refreshed, the OCFL object's inventory sidecar is
+// compared to check if a full inventory read is nessary. If the service has
+// an access policy that doesn't allow the principal in ctx to access the
+// object, ErrNotFound is returned.
 func (s *Service) SyncObject(ctx context.Context, objID string) (ObjectInfo, error) {
+	obj, err := s.refreshObject(ctx, objID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx, obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// refreshObject returns the index record for objID, syncing it with the
+// storage root if it isn't indexed or if the sync mode requires it.
+func (s *Service) refreshObject(ctx context.Context, objID string) (ObjectInfo, error) {
 	obj, err := s.db.GetObject(ctx, s.rootID, objID)
 	if err != nil && !errors.Is(err, ErrNotFound) {
 		return nil, err
 	}
-	if obj != nil && time.Now().Before(obj.IndexedAt().Add(RefreshInterval)) {
-		// Not enough time has passed since last sync. Use current value.
+	if obj != nil && !s.needsSync(obj) {
+		// Use current value.
 		return obj, nil
 	}
 	val, err, _ := s.inflight.Do("obj:"+objID, func() (any, error) {
@@ -68,10 +133,13 @@ func (s *Service) SyncObject(ctx context.Context, objID string) (ObjectInfo, err
 // GetVersionInfo returns basic information about a version. If vn < 1, the
 // object's most recent version is used.
 func (s *Service) GetVersionInfo(ctx context.Context, objID string, vn int) (VersionInfo, error) {
-	_, err := s.syncObjectCheckVersion(ctx, objID, vn)
+	obj, err := s.syncObjectCheckVersion(ctx, objID, vn)
 	if err != nil {
 		return nil, err
 	}
+	if vn < 1 {
+		vn = obj.Head().Num()
+	}
 	return s.db.GetObjectVersion(ctx, s.rootID, objID, vn)
 }
 
@@ -95,30 +163,172 @@ func (s *Service) GetVersionChanges(ctx context.Context, objID string, fromV, to
 	return s.db.GetObjectVersionChanges(ctx, s.rootID, objID, fromV, toV)
 }
 
-// IndexRoot indexes the all objects in the storage root. For duplicate calls,
-// the duplicate caller waits for the original to complete and receives the same
-// results.
-func (s *Service) IndexRoot(ctx context.Context) error {
-	_, err, _ := s.inflight.Do(s.rootID, func() (any, error) {
+// IndexOptions are used to configure IndexRoot.
+type IndexOptions struct {
+	// Full reads every object's root inventory. By default, an indexed
+	// object's inventory is only read if its inventory sidecar doesn't match
+	// the index.
+	Full bool
+}
+
+// IndexResult summarizes a storage root scan by IndexRoot.
+type IndexResult struct {
+	Objects int // objects found in the storage root and indexed
+	Updated int // indexed objects that were new or had changed inventories
+	Removed int // index records removed for objects that no longer exist
+	Errors  int // objects that couldn't be indexed
+}
+
+// IndexRoot indexes the all objects in the storage root. Objects in the index
+// with storage paths that weren't found during the scan are removed from the
+// index if they no longer have an object declaration, unless they were indexed
+// after the scan started or the storage root couldn't be fully scanned. For
+// duplicate calls with the same options, the duplicate caller waits for the
+// original to complete and receives the same results. Progress is logged with
+// the service's logger. If ctx is canceled, the scan stops and the context's
+// error is returned.
+func (s *Service) IndexRoot(ctx context.Context, opts IndexOptions) (IndexResult, error) {
+	key := fmt.Sprintf("index:%s:full=%t", s.rootID, opts.Full)
+	val, err, _ := s.inflight.Do(key, func() (any, error) {
+		var result IndexResult
+		var scanErrs int          // errors from reading object declarations
+		seen := map[string]bool{} // storage paths of objects found in the storage root
+		start := time.Now()
+		s.logger.Info("indexing storage root", "root_id", s.rootID)
 		for decl, err := range s.root.ObjectDeclarations(ctx) {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				s.logger.Warn("storage root indexing canceled",
+					"root_id", s.rootID, "objects", result.Objects)
+				return result, ctxErr
+			}
 			if err != nil {
 				s.logger.Error(err.Error())
+				result.Errors++
+				scanErrs++
 				continue
 			}
 			objPath := path.Dir(decl.FullPath())
-			objInfo, err := s.db.GetObjectByPath(ctx, s.rootID, objPath)
+			// objects that can't be indexed are still in the storage root
+			seen[objPath] = true
+			prev, err := s.db.GetObjectByPath(ctx, s.rootID, objPath)
 			if err != nil && !errors.Is(err, ErrNotFound) {
 				s.logger.Error(err.Error(), "storage_path", objPath)
+				result.Errors++
 				continue
 			}
-			if _, err := s.syncObjectPath(ctx, objPath, objInfo); err != nil {
+			sidecarPrev := prev
+			if opts.Full {
+				sidecarPrev = nil
+			}
+			obj, err := s.syncObjectPath(ctx, objPath, sidecarPrev)
+			if err != nil {
 				s.logger.Error(err.Error(), "storage_path", objPath)
+				result.Errors++
 				continue
 			}
+			result.Objects++
+			if prev == nil || prev.InventoryDigest() != obj.InventoryDigest() {
+				result.Updated++
+			}
+			if result.Objects%indexProgressInterval == 0 {
+				s.logger.Info("indexing storage root", "root_id", s.rootID,
+					"objects", result.Objects, "errors", result.Errors)
+			}
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return result, ctxErr
+		}
+		if scanErrs > 0 {
+			s.logger.Warn("not removing missing objects from index: storage root wasn't fully scanned",
+				"root_id", s.rootID, "errors", scanErrs)
+		} else {
+			// indexed_at has a precision of seconds, so objects indexed in the
+			// second the scan started are candidates too: the storage root is
+			// checked to confirm that unseen objects are gone.
+			keep := func(objPath string) bool {
+				return seen[objPath] || s.objectPathExists(ctx, objPath)
+			}
+			removed, err := s.db.PruneObjects(ctx, s.rootID, start, keep)
+			if err != nil {
+				return result, fmt.Errorf("removing missing objects from index: %w", err)
+			}
+			for _, id := range removed {
+				s.logger.Info("removed missing object from index", "object_id", id)
+			}
+			result.Removed = len(removed)
 		}
-		return nil, nil
+		s.logger.Info("finished indexing storage root", "root_id", s.rootID,
+			"objects", result.Objects, "updated", result.Updated,
+			"removed", result.Removed, "errors", result.Errors,
+			"duration", time.Since(start))
+		return result, nil
 	})
-	return err
+	result, _ := val.(IndexResult)
+	return result, err
+}
+
+// ListObjects returns a page of objects from the index. Objects that haven't
+// been indexed yet are not included. If the service has an access policy,
+// opts.Offset and opts.Limit apply to the objects that the principal in ctx
+// can access.
+func (s *Service) ListObjects(ctx context.Context, opts ListObjectOptions) ([]ObjectInfo, error) {
+	if s.policy == nil {
+		return s.db.ListObjects(ctx, s.rootID, opts)
+	}
+	list := func(offset, limit int) ([]ObjectInfo, error) {
+		opts.Offset, opts.Limit = offset, limit
+		return s.db.ListObjects(ctx, s.rootID, opts)
+	}
+	return filterPage(opts.Offset, opts.Limit, list, s.objectAllowed(ctx))
+}
+
+// SearchObjects returns indexed objects with IDs matching query. As with
+// ListObjects, opts.Offset and opts.Limit apply to the objects that the
+// principal in ctx can access.
+func (s *Service) SearchObjects(ctx context.Context, query string, opts SearchObjectOptions) ([]ObjectInfo, error) {
+	if s.policy == nil {
+		return s.db.SearchObjects(ctx, s.rootID, query, opts)
+	}
+	list := func(offset, limit int) ([]ObjectInfo, error) {
+		opts.Offset, opts.Limit = offset, limit
+		return s.db.SearchObjects(ctx, s.rootID, query, opts)
+	}
+	return filterPage(opts.Offset, opts.Limit, list, s.objectAllowed(ctx))
+}
+
+// SearchContent returns object versions with messages, user names, or logical
+// paths matching the query. As with ListObjects, opts.Offset and opts.Limit
+// apply to versions of objects that the principal in ctx can access.
+func (s *Service) SearchContent(ctx context.Context, query string, opts SearchContentOptions) ([]ContentSearchResult, error) {
+	if s.policy == nil {
+		return s.db.SearchContent(ctx, s.rootID, query, opts)
+	}
+	list := func(offset, limit int) ([]ContentSearchResult, error) {
+		opts.Offset, opts.Limit = offset, limit
+		return s.db.SearchContent(ctx, s.rootID, query, opts)
+	}
+	allowed := map[string]bool{} // by object ID
+	return filterPage(opts.Offset, opts.Limit, list, func(result ContentSearchResult) (bool, error) {
+		id := result.ObjectID()
+		if ok, checked := allowed[id]; checked {
+			return ok, nil
+		}
+		obj, err := s.db.GetObject(ctx, s.rootID, id)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return false, err
+		}
+		allowed[id] = obj != nil && s.authorize(ctx, obj) == nil
+		return allowed[id], nil
+	})
+}
+
+// Metrics returns counts for objects in the index. If the service has an
+// access policy, it returns ErrCountsHidden.
+func (s *Service) Metrics(ctx context.Context) (Metrics, error) {
+	if s.policy != nil {
+		return Metrics{}, ErrCountsHidden
+	}
+	return s.db.Metrics(ctx, s.rootID)
 }
 
 func (s *Service) Logger() *slog.Logger { return s.logger }
@@ -145,6 +355,65 @@ func (s *Service) OpenVersionFile(ctx context.Context, objID string, vn int, nam
 	return f, nil
 }
 
+// OpenVersionFileReader returns a *VersionFile for reading and seeking the
+// contents of a file in an object version. If vn is < 1, the object's most
+// recent version is used. The file's media type is determined by the extension
+// of its logical path. If the extension doesn't have a known type, the type is
+// detected from the file's contents, which is indexed for the file's digest.
+func (s *Service) OpenVersionFileReader(ctx context.Context, objID string, vn int, name string) (*VersionFile, error) {
+	obj, err := s.syncObjectCheckVersion(ctx, objID, vn)
+	if err != nil {
+		return nil, err
+	}
+	if vn < 1 {
+		vn = obj.Head().Num()
+	}
+	info, err := s.db.StatObjectVersionFile(ctx, s.rootID, objID, vn, name)
+	if err != nil {
+		return nil, err
+	}
+	filePath := path.Join(obj.StoragePath(), info.ContentPath())
+	reader, err := OpenFileReader(ctx, s.root.FS(), filePath)
+	if err != nil {
+		return nil, err
+	}
+	// Content paths may not have the logical path's extension, and the same
+	// content may have logical paths with different extensions, so only
+	// types detected from contents are cached in the index.
+	mediaType := MediaTypeByExtension(name)
+	if mediaType == "" {
+		mediaType = info.MediaType()
+	}
+	if mediaType == "" {
+		mediaType, err = SniffMediaType(reader)
+		if err != nil {
+			reader.Close()
+			return nil, err
+		}
+		// failing to save the detected type isn't fatal.
+		err = s.db.SetObjectFileMediaType(ctx, s.rootID, objID, info.Digest(), mediaType)
+		if err != nil {
+			s.logger.LogAttrs(ctx, slog.LevelError, "saving media type: "+err.Error(),
+				slog.String("object_id", objID),
+				slog.String("path", name))
+		}
+	}
+	return &VersionFile{FileReader: reader, info: info, mediaType: mediaType}, nil
+}
+
+// StatVersionFile returns information about the file name in the given object
+// version's state. If vn is < 1, the object's most recent version is used.
+func (s *Service) StatVersionFile(ctx context.Context, objID string, vn int, name string) (VersionFileInfo, error) {
+	obj, err := s.syncObjectCheckVersion(ctx, objID, vn)
+	if err != nil {
+		return nil, err
+	}
+	if vn < 1 {
+		vn = obj.Head().Num()
+	}
+	return s.db.StatObjectVersionFile(ctx, s.rootID, objID, vn, name)
+}
+
 // ReadVersionDir returns a slice of directory entries for the contents of the
 // directory dir in the given object version's state.
 func (s *Service) ReadVersionDir(ctx context.Context, objID string, vn int, dir string) ([]VersionDirEntry, error) {
@@ -155,6 +424,20 @@ func (s *Service) ReadVersionDir(ctx context.Context, objID string, vn int, dir
 	return s.db.ReadObjectVersionDir(ctx, s.rootID, objID, vn, dir)
 }
 
+// ListVersionFiles returns information for all files in the directory dir of
+// the given object version's state, including files in sub-directories. Files
+// are ordered by logical path.
+func (s *Service) ListVersionFiles(ctx context.Context, objID string, vn int, dir string) ([]VersionFileInfo, error) {
+	obj, err := s.syncObjectCheckVersion(ctx, objID, vn)
+	if err != nil {
+		return nil, err
+	}
+	if vn < 1 {
+		vn = obj.Head().Num()
+	}
+	return s.db.ReadObjectVersionFiles(ctx, s.rootID, objID, vn, dir)
+}
+
 // Root returns the service's OCFL Storage Root.
 func (s *Service) Root() *ocfl.Root { return s.root }
 
@@ -213,6 +496,17 @@ func (s *Service) syncObject(ctx context.Context, objID string, prev ObjectInfo)
 	return s.db.GetObject(ctx, s.rootID, objID)
 }
 
+// objectPathExists reports whether the storage root has an object declaration
+// in objPath. If the directory can't be read for reasons other than not
+// existing, it's assumed to exist.
+func (s *Service) objectPathExists(ctx context.Context, objPath string) bool {
+	entries, err := ocflfs.ReadDir(ctx, s.root.FS(), objPath)
+	if err != nil {
+		return !errors.Is(err, fs.ErrNotExist)
+	}
+	return ocfl.ParseObjectDir(entries).HasNamaste()
+}
+
 // sync using path instead of ID
 func (s *Service) syncObjectPath(ctx context.Context, objPath string, prev ObjectInfo) (ObjectInfo, error) {
 	if prev != nil {
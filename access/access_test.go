@@ -1,7 +1,9 @@
 package access_test
 
 import (
+	"context"
 	"errors"
+	"io"
 	"log/slog"
 	"path/filepath"
 	"slices"
@@ -12,8 +14,8 @@ import (
 	"github.com/carlmjohnson/be"
 	"github.com/srerickson/ocfl-go"
 	"github.com/srerickson/ocfl-go/digest"
+	ocflfs "github.com/srerickson/ocfl-go/fs"
 	"github.com/srerickson/ocfl-services/access"
-	"github.com/srerickson/ocfl-services/access/sqlite"
 	"github.com/srerickson/ocfl-services/internal/testutil"
 )
 
@@ -70,6 +72,23 @@ func TestRepo_SyncObject(t *testing.T) {
 			}
 		})
 	})
+
+	t.Run("custom refresh interval", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			ctx := t.Context()
+			r := testService(t, access.WithRefreshInterval(time.Hour))
+			first, err := r.SyncObject(ctx, fixtureObjectID)
+			be.NilErr(t, err)
+			time.Sleep(access.RefreshInterval)
+			second, err := r.SyncObject(ctx, fixtureObjectID)
+			be.NilErr(t, err)
+			be.True(t, first.IndexedAt().Equal(second.IndexedAt()))
+			time.Sleep(time.Hour)
+			third, err := r.SyncObject(ctx, fixtureObjectID)
+			be.NilErr(t, err)
+			be.True(t, third.IndexedAt().After(first.IndexedAt()))
+		})
+	})
 }
 
 func TestRepo_IndexRoot(t *testing.T) {
@@ -77,8 +96,9 @@ func TestRepo_IndexRoot(t *testing.T) {
 		synctest.Test(t, func(t *testing.T) {
 			ctx := t.Context()
 			svc := testService(t)
-			err := svc.IndexRoot(ctx)
+			result, err := svc.IndexRoot(ctx, access.IndexOptions{})
 			be.NilErr(t, err)
+			be.Equal(t, access.IndexResult{Objects: 1, Updated: 1}, result)
 			time.Sleep(time.Second)
 			syncedAt := time.Now()
 			obj, err := svc.SyncObject(ctx, fixtureObjectID)
@@ -87,6 +107,85 @@ func TestRepo_IndexRoot(t *testing.T) {
 			be.True(t, obj.IndexedAt().Before(syncedAt))
 		})
 	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		ctx := t.Context()
+		svc := testService(t)
+		_, err := svc.IndexRoot(ctx, access.IndexOptions{})
+		be.NilErr(t, err)
+		result, err := svc.IndexRoot(ctx, access.IndexOptions{})
+		be.NilErr(t, err)
+		be.Equal(t, access.IndexResult{Objects: 1}, result)
+		result, err = svc.IndexRoot(ctx, access.IndexOptions{Full: true})
+		be.NilErr(t, err)
+		be.Equal(t, access.IndexResult{Objects: 1}, result)
+	})
+
+	t.Run("removed objects", func(t *testing.T) {
+		ctx := t.Context()
+		svc := testService(t)
+		obj, err := svc.SyncObject(ctx, fixtureObjectID)
+		be.NilErr(t, err)
+		be.NilErr(t, ocflfs.RemoveAll(ctx, svc.Root().FS(), obj.StoragePath()))
+		result, err := svc.IndexRoot(ctx, access.IndexOptions{})
+		be.NilErr(t, err)
+		be.Equal(t, access.IndexResult{Removed: 1}, result)
+		metrics, err := svc.Metrics(ctx)
+		be.NilErr(t, err)
+		be.Equal(t, 0, metrics.NumObjects)
+		objects, err := svc.ListObjects(ctx, access.ListObjectOptions{Limit: 10})
+		be.NilErr(t, err)
+		be.Equal(t, 0, len(objects))
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		svc := testService(t)
+		_, err := svc.IndexRoot(ctx, access.IndexOptions{})
+		be.True(t, errors.Is(err, context.Canceled))
+	})
+
+	t.Run("full scan during scan", func(t *testing.T) {
+		ctx := t.Context()
+		db := &blockingLookups{entered: make(chan struct{}, 2), release: make(chan struct{})}
+		svc := testServiceWithDB(t, func(inner access.Database) access.Database {
+			db.Database = inner
+			return db
+		})
+		done := make(chan error, 2)
+		index := func(opts access.IndexOptions) {
+			_, err := svc.IndexRoot(ctx, opts)
+			done <- err
+		}
+		go index(access.IndexOptions{})
+		<-db.entered
+		// the full scan doesn't wait for the result of the other scan
+		go index(access.IndexOptions{Full: true})
+		select {
+		case <-db.entered:
+		case <-time.After(5 * time.Second):
+			t.Fatal("full scan didn't start during the other scan")
+		}
+		close(db.release)
+		for range 2 {
+			be.NilErr(t, <-done)
+		}
+	})
+}
+
+// blockingLookups is an access.Database that signals entered when
+// GetObjectByPath is called and blocks the call until release is closed.
+type blockingLookups struct {
+	access.Database
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (db *blockingLookups) GetObjectByPath(ctx context.Context, rootID string, objPath string) (access.ObjectInfo, error) {
+	db.entered <- struct{}{}
+	<-db.release
+	return db.Database.GetObjectByPath(ctx, rootID, objPath)
 }
 
 func TestRepo_ReadVersionDir(t *testing.T) {
@@ -183,24 +282,26 @@ func TestRepo_ReadVersionDir(t *testing.T) {
 
 }
 
-func testService(t *testing.T) *access.Service {
+func testService(t *testing.T, opts ...access.ServiceOption) *access.Service {
+	t.Helper()
+	return testServiceWithDB(t, nil, opts...)
+}
+
+// testServiceWithDB is like testService, but if wrap isn't nil, the service
+// uses the database that wrap returns for the test database.
+func testServiceWithDB(t *testing.T, wrap func(access.Database) access.Database, opts ...access.ServiceOption) *access.Service {
 	t.Helper()
-	tempDir := t.TempDir()
-	dbPath := filepath.Join(tempDir, "test_database.db")
-	indexer, err := sqlite.NewDB(dbPath)
-	if err != nil {
-		t.Fatal("setting up test indexer: ", err)
+	var db access.Database = testutil.NewDB(t)
+	if wrap != nil {
+		db = wrap(db)
 	}
-	t.Cleanup(func() {
-		indexer.Close()
-	})
 	root := testutil.FixtureRootCopy(t, filepath.Join(`..`, `testdata`))
 	rootName := "test-root"
 	var logger *slog.Logger
 	// logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
 	// 	Level: slog.LevelDebug,
 	// }))
-	return access.NewService(root, indexer, rootName, logger)
+	return access.NewService(root, db, rootName, logger, opts...)
 }
 
 // testStateDirEntry is a simple implementation of StateDirEntry for testing
@@ -273,3 +374,35 @@ func eqStateDirEntry(a, b access.VersionDirEntry) bool {
 		a.HasSize() == b.HasSize() &&
 		a.IsDir() == b.IsDir()
 }
+
+func TestService_OpenVersionFileReader(t *testing.T) {
+	ctx := t.Context()
+	service := testService(t)
+	f, err := service.OpenVersionFileReader(ctx, fixtureObjectID, 1, "a_file.txt")
+	be.NilErr(t, err)
+	be.Equal(t, "text/plain; charset=utf-8", f.MediaType())
+	// media type detection doesn't change the reader's offset
+	content, err := io.ReadAll(f)
+	be.NilErr(t, err)
+	be.Equal(t, "Hello! I am a file.\n", string(content))
+	be.NilErr(t, f.Close())
+
+	// types from logical path extensions aren't saved in the index
+	f, err = service.OpenVersionFileReader(ctx, fixtureObjectID, 1, "a_file.txt")
+	be.NilErr(t, err)
+	be.Equal(t, "", f.Info().MediaType())
+	be.NilErr(t, f.Close())
+
+	// types detected from contents are saved in the index
+	f, err = service.OpenVersionFileReader(ctx, fixtureObjectID, 2, "exampl/folder/justfile")
+	be.NilErr(t, err)
+	be.Equal(t, "text/plain; charset=utf-8", f.MediaType())
+	be.NilErr(t, f.Close())
+	f, err = service.OpenVersionFileReader(ctx, fixtureObjectID, 2, "exampl/folder/justfile")
+	be.NilErr(t, err)
+	defer f.Close()
+	be.Equal(t, "text/plain; charset=utf-8", f.Info().MediaType())
+
+	_, err = service.OpenVersionFileReader(ctx, fixtureObjectID, 1, "missing.txt")
+	be.True(t, errors.Is(err, access.ErrNotFound))
+}
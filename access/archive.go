@@ -0,0 +1,208 @@
+package access
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"time"
+
+	ocflfs "github.com/srerickson/ocfl-go/fs"
+)
+
+// ArchiveFormat is a file format used to download a directory of an object
+// version's state.
+type ArchiveFormat string
+
+const (
+	ArchiveZip   ArchiveFormat = "zip"    // zip file with deflate compression
+	ArchiveTarGz ArchiveFormat = "tar.gz" // gzip-compressed tar file
+)
+
+// ParseArchiveFormat parses the string s as an ArchiveFormat.
+func ParseArchiveFormat(s string) (ArchiveFormat, error) {
+	switch format := ArchiveFormat(s); format {
+	case ArchiveZip, ArchiveTarGz:
+		return format, nil
+	default:
+		return "", fmt.Errorf("invalid archive format: %q", s)
+	}
+}
+
+// MediaType returns the media type for archives in the format.
+func (f ArchiveFormat) MediaType() string {
+	switch f {
+	case ArchiveZip:
+		return "application/zip"
+	case ArchiveTarGz:
+		return "application/gzip"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// VersionArchive is used to write the files in a directory of an object
+// version's state to an archive. Files in the archive are named with their
+// logical paths and their modification times are set to the version's created
+// timestamp.
+type VersionArchive struct {
+	fsys    ocflfs.FS
+	objPath string // object's storage path
+	created time.Time
+	files   []VersionFileInfo
+	sizes   []int64 // file sizes, by index in files
+}
+
+// NewVersionArchive returns a *VersionArchive for the files in the directory
+// dir of an object version's state. If vn < 1, the object's most recent
+// version is used. The sizes of files that aren't indexed are read from
+// storage, so Size is accurate before the archive is written. File contents
+// aren't read until the archive is written.
+func (s *Service) NewVersionArchive(ctx context.Context, objID string, vn int, dir string) (*VersionArchive, error) {
+	obj, err := s.syncObjectCheckVersion(ctx, objID, vn)
+	if err != nil {
+		return nil, err
+	}
+	if vn < 1 {
+		vn = obj.Head().Num()
+	}
+	ver, err := s.db.GetObjectVersion(ctx, s.rootID, objID, vn)
+	if err != nil {
+		return nil, err
+	}
+	files, err := s.db.ReadObjectVersionFiles(ctx, s.rootID, objID, vn, dir)
+	if err != nil {
+		return nil, err
+	}
+	archive := &VersionArchive{
+		fsys:    s.root.FS(),
+		objPath: obj.StoragePath(),
+		created: ver.Created(),
+		files:   files,
+		sizes:   make([]int64, len(files)),
+	}
+	for i, info := range files {
+		if info.HasSize() {
+			archive.sizes[i] = info.Size()
+			continue
+		}
+		stat, err := ocflfs.StatFile(ctx, archive.fsys, archive.contentPath(info))
+		if err != nil {
+			return nil, err
+		}
+		archive.sizes[i] = stat.Size()
+	}
+	return archive, nil
+}
+
+// NumFiles returns the number of files in the archive.
+func (a *VersionArchive) NumFiles() int { return len(a.files) }
+
+// Size returns the total size in bytes of the files in the archive. Write
+// copies exactly this many bytes of file content.
+func (a *VersionArchive) Size() int64 {
+	var size int64
+	for _, s := range a.sizes {
+		size += s
+	}
+	return size
+}
+
+// Write streams the archive to w in the given format. File contents are
+// copied to w as they are read from storage. If ctx is canceled, writing stops
+// and the context's error is returned. If an error occurs, the archive written
+// to w is incomplete.
+func (a *VersionArchive) Write(ctx context.Context, w io.Writer, format ArchiveFormat) error {
+	switch format {
+	case ArchiveZip:
+		return a.writeZip(ctx, w)
+	case ArchiveTarGz:
+		return a.writeTarGz(ctx, w)
+	default:
+		return fmt.Errorf("invalid archive format: %q", format)
+	}
+}
+
+func (a *VersionArchive) writeZip(ctx context.Context, w io.Writer) error {
+	zw := zip.NewWriter(w)
+	for i, info := range a.files {
+		header := &zip.FileHeader{
+			Name:     info.Path(),
+			Method:   zip.Deflate,
+			Modified: a.created,
+		}
+		header.SetMode(0644)
+		fileWriter, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		if err := a.copyFile(ctx, fileWriter, info, a.sizes[i]); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func (a *VersionArchive) writeTarGz(ctx context.Context, w io.Writer) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	for i, info := range a.files {
+		size := a.sizes[i]
+		header := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     info.Path(),
+			Size:     size,
+			Mode:     0644,
+			ModTime:  a.created,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if err := a.copyFile(ctx, tw, info, size); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// copyFile copies the content of the file to w. Size is the exact number of
+// bytes that must be copied.
+func (a *VersionArchive) copyFile(ctx context.Context, w io.Writer, info VersionFileInfo, size int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f, err := a.fsys.OpenFile(ctx, a.contentPath(info))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	reader := &contextReader{ctx: ctx, reader: f}
+	if _, err := io.CopyN(w, reader, size); err != nil {
+		return fmt.Errorf("copying %q to archive: %w", info.Path(), err)
+	}
+	return nil
+}
+
+func (a *VersionArchive) contentPath(info VersionFileInfo) string {
+	return path.Join(a.objPath, info.ContentPath())
+}
+
+// contextReader is an io.Reader that stops reading when its context is
+// canceled.
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.reader.Read(p)
+}
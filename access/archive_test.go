@@ -0,0 +1,100 @@
+package access_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/be"
+	"github.com/srerickson/ocfl-go"
+	"github.com/srerickson/ocfl-go/digest"
+	"github.com/srerickson/ocfl-services/access"
+)
+
+func TestService_NewVersionArchive(t *testing.T) {
+	ctx := t.Context()
+	svc := testService(t)
+	objID := "archive-object"
+	created := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	content := map[string][]byte{
+		"a.txt":         []byte("file a"),
+		"dir/b.txt":     []byte("file b"),
+		"dir/sub/c.txt": []byte("file c"),
+	}
+	obj, err := svc.Root().NewObject(ctx, objID)
+	be.NilErr(t, err)
+	stage, err := ocfl.StageBytes(content, digest.SHA256)
+	be.NilErr(t, err)
+	_, err = obj.Update(ctx, stage, "v1", ocfl.User{Name: "tester"}, ocfl.UpdateWithVersionCreated(created))
+	be.NilErr(t, err)
+
+	t.Run("zip", func(t *testing.T) {
+		archive, err := svc.NewVersionArchive(ctx, objID, 0, ".")
+		be.NilErr(t, err)
+		be.Equal(t, 3, archive.NumFiles())
+		be.Equal(t, int64(18), archive.Size())
+		var buf bytes.Buffer
+		be.NilErr(t, archive.Write(ctx, &buf, access.ArchiveZip))
+		zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		be.NilErr(t, err)
+		be.Equal(t, 3, len(zr.File))
+		for _, f := range zr.File {
+			be.True(t, f.Modified.Equal(created))
+			rc, err := f.Open()
+			be.NilErr(t, err)
+			got, err := io.ReadAll(rc)
+			be.NilErr(t, err)
+			rc.Close()
+			be.Equal(t, string(content[f.Name]), string(got))
+		}
+	})
+
+	t.Run("tar.gz sub-directory", func(t *testing.T) {
+		archive, err := svc.NewVersionArchive(ctx, objID, 1, "dir")
+		be.NilErr(t, err)
+		var buf bytes.Buffer
+		be.NilErr(t, archive.Write(ctx, &buf, access.ArchiveTarGz))
+		gr, err := gzip.NewReader(&buf)
+		be.NilErr(t, err)
+		tr := tar.NewReader(gr)
+		var names []string
+		for {
+			header, err := tr.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			be.NilErr(t, err)
+			be.True(t, header.ModTime.Equal(created))
+			got, err := io.ReadAll(tr)
+			be.NilErr(t, err)
+			be.Equal(t, string(content[header.Name]), string(got))
+			names = append(names, header.Name)
+		}
+		be.AllEqual(t, []string{"dir/b.txt", "dir/sub/c.txt"}, names)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		archive, err := svc.NewVersionArchive(ctx, objID, 1, ".")
+		be.NilErr(t, err)
+		canceledCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		err = archive.Write(canceledCtx, io.Discard, access.ArchiveZip)
+		be.True(t, errors.Is(err, context.Canceled))
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		_, err := svc.NewVersionArchive(ctx, objID, 1, "missing")
+		be.True(t, errors.Is(err, access.ErrNotFound))
+	})
+
+	t.Run("missing version", func(t *testing.T) {
+		_, err := svc.NewVersionArchive(ctx, objID, 2, ".")
+		be.True(t, errors.Is(err, access.ErrNotFound))
+	})
+}
@@ -0,0 +1,182 @@
+package access
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/srerickson/ocfl-go"
+	"github.com/srerickson/ocfl-go/digest"
+)
+
+// ErrConflict is returned by CommitVersion if the object's head version isn't
+// the commit's expected head, as when another commit to the object finished
+// first.
+var ErrConflict = errors.New("object version conflict")
+
+// ErrInvalidCommit is returned by CommitVersion if the commit's changes can't
+// be applied to the object.
+var ErrInvalidCommit = errors.New("invalid commit")
+
+// Commit describes a new object version created with CommitVersion. The new
+// version's state is the previous version's state without the Remove paths,
+// plus the files in Content, which replace existing files with the same
+// logical paths.
+type Commit struct {
+	Head    int       // the object's expected head version number; 0 for new objects
+	Message string    // version message
+	User    ocfl.User // version user; the name is required
+	Content Stager    // new content; may be nil
+	Remove  []string  // logical paths of files or directories to remove
+}
+
+// Stager is a source of new content for a Commit.
+type Stager interface {
+	// Stage digests the content with alg and returns an *ocfl.Stage with the
+	// content's logical paths.
+	Stage(ctx context.Context, alg digest.Algorithm) (*ocfl.Stage, error)
+}
+
+// CommitVersion creates a new version of the object objID (creating the
+// object if necessary) and updates the index with the new version. If the
+// object's current head isn't commit.Head, ErrConflict is returned. Commits to
+// the same object are serialized, so a client that commits with the head
+// version it last read never overwrites changes it hasn't seen.
+//
+// The service's access policy must allow the principal in ctx to write the
+// object: if the principal can't read the object, ErrNotFound is returned;
+// if it can read but not write the object, or if there is no policy,
+// ErrForbidden is returned. Commits that add, change, or remove the object
+// policy file also require the principal to be one of the policy's admins.
+func (s *Service) CommitVersion(ctx context.Context, objID string, commit *Commit) (ObjectInfo, error) {
+	if commit.User.Name == "" {
+		return nil, fmt.Errorf("%w: the version user's name is required", ErrInvalidCommit)
+	}
+	unlock := s.commitLocks.lock(objID)
+	defer unlock()
+	obj, err := s.root.NewObject(ctx, objID)
+	if err != nil {
+		return nil, fmt.Errorf("with object_id=%q: %w", objID, err)
+	}
+	if err := s.authorizeCommit(ctx, obj); err != nil {
+		return nil, err
+	}
+	if head := obj.Head().Num(); head != commit.Head {
+		return nil, fmt.Errorf("with object_id=%q: head is v%d, not v%d: %w", objID, head, commit.Head, ErrConflict)
+	}
+	alg := obj.DigestAlgorithm()
+	stage := &ocfl.Stage{State: ocfl.DigestMap{}, DigestAlgorithm: alg}
+	if obj.Exists() {
+		stage = obj.VersionStage(0)
+		paths := stage.State.PathMap()
+		for _, name := range commit.Remove {
+			name = strings.Trim(name, "/")
+			if !fs.ValidPath(name) || name == "." {
+				return nil, fmt.Errorf("%w: invalid path to remove: %q", ErrInvalidCommit, name)
+			}
+			for p := range paths {
+				if p == name || strings.HasPrefix(p, name+"/") {
+					delete(paths, p)
+				}
+			}
+		}
+		stage.State = paths.DigestMap()
+	}
+	if commit.Content != nil {
+		content, err := commit.Content.Stage(ctx, alg)
+		if err != nil {
+			return nil, err
+		}
+		if err := stage.Overlay(content); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidCommit, err)
+		}
+	}
+	if obj.Exists() && stage.State.Eq(obj.Version(0).State()) {
+		return nil, fmt.Errorf("%w: the version state is unchanged", ErrInvalidCommit)
+	}
+	if s.changesPolicyFile(obj, stage) {
+		if err := s.authorizeAdmin(ctx); err != nil {
+			return nil, fmt.Errorf("with object_id=%q: changing %s: %w", objID, s.policy.ObjectPolicyFile, err)
+		}
+	}
+	newHead := commit.Head + 1
+	_, err = obj.Update(ctx, stage, commit.Message, commit.User, ocfl.UpdateWithNewHead(newHead))
+	if err != nil {
+		return nil, fmt.Errorf("with object_id=%q: %w", objID, err)
+	}
+	s.logger.LogAttrs(ctx, slog.LevelInfo, "committed object version",
+		slog.String("object_id", objID),
+		slog.Int("version", newHead))
+	if err := s.db.SetObject(ctx, s.rootID, obj); err != nil {
+		return nil, err
+	}
+	return s.db.GetObject(ctx, s.rootID, objID)
+}
+
+// authorizeCommit returns an error if the principal in ctx can't write obj,
+// which may not exist yet (see authorizeWrite).
+func (s *Service) authorizeCommit(ctx context.Context, obj *ocfl.Object) error {
+	var info ObjectInfo
+	if obj.Exists() && s.policy != nil {
+		var err error
+		info, err = s.refreshObject(ctx, obj.ID())
+		if err != nil {
+			return fmt.Errorf("with object_id=%q: %w", obj.ID(), err)
+		}
+	}
+	return s.authorizeWrite(ctx, obj.ID(), obj.Path(), info)
+}
+
+// changesPolicyFile reports whether the object policy file in stage's state
+// is different from the file in obj's head version.
+func (s *Service) changesPolicyFile(obj *ocfl.Object, stage *ocfl.Stage) bool {
+	if s.policy == nil || s.policy.ObjectPolicyFile == "" {
+		return false
+	}
+	name := strings.Trim(s.policy.ObjectPolicyFile, "/")
+	var prev string
+	if obj.Exists() {
+		prev = obj.Version(0).State().PathMap()[name]
+	}
+	return stage.State.PathMap()[name] != prev
+}
+
+// keyedMutex provides a mutex for each key.
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	sync.Mutex
+	refs int // number of callers holding or waiting for the lock
+}
+
+// lock locks the mutex for key and returns a function to unlock it.
+func (m *keyedMutex) lock(key string) func() {
+	m.mu.Lock()
+	if m.locks == nil {
+		m.locks = map[string]*keyedLock{}
+	}
+	l := m.locks[key]
+	if l == nil {
+		l = &keyedLock{}
+		m.locks[key] = l
+	}
+	l.refs++
+	m.mu.Unlock()
+	l.Lock()
+	return func() {
+		l.Unlock()
+		m.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(m.locks, key)
+		}
+		m.mu.Unlock()
+	}
+}
@@ -0,0 +1,209 @@
+package access_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/carlmjohnson/be"
+	"github.com/srerickson/ocfl-go"
+	"github.com/srerickson/ocfl-go/digest"
+	"github.com/srerickson/ocfl-services/access"
+)
+
+func TestService_CommitVersion(t *testing.T) {
+	ctx := t.Context()
+	svc := testWriteService(t)
+	user := ocfl.User{Name: "Tester", Address: "mailto:tester@example.com"}
+
+	t.Run("new object", func(t *testing.T) {
+		obj, err := svc.CommitVersion(ctx, "new-object", &access.Commit{
+			Message: "first version",
+			User:    user,
+			Content: testContent{"a.txt": []byte("a"), "dir/b.txt": []byte("b")},
+		})
+		be.NilErr(t, err)
+		be.Equal(t, "new-object", obj.ID())
+		be.Equal(t, ocfl.V(1), obj.Head())
+		ver, err := svc.GetVersionInfo(ctx, "new-object", 1)
+		be.NilErr(t, err)
+		be.Equal(t, "first version", ver.Message())
+		be.Equal(t, "Tester", ver.UserName())
+		be.Equal(t, "mailto:tester@example.com", ver.UserAddr())
+		f, err := svc.OpenVersionFile(ctx, "new-object", 1, "dir/b.txt")
+		be.NilErr(t, err)
+		defer f.Close()
+		b, err := io.ReadAll(f)
+		be.NilErr(t, err)
+		be.Equal(t, "b", string(b))
+	})
+
+	t.Run("update with remove", func(t *testing.T) {
+		obj, err := svc.CommitVersion(ctx, "new-object", &access.Commit{
+			Head:    1,
+			Message: "second version",
+			User:    user,
+			Content: testContent{"a.txt": []byte("new a")},
+			Remove:  []string{"dir"},
+		})
+		be.NilErr(t, err)
+		be.Equal(t, ocfl.V(2), obj.Head())
+		entries, err := svc.ReadVersionDir(ctx, "new-object", 2, ".")
+		be.NilErr(t, err)
+		be.Equal(t, 1, len(entries))
+		be.Equal(t, "a.txt", entries[0].Name())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := svc.CommitVersion(ctx, "new-object", &access.Commit{
+			Head:    1,
+			User:    user,
+			Content: testContent{"c.txt": []byte("c")},
+		})
+		be.True(t, errors.Is(err, access.ErrConflict))
+		_, err = svc.CommitVersion(ctx, "new-object", &access.Commit{
+			Head:    2,
+			User:    user,
+			Content: testContent{"a.txt": []byte("new a")},
+		})
+		be.True(t, errors.Is(err, access.ErrInvalidCommit))
+		_, err = svc.CommitVersion(ctx, "new-object", &access.Commit{
+			Head:   2,
+			User:   user,
+			Remove: []string{"../a.txt"},
+		})
+		be.True(t, errors.Is(err, access.ErrInvalidCommit))
+		_, err = svc.CommitVersion(ctx, "new-object", &access.Commit{
+			Head:    2,
+			Content: testContent{"c.txt": []byte("c")},
+		})
+		be.True(t, errors.Is(err, access.ErrInvalidCommit))
+	})
+
+	t.Run("concurrent commits", func(t *testing.T) {
+		var wg sync.WaitGroup
+		errs := make([]error, 4)
+		for i := range errs {
+			wg.Go(func() {
+				_, errs[i] = svc.CommitVersion(ctx, "new-object", &access.Commit{
+					Head:    2,
+					User:    user,
+					Content: testContent{"c.txt": []byte{byte(i)}},
+				})
+			})
+		}
+		wg.Wait()
+		var committed int
+		for _, err := range errs {
+			if err == nil {
+				committed++
+				continue
+			}
+			be.True(t, errors.Is(err, access.ErrConflict))
+		}
+		be.Equal(t, 1, committed)
+	})
+}
+
+func TestService_CommitVersionPolicy(t *testing.T) {
+	policy := &access.Policy{
+		Default:          access.Deny,
+		ObjectPolicyFile: "access.json",
+		Admins:           access.Subjects{Groups: []string{"curators"}},
+		Rules: []access.Rule{
+			{
+				Effect:   access.Allow,
+				Subjects: access.Subjects{Groups: []string{"depositors", "curators"}},
+				Objects:  []string{"deposit-*"},
+				Actions:  []access.Action{access.Read, access.Write},
+			},
+			{Effect: access.Allow, Subjects: access.Subjects{Groups: []string{"readers"}}},
+		},
+	}
+	svc := testPolicyService(t, policy, nil)
+	ctx := access.WithPrincipal(t.Context(), &access.Principal{ID: "user-1", Groups: []string{"depositors"}})
+	reader := access.WithPrincipal(t.Context(), &access.Principal{ID: "user-2", Groups: []string{"readers"}})
+	curator := access.WithPrincipal(t.Context(), &access.Principal{ID: "user-3", Groups: []string{"curators"}})
+	commit := &access.Commit{
+		User:    ocfl.User{Name: "user-1"},
+		Content: testContent{"a.txt": []byte("a")},
+	}
+	_, err := svc.CommitVersion(ctx, "deposit-1", commit)
+	be.NilErr(t, err)
+	_, err = svc.CommitVersion(ctx, "other-1", commit)
+	be.True(t, errors.Is(err, access.ErrNotFound))
+	_, err = svc.CommitVersion(t.Context(), "deposit-2", commit)
+	be.True(t, errors.Is(err, access.ErrNotFound))
+	// readers can read but not write
+	_, err = svc.CommitVersion(reader, "deposit-2", commit)
+	be.True(t, errors.Is(err, access.ErrForbidden))
+
+	t.Run("object policy file", func(t *testing.T) {
+		change := &access.Commit{
+			Head:    1,
+			User:    ocfl.User{Name: "user-1"},
+			Content: testContent{"access.json": []byte(`{"rules": []}`)},
+		}
+		_, err := svc.CommitVersion(ctx, "deposit-1", change)
+		be.True(t, errors.Is(err, access.ErrForbidden))
+		_, err = svc.CommitVersion(curator, "deposit-1", change)
+		be.NilErr(t, err)
+		remove := &access.Commit{Head: 2, User: ocfl.User{Name: "user-1"}, Remove: []string{"access.json"}}
+		_, err = svc.CommitVersion(ctx, "deposit-1", remove)
+		be.True(t, errors.Is(err, access.ErrForbidden))
+		// other files can be changed without admin permission
+		_, err = svc.CommitVersion(ctx, "deposit-1", &access.Commit{
+			Head:    2,
+			User:    ocfl.User{Name: "user-1"},
+			Content: testContent{"b.txt": []byte("b")},
+		})
+		be.NilErr(t, err)
+	})
+
+	t.Run("no policy", func(t *testing.T) {
+		_, err := testService(t).CommitVersion(ctx, "deposit-1", commit)
+		be.True(t, errors.Is(err, access.ErrForbidden))
+	})
+}
+
+func TestService_AuthorizeUploads(t *testing.T) {
+	policy := &access.Policy{
+		Rules: []access.Rule{
+			{
+				Effect:   access.Allow,
+				Subjects: access.Subjects{Groups: []string{"depositors"}},
+				Objects:  []string{"deposit-*"},
+				Actions:  []access.Action{access.Write},
+			},
+		},
+	}
+	svc := testPolicyService(t, policy, nil)
+	depositor := access.WithPrincipal(t.Context(), &access.Principal{ID: "user-1", Groups: []string{"depositors"}})
+	reader := access.WithPrincipal(t.Context(), &access.Principal{ID: "user-2"})
+	be.NilErr(t, svc.AuthorizeUploads(depositor))
+	be.True(t, errors.Is(svc.AuthorizeUploads(reader), access.ErrForbidden))
+	be.True(t, errors.Is(svc.AuthorizeUploads(t.Context()), access.ErrForbidden))
+	be.True(t, errors.Is(testService(t).AuthorizeUploads(depositor), access.ErrForbidden))
+
+	// object policy files can allow writes
+	policy.ObjectPolicyFile = "access.json"
+	be.NilErr(t, testPolicyService(t, policy, nil).AuthorizeUploads(reader))
+}
+
+// testWriteService returns a service for a copy of the fixture root with a
+// policy that allows all reads and writes.
+func testWriteService(t *testing.T) *access.Service {
+	t.Helper()
+	return testPolicyService(t, &access.Policy{
+		Rules: []access.Rule{{Effect: access.Allow, Actions: []access.Action{access.Read, access.Write}}},
+	}, nil)
+}
+
+// testContent is an access.Stager for file contents keyed by logical path.
+type testContent map[string][]byte
+
+func (c testContent) Stage(_ context.Context, alg digest.Algorithm) (*ocfl.Stage, error) {
+	return ocfl.StageBytes(c, alg)
+}
@@ -2,6 +2,7 @@ package access
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/srerickson/ocfl-go"
@@ -30,10 +31,25 @@ type Database interface {
 	// object doesn't exist.
 	UnsetObject(ctx context.Context, rootID string, objdID string) error
 
+	// PruneObjects removes objects that were last indexed at or before
+	// indexedBy and for which keep returns false, in a single transaction. keep
+	// is called with each candidate's storage path. It returns the IDs of the
+	// removed objects.
+	PruneObjects(ctx context.Context, rootID string, indexedBy time.Time, keep func(storagePath string) bool) ([]string, error)
+
 	// ListObjects returns a slice of objects representing representing a "page"
 	// of results. The slice will have length of opts.Limit or less.
 	ListObjects(ctx context.Context, rootID string, opts ListObjectOptions) ([]ObjectInfo, error)
 
+	// SearchObjects returns a slice of objects with IDs matching query. Results
+	// are ordered by object ID and have length of opts.Limit or less.
+	SearchObjects(ctx context.Context, rootID string, query string, opts SearchObjectOptions) ([]ObjectInfo, error)
+
+	// SearchContent returns a slice of object versions with messages, user
+	// names, or logical paths matching query. Results have length of
+	// opts.Limit or less.
+	SearchContent(ctx context.Context, rootID string, query string, opts SearchContentOptions) ([]ContentSearchResult, error)
+
 	// GetObjectVersion returns VersionInfo for the object. If vn < 1, the
 	// object's most recent version is used.
 	GetObjectVersion(ctx context.Context, rootID string, objID string, vn int) (VersionInfo, error)
@@ -50,12 +66,90 @@ type Database interface {
 	// the object's most recent version is used.
 	ReadObjectVersionDir(ctx context.Context, rootID string, objID string, vn int, dir string) ([]VersionDirEntry, error)
 
+	// ReadObjectVersionFiles returns information for all files in the
+	// directory dir of an OCFL object version's logical state, including files
+	// in sub-directories. Files are ordered by path.
+	ReadObjectVersionFiles(ctx context.Context, rootID string, objID string, vn int, dir string) ([]VersionFileInfo, error)
+
 	//StatObjectVersionFile returns information a file in an object version's logical state. If vn < 1,
 	// the object's most recent version is used.
 	StatObjectVersionFile(ctx context.Context, rootID string, objID string, vn int, name string) (VersionFileInfo, error)
 
+	// SetObjectFileMediaType sets the detected media type for the object's
+	// content files with the given digest.
+	SetObjectFileMediaType(ctx context.Context, rootID string, objID string, digest string, mediaType string) error
+
 	// Returns various counts for objects in a storage root
 	Metrics(ctx context.Context, rootID string) (Metrics, error)
+
+	// CreateDraft adds a draft of the next version of the object, owned by
+	// owner, with the state of the object's indexed head version. If the
+	// object isn't indexed, the draft's state is empty. If owner already has a
+	// draft for the object, it is returned instead.
+	CreateDraft(ctx context.Context, rootID string, objID string, owner string) (DraftInfo, error)
+
+	// GetDraft returns the draft with the given ID.
+	GetDraft(ctx context.Context, rootID string, draftID int64) (DraftInfo, error)
+
+	// ListDrafts returns the drafts owned by owner, or all drafts if owner is
+	// empty. Drafts are ordered by when they were last changed, most recent
+	// first.
+	ListDrafts(ctx context.Context, rootID string, owner string) ([]DraftInfo, error)
+
+	// DeleteDraft removes the draft and its files.
+	DeleteDraft(ctx context.Context, rootID string, draftID int64) error
+
+	// ListDraftFiles returns the files in the draft's state, ordered by path.
+	ListDraftFiles(ctx context.Context, rootID string, draftID int64) ([]DraftFileInfo, error)
+
+	// SetDraftFile adds a file to the draft's state, replacing any file with
+	// the same name. If the file would conflict with a directory, the error
+	// wraps ErrInvalidChange.
+	SetDraftFile(ctx context.Context, rootID string, draftID int64, name string, file DraftFile) error
+
+	// RenameDraftPath moves the file or directory src in the draft's state to
+	// dst. If dst exists, the error wraps ErrInvalidChange.
+	RenameDraftPath(ctx context.Context, rootID string, draftID int64, src string, dst string) error
+
+	// RemoveDraftPath removes the file or directory name from the draft's
+	// state.
+	RemoveDraftPath(ctx context.Context, rootID string, draftID int64, name string) error
+
+	// ReadDraftDir returns entries for the directory dir in the draft's state.
+	ReadDraftDir(ctx context.Context, rootID string, draftID int64, dir string) ([]VersionDirEntry, error)
+
+	// ListFixityDue returns up to limit content files that haven't been
+	// checked since before, least recently checked first.
+	ListFixityDue(ctx context.Context, rootID string, before time.Time, limit int) ([]FixityInfo, error)
+
+	// ListObjectFixity returns the object's content files, ordered by content
+	// path. If status isn't FixityUnchecked, only files with the status are
+	// returned.
+	ListObjectFixity(ctx context.Context, rootID string, objID string, status FixityStatus) ([]FixityInfo, error)
+
+	// ListFixityFailures returns up to limit content files that failed their
+	// most recent fixity check, most recently checked first.
+	ListFixityFailures(ctx context.Context, rootID string, limit int) ([]FixityInfo, error)
+
+	// SetFixity records the result of a fixity check of the object's content
+	// file.
+	SetFixity(ctx context.Context, rootID string, objID string, contentPath string, result FixityResult) error
+
+	// FixitySummary returns counts of content files by fixity status.
+	FixitySummary(ctx context.Context, rootID string) (FixitySummary, error)
+
+	// SetValidation adds the validation report for report.ObjectID, replacing
+	// any existing report for the object.
+	SetValidation(ctx context.Context, rootID string, report *ValidationReport) error
+
+	// GetValidation returns the object's validation report. If the object
+	// hasn't been validated, the error wraps ErrNotFound.
+	GetValidation(ctx context.Context, rootID string, objID string) (*ValidationReport, error)
+
+	// ListInvalidObjects returns up to limit validation reports, without
+	// messages, for objects that failed their most recent validation, most
+	// recently validated first.
+	ListInvalidObjects(ctx context.Context, rootID string, limit int) ([]*ValidationReport, error)
 }
 
 // Metrics includes counts for indexed objects in a storage root
@@ -63,9 +157,95 @@ type Metrics struct {
 	NumObjects int
 }
 
+// ListObjectOptions are used to page and order results from ListObjects.
 type ListObjectOptions struct {
 	Offset int
 	Limit  int
+	Sort   ObjectSort // field used to sort results (default: SortByID)
+	Desc   bool       // sort in descending order
+}
+
+// ObjectSort is a field used to order objects returned by ListObjects.
+type ObjectSort string
+
+const (
+	SortByID          ObjectSort = "id"      // object ID
+	SortByHead        ObjectSort = "head"    // head version number
+	SortByCreated     ObjectSort = "created" // first version's created timestamp
+	SortByUpdated     ObjectSort = "updated" // head version's created timestamp
+	SortByStoragePath ObjectSort = "path"    // object's storage path
+)
+
+// ParseObjectSort parses the string s as an ObjectSort. The empty string is
+// parsed as SortByID.
+func ParseObjectSort(s string) (ObjectSort, error) {
+	switch sort := ObjectSort(s); sort {
+	case "":
+		return SortByID, nil
+	case SortByID, SortByHead, SortByCreated, SortByUpdated, SortByStoragePath:
+		return sort, nil
+	default:
+		return "", fmt.Errorf("invalid object sort value: %q", s)
+	}
+}
+
+// SearchObjectOptions are used to configure SearchObjects.
+type SearchObjectOptions struct {
+	Mode       SearchMode // prefix or substring matching (default: SearchPrefix)
+	IgnoreCase bool       // case-insensitive matching
+	Offset     int
+	Limit      int
+}
+
+// SearchMode determines how object IDs are matched in SearchObjects.
+type SearchMode string
+
+const (
+	SearchPrefix    SearchMode = "prefix"    // IDs starting with the query
+	SearchSubstring SearchMode = "substring" // IDs that include the query
+)
+
+// ParseSearchMode parses the string s as a SearchMode. The empty string is
+// parsed as SearchPrefix.
+func ParseSearchMode(s string) (SearchMode, error) {
+	switch mode := SearchMode(s); mode {
+	case "":
+		return SearchPrefix, nil
+	case SearchPrefix, SearchSubstring:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid search mode: %q", s)
+	}
+}
+
+// SearchContentOptions are used to configure SearchContent.
+type SearchContentOptions struct {
+	Field    SearchField // field to search (default: SearchMessage)
+	HeadOnly bool        // for path searches, only match paths in the head version
+	Offset   int
+	Limit    int
+}
+
+// SearchField is a version field searched by SearchContent.
+type SearchField string
+
+const (
+	SearchMessage  SearchField = "message" // version messages
+	SearchUserName SearchField = "user"    // version user names
+	SearchPath     SearchField = "path"    // logical paths added or modified in a version
+)
+
+// ParseSearchField parses the string s as a SearchField. The empty string is
+// parsed as SearchMessage.
+func ParseSearchField(s string) (SearchField, error) {
+	switch field := SearchField(s); field {
+	case "":
+		return SearchMessage, nil
+	case SearchMessage, SearchUserName, SearchPath:
+		return field, nil
+	default:
+		return "", fmt.Errorf("invalid search field: %q", s)
+	}
 }
 
 // ObjectInfo represents a hig-level summary of the object: it doesn't not
@@ -98,6 +278,16 @@ type VersionInfo interface {
 	Created() time.Time
 }
 
+// ContentSearchResult is an object version matching a SearchContent query.
+type ContentSearchResult interface {
+	ObjectID() string   // matching object's ID
+	VNum() ocfl.VNum    // matching version
+	Path() string       // matching logical path (path searches only)
+	Message() string    // version message
+	UserName() string   // version user name
+	Created() time.Time // version created timestamp
+}
+
 type VersionFileInfo interface {
 	Path() string
 	ContentPath() string
@@ -106,6 +296,7 @@ type VersionFileInfo interface {
 	Modtime() time.Time
 	Size() int64
 	HasSize() bool
+	MediaType() string // detected media type, or "" if not detected
 }
 
 type VersionDirEntry interface {
@@ -119,6 +310,62 @@ type VersionDirEntry interface {
 }
 
 type VersionFileChange interface {
-	Path() string // file path
-	Type() string // "added", "modified", "deleted"
+	Path() string       // file path
+	Type() string       // "added", "modified", "deleted", "renamed", "copied"
+	SourcePath() string // path the content was renamed or copied from; empty for other changes
+}
+
+// DraftInfo represents a new object version that is being prepared.
+type DraftInfo interface {
+	ID() int64
+	ObjectID() string     // ID of the object the draft is for
+	Owner() string        // ID of the principal that opened the draft
+	Head() ocfl.VNum      // object's head version when the draft was opened (v0 for new objects)
+	Alg() string          // digest algorithm for the draft's files
+	CreatedAt() time.Time // when the draft was opened
+	UpdatedAt() time.Time // when the draft was last changed
+}
+
+// DraftFileInfo is a file in a draft's state.
+type DraftFileInfo interface {
+	Path() string
+	Digest() string
+	Size() int64
+	HasSize() bool
+	Upload() string // ID of the upload with the file's content; empty if the content is in the object
+}
+
+// FixityInfo is a content file with the result of its most recent fixity
+// check.
+type FixityInfo interface {
+	ContentFileInfo
+	ObjectID() string           // ID of the object the file belongs to
+	StoragePath() string        // object's storage path
+	Alg() string                // object's digest algorithm
+	FixityStatus() FixityStatus // FixityUnchecked if the file hasn't been checked
+	FixityError() string        // reason for a failed check
+	FixityCheckedAt() time.Time // zero if the file hasn't been checked
+}
+
+// FixityResult is the result of a fixity check.
+type FixityResult struct {
+	Status    FixityStatus
+	Error     string // reason for a failed check
+	CheckedAt time.Time
+}
+
+// FixitySummary includes counts of content files in a storage root by fixity
+// status.
+type FixitySummary struct {
+	NumFiles    int       // all content files
+	NumChecked  int       // files that have been checked
+	NumFailed   int       // files that failed their most recent check
+	OldestCheck time.Time // least recent check; zero if any file hasn't been checked
+}
+
+// DraftFile is new content for a file in a draft.
+type DraftFile struct {
+	Digest string // content digest, using the draft's digest algorithm
+	Size   int64  // size in bytes
+	Upload string // ID of the upload with the content
 }
@@ -0,0 +1,313 @@
+package access
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+
+	"github.com/srerickson/ocfl-go"
+	"github.com/srerickson/ocfl-go/digest"
+	ocflfs "github.com/srerickson/ocfl-go/fs"
+)
+
+// ErrInvalidChange is returned when a change to a draft isn't valid, as when
+// a file would have the same path as a directory.
+var ErrInvalidChange = errors.New("invalid draft change")
+
+// ErrNoPrincipal is returned by draft methods if there is no principal in the
+// context. Drafts belong to the principal that opened them.
+var ErrNoPrincipal = errors.New("drafts require an authenticated principal")
+
+// UploadSource provides the content of uploads that were added to drafts.
+type UploadSource interface {
+	// UploadContent returns the FS and path for the upload's content. If the
+	// upload doesn't exist, fsys is nil and name is empty.
+	UploadContent(id string) (fsys ocflfs.FS, name string)
+}
+
+// OpenDraft returns the draft of the next version of the object objID for the
+// principal in ctx. If the principal doesn't have a draft for the object, a
+// new one is created with the state of the object's head version. The object
+// doesn't have to exist. As with CommitVersion, the service's access policy
+// must allow the principal to write the object: ErrNotFound is returned if
+// the principal can't read it, and ErrForbidden if it can't write it.
+func (s *Service) OpenDraft(ctx context.Context, objID string) (DraftInfo, error) {
+	p := PrincipalFrom(ctx)
+	if p == nil {
+		return nil, ErrNoPrincipal
+	}
+	info, err := s.SyncObject(ctx, objID)
+	switch {
+	case err == nil:
+		if err := s.authorizeWrite(ctx, objID, info.StoragePath(), info); err != nil {
+			return nil, err
+		}
+	case errors.Is(err, ErrNotFound):
+		// the object may not exist yet
+		obj, err := s.root.NewObject(ctx, objID)
+		if err != nil {
+			return nil, fmt.Errorf("with object_id=%q: %w", objID, err)
+		}
+		if obj.Exists() {
+			return nil, fmt.Errorf("with object_id=%q: %w", objID, ErrNotFound)
+		}
+		if err := s.authorizeCommit(ctx, obj); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+	return s.db.CreateDraft(ctx, s.rootID, objID, p.ID)
+}
+
+// GetDraft returns the draft with the ID. If the draft doesn't belong to the
+// principal in ctx, ErrNotFound is returned.
+func (s *Service) GetDraft(ctx context.Context, draftID int64) (DraftInfo, error) {
+	p := PrincipalFrom(ctx)
+	if p == nil {
+		return nil, ErrNoPrincipal
+	}
+	draft, err := s.db.GetDraft(ctx, s.rootID, draftID)
+	if err != nil {
+		return nil, err
+	}
+	if draft.Owner() != p.ID {
+		return nil, fmt.Errorf("with draft_id=%d: %w", draftID, ErrNotFound)
+	}
+	return draft, nil
+}
+
+// ListDrafts returns the drafts that belong to the principal in ctx, most
+// recently changed first.
+func (s *Service) ListDrafts(ctx context.Context) ([]DraftInfo, error) {
+	p := PrincipalFrom(ctx)
+	if p == nil {
+		return nil, ErrNoPrincipal
+	}
+	return s.db.ListDrafts(ctx, s.rootID, p.ID)
+}
+
+// DraftUploads returns the IDs of all uploads used by files in drafts,
+// including drafts that belong to other principals.
+func (s *Service) DraftUploads(ctx context.Context) (map[string]bool, error) {
+	drafts, err := s.db.ListDrafts(ctx, s.rootID, "")
+	if err != nil {
+		return nil, err
+	}
+	uploads := map[string]bool{}
+	for _, draft := range drafts {
+		files, err := s.db.ListDraftFiles(ctx, s.rootID, draft.ID())
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			if f.Upload() != "" {
+				uploads[f.Upload()] = true
+			}
+		}
+	}
+	return uploads, nil
+}
+
+// ListDraftFiles returns all files in the draft's state, ordered by path.
+func (s *Service) ListDraftFiles(ctx context.Context, draftID int64) ([]DraftFileInfo, error) {
+	if _, err := s.GetDraft(ctx, draftID); err != nil {
+		return nil, err
+	}
+	return s.db.ListDraftFiles(ctx, s.rootID, draftID)
+}
+
+// ReadDraftDir returns entries for the directory dir in the draft's state.
+func (s *Service) ReadDraftDir(ctx context.Context, draftID int64, dir string) ([]VersionDirEntry, error) {
+	if _, err := s.GetDraft(ctx, draftID); err != nil {
+		return nil, err
+	}
+	return s.db.ReadDraftDir(ctx, s.rootID, draftID, dir)
+}
+
+// AddDraftFile adds the file name to the draft's state, replacing any file
+// with the same name. Adding the object policy file requires admin
+// permission.
+func (s *Service) AddDraftFile(ctx context.Context, draftID int64, name string, file DraftFile) error {
+	draft, err := s.GetDraft(ctx, draftID)
+	if err != nil {
+		return err
+	}
+	name, err = draftPath(name)
+	if err != nil {
+		return err
+	}
+	if err := s.authorizeDraftChange(ctx, draft, name); err != nil {
+		return err
+	}
+	if file.Digest == "" || file.Upload == "" {
+		return fmt.Errorf("%w: file %q has no content", ErrInvalidChange, name)
+	}
+	return s.db.SetDraftFile(ctx, s.rootID, draftID, name, file)
+}
+
+// RenameDraftPath moves the file or directory src in the draft's state to
+// dst. Moving the object policy file, or a file to its path, requires admin
+// permission.
+func (s *Service) RenameDraftPath(ctx context.Context, draftID int64, src string, dst string) error {
+	draft, err := s.GetDraft(ctx, draftID)
+	if err != nil {
+		return err
+	}
+	src, err = draftPath(src)
+	if err != nil {
+		return err
+	}
+	dst, err = draftPath(dst)
+	if err != nil {
+		return err
+	}
+	if err := s.authorizeDraftChange(ctx, draft, src, dst); err != nil {
+		return err
+	}
+	return s.db.RenameDraftPath(ctx, s.rootID, draftID, src, dst)
+}
+
+// RemoveDraftPath removes the file or directory name from the draft's state.
+// Removing the object policy file requires admin permission.
+func (s *Service) RemoveDraftPath(ctx context.Context, draftID int64, name string) error {
+	draft, err := s.GetDraft(ctx, draftID)
+	if err != nil {
+		return err
+	}
+	name, err = draftPath(name)
+	if err != nil {
+		return err
+	}
+	if err := s.authorizeDraftChange(ctx, draft, name); err != nil {
+		return err
+	}
+	return s.db.RemoveDraftPath(ctx, s.rootID, draftID, name)
+}
+
+// DiscardDraft deletes the draft without committing it. The content of
+// uploads added to the draft isn't deleted.
+func (s *Service) DiscardDraft(ctx context.Context, draftID int64) error {
+	if _, err := s.GetDraft(ctx, draftID); err != nil {
+		return err
+	}
+	return s.db.DeleteDraft(ctx, s.rootID, draftID)
+}
+
+// CommitDraft creates a new object version with the draft's state, using
+// uploads for the content of files added to the draft, and deletes the draft.
+// If the object's head version changed after the draft was opened,
+// ErrConflict is returned and the draft is kept.
+func (s *Service) CommitDraft(ctx context.Context, draftID int64, message string, user ocfl.User, uploads UploadSource) (ObjectInfo, error) {
+	draft, err := s.GetDraft(ctx, draftID)
+	if err != nil {
+		return nil, err
+	}
+	files, err := s.db.ListDraftFiles(ctx, s.rootID, draftID)
+	if err != nil {
+		return nil, err
+	}
+	content := &draftContent{
+		alg:     draft.Alg(),
+		state:   ocfl.DigestMap{},
+		uploads: uploads,
+		files:   map[string]string{},
+	}
+	draftPaths := map[string]bool{}
+	for _, f := range files {
+		content.state[f.Digest()] = append(content.state[f.Digest()], f.Path())
+		if f.Upload() != "" {
+			content.files[f.Digest()] = f.Upload()
+		}
+		draftPaths[f.Path()] = true
+	}
+	commit := &Commit{
+		Head:    draft.Head().Num(),
+		Message: message,
+		User:    user,
+		Content: content,
+	}
+	if commit.Head > 0 {
+		// files in the head version that aren't in the draft are removed
+		headFiles, err := s.db.ReadObjectVersionFiles(ctx, s.rootID, draft.ObjectID(), commit.Head, ".")
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range headFiles {
+			if !draftPaths[f.Path()] {
+				commit.Remove = append(commit.Remove, f.Path())
+			}
+		}
+	}
+	obj, err := s.CommitVersion(ctx, draft.ObjectID(), commit)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.DeleteDraft(ctx, s.rootID, draftID); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// authorizeDraftChange returns an error if a change to paths in the draft's
+// state would change the object policy file and the principal in ctx isn't
+// allowed to: it must be able to write the object and be one of the policy's
+// admins. The errors are the same as CommitVersion's.
+func (s *Service) authorizeDraftChange(ctx context.Context, draft DraftInfo, paths ...string) error {
+	if !slices.ContainsFunc(paths, s.isPolicyPath) {
+		return nil
+	}
+	obj, err := s.root.NewObject(ctx, draft.ObjectID())
+	if err != nil {
+		return fmt.Errorf("with object_id=%q: %w", draft.ObjectID(), err)
+	}
+	if err := s.authorizeCommit(ctx, obj); err != nil {
+		return err
+	}
+	if err := s.authorizeAdmin(ctx); err != nil {
+		return fmt.Errorf("with object_id=%q: changing %s: %w", draft.ObjectID(), s.policy.ObjectPolicyFile, err)
+	}
+	return nil
+}
+
+// draftPath returns name without leading or trailing slashes, or an error
+// wrapping ErrInvalidChange if it isn't a valid logical path.
+func draftPath(name string) (string, error) {
+	name = strings.Trim(name, "/")
+	if !fs.ValidPath(name) || name == "." {
+		return "", fmt.Errorf("%w: invalid path: %q", ErrInvalidChange, name)
+	}
+	return name, nil
+}
+
+// draftContent is a Stager for a draft's state.
+type draftContent struct {
+	alg     string
+	state   ocfl.DigestMap
+	uploads UploadSource
+	files   map[string]string // upload IDs by digest
+}
+
+func (c *draftContent) Stage(_ context.Context, alg digest.Algorithm) (*ocfl.Stage, error) {
+	if alg.ID() != c.alg {
+		// the object was created with a different algorithm after the
+		// draft was opened.
+		return nil, fmt.Errorf("draft uses %s, not the object's %s: %w", c.alg, alg.ID(), ErrConflict)
+	}
+	return &ocfl.Stage{
+		State:           c.state,
+		DigestAlgorithm: alg,
+		ContentSource:   c,
+	}, nil
+}
+
+func (c *draftContent) GetContent(digest string) (ocflfs.FS, string) {
+	id, ok := c.files[digest]
+	if !ok || c.uploads == nil {
+		return nil, ""
+	}
+	return c.uploads.UploadContent(id)
+}
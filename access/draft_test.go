@@ -0,0 +1,180 @@
+package access_test
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/carlmjohnson/be"
+	"github.com/srerickson/ocfl-go"
+	"github.com/srerickson/ocfl-go/digest"
+	ocflfs "github.com/srerickson/ocfl-go/fs"
+	"github.com/srerickson/ocfl-services/access"
+)
+
+func TestService_Drafts(t *testing.T) {
+	svc := testWriteService(t)
+	ctx := access.WithPrincipal(t.Context(), &access.Principal{ID: "user-1"})
+	other := access.WithPrincipal(t.Context(), &access.Principal{ID: "user-2"})
+	uploads := testUploads(t, map[string]string{"upload-1": "new content"})
+	user := ocfl.User{Name: "User One"}
+
+	t.Run("open, change, and commit", func(t *testing.T) {
+		head, err := svc.SyncObject(ctx, fixtureObjectID)
+		be.NilErr(t, err)
+		draft, err := svc.OpenDraft(ctx, fixtureObjectID)
+		be.NilErr(t, err)
+		be.Equal(t, head.Head(), draft.Head())
+		again, err := svc.OpenDraft(ctx, fixtureObjectID)
+		be.NilErr(t, err)
+		be.Equal(t, draft.ID(), again.ID())
+		headFiles, err := svc.ListVersionFiles(ctx, fixtureObjectID, 0, ".")
+		be.NilErr(t, err)
+		draftFiles, err := svc.ListDraftFiles(ctx, draft.ID())
+		be.NilErr(t, err)
+		be.Equal(t, len(headFiles), len(draftFiles))
+
+		// drafts belong to the principal that opened them
+		_, err = svc.GetDraft(other, draft.ID())
+		be.True(t, errors.Is(err, access.ErrNotFound))
+		_, err = svc.GetDraft(t.Context(), draft.ID())
+		be.True(t, errors.Is(err, access.ErrNoPrincipal))
+
+		sum := testDigest(t, draft.Alg(), "new content")
+		be.NilErr(t, svc.AddDraftFile(ctx, draft.ID(), "new/file.txt", access.DraftFile{
+			Digest: sum,
+			Size:   11,
+			Upload: "upload-1",
+		}))
+		renamed := headFiles[0].Path()
+		be.NilErr(t, svc.RenameDraftPath(ctx, draft.ID(), renamed, "renamed.txt"))
+		err = svc.RenameDraftPath(ctx, draft.ID(), "renamed.txt", "new/file.txt")
+		be.True(t, errors.Is(err, access.ErrInvalidChange))
+		err = svc.RemoveDraftPath(ctx, draft.ID(), "../a")
+		be.True(t, errors.Is(err, access.ErrInvalidChange))
+		entries, err := svc.ReadDraftDir(ctx, draft.ID(), "new")
+		be.NilErr(t, err)
+		be.Equal(t, 1, len(entries))
+		be.Equal(t, "file.txt", entries[0].Name())
+		be.Equal(t, draft.Head().Num()+1, entries[0].ModVNum())
+
+		obj, err := svc.CommitDraft(ctx, draft.ID(), "from draft", user, uploads)
+		be.NilErr(t, err)
+		be.Equal(t, head.Head().Num()+1, obj.Head().Num())
+		f, err := svc.OpenVersionFile(ctx, fixtureObjectID, 0, "new/file.txt")
+		be.NilErr(t, err)
+		defer f.Close()
+		b, err := io.ReadAll(f)
+		be.NilErr(t, err)
+		be.Equal(t, "new content", string(b))
+		_, err = svc.StatVersionFile(ctx, fixtureObjectID, 0, renamed)
+		be.True(t, errors.Is(err, access.ErrNotFound))
+		_, err = svc.StatVersionFile(ctx, fixtureObjectID, 0, "renamed.txt")
+		be.NilErr(t, err)
+
+		// the draft is deleted
+		_, err = svc.GetDraft(ctx, draft.ID())
+		be.True(t, errors.Is(err, access.ErrNotFound))
+	})
+
+	t.Run("stale draft", func(t *testing.T) {
+		draft, err := svc.OpenDraft(ctx, "new-object")
+		be.NilErr(t, err)
+		be.Equal(t, 0, draft.Head().Num())
+		sum := testDigest(t, draft.Alg(), "new content")
+		file := access.DraftFile{Digest: sum, Size: 11, Upload: "upload-1"}
+		be.NilErr(t, svc.AddDraftFile(ctx, draft.ID(), "a.txt", file))
+		otherDraft, err := svc.OpenDraft(other, "new-object")
+		be.NilErr(t, err)
+		be.NilErr(t, svc.AddDraftFile(other, otherDraft.ID(), "b.txt", file))
+		_, err = svc.CommitDraft(other, otherDraft.ID(), "first", user, uploads)
+		be.NilErr(t, err)
+		_, err = svc.CommitDraft(ctx, draft.ID(), "stale", user, uploads)
+		be.True(t, errors.Is(err, access.ErrConflict))
+		drafts, err := svc.ListDrafts(ctx)
+		be.NilErr(t, err)
+		be.Equal(t, 1, len(drafts))
+		inUse, err := svc.DraftUploads(t.Context())
+		be.NilErr(t, err)
+		be.True(t, inUse["upload-1"])
+		be.NilErr(t, svc.DiscardDraft(ctx, draft.ID()))
+		drafts, err = svc.ListDrafts(ctx)
+		be.NilErr(t, err)
+		be.Equal(t, 0, len(drafts))
+	})
+}
+
+func TestService_DraftsPolicy(t *testing.T) {
+	policy := &access.Policy{
+		ObjectPolicyFile: "access.json",
+		Admins:           access.Subjects{Groups: []string{"curators"}},
+		Rules: []access.Rule{
+			{
+				Effect:   access.Allow,
+				Subjects: access.Subjects{Groups: []string{"depositors", "curators"}},
+				Actions:  []access.Action{access.Write},
+			},
+		},
+	}
+	svc := testPolicyService(t, policy, nil)
+	depositor := access.WithPrincipal(t.Context(), &access.Principal{ID: "user-1", Groups: []string{"depositors"}})
+	curator := access.WithPrincipal(t.Context(), &access.Principal{ID: "user-2", Groups: []string{"curators"}})
+	reader := access.WithPrincipal(t.Context(), &access.Principal{ID: "user-3"})
+	uploads := testUploads(t, map[string]string{"upload-1": "{}"})
+
+	// reading doesn't allow opening drafts
+	_, err := svc.OpenDraft(reader, fixtureObjectID)
+	be.True(t, errors.Is(err, access.ErrForbidden))
+	_, err = svc.OpenDraft(reader, "new-object")
+	be.True(t, errors.Is(err, access.ErrForbidden))
+
+	// the object policy file can only be changed by admins
+	draft, err := svc.OpenDraft(depositor, fixtureObjectID)
+	be.NilErr(t, err)
+	file := access.DraftFile{Digest: testDigest(t, draft.Alg(), "{}"), Size: 2, Upload: "upload-1"}
+	err = svc.AddDraftFile(depositor, draft.ID(), "access.json", file)
+	be.True(t, errors.Is(err, access.ErrForbidden))
+	be.NilErr(t, svc.AddDraftFile(depositor, draft.ID(), "policy.json", file))
+	err = svc.RenameDraftPath(depositor, draft.ID(), "policy.json", "access.json")
+	be.True(t, errors.Is(err, access.ErrForbidden))
+	adminDraft, err := svc.OpenDraft(curator, fixtureObjectID)
+	be.NilErr(t, err)
+	be.NilErr(t, svc.AddDraftFile(curator, adminDraft.ID(), "access.json", file))
+	_, err = svc.CommitDraft(curator, adminDraft.ID(), "add policy", ocfl.User{Name: "Curator"}, uploads)
+	be.NilErr(t, err)
+	other, err := svc.OpenDraft(depositor, "other-object")
+	be.NilErr(t, err)
+	err = svc.RemoveDraftPath(depositor, other.ID(), "access.json")
+	be.True(t, errors.Is(err, access.ErrForbidden))
+}
+
+// testUploadSource is an access.UploadSource for files in a directory, named
+// with their upload IDs.
+type testUploadSource struct {
+	fsys ocflfs.FS
+}
+
+// testUploads returns a testUploadSource for uploads with the given contents,
+// keyed by upload ID.
+func testUploads(t *testing.T, contents map[string]string) *testUploadSource {
+	t.Helper()
+	dir := t.TempDir()
+	for id, content := range contents {
+		be.NilErr(t, os.WriteFile(filepath.Join(dir, id), []byte(content), 0o644))
+	}
+	return &testUploadSource{fsys: ocflfs.DirFS(dir)}
+}
+
+func (u *testUploadSource) UploadContent(id string) (ocflfs.FS, string) {
+	return u.fsys, id
+}
+
+func testDigest(t *testing.T, alg string, content string) string {
+	t.Helper()
+	digester := digest.DefaultRegistry().MustGet(alg).Digester()
+	_, err := io.WriteString(digester, content)
+	be.NilErr(t, err)
+	return digester.String()
+}
@@ -0,0 +1,285 @@
+package access
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	s3v2 "github.com/aws/aws-sdk-go-v2/service/s3"
+	ocflfs "github.com/srerickson/ocfl-go/fs"
+	ocflS3 "github.com/srerickson/ocfl-go/fs/s3"
+)
+
+// number of bytes used to detect a file's media type from its contents
+const sniffLen = 512
+
+// VersionFile is an open file in an object version.
+type VersionFile struct {
+	*FileReader
+	info      VersionFileInfo
+	mediaType string
+}
+
+// Info returns indexed information about the version file, including its
+// digest.
+func (f *VersionFile) Info() VersionFileInfo { return f.info }
+
+// MediaType returns the file's detected media type.
+func (f *VersionFile) MediaType() string { return f.mediaType }
+
+// media types for common file extensions that aren't included in the mime
+// package's built-in table. These take precedence over the system's mime
+// types.
+var extensionMediaTypes = map[string]string{
+	".csv":  "text/csv; charset=utf-8",
+	".md":   "text/markdown; charset=utf-8",
+	".toml": "application/toml",
+	".tsv":  "text/tab-separated-values; charset=utf-8",
+	".txt":  "text/plain; charset=utf-8",
+	".yaml": "application/yaml",
+	".yml":  "application/yaml",
+}
+
+// DetectMediaType returns the media type for a file with the given name and
+// contents. The type is determined by the name's extension, if possible.
+// Otherwise, it is detected from the contents with SniffMediaType.
+func DetectMediaType(name string, r io.ReadSeeker) (string, error) {
+	if mediaType := MediaTypeByExtension(name); mediaType != "" {
+		return mediaType, nil
+	}
+	return SniffMediaType(r)
+}
+
+// MediaTypeByExtension returns the media type for the extension of name, or an
+// empty string if the extension doesn't have a known type.
+func MediaTypeByExtension(name string) string {
+	ext := strings.ToLower(path.Ext(name))
+	if mediaType := extensionMediaTypes[ext]; mediaType != "" {
+		return mediaType
+	}
+	return mime.TypeByExtension(ext)
+}
+
+// SniffMediaType detects the media type of up to 512 bytes read from r, after
+// which r is returned to its original offset. If r implements io.ReaderAt (as
+// *FileReader does), it is used to read only the bytes needed.
+func SniffMediaType(r io.ReadSeeker) (string, error) {
+	offset, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", err
+	}
+	buf := make([]byte, sniffLen)
+	if ra, ok := r.(io.ReaderAt); ok {
+		n, err := ra.ReadAt(buf, offset)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", err
+		}
+		return http.DetectContentType(buf[:n]), nil
+	}
+	n, err := io.ReadFull(r, buf)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", err
+	}
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return "", err
+	}
+	return http.DetectContentType(buf[:n]), nil
+}
+
+// FileReader is an io.ReadSeekCloser for a file in an ocfl-go FS. Seeking
+// doesn't read from the underlying file. Reads from files in S3 buckets use
+// ranged GET requests starting at the current offset. For other FS types, the
+// underlying file is used directly if it implements io.Seeker; otherwise, the
+// file is reopened (if necessary) and bytes before the offset are discarded.
+type FileReader struct {
+	ctx  context.Context
+	fsys ocflfs.FS
+	name string
+	size int64
+
+	file   fs.File       // file returned by OpenFile
+	reader io.ReadCloser // reader positioned at readPos, may be nil
+	// readPos is the position of reader; pos is the position of the next
+	// Read.
+	readPos int64
+	pos     int64
+}
+
+var (
+	_ io.ReadSeekCloser = (*FileReader)(nil)
+	_ io.ReaderAt       = (*FileReader)(nil)
+)
+
+// OpenFileReader opens the file name in fsys and returns a new *FileReader
+// for it.
+func OpenFileReader(ctx context.Context, fsys ocflfs.FS, name string) (*FileReader, error) {
+	f, err := fsys.OpenFile(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	r := &FileReader{
+		ctx:  ctx,
+		fsys: fsys,
+		name: name,
+		size: info.Size(),
+		file: f,
+	}
+	if _, isS3 := fsys.(*ocflS3.BucketFS); !isS3 {
+		// reads from S3 always use ranged GETs
+		r.reader = f
+	}
+	return r, nil
+}
+
+// Size returns the size of the file in bytes.
+func (r *FileReader) Size() int64 { return r.size }
+
+// Read implements io.Reader.
+func (r *FileReader) Read(p []byte) (int, error) {
+	if r.pos >= r.size {
+		return 0, io.EOF
+	}
+	if r.reader == nil || r.readPos != r.pos {
+		if err := r.reposition(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.reader.Read(p)
+	r.pos += int64(n)
+	r.readPos = r.pos
+	return n, err
+}
+
+// Seek implements io.Seeker.
+func (r *FileReader) Seek(offset int64, whence int) (int64, error) {
+	var pos int64
+	switch whence {
+	case io.SeekStart:
+		pos = offset
+	case io.SeekCurrent:
+		pos = r.pos + offset
+	case io.SeekEnd:
+		pos = r.size + offset
+	default:
+		return 0, fmt.Errorf("seek %s: invalid whence: %d", r.name, whence)
+	}
+	if pos < 0 {
+		return 0, fmt.Errorf("seek %s: negative position", r.name)
+	}
+	r.pos = pos
+	return pos, nil
+}
+
+// ReadAt implements io.ReaderAt. It doesn't change the offset used by Read
+// and Seek. Reads from files in S3 buckets use a ranged GET request for only
+// the bytes in p.
+func (r *FileReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("read %s: negative offset", r.name)
+	}
+	if off >= r.size {
+		return 0, io.EOF
+	}
+	want := min(int64(len(p)), r.size-off)
+	if bucketFS, ok := r.fsys.(*ocflS3.BucketFS); ok {
+		byteRange := fmt.Sprintf("bytes=%d-%d", off, off+want-1)
+		out, err := bucketFS.S3.GetObject(r.ctx, &s3v2.GetObjectInput{
+			Bucket: &bucketFS.Bucket,
+			Key:    &r.name,
+			Range:  &byteRange,
+		})
+		if err != nil {
+			return 0, &fs.PathError{Op: "read", Path: r.name, Err: err}
+		}
+		defer out.Body.Close()
+		n, err := io.ReadFull(out.Body, p[:want])
+		if err == nil && want < int64(len(p)) {
+			err = io.EOF
+		}
+		return n, err
+	}
+	pos := r.pos
+	defer func() { r.pos = pos }()
+	r.pos = off
+	n, err := io.ReadFull(r, p[:want])
+	if err == nil && want < int64(len(p)) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// Close closes the underlying file and any open ranged reads.
+func (r *FileReader) Close() error {
+	var err error
+	if r.reader != nil && r.reader != io.ReadCloser(r.file) {
+		err = r.reader.Close()
+	}
+	return errors.Join(err, r.file.Close())
+}
+
+// reposition sets r.reader to a reader starting at r.pos.
+func (r *FileReader) reposition() error {
+	if bucketFS, ok := r.fsys.(*ocflS3.BucketFS); ok {
+		return r.rangeGet(bucketFS)
+	}
+	if seeker, ok := r.file.(io.Seeker); ok {
+		if _, err := seeker.Seek(r.pos, io.SeekStart); err != nil {
+			return err
+		}
+		r.reader = r.file
+		r.readPos = r.pos
+		return nil
+	}
+	if r.reader == nil || r.readPos > r.pos {
+		// start over with a new file
+		f, err := r.fsys.OpenFile(r.ctx, r.name)
+		if err != nil {
+			return err
+		}
+		r.closeReader()
+		r.file.Close()
+		r.file = f
+		r.reader = f
+		r.readPos = 0
+	}
+	n, err := io.CopyN(io.Discard, r.reader, r.pos-r.readPos)
+	r.readPos += n
+	return err
+}
+
+// rangeGet sets r.reader to the body of a ranged GET request starting at
+// r.pos.
+func (r *FileReader) rangeGet(bucketFS *ocflS3.BucketFS) error {
+	r.closeReader()
+	byteRange := fmt.Sprintf("bytes=%d-", r.pos)
+	out, err := bucketFS.S3.GetObject(r.ctx, &s3v2.GetObjectInput{
+		Bucket: &bucketFS.Bucket,
+		Key:    &r.name,
+		Range:  &byteRange,
+	})
+	if err != nil {
+		return &fs.PathError{Op: "read", Path: r.name, Err: err}
+	}
+	r.reader = out.Body
+	r.readPos = r.pos
+	return nil
+}
+
+// closeReader closes r.reader if it is a ranged read.
+func (r *FileReader) closeReader() {
+	if r.reader != nil && r.reader != io.ReadCloser(r.file) {
+		r.reader.Close()
+	}
+	r.reader = nil
+}
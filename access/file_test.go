@@ -0,0 +1,213 @@
+package access_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	s3v2 "github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/carlmjohnson/be"
+	ocflfs "github.com/srerickson/ocfl-go/fs"
+	ocflS3 "github.com/srerickson/ocfl-go/fs/s3"
+	"github.com/srerickson/ocfl-services/access"
+)
+
+const fileReaderContent = "0123456789abcdefghij"
+
+func TestFileReader(t *testing.T) {
+	t.Run("seekable file", func(t *testing.T) {
+		fsys := ocflfs.NewWrapFS(fstest.MapFS{
+			"file.txt": &fstest.MapFile{Data: []byte(fileReaderContent)},
+		})
+		testFileReader(t, fsys)
+	})
+
+	t.Run("non-seekable file", func(t *testing.T) {
+		fsys := &noSeekFS{FS: ocflfs.NewWrapFS(fstest.MapFS{
+			"file.txt": &fstest.MapFile{Data: []byte(fileReaderContent)},
+		})}
+		testFileReader(t, fsys)
+		be.True(t, fsys.opens > 1)
+	})
+
+	t.Run("s3 bucket", func(t *testing.T) {
+		api := &fakeS3{data: []byte(fileReaderContent)}
+		fsys := &ocflS3.BucketFS{S3: api, Bucket: "bucket"}
+		testFileReader(t, fsys)
+		// reads following seeks use ranged GETs
+		be.AllEqual(t, []string{"bytes=10-", "bytes=2-"}, api.ranges)
+	})
+}
+
+func TestFileReader_ReadAt(t *testing.T) {
+	ctx := t.Context()
+	api := &fakeS3{data: []byte(fileReaderContent)}
+	fsys := &ocflS3.BucketFS{S3: api, Bucket: "bucket"}
+	r, err := access.OpenFileReader(ctx, fsys, "file.txt")
+	be.NilErr(t, err)
+	defer r.Close()
+	got := make([]byte, 5)
+	n, err := r.ReadAt(got, 10)
+	be.NilErr(t, err)
+	be.Equal(t, "abcde", string(got[:n]))
+	// reads past the end return io.EOF
+	n, err = r.ReadAt(got, 18)
+	be.Equal(t, io.EOF, err)
+	be.Equal(t, "ij", string(got[:n]))
+	// sniffing the media type only requests the bytes it needs
+	mediaType, err := access.SniffMediaType(r)
+	be.NilErr(t, err)
+	be.Equal(t, "text/plain; charset=utf-8", mediaType)
+	be.AllEqual(t, []string{"bytes=10-14", "bytes=18-19", "bytes=0-19"}, api.ranges)
+	// ReadAt doesn't change the offset for Read
+	rest, err := io.ReadAll(r)
+	be.NilErr(t, err)
+	be.Equal(t, fileReaderContent, string(rest))
+}
+
+func testFileReader(t *testing.T, fsys ocflfs.FS) {
+	t.Helper()
+	ctx := t.Context()
+	r, err := access.OpenFileReader(ctx, fsys, "file.txt")
+	be.NilErr(t, err)
+	defer r.Close()
+	be.Equal(t, int64(len(fileReaderContent)), r.Size())
+
+	// seeking to the end doesn't read
+	end, err := r.Seek(0, io.SeekEnd)
+	be.NilErr(t, err)
+	be.Equal(t, int64(20), end)
+	n, err := r.Read(make([]byte, 1))
+	be.Equal(t, 0, n)
+	be.Equal(t, io.EOF, err)
+
+	// read from the middle
+	_, err = r.Seek(10, io.SeekStart)
+	be.NilErr(t, err)
+	got := make([]byte, 5)
+	_, err = io.ReadFull(r, got)
+	be.NilErr(t, err)
+	be.Equal(t, "abcde", string(got))
+
+	// seek backwards
+	_, err = r.Seek(-13, io.SeekCurrent)
+	be.NilErr(t, err)
+	_, err = io.ReadFull(r, got)
+	be.NilErr(t, err)
+	be.Equal(t, "23456", string(got))
+
+	// read the rest
+	rest, err := io.ReadAll(r)
+	be.NilErr(t, err)
+	be.Equal(t, "789abcdefghij", string(rest))
+
+	// invalid seeks
+	_, err = r.Seek(-1, io.SeekStart)
+	be.Nonzero(t, err)
+	_, err = r.Seek(0, 99)
+	be.Nonzero(t, err)
+}
+
+// noSeekFS is an ocflfs.FS with files that don't implement io.Seeker
+type noSeekFS struct {
+	ocflfs.FS
+	opens int
+}
+
+func (fsys *noSeekFS) OpenFile(ctx context.Context, name string) (fs.File, error) {
+	f, err := fsys.FS.OpenFile(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	fsys.opens++
+	return struct{ fs.File }{f}, nil
+}
+
+// fakeS3 implements the HeadObject and GetObject methods of ocflS3.S3API for
+// a single object.
+type fakeS3 struct {
+	ocflS3.S3API
+	data   []byte
+	ranges []string // ranges from GetObject requests
+}
+
+func (api *fakeS3) HeadObject(_ context.Context, _ *s3v2.HeadObjectInput, _ ...func(*s3v2.Options)) (*s3v2.HeadObjectOutput, error) {
+	size := int64(len(api.data))
+	modtime := time.Now()
+	return &s3v2.HeadObjectOutput{ContentLength: &size, LastModified: &modtime}, nil
+}
+
+func (api *fakeS3) GetObject(_ context.Context, in *s3v2.GetObjectInput, _ ...func(*s3v2.Options)) (*s3v2.GetObjectOutput, error) {
+	start, end := 0, len(api.data)
+	if in.Range != nil {
+		api.ranges = append(api.ranges, *in.Range)
+		first, last, _ := strings.Cut(strings.TrimPrefix(*in.Range, "bytes="), "-")
+		if _, err := fmt.Sscan(first, &start); err != nil {
+			return nil, err
+		}
+		if last != "" {
+			if _, err := fmt.Sscan(last, &end); err != nil {
+				return nil, err
+			}
+			end = min(end+1, len(api.data))
+		}
+	}
+	body := io.NopCloser(bytes.NewReader(api.data[start:end]))
+	return &s3v2.GetObjectOutput{Body: body}, nil
+}
+
+func TestDetectMediaType(t *testing.T) {
+	tests := map[string]struct {
+		name    string
+		content string
+		want    string
+	}{
+		"extension": {
+			name:    "v1/content/image.png",
+			content: "not really a png",
+			want:    "image/png",
+		},
+		"extension without built-in type": {
+			name:    "v1/content/DATA.CSV",
+			content: "a,b,c",
+			want:    "text/csv; charset=utf-8",
+		},
+		"sniffed text": {
+			name:    "v1/content/README",
+			content: "plain text",
+			want:    "text/plain; charset=utf-8",
+		},
+		"sniffed pdf": {
+			name:    "v1/content/file",
+			content: "%PDF-1.4",
+			want:    "application/pdf",
+		},
+		"sniffed binary": {
+			name:    "v1/content/file.unknown-ext",
+			content: "\x00\x01\x02",
+			want:    "application/octet-stream",
+		},
+		"empty": {
+			name: "v1/content/empty",
+			want: "text/plain; charset=utf-8",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := strings.NewReader(tt.content)
+			got, err := access.DetectMediaType(tt.name, r)
+			be.NilErr(t, err)
+			be.Equal(t, tt.want, got)
+			// reader is returned to its original offset
+			rest, err := io.ReadAll(r)
+			be.NilErr(t, err)
+			be.Equal(t, tt.content, string(rest))
+		})
+	}
+}
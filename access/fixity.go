@@ -0,0 +1,226 @@
+package access
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/srerickson/ocfl-go/digest"
+)
+
+// FixityStatus is the result of a content file's most recent fixity check.
+type FixityStatus string
+
+const (
+	FixityUnchecked FixityStatus = ""      // the file hasn't been checked
+	FixityPass      FixityStatus = "pass"  // the file's digest matched the inventory
+	FixityFail      FixityStatus = "fail"  // the file is missing or its digest didn't match
+	FixityErrored   FixityStatus = "error" // the file couldn't be read
+)
+
+// default number of files checked by AuditFixity
+const defaultFixityBatchSize = 100
+
+// FixityAuditOptions are used to configure AuditFixity.
+type FixityAuditOptions struct {
+	// Interval is the time after which files are checked again: files that
+	// were checked less than Interval ago are skipped.
+	Interval time.Duration
+	// MaxFiles is the max number of files to check (default: 100).
+	MaxFiles int
+	// MaxRate is the max number of bytes per second read from the storage
+	// root. If it is 0, reads aren't limited.
+	MaxRate int64
+}
+
+// FixityAuditResult summarizes the files checked by AuditFixity.
+type FixityAuditResult struct {
+	Checked int   // files checked
+	Failed  int   // files that failed
+	Errors  int   // files that couldn't be read
+	Bytes   int64 // bytes read from the storage root
+}
+
+// AuditFixity checks the fixity of content files that haven't been checked
+// within opts.Interval, least recently checked first. Each file is read from
+// the storage root, its digest is computed with the object's digest
+// algorithm, and the result is recorded in the index. A file fails if it is
+// missing or its digest doesn't match the inventory. If the file can't be
+// read for another reason, which may be temporary, it is recorded with
+// FixityErrored and checked again after opts.Interval; the audit continues
+// with the next file. The access policy isn't applied.
+func (s *Service) AuditFixity(ctx context.Context, opts FixityAuditOptions) (FixityAuditResult, error) {
+	var result FixityAuditResult
+	limit := opts.MaxFiles
+	if limit < 1 {
+		limit = defaultFixityBatchSize
+	}
+	due, err := s.db.ListFixityDue(ctx, s.rootID, time.Now().Add(-opts.Interval), limit)
+	if err != nil {
+		return result, err
+	}
+	limiter := &rateLimiter{rate: opts.MaxRate, start: time.Now()}
+	for _, file := range due {
+		fixity, n, err := s.checkFixity(ctx, file, limiter)
+		result.Bytes += n
+		if err != nil {
+			if ctx.Err() != nil {
+				return result, ctx.Err()
+			}
+			fixity = FixityResult{Status: FixityErrored, Error: err.Error(), CheckedAt: time.Now()}
+		}
+		if err := s.db.SetFixity(ctx, s.rootID, file.ObjectID(), file.ContentPath(), fixity); err != nil {
+			return result, err
+		}
+		if fixity.Status == FixityErrored {
+			result.Errors++
+			s.logger.LogAttrs(ctx, slog.LevelWarn, "fixity check error: "+fixity.Error,
+				slog.String("object_id", file.ObjectID()),
+				slog.String("content_path", file.ContentPath()))
+			continue
+		}
+		result.Checked++
+		if fixity.Status == FixityFail {
+			result.Failed++
+			s.logger.LogAttrs(ctx, slog.LevelWarn, "fixity check failed: "+fixity.Error,
+				slog.String("object_id", file.ObjectID()),
+				slog.String("content_path", file.ContentPath()))
+		}
+	}
+	return result, nil
+}
+
+// ObjectFixity returns the object's content files with the results of their
+// most recent fixity checks, ordered by content path. If status isn't
+// FixityUnchecked, only files with the status are returned.
+func (s *Service) ObjectFixity(ctx context.Context, objID string, status FixityStatus) ([]FixityInfo, error) {
+	if _, err := s.SyncObject(ctx, objID); err != nil {
+		return nil, err
+	}
+	return s.db.ListObjectFixity(ctx, s.rootID, objID, status)
+}
+
+// FixityFailures returns up to limit content files that failed their most
+// recent fixity check, most recently checked first. Files in objects that the
+// principal in ctx can't access are omitted.
+func (s *Service) FixityFailures(ctx context.Context, limit int) ([]FixityInfo, error) {
+	files, err := s.db.ListFixityFailures(ctx, s.rootID, limit)
+	if err != nil || s.policy == nil {
+		return files, err
+	}
+	allowed := make([]FixityInfo, 0, len(files))
+	for _, f := range files {
+		obj, err := s.db.GetObject(ctx, s.rootID, f.ObjectID())
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		if err := s.authorize(ctx, obj); err != nil {
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		allowed = append(allowed, f)
+	}
+	return allowed, nil
+}
+
+// FixitySummary returns counts of the storage root's content files by fixity
+// status. If the service has an access policy, it returns ErrCountsHidden.
+func (s *Service) FixitySummary(ctx context.Context) (FixitySummary, error) {
+	if s.policy != nil {
+		return FixitySummary{}, ErrCountsHidden
+	}
+	return s.db.FixitySummary(ctx, s.rootID)
+}
+
+// checkFixity reads the content file and compares its digest with the
+// indexed digest. It returns the result and the number of bytes read.
+func (s *Service) checkFixity(ctx context.Context, file FixityInfo, limiter *rateLimiter) (FixityResult, int64, error) {
+	result := FixityResult{Status: FixityFail, CheckedAt: time.Now()}
+	alg, err := digest.DefaultRegistry().Get(file.Alg())
+	if err != nil {
+		result.Error = "unsupported digest algorithm: " + file.Alg()
+		return result, 0, nil
+	}
+	f, err := s.root.FS().OpenFile(ctx, path.Join(file.StoragePath(), file.ContentPath()))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			result.Error = "content file not found"
+			return result, 0, nil
+		}
+		return result, 0, err
+	}
+	defer f.Close()
+	digester := alg.Digester()
+	n, err := io.Copy(digester, &rateLimitedReader{ctx: ctx, r: f, limiter: limiter})
+	if err != nil {
+		return result, n, err
+	}
+	switch got := digester.String(); {
+	case !strings.EqualFold(got, file.Digest()):
+		result.Error = fmt.Sprintf("digest mismatch: computed %s %s", alg.ID(), got)
+	case file.HasSize() && n != file.Size():
+		result.Error = fmt.Sprintf("size mismatch: read %d bytes, expected %d", n, file.Size())
+	default:
+		result.Status = FixityPass
+	}
+	return result, n, nil
+}
+
+// rateLimiter limits the average rate of reads by rateLimitedReaders that
+// share it.
+type rateLimiter struct {
+	rate  int64 // bytes per second; 0 for no limit
+	start time.Time
+	total int64 // bytes read since start
+}
+
+// wait records that n bytes were read and sleeps until the average rate since
+// start is at most the limiter's rate.
+func (l *rateLimiter) wait(ctx context.Context, n int) error {
+	if l.rate <= 0 {
+		return nil
+	}
+	l.total += int64(n)
+	due := l.start.Add(time.Duration(float64(l.total) / float64(l.rate) * float64(time.Second)))
+	delay := time.Until(due)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+type rateLimitedReader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *rateLimiter
+}
+
+func (r *rateLimitedReader) Read(p []byte) (int, error) {
+	if rate := r.limiter.rate; rate > 0 && int64(len(p)) > rate {
+		// avoid bursts larger than one second of reading
+		p = p[:rate]
+	}
+	n, err := r.r.Read(p)
+	if waitErr := r.limiter.wait(r.ctx, n); waitErr != nil && err == nil {
+		err = waitErr
+	}
+	return n, err
+}
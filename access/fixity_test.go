@@ -0,0 +1,104 @@
+package access_test
+
+import (
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/be"
+	ocflfs "github.com/srerickson/ocfl-go/fs"
+	"github.com/srerickson/ocfl-services/access"
+)
+
+func TestService_AuditFixity(t *testing.T) {
+	ctx := t.Context()
+	svc := testService(t)
+	_, err := svc.IndexRoot(ctx, access.IndexOptions{})
+	be.NilErr(t, err)
+	obj, err := svc.SyncObject(ctx, fixtureObjectID)
+	be.NilErr(t, err)
+
+	t.Run("unchecked", func(t *testing.T) {
+		summary, err := svc.FixitySummary(ctx)
+		be.NilErr(t, err)
+		be.Equal(t, 3, summary.NumFiles)
+		be.Equal(t, 0, summary.NumChecked)
+		files, err := svc.ObjectFixity(ctx, fixtureObjectID, access.FixityUnchecked)
+		be.NilErr(t, err)
+		be.Equal(t, 3, len(files))
+		for _, f := range files {
+			be.Equal(t, access.FixityUnchecked, f.FixityStatus())
+			be.True(t, f.FixityCheckedAt().IsZero())
+		}
+	})
+
+	t.Run("max files", func(t *testing.T) {
+		result, err := svc.AuditFixity(ctx, access.FixityAuditOptions{Interval: time.Hour, MaxFiles: 2})
+		be.NilErr(t, err)
+		be.Equal(t, 2, result.Checked)
+		be.Equal(t, 0, result.Failed)
+		be.True(t, result.Bytes > 0)
+		// only the remaining file is due
+		result, err = svc.AuditFixity(ctx, access.FixityAuditOptions{Interval: time.Hour, MaxRate: 1 << 20})
+		be.NilErr(t, err)
+		be.Equal(t, 1, result.Checked)
+		result, err = svc.AuditFixity(ctx, access.FixityAuditOptions{Interval: time.Hour})
+		be.NilErr(t, err)
+		be.Equal(t, 0, result.Checked)
+		summary, err := svc.FixitySummary(ctx)
+		be.NilErr(t, err)
+		be.Equal(t, 3, summary.NumChecked)
+		be.Equal(t, 0, summary.NumFailed)
+		be.False(t, summary.OldestCheck.IsZero())
+	})
+
+	t.Run("corrupt and missing files", func(t *testing.T) {
+		fsys := svc.Root().FS()
+		_, err := ocflfs.Write(ctx, fsys, path.Join(obj.StoragePath(), "v2/content/README.md"), strings.NewReader("corrupt"))
+		be.NilErr(t, err)
+		be.NilErr(t, ocflfs.Remove(ctx, fsys, path.Join(obj.StoragePath(), "v1/content/a_file.txt")))
+		// a negative interval makes every file due
+		result, err := svc.AuditFixity(ctx, access.FixityAuditOptions{Interval: -time.Hour})
+		be.NilErr(t, err)
+		be.Equal(t, 3, result.Checked)
+		be.Equal(t, 2, result.Failed)
+		failed, err := svc.ObjectFixity(ctx, fixtureObjectID, access.FixityFail)
+		be.NilErr(t, err)
+		be.Equal(t, 2, len(failed))
+		be.Equal(t, "v1/content/a_file.txt", failed[0].ContentPath())
+		be.In(t, "not found", failed[0].FixityError())
+		be.Equal(t, "v2/content/README.md", failed[1].ContentPath())
+		be.In(t, "digest mismatch", failed[1].FixityError())
+		failures, err := svc.FixityFailures(ctx, 10)
+		be.NilErr(t, err)
+		be.Equal(t, 2, len(failures))
+		be.Equal(t, fixtureObjectID, failures[0].ObjectID())
+		summary, err := svc.FixitySummary(ctx)
+		be.NilErr(t, err)
+		be.Equal(t, 2, summary.NumFailed)
+	})
+
+	t.Run("unreadable files", func(t *testing.T) {
+		// a directory in place of the missing content file can't be read, but
+		// it isn't missing either.
+		fsys := svc.Root().FS()
+		_, err := ocflfs.Write(ctx, fsys, path.Join(obj.StoragePath(), "v1/content/a_file.txt/file"), strings.NewReader("x"))
+		be.NilErr(t, err)
+		result, err := svc.AuditFixity(ctx, access.FixityAuditOptions{Interval: -time.Hour})
+		be.NilErr(t, err)
+		be.Equal(t, 2, result.Checked)
+		be.Equal(t, 1, result.Failed)
+		be.Equal(t, 1, result.Errors)
+		errored, err := svc.ObjectFixity(ctx, fixtureObjectID, access.FixityErrored)
+		be.NilErr(t, err)
+		be.Equal(t, 1, len(errored))
+		be.Equal(t, "v1/content/a_file.txt", errored[0].ContentPath())
+		be.True(t, errored[0].FixityError() != "")
+		be.False(t, errored[0].FixityCheckedAt().IsZero())
+		// the errored file isn't due until after the interval
+		result, err = svc.AuditFixity(ctx, access.FixityAuditOptions{Interval: time.Hour})
+		be.NilErr(t, err)
+		be.Equal(t, 0, result.Checked+result.Errors)
+	})
+}
@@ -0,0 +1,532 @@
+package access
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// maximum size of an object policy file
+const maxObjectPolicySize = 1 << 20
+
+// Effect is the result of an access rule that matches a request.
+type Effect string
+
+const (
+	Allow Effect = "allow"
+	Deny  Effect = "deny"
+)
+
+// Action is an operation on an object that access rules apply to.
+type Action string
+
+const (
+	Read  Action = "read"  // view the object's versions, files, and metadata
+	Write Action = "write" // commit new versions and open drafts
+)
+
+// Principal is the user or client that access policies are evaluated for.
+type Principal struct {
+	ID     string   // principal identifier
+	Groups []string // groups the principal belongs to
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx with the principal used to evaluate
+// access policies. Service methods called with a context that doesn't have a
+// principal are evaluated as anonymous requests.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom returns the principal in ctx, or nil if ctx doesn't have one.
+func PrincipalFrom(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+// Subjects is a set of principals.
+type Subjects struct {
+	Principals []string `json:"principals,omitempty"` // principal IDs; "*" is any principal
+	Groups     []string `json:"groups,omitempty"`     // principals in any of the groups
+	Anonymous  bool     `json:"anonymous,omitempty"`  // requests without a principal
+}
+
+// Includes reports whether p is in the set. A nil p is an anonymous request.
+func (s Subjects) Includes(p *Principal) bool {
+	if p == nil {
+		return s.Anonymous
+	}
+	if slices.Contains(s.Principals, "*") || slices.Contains(s.Principals, p.ID) {
+		return true
+	}
+	return slices.ContainsFunc(p.Groups, func(g string) bool {
+		return slices.Contains(s.Groups, g)
+	})
+}
+
+func (s Subjects) empty() bool {
+	return len(s.Principals) == 0 && len(s.Groups) == 0 && !s.Anonymous
+}
+
+// Rule allows or denies access to objects for a set of principals. A rule
+// without principals, groups, or anonymous applies to all requests. A rule
+// without object patterns or path prefixes applies to all objects; otherwise
+// it applies to objects matching any of them. A rule without actions applies
+// to reads only.
+type Rule struct {
+	Effect Effect `json:"effect"`
+	Subjects
+
+	// Actions the rule applies to: "read", "write", or both. Defaults to
+	// "read".
+	Actions []Action `json:"actions,omitempty"`
+
+	// Objects are object ID patterns, in which "*" matches any sequence of
+	// characters.
+	Objects []string `json:"objects,omitempty"`
+
+	// PathPrefixes are object storage path prefixes, relative to the storage
+	// root. Prefixes match whole path elements: "restricted" matches
+	// "restricted/obj1" but not "restricted-2/obj1".
+	PathPrefixes []string `json:"path_prefixes,omitempty"`
+}
+
+func (r Rule) matches(p *Principal, action Action, objID string, objPath string) bool {
+	if !r.appliesTo(action) {
+		return false
+	}
+	if !r.Subjects.empty() && !r.Subjects.Includes(p) {
+		return false
+	}
+	if len(r.Objects) == 0 && len(r.PathPrefixes) == 0 {
+		return true
+	}
+	for _, pattern := range r.Objects {
+		if matchPattern(pattern, objID) {
+			return true
+		}
+	}
+	for _, prefix := range r.PathPrefixes {
+		prefix = strings.Trim(prefix, "/")
+		if prefix == "" || objPath == prefix || strings.HasPrefix(objPath, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Rule) appliesTo(action Action) bool {
+	if len(r.Actions) == 0 {
+		return action == Read
+	}
+	return slices.Contains(r.Actions, action)
+}
+
+func (r Rule) validate() error {
+	if r.Effect != Allow && r.Effect != Deny {
+		return fmt.Errorf("invalid rule effect: %q", r.Effect)
+	}
+	for _, action := range r.Actions {
+		if action != Read && action != Write {
+			return fmt.Errorf("invalid rule action: %q", action)
+		}
+	}
+	return nil
+}
+
+// Policy controls which principals can read and write objects in a storage
+// root. Objects that a principal can't read are reported as not found.
+//
+// For each object and action, the policy is evaluated in order:
+//
+//   - If the object is embargoed by its object policy file, access is denied
+//     unless the principal is in EmbargoExempt.
+//   - The first matching rule from the object policy file.
+//   - The first matching rule from Rules.
+//   - Default for reads; writes are denied.
+//
+// Writes also require read access. Without a policy, all reads are allowed
+// and all writes are denied.
+type Policy struct {
+	// Default is the effect if no rule matches a read: "allow" (the
+	// default) or "deny". Writes that no rule allows are denied.
+	Default Effect `json:"default,omitempty"`
+
+	// Rules are evaluated in order; the first matching rule applies.
+	Rules []Rule `json:"rules,omitempty"`
+
+	// ObjectPolicyFile is the logical path of a policy file in objects'
+	// head versions (see ObjectPolicy). If empty, objects' contents aren't
+	// used to evaluate access.
+	ObjectPolicyFile string `json:"object_policy_file,omitempty"`
+
+	// EmbargoExempt are principals that can access embargoed objects.
+	EmbargoExempt Subjects `json:"embargo_exempt,omitzero"`
+
+	// Admins are principals that can add, change, or remove object policy
+	// files in objects that they can write.
+	Admins Subjects `json:"admins,omitzero"`
+}
+
+// LoadPolicy reads a JSON-encoded access policy from the file name.
+func LoadPolicy(name string) (*Policy, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	pol, err := ParsePolicy(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading access policy %q: %w", name, err)
+	}
+	return pol, nil
+}
+
+// ParsePolicy reads a JSON-encoded access policy from r:
+//
+//	{
+//	  "default": "deny",
+//	  "object_policy_file": "access.json",
+//	  "embargo_exempt": {"groups": ["curators"]},
+//	  "admins": {"groups": ["curators"]},
+//	  "rules": [
+//	    {"effect": "deny", "objects": ["ark:/12345/private-*"]},
+//	    {"effect": "allow", "groups": ["staff"], "actions": ["read", "write"]},
+//	    {"effect": "allow", "anonymous": true, "path_prefixes": ["public"]}
+//	  ]
+//	}
+func ParsePolicy(r io.Reader) (*Policy, error) {
+	var pol Policy
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&pol); err != nil {
+		return nil, err
+	}
+	if pol.Default != "" && pol.Default != Allow && pol.Default != Deny {
+		return nil, fmt.Errorf("invalid default effect: %q", pol.Default)
+	}
+	for i, rule := range pol.Rules {
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+	}
+	return &pol, nil
+}
+
+// ObjectPolicy is an access policy stored as a JSON file in an object. It
+// is read from the object's head version.
+//
+//	{
+//	  "embargo_until": "2030-01-01",
+//	  "rules": [{"effect": "allow", "principals": ["depositor-1"]}]
+//	}
+type ObjectPolicy struct {
+	// Rules apply to the object; their object patterns and path prefixes
+	// are ignored.
+	Rules []Rule `json:"rules,omitempty"`
+
+	// EmbargoUntil is a date (2006-01-02) or an RFC 3339 timestamp. Until
+	// then, the object can only be accessed by principals exempt from
+	// embargoes.
+	EmbargoUntil string `json:"embargo_until,omitempty"`
+
+	embargoUntil time.Time
+}
+
+// ParseObjectPolicy reads a JSON-encoded object policy from r.
+func ParseObjectPolicy(r io.Reader) (*ObjectPolicy, error) {
+	var pol ObjectPolicy
+	if err := json.NewDecoder(r).Decode(&pol); err != nil {
+		return nil, err
+	}
+	for i, rule := range pol.Rules {
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+		// rules only apply to the object
+		pol.Rules[i].Objects = nil
+		pol.Rules[i].PathPrefixes = nil
+	}
+	if pol.EmbargoUntil != "" {
+		var err error
+		pol.embargoUntil, err = time.Parse(time.DateOnly, pol.EmbargoUntil)
+		if err != nil {
+			pol.embargoUntil, err = time.Parse(time.RFC3339, pol.EmbargoUntil)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid embargo date: %q", pol.EmbargoUntil)
+		}
+	}
+	return &pol, nil
+}
+
+// Embargoed reports whether the object is embargoed at time t.
+func (op *ObjectPolicy) Embargoed(t time.Time) bool {
+	return op != nil && t.Before(op.embargoUntil)
+}
+
+// allows reports whether the policy allows p to perform the action on the
+// object with the ID and storage path (relative to the storage root). objPol
+// is the object's policy file, or nil if it doesn't have one.
+func (pol *Policy) allows(p *Principal, action Action, objID, objPath string, objPol *ObjectPolicy, now time.Time) bool {
+	if objPol.Embargoed(now) && !pol.EmbargoExempt.Includes(p) {
+		return false
+	}
+	var rules []Rule
+	if objPol != nil {
+		rules = objPol.Rules
+	}
+	for _, rule := range slices.Concat(rules, pol.Rules) {
+		if rule.matches(p, action, objID, objPath) {
+			return rule.Effect == Allow
+		}
+	}
+	return action == Read && pol.Default != Deny
+}
+
+// authorize returns an error wrapping ErrNotFound if the principal in ctx
+// can't read obj. If the object's policy file can't be read, access is
+// denied.
+func (s *Service) authorize(ctx context.Context, obj ObjectInfo) error {
+	if s.policy == nil {
+		return nil
+	}
+	objPol, err := s.objectPolicy(ctx, obj)
+	if err != nil {
+		s.logger.LogAttrs(ctx, slog.LevelError, "reading object policy: "+err.Error(),
+			slog.String("object_id", obj.ID()))
+		return fmt.Errorf("with object_id=%q: %w", obj.ID(), ErrNotFound)
+	}
+	if !s.allows(ctx, Read, obj.ID(), obj.StoragePath(), objPol) {
+		return fmt.Errorf("with object_id=%q: %w", obj.ID(), ErrNotFound)
+	}
+	return nil
+}
+
+// authorizeWrite returns an error if the principal in ctx can't commit new
+// versions of the object with the ID and storage path. obj is the object's
+// index entry, or nil if the object doesn't exist yet. The error wraps
+// ErrNotFound if the principal can't read the object, or ErrForbidden if it
+// can read but not write it. Without a policy, writes are denied.
+func (s *Service) authorizeWrite(ctx context.Context, objID string, storagePath string, obj ObjectInfo) error {
+	if s.policy == nil {
+		return fmt.Errorf("with object_id=%q: writes require an access policy: %w", objID, ErrForbidden)
+	}
+	var objPol *ObjectPolicy
+	if obj != nil {
+		if err := s.authorize(ctx, obj); err != nil {
+			return err
+		}
+		var err error
+		if objPol, err = s.objectPolicy(ctx, obj); err != nil {
+			return err
+		}
+	} else if !s.allows(ctx, Read, objID, storagePath, nil) {
+		return fmt.Errorf("with object_id=%q: %w", objID, ErrNotFound)
+	}
+	if !s.allows(ctx, Write, objID, storagePath, objPol) {
+		return fmt.Errorf("with object_id=%q: %w", objID, ErrForbidden)
+	}
+	return nil
+}
+
+// AuthorizeUploads returns an error wrapping ErrForbidden if the principal in
+// ctx can't commit versions of any object, so uploads can be rejected before
+// their content is staged. Principals that may be allowed to write by object
+// policy files can upload; commits are still checked for each object.
+func (s *Service) AuthorizeUploads(ctx context.Context) error {
+	if s.policy == nil {
+		return fmt.Errorf("writes require an access policy: %w", ErrForbidden)
+	}
+	if !s.policy.mayWrite(PrincipalFrom(ctx)) {
+		return fmt.Errorf("write permission is required: %w", ErrForbidden)
+	}
+	return nil
+}
+
+// mayWrite reports whether p may be allowed to write some object: whether
+// any rule allows p to write, or object policy files, which can allow
+// writes, are used.
+func (pol *Policy) mayWrite(p *Principal) bool {
+	if pol.ObjectPolicyFile != "" {
+		return true
+	}
+	return slices.ContainsFunc(pol.Rules, func(r Rule) bool {
+		return r.Effect == Allow && r.appliesTo(Write) &&
+			(r.Subjects.empty() || r.Subjects.Includes(p))
+	})
+}
+
+// authorizeAdmin returns an error wrapping ErrForbidden if the principal in
+// ctx isn't one of the policy's admins.
+func (s *Service) authorizeAdmin(ctx context.Context) error {
+	if s.policy == nil || !s.policy.Admins.Includes(PrincipalFrom(ctx)) {
+		return fmt.Errorf("admin permission is required: %w", ErrForbidden)
+	}
+	return nil
+}
+
+// isPolicyPath reports whether the logical path name is the object policy
+// file or a directory that includes it.
+func (s *Service) isPolicyPath(name string) bool {
+	if s.policy == nil || s.policy.ObjectPolicyFile == "" {
+		return false
+	}
+	polFile := strings.Trim(s.policy.ObjectPolicyFile, "/")
+	return name == polFile || strings.HasPrefix(polFile, name+"/")
+}
+
+// allows reports whether the service's policy allows the principal in ctx to
+// perform the action on the object. Denied requests are logged.
+func (s *Service) allows(ctx context.Context, action Action, objID string, storagePath string, objPol *ObjectPolicy) bool {
+	principal := PrincipalFrom(ctx)
+	objPath := s.rootRelPath(storagePath)
+	if s.policy.allows(principal, action, objID, objPath, objPol, time.Now()) {
+		return true
+	}
+	attrs := []slog.Attr{slog.String("object_id", objID), slog.String("action", string(action))}
+	if principal != nil {
+		attrs = append(attrs, slog.String("principal", principal.ID))
+	}
+	s.logger.LogAttrs(ctx, slog.LevelDebug, "object access denied", attrs...)
+	return false
+}
+
+// rootRelPath returns the storage path p relative to the storage root.
+func (s *Service) rootRelPath(p string) string {
+	if rootPath := s.root.Path(); rootPath != "." {
+		return strings.TrimPrefix(p, rootPath+"/")
+	}
+	return p
+}
+
+// objectPolicy returns the parsed policy file from obj's head version, or nil
+// if the service's policy doesn't use policy files or the object doesn't
+// have one. Policies are cached until the object's inventory changes. Only
+// objects with policy files are cached, so the cache doesn't grow with the
+// number of objects accessed.
+func (s *Service) objectPolicy(ctx context.Context, obj ObjectInfo) (*ObjectPolicy, error) {
+	name := s.policy.ObjectPolicyFile
+	if name == "" {
+		return nil, nil
+	}
+	s.policyMu.Lock()
+	cached, ok := s.objectPolicies[obj.ID()]
+	s.policyMu.Unlock()
+	if ok && cached.inventoryDigest == obj.InventoryDigest() {
+		return cached.policy, nil
+	}
+	var objPol *ObjectPolicy
+	info, err := s.db.StatObjectVersionFile(ctx, s.rootID, obj.ID(), obj.Head().Num(), name)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		// no policy file
+	case err != nil:
+		return nil, err
+	default:
+		f, err := s.root.FS().OpenFile(ctx, path.Join(obj.StoragePath(), info.ContentPath()))
+		if err != nil {
+			return nil, err
+		}
+		objPol, err = ParseObjectPolicy(io.LimitReader(f, maxObjectPolicySize))
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	s.policyMu.Lock()
+	if objPol != nil {
+		s.objectPolicies[obj.ID()] = cachedObjectPolicy{
+			inventoryDigest: obj.InventoryDigest(),
+			policy:          objPol,
+		}
+	} else {
+		delete(s.objectPolicies, obj.ID())
+	}
+	s.policyMu.Unlock()
+	return objPol, nil
+}
+
+// cachedObjectPolicy is an object's policy file for an inventory digest.
+type cachedObjectPolicy struct {
+	inventoryDigest string
+	policy          *ObjectPolicy
+}
+
+// number of index entries read at a time by filterPage
+const filterBatchSize = 100
+
+// objectAllowed returns a function that reports whether the principal in ctx
+// can access an object, for use with filterPage.
+func (s *Service) objectAllowed(ctx context.Context) func(ObjectInfo) (bool, error) {
+	return func(obj ObjectInfo) (bool, error) {
+		return s.authorize(ctx, obj) == nil, nil
+	}
+}
+
+// filterPage returns up to limit entries for which allowed returns true,
+// after skipping the first offset of them. Entries are read from the index in
+// batches with list, which returns up to limit entries starting at offset, so
+// that pages don't depend on how many entries the policy removes.
+func filterPage[T any](offset, limit int, list func(offset, limit int) ([]T, error), allowed func(T) (bool, error)) ([]T, error) {
+	if limit < 1 {
+		return nil, nil
+	}
+	batchSize := max(limit, filterBatchSize)
+	var page []T
+	for start := 0; ; start += batchSize {
+		batch, err := list(start, batchSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range batch {
+			ok, err := allowed(entry)
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case !ok:
+			case offset > 0:
+				offset--
+			default:
+				page = append(page, entry)
+				if len(page) == limit {
+					return page, nil
+				}
+			}
+		}
+		if len(batch) < batchSize {
+			return page, nil
+		}
+	}
+}
+
+// matchPattern reports whether s matches pattern, in which "*" matches any
+// sequence of characters (including "/") and other characters match
+// themselves.
+func matchPattern(pattern, s string) bool {
+	prefix, rest, wildcard := strings.Cut(pattern, "*")
+	if !wildcard {
+		return pattern == s
+	}
+	if !strings.HasPrefix(s, prefix) {
+		return false
+	}
+	s = s[len(prefix):]
+	for i := 0; i <= len(s); i++ {
+		if matchPattern(rest, s[i:]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+package access_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/carlmjohnson/be"
+	"github.com/srerickson/ocfl-go"
+	"github.com/srerickson/ocfl-go/digest"
+	"github.com/srerickson/ocfl-services/access"
+)
+
+func TestService_Policy(t *testing.T) {
+	policy, err := access.ParsePolicy(strings.NewReader(`{
+		"default": "deny",
+		"object_policy_file": "access.json",
+		"embargo_exempt": {"groups": ["curators"]},
+		"rules": [
+			{"effect": "allow", "anonymous": true, "objects": ["public-*"]},
+			{"effect": "allow", "groups": ["staff", "curators"]}
+		]
+	}`))
+	be.NilErr(t, err)
+	svc := testPolicyService(t, policy, map[string]map[string][]byte{
+		"public-1":  {"a.txt": []byte("public")},
+		"embargoed": {"access.json": []byte(`{"embargo_until": "2999-01-01"}`)},
+		"private-1": {"access.json": []byte(`{"rules": [{"effect": "allow", "principals": ["depositor"]}]}`)},
+		"invalid":   {"access.json": []byte(`{"embargo_until": "someday"}`)},
+	})
+	_, err = svc.IndexRoot(t.Context(), access.IndexOptions{})
+	be.NilErr(t, err)
+
+	anonymous := t.Context()
+	staff := access.WithPrincipal(t.Context(), &access.Principal{ID: "staff-1", Groups: []string{"staff"}})
+	curator := access.WithPrincipal(t.Context(), &access.Principal{ID: "curator-1", Groups: []string{"curators"}})
+	depositor := access.WithPrincipal(t.Context(), &access.Principal{ID: "depositor"})
+
+	t.Run("object access", func(t *testing.T) {
+		for _, tc := range []struct {
+			name    string
+			ctx     context.Context
+			objects map[string]bool // allowed by object ID
+		}{
+			{"anonymous", anonymous, map[string]bool{"public-1": true, fixtureObjectID: false, "embargoed": false, "private-1": false}},
+			{"staff", staff, map[string]bool{"public-1": true, fixtureObjectID: true, "embargoed": false, "private-1": true, "invalid": false}},
+			{"curator", curator, map[string]bool{"public-1": true, fixtureObjectID: true, "embargoed": true, "invalid": false}},
+			{"depositor", depositor, map[string]bool{"public-1": false, fixtureObjectID: false, "private-1": true}},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				for objID, allowed := range tc.objects {
+					_, err := svc.SyncObject(tc.ctx, objID)
+					if allowed {
+						be.NilErr(t, err)
+						continue
+					}
+					be.True(t, errors.Is(err, access.ErrNotFound))
+				}
+			})
+		}
+	})
+
+	t.Run("denied files are not found", func(t *testing.T) {
+		_, err := svc.OpenVersionFile(depositor, "public-1", 0, "a.txt")
+		be.True(t, errors.Is(err, access.ErrNotFound))
+		_, err = svc.ReadVersionDir(anonymous, "private-1", 0, ".")
+		be.True(t, errors.Is(err, access.ErrNotFound))
+		_, err = svc.OpenObjectInventory(anonymous, fixtureObjectID)
+		be.True(t, errors.Is(err, access.ErrNotFound))
+		f, err := svc.OpenVersionFile(anonymous, "public-1", 0, "a.txt")
+		be.NilErr(t, err)
+		defer f.Close()
+		b, err := io.ReadAll(f)
+		be.NilErr(t, err)
+		be.Equal(t, "public", string(b))
+	})
+
+	t.Run("lists are filtered", func(t *testing.T) {
+		objs, err := svc.ListObjects(anonymous, access.ListObjectOptions{Limit: 10})
+		be.NilErr(t, err)
+		be.Equal(t, 1, len(objs))
+		be.Equal(t, "public-1", objs[0].ID())
+		objs, err = svc.SearchObjects(staff, "", access.SearchObjectOptions{Limit: 10})
+		be.NilErr(t, err)
+		be.Equal(t, 3, len(objs))
+		results, err := svc.SearchContent(depositor, "access.json", access.SearchContentOptions{
+			Field: access.SearchPath,
+			Limit: 10,
+		})
+		be.NilErr(t, err)
+		be.Equal(t, 1, len(results))
+		be.Equal(t, "private-1", results[0].ObjectID())
+	})
+
+	t.Run("pages include only allowed objects", func(t *testing.T) {
+		// public-1 is the last object by ID
+		objs, err := svc.ListObjects(anonymous, access.ListObjectOptions{Limit: 1})
+		be.NilErr(t, err)
+		be.Equal(t, 1, len(objs))
+		be.Equal(t, "public-1", objs[0].ID())
+		// staff can access the fixture object, private-1, and public-1
+		objs, err = svc.ListObjects(staff, access.ListObjectOptions{Offset: 1, Limit: 1})
+		be.NilErr(t, err)
+		be.Equal(t, 1, len(objs))
+		be.Equal(t, "private-1", objs[0].ID())
+		objs, err = svc.SearchObjects(staff, "p", access.SearchObjectOptions{Offset: 1, Limit: 10})
+		be.NilErr(t, err)
+		be.Equal(t, 1, len(objs))
+		be.Equal(t, "public-1", objs[0].ID())
+	})
+
+	t.Run("counts are hidden", func(t *testing.T) {
+		_, err := svc.Metrics(staff)
+		be.True(t, errors.Is(err, access.ErrCountsHidden))
+		_, err = svc.FixitySummary(staff)
+		be.True(t, errors.Is(err, access.ErrCountsHidden))
+	})
+}
+
+func TestService_PolicyPathPrefix(t *testing.T) {
+	// the fixture object's storage path is a47/817/83d/cec/ark%3a123%2fabc
+	policy := &access.Policy{
+		Default: access.Deny,
+		Rules: []access.Rule{
+			{Effect: access.Allow, PathPrefixes: []string{"a47/8"}},
+			{Effect: access.Allow, Subjects: access.Subjects{Principals: []string{"*"}}, PathPrefixes: []string{"a47/817/"}},
+		},
+	}
+	svc := testPolicyService(t, policy, nil)
+	_, err := svc.SyncObject(t.Context(), fixtureObjectID)
+	be.True(t, errors.Is(err, access.ErrNotFound))
+	ctx := access.WithPrincipal(t.Context(), &access.Principal{ID: "user-1"})
+	_, err = svc.SyncObject(ctx, fixtureObjectID)
+	be.NilErr(t, err)
+}
+
+func TestParsePolicy(t *testing.T) {
+	for _, content := range []string{
+		`{"default": "maybe"}`,
+		`{"rules": [{"effect": "permit"}]}`,
+		`{"rules": [{"effect": "allow", "user": "alice"}]}`,
+		`{"rules": [{"effect": "allow", "actions": ["delete"]}]}`,
+		`not json`,
+	} {
+		_, err := access.ParsePolicy(strings.NewReader(content))
+		be.Nonzero(t, err)
+	}
+}
+
+// testPolicyService returns a service with the access policy for a copy of
+// the fixture root that includes additional objects with the given contents.
+func testPolicyService(t *testing.T, policy *access.Policy, objects map[string]map[string][]byte) *access.Service {
+	t.Helper()
+	ctx := t.Context()
+	svc := testService(t, access.WithPolicy(policy))
+	for objID, content := range objects {
+		obj, err := svc.Root().NewObject(ctx, objID)
+		if err != nil {
+			t.Fatal("creating test object:", err)
+		}
+		stage, err := ocfl.StageBytes(content, digest.SHA512)
+		if err != nil {
+			t.Fatal("staging test object content:", err)
+		}
+		if _, err := obj.Update(ctx, stage, "test object", ocfl.User{Name: "tester"}); err != nil {
+			t.Fatal("creating test object:", err)
+		}
+	}
+	return svc
+}
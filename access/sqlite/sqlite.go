@@ -18,25 +18,57 @@ import (
 	"zombiezen.com/go/sqlite/sqlitex"
 )
 
-// the number of goroutines used to stat files to get file sizes.
-const stat_concurrency = 4
+// default number of goroutines used to stat files to get file sizes.
+const defaultStatConcurrency = 4
 
 // DB is a sqlite-base implementation of access.Database. It supports
 // indexing and access quries for objects in OCFL repository using a sqlite
 // database.
 type DB struct {
 	Pool *sqlitemigration.Pool
+
+	statConcurrency int
+}
+
+// options holds settings for NewDB.
+type options struct {
+	poolSize        int
+	statConcurrency int
 }
 
-func NewDB(uri string) (*DB, error) {
+// Option is used to configure the DB returned by NewDB.
+type Option func(*options)
+
+// WithPoolSize sets the max number of open database connections. If n is 0,
+// the sqlitemigration default is used.
+func WithPoolSize(n int) Option {
+	return func(o *options) { o.poolSize = n }
+}
+
+// WithStatConcurrency sets the number of goroutines used to stat content
+// files to get their sizes when objects are indexed. The default is 4.
+func WithStatConcurrency(n int) Option {
+	return func(o *options) { o.statConcurrency = n }
+}
+
+func NewDB(uri string, opts ...Option) (*DB, error) {
+	o := options{statConcurrency: defaultStatConcurrency}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.poolSize < 0 {
+		return nil, fmt.Errorf("invalid pool size: %d", o.poolSize)
+	}
+	if o.statConcurrency < 1 {
+		return nil, fmt.Errorf("invalid stat concurrency: %d", o.statConcurrency)
+	}
 	schema := sqlitemigration.Schema{
-		Migrations: []string{
-			ocflite.MigrateSQL(),
-		},
+		Migrations: ocflite.Migrations(),
 	}
-	opts := sqlitemigration.Options{}
+	poolOpts := sqlitemigration.Options{PoolSize: o.poolSize}
 	db := &DB{
-		Pool: sqlitemigration.NewPool(uri, schema, opts),
+		Pool:            sqlitemigration.NewPool(uri, schema, poolOpts),
+		statConcurrency: o.statConcurrency,
 	}
 	return db, nil
 }
@@ -78,6 +110,18 @@ func (db *DB) UnsetObject(ctx context.Context, rootID string, objID string) (err
 	return
 }
 
+func (db *DB) PruneObjects(ctx context.Context, rootID string, indexedBy time.Time, keep func(string) bool) (ids []string, err error) {
+	conn, err := db.Pool.Take(ctx)
+	if err != nil {
+		return
+	}
+	defer db.Pool.Put(conn)
+	commit := sqlitex.Transaction(conn)
+	defer commit(&err)
+	ids, err = ocflite.PruneObjects(conn, rootID, indexedBy, keep)
+	return
+}
+
 func (db *DB) GetObject(ctx context.Context, rootID string, objID string) (access.ObjectInfo, error) {
 	conn, err := db.Pool.Take(ctx)
 	if err != nil {
@@ -186,6 +230,26 @@ func (db *DB) ReadObjectVersionDir(ctx context.Context, rootID string, objID str
 	return result, nil
 }
 
+func (db *DB) ReadObjectVersionFiles(ctx context.Context, rootID string, objID string, vn int, dir string) ([]access.VersionFileInfo, error) {
+	conn, err := db.Pool.Take(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Pool.Put(conn)
+	files, err := ocflite.ReadVersionFiles(conn, rootID, objID, vn, dir)
+	if err != nil {
+		if errors.Is(err, ocflite.ErrNotFound) {
+			return nil, access.ErrNotFound
+		}
+		return nil, err
+	}
+	result := make([]access.VersionFileInfo, len(files))
+	for i, info := range files {
+		result[i] = &versionFileInfo{info: info}
+	}
+	return result, nil
+}
+
 func (db *DB) StatObjectVersionFile(ctx context.Context, rootID string, objID string, vn int, name string) (access.VersionFileInfo, error) {
 	conn, err := db.Pool.Take(ctx)
 	if err != nil {
@@ -202,13 +266,68 @@ func (db *DB) StatObjectVersionFile(ctx context.Context, rootID string, objID st
 	return &versionFileInfo{info: info}, nil
 }
 
+func (db *DB) SetObjectFileMediaType(ctx context.Context, rootID string, objID string, digest string, mediaType string) error {
+	conn, err := db.Pool.Take(ctx)
+	if err != nil {
+		return err
+	}
+	defer db.Pool.Put(conn)
+	return ocflite.SetObjectFileMediaType(conn, rootID, objID, digest, mediaType)
+}
+
 func (db *DB) ListObjects(ctx context.Context, rootID string, opts access.ListObjectOptions) ([]access.ObjectInfo, error) {
 	conn, err := db.Pool.Take(ctx)
 	if err != nil {
 		return nil, err
 	}
 	defer db.Pool.Put(conn)
-	result, err := ocflite.ListObjects(conn, rootID, opts.Limit, opts.Offset)
+	var sort ocflite.ObjectSort
+	switch opts.Sort {
+	case access.SortByID, "":
+		sort = ocflite.SortByID
+	case access.SortByHead:
+		sort = ocflite.SortByHead
+	case access.SortByCreated:
+		sort = ocflite.SortByCreated
+	case access.SortByUpdated:
+		sort = ocflite.SortByUpdated
+	case access.SortByStoragePath:
+		sort = ocflite.SortByStoragePath
+	default:
+		return nil, fmt.Errorf("invalid object sort value: %q", opts.Sort)
+	}
+	result, err := ocflite.ListObjects(conn, rootID, opts.Limit, opts.Offset, sort, opts.Desc)
+	if err != nil {
+		return nil, err
+	}
+	objects := make([]access.ObjectInfo, len(result))
+	for i := range result {
+		objects[i] = &objectInfo{obj: result[i]}
+	}
+	return objects, nil
+}
+
+func (db *DB) SearchObjects(ctx context.Context, rootID string, query string, opts access.SearchObjectOptions) ([]access.ObjectInfo, error) {
+	search := ocflite.ObjectSearch{
+		Query:      query,
+		IgnoreCase: opts.IgnoreCase,
+		Limit:      opts.Limit,
+		Offset:     opts.Offset,
+	}
+	switch opts.Mode {
+	case access.SearchPrefix, "":
+		search.Mode = ocflite.SearchPrefix
+	case access.SearchSubstring:
+		search.Mode = ocflite.SearchSubstring
+	default:
+		return nil, fmt.Errorf("invalid search mode: %q", opts.Mode)
+	}
+	conn, err := db.Pool.Take(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Pool.Put(conn)
+	result, err := ocflite.SearchObjects(conn, rootID, search)
 	if err != nil {
 		return nil, err
 	}
@@ -219,6 +338,39 @@ func (db *DB) ListObjects(ctx context.Context, rootID string, opts access.ListOb
 	return objects, nil
 }
 
+func (db *DB) SearchContent(ctx context.Context, rootID string, query string, opts access.SearchContentOptions) ([]access.ContentSearchResult, error) {
+	search := ocflite.ContentSearch{
+		Query:    query,
+		HeadOnly: opts.HeadOnly,
+		Limit:    opts.Limit,
+		Offset:   opts.Offset,
+	}
+	switch opts.Field {
+	case access.SearchMessage, "":
+		search.Field = ocflite.SearchMessage
+	case access.SearchUserName:
+		search.Field = ocflite.SearchUserName
+	case access.SearchPath:
+		search.Field = ocflite.SearchPath
+	default:
+		return nil, fmt.Errorf("invalid search field: %q", opts.Field)
+	}
+	conn, err := db.Pool.Take(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Pool.Put(conn)
+	result, err := ocflite.SearchContent(conn, rootID, search)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]access.ContentSearchResult, len(result))
+	for i := range result {
+		results[i] = &contentSearchResult{result: result[i]}
+	}
+	return results, nil
+}
+
 func (db *DB) TouchObject(ctx context.Context, rootID string, objID string) (access.ObjectInfo, error) {
 	conn, err := db.Pool.Take(ctx)
 	if err != nil {
@@ -232,6 +384,151 @@ func (db *DB) TouchObject(ctx context.Context, rootID string, objID string) (acc
 	return &objectInfo{obj: obj}, nil
 }
 
+func (db *DB) CreateDraft(ctx context.Context, rootID string, objID string, owner string) (_ access.DraftInfo, err error) {
+	conn, err := db.Pool.Take(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Pool.Put(conn)
+	commit := sqlitex.Transaction(conn)
+	defer commit(&err)
+	draft, err := ocflite.CreateDraft(conn, rootID, objID, owner)
+	if err != nil {
+		return nil, err
+	}
+	return &draftInfo{draft: draft}, nil
+}
+
+func (db *DB) GetDraft(ctx context.Context, rootID string, draftID int64) (access.DraftInfo, error) {
+	conn, err := db.Pool.Take(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Pool.Put(conn)
+	draft, err := ocflite.GetDraft(conn, rootID, draftID)
+	if err != nil {
+		return nil, draftError(err)
+	}
+	return &draftInfo{draft: draft}, nil
+}
+
+func (db *DB) ListDrafts(ctx context.Context, rootID string, owner string) ([]access.DraftInfo, error) {
+	conn, err := db.Pool.Take(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Pool.Put(conn)
+	drafts, err := ocflite.ListDrafts(conn, rootID, owner)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]access.DraftInfo, len(drafts))
+	for i, d := range drafts {
+		result[i] = &draftInfo{draft: d}
+	}
+	return result, nil
+}
+
+func (db *DB) DeleteDraft(ctx context.Context, rootID string, draftID int64) (err error) {
+	conn, err := db.Pool.Take(ctx)
+	if err != nil {
+		return err
+	}
+	defer db.Pool.Put(conn)
+	commit := sqlitex.Transaction(conn)
+	defer commit(&err)
+	err = draftError(ocflite.DeleteDraft(conn, rootID, draftID))
+	return
+}
+
+func (db *DB) ListDraftFiles(ctx context.Context, rootID string, draftID int64) ([]access.DraftFileInfo, error) {
+	conn, err := db.Pool.Take(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Pool.Put(conn)
+	files, err := ocflite.ListDraftFiles(conn, rootID, draftID)
+	if err != nil {
+		return nil, draftError(err)
+	}
+	result := make([]access.DraftFileInfo, len(files))
+	for i, f := range files {
+		result[i] = &draftFileInfo{file: f}
+	}
+	return result, nil
+}
+
+func (db *DB) SetDraftFile(ctx context.Context, rootID string, draftID int64, name string, file access.DraftFile) (err error) {
+	conn, err := db.Pool.Take(ctx)
+	if err != nil {
+		return err
+	}
+	defer db.Pool.Put(conn)
+	commit := sqlitex.Transaction(conn)
+	defer commit(&err)
+	err = draftError(ocflite.SetDraftFile(conn, rootID, draftID, &ocflite.DraftFile{
+		Path:     name,
+		Digest:   file.Digest,
+		Size:     file.Size,
+		HasSize:  true,
+		UploadID: file.Upload,
+	}))
+	return
+}
+
+func (db *DB) RenameDraftPath(ctx context.Context, rootID string, draftID int64, src string, dst string) (err error) {
+	conn, err := db.Pool.Take(ctx)
+	if err != nil {
+		return err
+	}
+	defer db.Pool.Put(conn)
+	commit := sqlitex.Transaction(conn)
+	defer commit(&err)
+	err = draftError(ocflite.RenameDraftPath(conn, rootID, draftID, src, dst))
+	return
+}
+
+func (db *DB) RemoveDraftPath(ctx context.Context, rootID string, draftID int64, name string) (err error) {
+	conn, err := db.Pool.Take(ctx)
+	if err != nil {
+		return err
+	}
+	defer db.Pool.Put(conn)
+	commit := sqlitex.Transaction(conn)
+	defer commit(&err)
+	err = draftError(ocflite.RemoveDraftPath(conn, rootID, draftID, name))
+	return
+}
+
+func (db *DB) ReadDraftDir(ctx context.Context, rootID string, draftID int64, dir string) ([]access.VersionDirEntry, error) {
+	conn, err := db.Pool.Take(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Pool.Put(conn)
+	entries, err := ocflite.ReadDraftDir(conn, rootID, draftID, dir)
+	if err != nil {
+		return nil, draftError(err)
+	}
+	result := make([]access.VersionDirEntry, len(entries))
+	for i, entry := range entries {
+		result[i] = &versionDirEntry{entry: entry}
+	}
+	return result, nil
+}
+
+// draftError converts errors from ocflite's draft functions to errors from
+// the access package.
+func draftError(err error) error {
+	switch {
+	case errors.Is(err, ocflite.ErrNotFound):
+		return fmt.Errorf("%w: %w", access.ErrNotFound, err)
+	case errors.Is(err, ocflite.ErrPathConflict):
+		return fmt.Errorf("%w: %w", access.ErrInvalidChange, err)
+	}
+	return err
+}
+
 func (db *DB) objectContentFiles(ctx context.Context, rootID string, objID string) iter.Seq2[access.ContentFileInfo, error] {
 	return func(yield func(access.ContentFileInfo, error) bool) {
 		// return map of digest to path for content without file size
@@ -303,7 +600,7 @@ func (db *DB) setObjectFileSizes(ctx context.Context, rootID string, obj *ocfl.O
 		}
 	}
 	// get file sizes
-	sizes, err := batchStatFiles(ctx, obj.FS(), missing, stat_concurrency)
+	sizes, err := batchStatFiles(ctx, obj.FS(), missing, db.statConcurrency)
 	if err != nil {
 		return err
 	}
@@ -354,6 +651,153 @@ func objectVersions(obj *ocfl.Object) ([]*ocflite.Version, error) {
 	return versions, nil
 }
 
+func (db *DB) ListFixityDue(ctx context.Context, rootID string, before time.Time, limit int) ([]access.FixityInfo, error) {
+	conn, err := db.Pool.Take(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Pool.Put(conn)
+	files, err := ocflite.ListFixityDue(conn, rootID, before, limit)
+	return fixityInfos(files), err
+}
+
+func (db *DB) ListObjectFixity(ctx context.Context, rootID string, objID string, status access.FixityStatus) ([]access.FixityInfo, error) {
+	conn, err := db.Pool.Take(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Pool.Put(conn)
+	files, err := ocflite.ListObjectFixity(conn, rootID, objID, string(status))
+	return fixityInfos(files), err
+}
+
+func (db *DB) ListFixityFailures(ctx context.Context, rootID string, limit int) ([]access.FixityInfo, error) {
+	conn, err := db.Pool.Take(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Pool.Put(conn)
+	files, err := ocflite.ListFixityFailures(conn, rootID, limit)
+	return fixityInfos(files), err
+}
+
+func (db *DB) SetFixity(ctx context.Context, rootID string, objID string, contentPath string, result access.FixityResult) error {
+	conn, err := db.Pool.Take(ctx)
+	if err != nil {
+		return err
+	}
+	defer db.Pool.Put(conn)
+	err = ocflite.SetFixity(conn, rootID, objID, contentPath, string(result.Status), result.Error, result.CheckedAt)
+	if errors.Is(err, ocflite.ErrNotFound) {
+		err = fmt.Errorf("%w: %w", access.ErrNotFound, err)
+	}
+	return err
+}
+
+func (db *DB) FixitySummary(ctx context.Context, rootID string) (access.FixitySummary, error) {
+	conn, err := db.Pool.Take(ctx)
+	if err != nil {
+		return access.FixitySummary{}, err
+	}
+	defer db.Pool.Put(conn)
+	summary, err := ocflite.GetFixitySummary(conn, rootID)
+	if err != nil {
+		return access.FixitySummary{}, err
+	}
+	return access.FixitySummary{
+		NumFiles:    summary.NumFiles,
+		NumChecked:  summary.NumChecked,
+		NumFailed:   summary.NumFailed,
+		OldestCheck: summary.OldestCheck,
+	}, nil
+}
+
+func (db *DB) SetValidation(ctx context.Context, rootID string, report *access.ValidationReport) (err error) {
+	conn, err := db.Pool.Take(ctx)
+	if err != nil {
+		return err
+	}
+	defer db.Pool.Put(conn)
+	commit := sqlitex.Transaction(conn)
+	defer commit(&err)
+	v := &ocflite.Validation{
+		ObjectID:    report.ObjectID,
+		StoragePath: report.StoragePath,
+		Valid:       report.Valid,
+		ValidatedAt: report.ValidatedAt,
+		Messages:    make([]*ocflite.ValidationMessage, len(report.Messages)),
+	}
+	for i, msg := range report.Messages {
+		v.Messages[i] = &ocflite.ValidationMessage{
+			Level:   string(msg.Level),
+			Code:    msg.Code,
+			Message: msg.Message,
+		}
+	}
+	return ocflite.SetValidation(conn, rootID, v)
+}
+
+func (db *DB) GetValidation(ctx context.Context, rootID string, objID string) (*access.ValidationReport, error) {
+	conn, err := db.Pool.Take(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Pool.Put(conn)
+	v, err := ocflite.GetValidation(conn, rootID, objID)
+	if err != nil {
+		if errors.Is(err, ocflite.ErrNotFound) {
+			err = fmt.Errorf("%w: %w", access.ErrNotFound, err)
+		}
+		return nil, err
+	}
+	return validationReport(v), nil
+}
+
+func (db *DB) ListInvalidObjects(ctx context.Context, rootID string, limit int) ([]*access.ValidationReport, error) {
+	conn, err := db.Pool.Take(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Pool.Put(conn)
+	vals, err := ocflite.ListInvalidObjects(conn, rootID, limit)
+	if err != nil {
+		return nil, err
+	}
+	reports := make([]*access.ValidationReport, len(vals))
+	for i, v := range vals {
+		reports[i] = validationReport(v)
+	}
+	return reports, nil
+}
+
+func validationReport(v *ocflite.Validation) *access.ValidationReport {
+	report := &access.ValidationReport{
+		ObjectID:    v.ObjectID,
+		StoragePath: v.StoragePath,
+		Valid:       v.Valid,
+		ValidatedAt: v.ValidatedAt,
+	}
+	for _, msg := range v.Messages {
+		report.Messages = append(report.Messages, access.ValidationMessage{
+			Level:   access.ValidationLevel(msg.Level),
+			Code:    msg.Code,
+			Message: msg.Message,
+		})
+	}
+	return report
+}
+
+func fixityInfos(files []*ocflite.FixityFile) []access.FixityInfo {
+	if files == nil {
+		return nil
+	}
+	result := make([]access.FixityInfo, len(files))
+	for i, f := range files {
+		result[i] = &fixityInfo{file: f}
+	}
+	return result
+}
+
 type objectInfo struct {
 	obj *ocflite.ObjectBrief
 }
@@ -367,6 +811,51 @@ func (o *objectInfo) CreatedAt() time.Time    { return o.obj.CreatedAt }
 func (o *objectInfo) UpdatedAt() time.Time    { return o.obj.UpdatedAt }
 func (o *objectInfo) IndexedAt() time.Time    { return o.obj.IndexedAt }
 
+type draftInfo struct {
+	draft *ocflite.Draft
+}
+
+var _ access.DraftInfo = (*draftInfo)(nil)
+
+func (d *draftInfo) ID() int64            { return d.draft.ID }
+func (d *draftInfo) ObjectID() string     { return d.draft.ObjectID }
+func (d *draftInfo) Owner() string        { return d.draft.Owner }
+func (d *draftInfo) Head() ocfl.VNum      { return ocfl.V(d.draft.Head, d.draft.Vpadding) }
+func (d *draftInfo) Alg() string          { return d.draft.DigestAlgorithm }
+func (d *draftInfo) CreatedAt() time.Time { return d.draft.CreatedAt }
+func (d *draftInfo) UpdatedAt() time.Time { return d.draft.UpdatedAt }
+
+type draftFileInfo struct {
+	file *ocflite.DraftFile
+}
+
+var _ access.DraftFileInfo = (*draftFileInfo)(nil)
+
+func (f *draftFileInfo) Path() string   { return f.file.Path }
+func (f *draftFileInfo) Digest() string { return f.file.Digest }
+func (f *draftFileInfo) Size() int64    { return f.file.Size }
+func (f *draftFileInfo) HasSize() bool  { return f.file.HasSize }
+func (f *draftFileInfo) Upload() string { return f.file.UploadID }
+
+type fixityInfo struct {
+	file *ocflite.FixityFile
+}
+
+var _ access.FixityInfo = (*fixityInfo)(nil)
+
+func (f *fixityInfo) ObjectID() string    { return f.file.ObjectID }
+func (f *fixityInfo) StoragePath() string { return f.file.StoragePath }
+func (f *fixityInfo) Alg() string         { return f.file.DigestAlgorithm }
+func (f *fixityInfo) ContentPath() string { return f.file.ContentPath }
+func (f *fixityInfo) Digest() string      { return f.file.Digest }
+func (f *fixityInfo) Size() int64         { return f.file.Size }
+func (f *fixityInfo) HasSize() bool       { return f.file.HasSize }
+func (f *fixityInfo) FixityStatus() access.FixityStatus {
+	return access.FixityStatus(f.file.Status)
+}
+func (f *fixityInfo) FixityError() string        { return f.file.Error }
+func (f *fixityInfo) FixityCheckedAt() time.Time { return f.file.CheckedAt }
+
 type versionInfo struct {
 	ver *ocflite.VersionBrief
 }
@@ -379,6 +868,19 @@ func (v *versionInfo) UserName() string   { return v.ver.UserName }
 func (v *versionInfo) UserAddr() string   { return v.ver.UserAddr }
 func (v *versionInfo) Created() time.Time { return v.ver.Created }
 
+type contentSearchResult struct {
+	result *ocflite.ContentSearchResult
+}
+
+var _ access.ContentSearchResult = (*contentSearchResult)(nil)
+
+func (r *contentSearchResult) ObjectID() string   { return r.result.ObjectID }
+func (r *contentSearchResult) VNum() ocfl.VNum    { return ocfl.V(r.result.Vnum, r.result.Vpadding) }
+func (r *contentSearchResult) Path() string       { return r.result.Path }
+func (r *contentSearchResult) Message() string    { return r.result.Message }
+func (r *contentSearchResult) UserName() string   { return r.result.UserName }
+func (r *contentSearchResult) Created() time.Time { return r.result.Created }
+
 // versionDirEntry wraps ocflite.VersionDirEntry to implement access.StateDirEntry
 type versionDirEntry struct {
 	entry *ocflite.VersionDirEntry
@@ -407,6 +909,7 @@ func (c *versionFileInfo) ModVNum() int        { return c.info.ModVnum }
 func (c *versionFileInfo) Modtime() time.Time  { return c.info.Modtime }
 func (c *versionFileInfo) Size() int64         { return c.info.Size }
 func (c *versionFileInfo) HasSize() bool       { return c.info.HasSize }
+func (c *versionFileInfo) MediaType() string   { return c.info.MediaType }
 
 type objectFileInfo struct {
 	info *ocflite.ObjectFile
@@ -425,7 +928,8 @@ type versionFileChange struct {
 
 var _ access.VersionFileChange = (*versionFileChange)(nil)
 
-func (c *versionFileChange) Path() string { return c.change.Path }
+func (c *versionFileChange) Path() string       { return c.change.Path }
+func (c *versionFileChange) SourcePath() string { return c.change.SourcePath }
 func (c *versionFileChange) Type() string {
 	switch c.change.ModType {
 	case ocflite.FileAdded:
@@ -434,6 +938,10 @@ func (c *versionFileChange) Type() string {
 		return "modified"
 	case ocflite.FileDeleted:
 		return "deleted"
+	case ocflite.FileRenamed:
+		return "renamed"
+	case ocflite.FileCopied:
+		return "copied"
 	default:
 		return ""
 	}
@@ -0,0 +1,171 @@
+package access
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/srerickson/ocfl-go"
+	ocflfs "github.com/srerickson/ocfl-go/fs"
+)
+
+// name of object inventory files
+const inventoryFile = "inventory.json"
+
+// SyncMode determines when an indexed object is checked against the storage
+// root as it's accessed. Objects that aren't indexed are always read from the
+// storage root. IndexRoot and SyncObjectPath sync objects in every mode.
+type SyncMode int
+
+const (
+	// SyncInterval checks the object's inventory sidecar if the refresh
+	// interval (see WithRefreshInterval) has passed since the object was last
+	// indexed. It's the default.
+	SyncInterval SyncMode = iota
+
+	// SyncTrustIndex never checks indexed objects. It's for storage roots
+	// with objects that aren't changed, or that are only changed by this
+	// service.
+	SyncTrustIndex
+
+	// SyncAlways checks the object's inventory sidecar every time the object
+	// is accessed.
+	SyncAlways
+
+	// SyncOnEvent doesn't check indexed objects, like SyncTrustIndex. Changes
+	// to objects are expected to be reported with SyncObjectPath or
+	// SyncChangedFile, e.g., from storage event notifications: without an
+	// event source, the index isn't updated until the next root scan.
+	SyncOnEvent
+)
+
+var syncModeNames = map[SyncMode]string{
+	SyncInterval:   "interval",
+	SyncTrustIndex: "trust",
+	SyncAlways:     "always",
+	SyncOnEvent:    "events",
+}
+
+// ParseSyncMode returns the SyncMode with the given name: "interval",
+// "trust", "always", or "events".
+func ParseSyncMode(name string) (SyncMode, error) {
+	for m, n := range syncModeNames {
+		if n == name {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid sync mode %q: must be \"interval\", \"trust\", \"always\", or \"events\"", name)
+}
+
+func (m SyncMode) String() string {
+	if n, ok := syncModeNames[m]; ok {
+		return n
+	}
+	return fmt.Sprintf("SyncMode(%d)", int(m))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (m SyncMode) MarshalText() ([]byte, error) {
+	if _, ok := syncModeNames[m]; !ok {
+		return nil, fmt.Errorf("invalid sync mode: %d", int(m))
+	}
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler using ParseSyncMode.
+func (m *SyncMode) UnmarshalText(text []byte) error {
+	mode, err := ParseSyncMode(string(text))
+	if err != nil {
+		return err
+	}
+	*m = mode
+	return nil
+}
+
+// WithSyncMode sets when indexed objects are checked against the storage root
+// as they're accessed. The default is SyncInterval.
+func WithSyncMode(mode SyncMode) ServiceOption {
+	return func(s *Service) {
+		s.syncMode = mode
+	}
+}
+
+// SyncMode returns the service's sync mode.
+func (s *Service) SyncMode() SyncMode { return s.syncMode }
+
+// SyncObjectPath syncs the index with the object at objPath, a storage path
+// relative to the storage root's FS (see ObjectInfo.StoragePath), regardless
+// of the service's sync mode. If the object is indexed, its inventory sidecar
+// is compared to check if a full inventory read is necessary. If there is no
+// object at objPath, the object indexed with that path is removed from the
+// index and ErrNotFound is returned. The service's access policy isn't
+// checked: SyncObjectPath is for reporting storage changes, not for serving
+// principals.
+func (s *Service) SyncObjectPath(ctx context.Context, objPath string) (ObjectInfo, error) {
+	prev, err := s.db.GetObjectByPath(ctx, s.rootID, objPath)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	val, err, _ := s.inflight.Do("path:"+objPath, func() (any, error) {
+		return s.syncObjectPath(ctx, objPath, prev)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return val.(ObjectInfo), nil
+}
+
+// SyncChangedFile syncs the index with a change to the file name, a path
+// relative to the storage root's FS, reported by a storage event or a file
+// watcher. Changes to objects' root inventories, inventory sidecars, and
+// object declarations are synced with SyncObjectPath, using the storage path
+// of the file's directory, if the directory is indexed or has an object
+// declaration. Changes to other files, including the inventories in version
+// directories, are ignored, and a nil ObjectInfo is returned without an
+// error. If the changed object was removed,
+// it's removed from the index and ErrNotFound is returned.
+func (s *Service) SyncChangedFile(ctx context.Context, name string) (ObjectInfo, error) {
+	base := path.Base(name)
+	if base != inventoryFile &&
+		!strings.HasPrefix(base, inventoryFile+".") &&
+		!strings.HasPrefix(base, "0="+ocfl.NamasteTypeObject) {
+		return nil, nil
+	}
+	objPath := path.Dir(name)
+	if rootPath := s.root.Path(); rootPath != "." && rootPath != "" && !strings.HasPrefix(objPath, rootPath+"/") {
+		// not in the storage root
+		return nil, nil
+	}
+	_, err := s.db.GetObjectByPath(ctx, s.rootID, objPath)
+	if errors.Is(err, ErrNotFound) {
+		// a new object, or a version directory, which has an inventory but
+		// not an object declaration.
+		entries, err := ocflfs.ReadDir(ctx, s.root.FS(), objPath)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		if !ocfl.ParseObjectDir(entries).HasNamaste() {
+			return nil, nil
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	return s.SyncObjectPath(ctx, objPath)
+}
+
+// needsSync reports whether the indexed object should be checked against the
+// storage root before it's used.
+func (s *Service) needsSync(obj ObjectInfo) bool {
+	switch s.syncMode {
+	case SyncAlways:
+		return true
+	case SyncTrustIndex, SyncOnEvent:
+		return false
+	default:
+		return !time.Now().Before(obj.IndexedAt().Add(s.refreshInterval))
+	}
+}
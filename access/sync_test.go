@@ -0,0 +1,136 @@
+package access_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/carlmjohnson/be"
+	"github.com/srerickson/ocfl-services/access"
+)
+
+func TestService_SyncMode(t *testing.T) {
+	// syncTwice syncs the fixture object once, waits longer than the refresh
+	// interval, and then syncs it twice more, returning the number of
+	// TouchObject calls for the last two syncs.
+	syncTwice := func(t *testing.T, svc *access.Service, db *touchCounter) int32 {
+		ctx := t.Context()
+		_, err := svc.SyncObject(ctx, fixtureObjectID)
+		be.NilErr(t, err)
+		be.Equal(t, 0, db.touches.Load()) // first sync indexes the object
+		time.Sleep(2 * time.Hour)
+		for range 2 {
+			_, err = svc.SyncObject(ctx, fixtureObjectID)
+			be.NilErr(t, err)
+		}
+		return db.touches.Load()
+	}
+
+	t.Run("interval", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			svc, db := testSyncService(t, access.WithRefreshInterval(time.Hour))
+			be.Equal(t, access.SyncInterval, svc.SyncMode())
+			// the second sync is within the refresh interval
+			be.Equal(t, 1, syncTwice(t, svc, db))
+		})
+	})
+
+	t.Run("trust index", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			svc, db := testSyncService(t, access.WithSyncMode(access.SyncTrustIndex))
+			be.Equal(t, 0, syncTwice(t, svc, db))
+		})
+	})
+
+	t.Run("always", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			svc, db := testSyncService(t, access.WithSyncMode(access.SyncAlways))
+			be.Equal(t, 2, syncTwice(t, svc, db))
+			// without waiting
+			_, err := svc.SyncObject(t.Context(), fixtureObjectID)
+			be.NilErr(t, err)
+			be.Equal(t, 3, db.touches.Load())
+		})
+	})
+
+	t.Run("events", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			ctx := t.Context()
+			svc, db := testSyncService(t, access.WithSyncMode(access.SyncOnEvent))
+			be.Equal(t, 0, syncTwice(t, svc, db))
+			// a reported change syncs the object
+			obj, err := svc.SyncObject(ctx, fixtureObjectID)
+			be.NilErr(t, err)
+			synced, err := svc.SyncObjectPath(ctx, obj.StoragePath())
+			be.NilErr(t, err)
+			be.Equal(t, 1, db.touches.Load())
+			be.Equal(t, fixtureObjectID, synced.ID())
+			be.True(t, synced.IndexedAt().After(obj.IndexedAt()))
+			// nothing at the path
+			_, err = svc.SyncObjectPath(ctx, "missing")
+			be.True(t, errors.Is(err, access.ErrNotFound))
+		})
+	})
+}
+
+func TestService_SyncChangedFile(t *testing.T) {
+	ctx := t.Context()
+	const objPath = "reg-extension-dir-root/a47/817/83d/cec/ark%3a123%2fabc"
+	svc, _ := testSyncService(t, access.WithSyncMode(access.SyncOnEvent))
+
+	// the object isn't indexed, but it has a declaration
+	obj, err := svc.SyncChangedFile(ctx, objPath+"/0=ocfl_object_1.0")
+	be.NilErr(t, err)
+	be.Equal(t, fixtureObjectID, obj.ID())
+	obj, err = svc.SyncChangedFile(ctx, objPath+"/inventory.json")
+	be.NilErr(t, err)
+	be.Equal(t, fixtureObjectID, obj.ID())
+
+	// ignored changes
+	for _, name := range []string{
+		objPath + "/v1/inventory.json",
+		objPath + "/v1/content/a_file.txt",
+		"reg-extension-dir-root/a47/inventory.json",
+		"other-root/a47/817/83d/cec/ark%3a123%2fabc/inventory.json",
+	} {
+		obj, err := svc.SyncChangedFile(ctx, name)
+		be.NilErr(t, err)
+		be.True(t, obj == nil)
+	}
+}
+
+func TestParseSyncMode(t *testing.T) {
+	for _, mode := range []access.SyncMode{access.SyncInterval, access.SyncTrustIndex, access.SyncAlways, access.SyncOnEvent} {
+		parsed, err := access.ParseSyncMode(mode.String())
+		be.NilErr(t, err)
+		be.Equal(t, mode, parsed)
+	}
+	_, err := access.ParseSyncMode("never")
+	be.Nonzero(t, err)
+}
+
+// touchCounter is an access.Database that counts TouchObject calls.
+type touchCounter struct {
+	access.Database
+	touches atomic.Int32
+}
+
+func (db *touchCounter) TouchObject(ctx context.Context, rootID string, objID string) (access.ObjectInfo, error) {
+	db.touches.Add(1)
+	return db.Database.TouchObject(ctx, rootID, objID)
+}
+
+// testSyncService is like testService, but its database counts TouchObject
+// calls.
+func testSyncService(t *testing.T, opts ...access.ServiceOption) (*access.Service, *touchCounter) {
+	t.Helper()
+	counter := &touchCounter{}
+	svc := testServiceWithDB(t, func(db access.Database) access.Database {
+		counter.Database = db
+		return counter
+	}, opts...)
+	return svc, counter
+}
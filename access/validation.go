@@ -0,0 +1,231 @@
+package access
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"path"
+	"time"
+
+	"github.com/srerickson/ocfl-go"
+)
+
+// ValidationLevel is the severity of a ValidationMessage.
+type ValidationLevel string
+
+const (
+	ValidationError   ValidationLevel = "error"   // the object isn't valid
+	ValidationWarning ValidationLevel = "warning" // the object is valid but doesn't follow a recommendation
+)
+
+// ValidationReport is the result of a full OCFL validation of an object.
+type ValidationReport struct {
+	ObjectID    string
+	StoragePath string              // object's storage path when it was validated
+	Valid       bool                // true if validation found no errors
+	ValidatedAt time.Time           // when the object was validated
+	Messages    []ValidationMessage // errors and warnings, in the order they were reported
+}
+
+// ValidationMessage is an error or warning in a ValidationReport.
+type ValidationMessage struct {
+	Level   ValidationLevel
+	Code    string // OCFL validation code (E001, W004, ...); empty if not known
+	Message string
+}
+
+// ValidateRootResult summarizes the objects validated by ValidateRoot.
+type ValidateRootResult struct {
+	Validated int // objects validated
+	Invalid   int // validated objects with errors
+	Errors    int // objects that couldn't be validated
+}
+
+// IsAdmin reports whether the principal in ctx is one of the access policy's
+// admins. Without a policy, it returns false.
+func (s *Service) IsAdmin(ctx context.Context) bool {
+	return s.authorizeAdmin(ctx) == nil
+}
+
+// ValidateObject fully validates the object with ocfl-go, including its root
+// inventory sidecar and content digests, and stores the report in the index,
+// replacing the object's previous report. The object is validated at its
+// indexed storage path, so an object that has become invalid can be validated
+// as long as it was indexed before. Validation reads every content file, so
+// the principal in ctx must be an admin; otherwise, the error wraps
+// ErrForbidden.
+func (s *Service) ValidateObject(ctx context.Context, objID string) (*ValidationReport, error) {
+	if err := s.authorizeAdmin(ctx); err != nil {
+		return nil, err
+	}
+	obj, err := s.indexedObject(ctx, objID)
+	if err != nil {
+		return nil, err
+	}
+	return s.validateObject(ctx, obj.ID(), obj.StoragePath())
+}
+
+// ValidateRoot validates all objects in the storage root, like
+// ValidateObject, and logs progress with the service's logger. Objects that
+// can't be indexed are counted as errors: their IDs aren't known, so their
+// reports can't be stored. The principal in ctx must be an admin; otherwise,
+// the error wraps ErrForbidden. For duplicate calls, the duplicate caller
+// waits for the original to complete and receives the same results. If ctx is
+// canceled, validation stops and the context's error is returned.
+func (s *Service) ValidateRoot(ctx context.Context) (ValidateRootResult, error) {
+	if err := s.authorizeAdmin(ctx); err != nil {
+		return ValidateRootResult{}, err
+	}
+	val, err, _ := s.inflight.Do("validate:"+s.rootID, func() (any, error) {
+		var result ValidateRootResult
+		start := time.Now()
+		s.logger.Info("validating storage root", "root_id", s.rootID)
+		for decl, err := range s.root.ObjectDeclarations(ctx) {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				s.logger.Warn("storage root validation canceled",
+					"root_id", s.rootID, "objects", result.Validated)
+				return result, ctxErr
+			}
+			if err != nil {
+				s.logger.Error(err.Error())
+				result.Errors++
+				continue
+			}
+			objPath := path.Dir(decl.FullPath())
+			obj, err := s.db.GetObjectByPath(ctx, s.rootID, objPath)
+			if errors.Is(err, ErrNotFound) {
+				obj, err = s.syncObjectPath(ctx, objPath, nil)
+			}
+			if err != nil {
+				s.logger.Error("validating object: "+err.Error(), "storage_path", objPath)
+				result.Errors++
+				continue
+			}
+			report, err := s.validateObject(ctx, obj.ID(), objPath)
+			if err != nil {
+				if ctx.Err() == nil {
+					s.logger.Error("validating object: "+err.Error(), "object_id", obj.ID())
+					result.Errors++
+				}
+				continue
+			}
+			result.Validated++
+			if !report.Valid {
+				result.Invalid++
+			}
+			if result.Validated%indexProgressInterval == 0 {
+				s.logger.Info("validating storage root", "root_id", s.rootID,
+					"objects", result.Validated, "invalid", result.Invalid, "errors", result.Errors)
+			}
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return result, ctxErr
+		}
+		s.logger.Info("finished validating storage root", "root_id", s.rootID,
+			"objects", result.Validated, "invalid", result.Invalid, "errors", result.Errors,
+			"duration", time.Since(start))
+		return result, nil
+	})
+	result, _ := val.(ValidateRootResult)
+	return result, err
+}
+
+// ObjectValidation returns the object's most recent validation report, or nil
+// if the object hasn't been validated. If the service has an access policy
+// that doesn't allow the principal in ctx to access the object, ErrNotFound is
+// returned.
+func (s *Service) ObjectValidation(ctx context.Context, objID string) (*ValidationReport, error) {
+	obj, err := s.indexedObject(ctx, objID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx, obj); err != nil {
+		return nil, err
+	}
+	report, err := s.db.GetValidation(ctx, s.rootID, objID)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	return report, err
+}
+
+// InvalidObjects returns up to limit validation reports, without messages,
+// for objects that failed their most recent validation, most recently
+// validated first. Objects that the principal in ctx can't access are
+// omitted.
+func (s *Service) InvalidObjects(ctx context.Context, limit int) ([]*ValidationReport, error) {
+	reports, err := s.db.ListInvalidObjects(ctx, s.rootID, limit)
+	if err != nil || s.policy == nil {
+		return reports, err
+	}
+	allowed := make([]*ValidationReport, 0, len(reports))
+	for _, r := range reports {
+		obj, err := s.db.GetObject(ctx, s.rootID, r.ObjectID)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		if err := s.authorize(ctx, obj); err != nil {
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		allowed = append(allowed, r)
+	}
+	return allowed, nil
+}
+
+// indexedObject returns the object's index entry. The object is only synced
+// with the storage root if it isn't indexed: reports are for objects that may
+// no longer be valid, and syncing an invalid object fails.
+func (s *Service) indexedObject(ctx context.Context, objID string) (ObjectInfo, error) {
+	obj, err := s.db.GetObject(ctx, s.rootID, objID)
+	if errors.Is(err, ErrNotFound) {
+		return s.refreshObject(ctx, objID)
+	}
+	return obj, err
+}
+
+// validateObject validates the object at storagePath and stores the report.
+func (s *Service) validateObject(ctx context.Context, objID string, storagePath string) (*ValidationReport, error) {
+	report := &ValidationReport{
+		ObjectID:    objID,
+		StoragePath: storagePath,
+		ValidatedAt: time.Now(),
+	}
+	result := ocfl.ValidateObject(ctx, s.root.FS(), storagePath)
+	if err := ctx.Err(); err != nil {
+		// the results are incomplete
+		return nil, err
+	}
+	for _, err := range result.Errors() {
+		report.Messages = append(report.Messages, validationMessage(ValidationError, err))
+	}
+	for _, err := range result.WarnErrors() {
+		report.Messages = append(report.Messages, validationMessage(ValidationWarning, err))
+	}
+	report.Valid = len(result.Errors()) == 0
+	if err := s.db.SetValidation(ctx, s.rootID, report); err != nil {
+		return nil, err
+	}
+	if !report.Valid {
+		s.logger.LogAttrs(ctx, slog.LevelWarn, "object is invalid",
+			slog.String("object_id", objID),
+			slog.Int("errors", len(result.Errors())))
+	}
+	return report, nil
+}
+
+// validationMessage returns a message for an error from ocfl-go's validation,
+// with the OCFL validation code if the error has one.
+func validationMessage(level ValidationLevel, err error) ValidationMessage {
+	msg := ValidationMessage{Level: level, Message: err.Error()}
+	var verr *ocfl.ValidationError
+	if errors.As(err, &verr) {
+		msg.Code = verr.Code
+	}
+	return msg
+}
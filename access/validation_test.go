@@ -0,0 +1,79 @@
+package access_test
+
+import (
+	"errors"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/carlmjohnson/be"
+	ocflfs "github.com/srerickson/ocfl-go/fs"
+	"github.com/srerickson/ocfl-services/access"
+)
+
+func TestService_ValidateObject(t *testing.T) {
+	policy := &access.Policy{
+		Admins: access.Subjects{Principals: []string{"admin"}},
+		Rules:  []access.Rule{{Effect: access.Allow}},
+	}
+	svc := testPolicyService(t, policy, nil)
+	admin := access.WithPrincipal(t.Context(), &access.Principal{ID: "admin"})
+	reader := access.WithPrincipal(t.Context(), &access.Principal{ID: "reader"})
+
+	t.Run("not validated", func(t *testing.T) {
+		report, err := svc.ObjectValidation(reader, fixtureObjectID)
+		be.NilErr(t, err)
+		be.True(t, report == nil)
+		_, err = svc.ObjectValidation(reader, "missing")
+		be.True(t, errors.Is(err, access.ErrNotFound))
+	})
+
+	t.Run("requires admin", func(t *testing.T) {
+		_, err := svc.ValidateObject(reader, fixtureObjectID)
+		be.True(t, errors.Is(err, access.ErrForbidden))
+		_, err = svc.ValidateRoot(reader)
+		be.True(t, errors.Is(err, access.ErrForbidden))
+		be.False(t, svc.IsAdmin(reader))
+		be.True(t, svc.IsAdmin(admin))
+	})
+
+	t.Run("valid object", func(t *testing.T) {
+		report, err := svc.ValidateObject(admin, fixtureObjectID)
+		be.NilErr(t, err)
+		be.True(t, report.Valid)
+		stored, err := svc.ObjectValidation(reader, fixtureObjectID)
+		be.NilErr(t, err)
+		be.True(t, stored.Valid)
+		be.Equal(t, report.StoragePath, stored.StoragePath)
+		be.Equal(t, report.ValidatedAt.Unix(), stored.ValidatedAt.Unix())
+	})
+
+	t.Run("invalid object", func(t *testing.T) {
+		obj, err := svc.SyncObject(reader, fixtureObjectID)
+		be.NilErr(t, err)
+		_, err = ocflfs.Write(t.Context(), svc.Root().FS(), path.Join(obj.StoragePath(), "v2/content/README.md"), strings.NewReader("corrupt"))
+		be.NilErr(t, err)
+		report, err := svc.ValidateObject(admin, fixtureObjectID)
+		be.NilErr(t, err)
+		be.False(t, report.Valid)
+		be.True(t, len(report.Messages) > 0)
+		be.Equal(t, access.ValidationError, report.Messages[0].Level)
+		be.True(t, report.Messages[0].Code != "")
+		stored, err := svc.ObjectValidation(reader, fixtureObjectID)
+		be.NilErr(t, err)
+		be.False(t, stored.Valid)
+		be.Equal(t, len(report.Messages), len(stored.Messages))
+		invalid, err := svc.InvalidObjects(reader, 10)
+		be.NilErr(t, err)
+		be.Equal(t, 1, len(invalid))
+		be.Equal(t, fixtureObjectID, invalid[0].ObjectID)
+	})
+
+	t.Run("root", func(t *testing.T) {
+		result, err := svc.ValidateRoot(admin)
+		be.NilErr(t, err)
+		be.Equal(t, 1, result.Validated)
+		be.Equal(t, 1, result.Invalid)
+		be.Equal(t, 0, result.Errors)
+	})
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/srerickson/ocfl-go"
+	"github.com/srerickson/ocfl-services/access"
+	"github.com/srerickson/ocfl-services/access/sqlite"
+	"github.com/srerickson/ocfl-services/internal/rootloc"
+)
+
+const envVarRoot = "OCFL_ROOT" // storage root location string
+
+var (
+	stopSigs = []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+)
+
+func main() {
+	err := runIndex(os.Args[1:], os.Stdout, os.Stderr)
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+// runIndex builds or refreshes the index database and writes a report to
+// out. Log messages are written to w. It needs to log its own errors.
+func runIndex(args []string, out io.Writer, w io.Writer) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), stopSigs...)
+	defer cancel()
+	flags := struct {
+		root  string
+		db    string
+		full  bool
+		debug bool
+	}{}
+	fs := flag.NewFlagSet("ocfl-index", flag.ContinueOnError)
+	fs.SetOutput(w)
+	fs.StringVar(&flags.root, "root", "", "OCFL storage root location (file path, s3://bucket/path). Must match the -root used with ocfl-webui.")
+	fs.StringVar(&flags.db, "db", "", "database file path")
+	fs.BoolVar(&flags.full, "full", false, "read every object's inventory instead of only objects with changed inventory sidecars")
+	fs.BoolVar(&flags.debug, "debug", false, "more verbose log messages")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	var logLevel slog.Level
+	if flags.debug {
+		logLevel = slog.LevelDebug
+	}
+	logger := slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{
+		Level: logLevel,
+	}))
+	if flags.root == "" {
+		flags.root = os.Getenv(envVarRoot)
+	}
+	if flags.root == "" {
+		err := errors.New("missing required -root flag")
+		logger.Error(err.Error())
+		return err
+	}
+	if flags.db == "" {
+		err := errors.New("missing required -db flag")
+		logger.Error(err.Error())
+		return err
+	}
+	fsys, rootPath, err := rootloc.Parse(ctx, flags.root, logger)
+	if err != nil {
+		err := fmt.Errorf("failed to parse root location %q: %w", flags.root, err)
+		logger.Error(err.Error())
+		return err
+	}
+	root, err := ocfl.NewRoot(ctx, fsys, rootPath)
+	if err != nil {
+		err := fmt.Errorf("failed to initialize OCFL root at %q: %w", flags.root, err)
+		logger.Error(err.Error())
+		return err
+	}
+	db, err := sqlite.NewDB(flags.db)
+	if err != nil {
+		err := fmt.Errorf("failed to initialize database at %q: %w", flags.db, err)
+		logger.Error(err.Error())
+		return err
+	}
+	defer db.Close()
+	// The root ID is the location string, as in ocfl-webui, so the server
+	// finds the objects indexed here.
+	service := access.NewService(root, db, flags.root, logger)
+	start := time.Now()
+	result, err := service.IndexRoot(ctx, access.IndexOptions{Full: flags.full})
+	writeReport(out, flags.root, result, time.Since(start))
+	if err != nil {
+		logger.Error("indexing storage root", "error", err)
+		return err
+	}
+	if result.Errors > 0 {
+		err := fmt.Errorf("%d objects couldn't be indexed", result.Errors)
+		logger.Error(err.Error())
+		return err
+	}
+	return nil
+}
+
+// writeReport writes a summary of the indexing results to out.
+func writeReport(out io.Writer, root string, result access.IndexResult, dur time.Duration) {
+	fmt.Fprintf(out, "root:     %s\n", root)
+	fmt.Fprintf(out, "objects:  %d\n", result.Objects)
+	fmt.Fprintf(out, "updated:  %d\n", result.Updated)
+	fmt.Fprintf(out, "removed:  %d\n", result.Removed)
+	fmt.Fprintf(out, "errors:   %d\n", result.Errors)
+	fmt.Fprintf(out, "duration: %s\n", dur.Round(time.Millisecond))
+}
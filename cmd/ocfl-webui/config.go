@@ -0,0 +1,408 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/srerickson/ocfl-services/access"
+	"github.com/srerickson/ocfl-services/internal/fswatch"
+	"github.com/srerickson/ocfl-services/internal/rootloc"
+)
+
+// in-memory database used if no database path is configured
+const memoryDB = "file::memory:?mode=memory&cache=shared"
+
+// environment variables that override configuration file settings
+const (
+	envVarAddr            = "OCFL_ADDR"
+	envVarTLSCertFile     = "OCFL_TLS_CERT_FILE"
+	envVarTLSKeyFile      = "OCFL_TLS_KEY_FILE"
+	envVarLogFormat       = "OCFL_LOG_FORMAT"
+	envVarLogLevel        = "OCFL_LOG_LEVEL"
+	envVarDB              = "OCFL_DB"
+	envVarDBPoolSize      = "OCFL_DB_POOL_SIZE"
+	envVarRefreshInterval = "OCFL_REFRESH_INTERVAL"
+	envVarSyncMode        = "OCFL_SYNC_MODE"
+	envVarStatConcurrency = "OCFL_STAT_CONCURRENCY"
+	envVarMarkdownMaxSize = "OCFL_MARKDOWN_MAX_SIZE"
+	envVarEventsQueueURL  = "OCFL_EVENTS_SQS_QUEUE_URL"
+	envVarEventsToken     = "OCFL_EVENTS_WEBHOOK_TOKEN"
+	envVarWatch           = "OCFL_WATCH"
+
+	// prefix for a root's S3 settings: OCFL_ROOT_{NAME}_S3_ACCESS_KEY_ID, etc.
+	envVarRootPrefix = "OCFL_ROOT_"
+)
+
+// config is the ocfl-webui configuration. Settings are read from the TOML
+// configuration file (-config), then from environment variables, then from
+// command line flags, each overriding the last:
+//
+//	addr = ":8443"
+//	refresh_interval = "5m"
+//	sync_mode = "interval"
+//
+//	[tls]
+//	cert_file = "/etc/ocfl/cert.pem"
+//	key_file = "/etc/ocfl/key.pem"
+//
+//	[database]
+//	path = "/var/lib/ocfl/index.db"
+//
+//	[watch]
+//	enabled = true
+//
+//	[[roots]]
+//	name = "main"
+//	location = "s3://bucket/path"
+//	s3.region = "us-west-2"
+//
+//	[[roots]]
+//	name = "archive"
+//	location = "/data/archive"
+//	sync_mode = "trust"
+type config struct {
+	Addr            string          `toml:"addr"`             // listen address
+	TLS             tlsConfig       `toml:"tls"`              // serve HTTPS if set
+	Log             logConfig       `toml:"log"`              // log message format
+	Database        databaseConfig  `toml:"database"`         // index database
+	RefreshInterval time.Duration   `toml:"refresh_interval"` // see access.WithRefreshInterval
+	SyncMode        access.SyncMode `toml:"sync_mode"`        // see access.WithSyncMode
+	StatConcurrency int             `toml:"stat_concurrency"` // see sqlite.WithStatConcurrency
+	Markdown        markdownConfig  `toml:"markdown"`         // README rendering
+	Events          eventsConfig    `toml:"events"`           // S3 event notifications
+	Watch           watchConfig     `toml:"watch"`            // watching local roots for changes
+	Roots           []rootConfig    `toml:"roots"`            // named roots, served under /r/{name}
+}
+
+type tlsConfig struct {
+	CertFile string `toml:"cert_file"` // PEM certificate (chain) file
+	KeyFile  string `toml:"key_file"`  // PEM private key file
+}
+
+type logConfig struct {
+	Format string `toml:"format"` // "text" or "json"
+	Level  string `toml:"level"`  // "debug", "info", "warn", or "error"
+}
+
+type databaseConfig struct {
+	Path     string `toml:"path"`      // sqlite file path or URI
+	PoolSize int    `toml:"pool_size"` // max open connections; 0 for the default
+}
+
+type markdownConfig struct {
+	MaxSize int64 `toml:"max_size"` // max size in bytes of rendered README files
+}
+
+// eventsConfig enables updating the index of S3 roots from S3 event
+// notifications for the roots' buckets.
+type eventsConfig struct {
+	SQSQueueURL  string `toml:"sqs_queue_url"` // SQS queue to poll for notifications
+	SQSRegion    string `toml:"sqs_region"`
+	SQSEndpoint  string `toml:"sqs_endpoint"`  // base URL of an SQS-compatible service
+	WebhookToken string `toml:"webhook_token"` // bearer token; enables the webhook
+}
+
+func (c eventsConfig) enabled() bool {
+	return c.SQSQueueURL != "" || c.WebhookToken != ""
+}
+
+// watchConfig enables updating the index of local roots when objects change
+// in the roots' directories.
+type watchConfig struct {
+	Enabled bool          `toml:"enabled"`
+	Delay   time.Duration `toml:"delay"` // time to wait after an object's last change
+}
+
+// rootConfig is a named storage root in the configuration file.
+type rootConfig struct {
+	Name     string   `toml:"name"`     // URL path segment for the root's pages
+	Location string   `toml:"location"` // file path, s3://bucket/path, or http(s) URL, as with -root
+	S3       s3Config `toml:"s3"`       // settings for s3:// locations
+
+	// settings that override the top-level settings for the root
+	RefreshInterval *time.Duration   `toml:"refresh_interval"`
+	SyncMode        *access.SyncMode `toml:"sync_mode"`
+}
+
+// s3Config are S3 settings for a storage root. Settings that aren't set are
+// read from the AWS environment.
+type s3Config struct {
+	Region          string `toml:"region"`
+	Endpoint        string `toml:"endpoint"` // base URL of an S3-compatible service
+	Profile         string `toml:"profile"`  // AWS shared config profile
+	AccessKeyID     string `toml:"access_key_id"`
+	SecretAccessKey string `toml:"secret_access_key"`
+	PathStyle       bool   `toml:"path_style"` // use path-style bucket addressing
+}
+
+// valid root names
+var rootNameRegexp = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// defaultConfig returns the settings used when they aren't configured.
+func defaultConfig() config {
+	return config{
+		Addr:            ":8283",
+		Log:             logConfig{Format: "text", Level: "info"},
+		Database:        databaseConfig{Path: memoryDB},
+		RefreshInterval: access.RefreshInterval,
+		StatConcurrency: 4,
+		Markdown:        markdownConfig{MaxSize: 1024 * 1024 * 2}, // 2 MiB
+		Watch:           watchConfig{Delay: fswatch.DefaultDelay},
+	}
+}
+
+// loadConfig returns the default configuration with settings from the
+// configuration file name, if it isn't empty, and then from the environment
+// variables returned by getenv. The result isn't validated.
+func loadConfig(name string, getenv func(string) string) (*config, error) {
+	cfg := defaultConfig()
+	if name != "" {
+		meta, err := toml.DecodeFile(name, &cfg)
+		if err != nil {
+			return nil, fmt.Errorf("reading config file %q: %w", name, err)
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return nil, fmt.Errorf("config file %q: unknown setting %q", name, undecoded[0].String())
+		}
+	}
+	if err := cfg.applyEnv(getenv); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// applyEnv overrides settings with the environment variables returned by
+// getenv. Variables that aren't set or are empty are ignored.
+func (c *config) applyEnv(getenv func(string) string) error {
+	str := func(s string) (string, error) { return s, nil }
+	errs := []error{
+		setFromEnv(getenv, envVarAddr, &c.Addr, str),
+		setFromEnv(getenv, envVarTLSCertFile, &c.TLS.CertFile, str),
+		setFromEnv(getenv, envVarTLSKeyFile, &c.TLS.KeyFile, str),
+		setFromEnv(getenv, envVarLogFormat, &c.Log.Format, str),
+		setFromEnv(getenv, envVarLogLevel, &c.Log.Level, str),
+		setFromEnv(getenv, envVarDB, &c.Database.Path, str),
+		setFromEnv(getenv, envVarDBPoolSize, &c.Database.PoolSize, strconv.Atoi),
+		setFromEnv(getenv, envVarRefreshInterval, &c.RefreshInterval, time.ParseDuration),
+		setFromEnv(getenv, envVarSyncMode, &c.SyncMode, access.ParseSyncMode),
+		setFromEnv(getenv, envVarStatConcurrency, &c.StatConcurrency, strconv.Atoi),
+		setFromEnv(getenv, envVarMarkdownMaxSize, &c.Markdown.MaxSize, func(s string) (int64, error) {
+			return strconv.ParseInt(s, 10, 64)
+		}),
+		setFromEnv(getenv, envVarEventsQueueURL, &c.Events.SQSQueueURL, str),
+		setFromEnv(getenv, envVarEventsToken, &c.Events.WebhookToken, str),
+		setFromEnv(getenv, envVarWatch, &c.Watch.Enabled, strconv.ParseBool),
+	}
+	for i := range c.Roots {
+		s3 := &c.Roots[i].S3
+		prefix := rootEnvPrefix(c.Roots[i].Name) + "S3_"
+		errs = append(errs,
+			setFromEnv(getenv, prefix+"ACCESS_KEY_ID", &s3.AccessKeyID, str),
+			setFromEnv(getenv, prefix+"SECRET_ACCESS_KEY", &s3.SecretAccessKey, str),
+			setFromEnv(getenv, prefix+"REGION", &s3.Region, str),
+			setFromEnv(getenv, prefix+"ENDPOINT", &s3.Endpoint, str),
+		)
+	}
+	return errors.Join(errs...)
+}
+
+// applyFlags overrides settings with the -addr, -db, and -debug flags, if
+// they were set in fs.
+func (c *config) applyFlags(fs *flag.FlagSet) {
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "addr":
+			c.Addr = f.Value.String()
+		case "db":
+			c.Database.Path = f.Value.String()
+		case "debug":
+			if f.Value.String() == "true" {
+				c.Log.Level = "debug"
+			}
+		}
+	})
+}
+
+// setFromEnv sets dst to the parsed value of the environment variable name,
+// if it's set.
+func setFromEnv[T any](getenv func(string) string, name string, dst *T, parse func(string) (T, error)) error {
+	val := getenv(name)
+	if val == "" {
+		return nil
+	}
+	v, err := parse(val)
+	if err != nil {
+		return fmt.Errorf("environment variable %s: invalid value %q", name, val)
+	}
+	*dst = v
+	return nil
+}
+
+// rootEnvPrefix returns the prefix of environment variables with settings for
+// the named root: the name is upper-cased, with '.' and '-' replaced by '_'.
+func rootEnvPrefix(name string) string {
+	name = strings.NewReplacer(".", "_", "-", "_").Replace(strings.ToUpper(name))
+	return envVarRootPrefix + name + "_"
+}
+
+// validate returns an error describing all invalid settings.
+func (c *config) validate() error {
+	var errs []error
+	addErr := func(field string, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", field, fmt.Sprintf(format, args...)))
+	}
+	if c.Addr == "" {
+		addErr("addr", "missing listen address")
+	}
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		addErr("tls", "cert_file and key_file must be set together")
+	}
+	if c.Log.Format != "text" && c.Log.Format != "json" {
+		addErr("log.format", "%q is not \"text\" or \"json\"", c.Log.Format)
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		addErr("log.level", "%q is not \"debug\", \"info\", \"warn\", or \"error\"", c.Log.Level)
+	}
+	if c.Database.Path == "" {
+		addErr("database.path", "missing database path")
+	}
+	if c.Database.PoolSize < 0 {
+		addErr("database.pool_size", "must not be negative: %d", c.Database.PoolSize)
+	}
+	if c.RefreshInterval < 0 {
+		addErr("refresh_interval", "must not be negative: %s", c.RefreshInterval)
+	}
+	if c.StatConcurrency < 1 {
+		addErr("stat_concurrency", "must be at least 1: %d", c.StatConcurrency)
+	}
+	if c.Markdown.MaxSize < 1 {
+		addErr("markdown.max_size", "must be at least 1: %d", c.Markdown.MaxSize)
+	}
+	if c.Events.SQSQueueURL == "" && (c.Events.SQSRegion != "" || c.Events.SQSEndpoint != "") {
+		addErr("events", "sqs_region and sqs_endpoint require sqs_queue_url")
+	}
+	if c.Watch.Delay < 0 {
+		addErr("watch.delay", "must not be negative: %s", c.Watch.Delay)
+	}
+	names := map[string]bool{}
+	envPrefixes := map[string]string{}
+	locations := map[string]bool{}
+	for i, root := range c.Roots {
+		field := fmt.Sprintf("roots[%d]", i)
+		switch {
+		case root.Name == "":
+			addErr(field, "missing name")
+		case !rootNameRegexp.MatchString(root.Name) || root.Name == "." || root.Name == "..":
+			addErr(field, "invalid name %q: names may only include letters, digits, '.', '_', and '-'", root.Name)
+		case names[root.Name]:
+			addErr(field, "duplicate name %q", root.Name)
+		case envPrefixes[rootEnvPrefix(root.Name)] != "":
+			addErr(field, "name %q has the same environment variables as %q", root.Name, envPrefixes[rootEnvPrefix(root.Name)])
+		}
+		names[root.Name] = true
+		if root.Name != "" {
+			envPrefixes[rootEnvPrefix(root.Name)] = root.Name
+		}
+		switch {
+		case root.Location == "":
+			addErr(field, "missing location")
+		case locations[root.Location]:
+			addErr(field, "duplicate location %q", root.Location)
+		}
+		locations[root.Location] = true
+		if root.RefreshInterval != nil && *root.RefreshInterval < 0 {
+			addErr(field+".refresh_interval", "must not be negative: %s", *root.RefreshInterval)
+		}
+		if root.Location != "" {
+			if err := c.checkSyncMode(root); err != nil {
+				addErr(field+".sync_mode", "%s", err)
+			}
+		}
+		if root.S3 != (s3Config{}) && !strings.HasPrefix(root.Location, "s3://") {
+			addErr(field+".s3", "S3 settings are only allowed for s3:// locations")
+		}
+		if (root.S3.AccessKeyID == "") != (root.S3.SecretAccessKey == "") {
+			addErr(field+".s3", "access_key_id and secret_access_key must be set together")
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// serviceOptions returns the options for the root's access.Service: the
+// refresh interval and sync mode, from the root's settings if they're set.
+func (c *config) serviceOptions(root rootConfig) []access.ServiceOption {
+	interval := c.RefreshInterval
+	if root.RefreshInterval != nil {
+		interval = *root.RefreshInterval
+	}
+	return []access.ServiceOption{
+		access.WithRefreshInterval(interval),
+		access.WithSyncMode(c.syncMode(root)),
+	}
+}
+
+// syncMode returns the root's sync mode: the root's setting, if it's set, or
+// the top-level setting.
+func (c *config) syncMode(root rootConfig) access.SyncMode {
+	if root.SyncMode != nil {
+		return *root.SyncMode
+	}
+	return c.SyncMode
+}
+
+// checkSyncMode returns an error if the root uses the "events" sync mode
+// without a source of events for its location: S3 event notifications for
+// s3:// locations, or watching local directories. Without one, the root's
+// index would never be updated.
+func (c *config) checkSyncMode(root rootConfig) error {
+	if c.syncMode(root) != access.SyncOnEvent {
+		return nil
+	}
+	hasEvents := c.Watch.Enabled
+	if u, err := url.Parse(root.Location); err == nil {
+		switch u.Scheme {
+		case "s3":
+			hasEvents = c.Events.enabled()
+		case "http", "https":
+			hasEvents = false
+		}
+	}
+	if !hasEvents {
+		return fmt.Errorf("%q requires [events] for s3:// locations or [watch] for local directories", access.SyncOnEvent)
+	}
+	return nil
+}
+
+// logger returns a logger that writes to w with the configured format and
+// level. The configuration must be valid.
+func (c logConfig) logger(w io.Writer) *slog.Logger {
+	var level slog.Level
+	_ = level.UnmarshalText([]byte(c.Level))
+	opts := &slog.HandlerOptions{Level: level}
+	if c.Format == "json" {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+// options returns the root location's S3 settings.
+func (c s3Config) options() rootloc.S3Options {
+	return rootloc.S3Options{
+		Region:          c.Region,
+		Endpoint:        c.Endpoint,
+		Profile:         c.Profile,
+		AccessKeyID:     c.AccessKeyID,
+		SecretAccessKey: c.SecretAccessKey,
+		PathStyle:       c.PathStyle,
+	}
+}
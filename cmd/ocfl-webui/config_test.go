@@ -0,0 +1,217 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/be"
+	"github.com/srerickson/ocfl-services/access"
+)
+
+const testConfigFile = `
+addr = ":8443"
+refresh_interval = "5m"
+sync_mode = "trust"
+stat_concurrency = 8
+
+[log]
+format = "json"
+
+[database]
+path = "/var/lib/ocfl/index.db"
+pool_size = 4
+
+[[roots]]
+name = "main"
+location = "s3://bucket/path"
+s3.region = "us-west-2"
+
+[[roots]]
+name = "archive-1"
+location = "/data/archive"
+refresh_interval = "0s"
+sync_mode = "always"
+`
+
+func TestLoadConfig(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "config.toml")
+	be.NilErr(t, os.WriteFile(name, []byte(testConfigFile), 0o644))
+	noEnv := func(string) string { return "" }
+
+	t.Run("defaults", func(t *testing.T) {
+		cfg, err := loadConfig("", noEnv)
+		be.NilErr(t, err)
+		be.DeepEqual(t, defaultConfig(), *cfg)
+		be.NilErr(t, cfg.validate())
+	})
+
+	t.Run("file", func(t *testing.T) {
+		cfg, err := loadConfig(name, noEnv)
+		be.NilErr(t, err)
+		be.NilErr(t, cfg.validate())
+		be.Equal(t, ":8443", cfg.Addr)
+		be.Equal(t, 5*time.Minute, cfg.RefreshInterval)
+		be.Equal(t, access.SyncTrustIndex, cfg.SyncMode)
+		be.Equal(t, 8, cfg.StatConcurrency)
+		be.Equal(t, "json", cfg.Log.Format)
+		be.Equal(t, "info", cfg.Log.Level) // default
+		be.Equal(t, "/var/lib/ocfl/index.db", cfg.Database.Path)
+		be.Equal(t, 4, cfg.Database.PoolSize)
+		be.Equal(t, 2, len(cfg.Roots))
+		be.Equal(t, "us-west-2", cfg.Roots[0].S3.Region)
+		be.True(t, cfg.Roots[0].RefreshInterval == nil)
+		be.True(t, cfg.Roots[0].SyncMode == nil)
+		// a zero refresh interval overrides the top-level setting
+		be.True(t, cfg.Roots[1].RefreshInterval != nil)
+		be.Equal(t, 0, *cfg.Roots[1].RefreshInterval)
+		be.Equal(t, access.SyncAlways, *cfg.Roots[1].SyncMode)
+	})
+
+	t.Run("environment overrides file", func(t *testing.T) {
+		env := map[string]string{
+			"OCFL_ADDR":                            ":9000",
+			"OCFL_DB":                              "/tmp/index.db",
+			"OCFL_REFRESH_INTERVAL":                "1m",
+			"OCFL_SYNC_MODE":                       "interval",
+			"OCFL_LOG_LEVEL":                       "debug",
+			"OCFL_WATCH":                           "true",
+			"OCFL_ROOT_MAIN_S3_REGION":             "us-east-1",
+			"OCFL_ROOT_ARCHIVE_1_S3_ACCESS_KEY_ID": "key",
+		}
+		cfg, err := loadConfig(name, func(k string) string { return env[k] })
+		be.NilErr(t, err)
+		be.Equal(t, ":9000", cfg.Addr)
+		be.Equal(t, "/tmp/index.db", cfg.Database.Path)
+		be.Equal(t, time.Minute, cfg.RefreshInterval)
+		be.Equal(t, access.SyncInterval, cfg.SyncMode)
+		be.Equal(t, "debug", cfg.Log.Level)
+		be.True(t, cfg.Watch.Enabled)
+		be.Equal(t, "us-east-1", cfg.Roots[0].S3.Region)
+		be.Equal(t, "key", cfg.Roots[1].S3.AccessKeyID)
+		// settings that aren't in the environment are from the file
+		be.Equal(t, 4, cfg.Database.PoolSize)
+	})
+
+	t.Run("invalid environment variable", func(t *testing.T) {
+		env := map[string]string{"OCFL_DB_POOL_SIZE": "many", "OCFL_SYNC_MODE": "sometimes"}
+		_, err := loadConfig("", func(k string) string { return env[k] })
+		be.Nonzero(t, err)
+		be.In(t, "OCFL_DB_POOL_SIZE", err.Error())
+		be.In(t, "OCFL_SYNC_MODE", err.Error())
+	})
+
+	t.Run("unknown setting", func(t *testing.T) {
+		name := filepath.Join(t.TempDir(), "config.toml")
+		be.NilErr(t, os.WriteFile(name, []byte("adr = \":8443\"\n"), 0o644))
+		_, err := loadConfig(name, noEnv)
+		be.Nonzero(t, err)
+		be.In(t, `"adr"`, err.Error())
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := loadConfig(filepath.Join(t.TempDir(), "missing.toml"), noEnv)
+		be.Nonzero(t, err)
+	})
+}
+
+func TestConfig_applyFlags(t *testing.T) {
+	newFlags := func(args ...string) *flag.FlagSet {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		fs.String("addr", ":8283", "")
+		fs.String("db", "", "")
+		fs.Bool("debug", false, "")
+		be.NilErr(t, fs.Parse(args))
+		return fs
+	}
+	env := map[string]string{"OCFL_ADDR": ":9000", "OCFL_DB": "/tmp/env.db"}
+	cfg, err := loadConfig("", func(k string) string { return env[k] })
+	be.NilErr(t, err)
+
+	// flags that aren't set don't override other settings
+	cfg.applyFlags(newFlags())
+	be.Equal(t, ":9000", cfg.Addr)
+	be.Equal(t, "/tmp/env.db", cfg.Database.Path)
+	be.Equal(t, "info", cfg.Log.Level)
+
+	cfg.applyFlags(newFlags("-addr", ":8080", "-db", "/tmp/flag.db", "-debug"))
+	be.Equal(t, ":8080", cfg.Addr)
+	be.Equal(t, "/tmp/flag.db", cfg.Database.Path)
+	be.Equal(t, "debug", cfg.Log.Level)
+}
+
+func TestConfig_validate(t *testing.T) {
+	negative := -time.Second
+	cfg := defaultConfig()
+	cfg.Addr = ""
+	cfg.TLS.CertFile = "cert.pem"
+	cfg.Log = logConfig{Format: "xml", Level: "loud"}
+	cfg.Database = databaseConfig{PoolSize: -1}
+	cfg.RefreshInterval = -time.Minute
+	cfg.StatConcurrency = 0
+	cfg.Markdown.MaxSize = 0
+	cfg.Events.SQSRegion = "us-west-2"
+	cfg.Watch.Delay = -time.Second
+	cfg.Roots = []rootConfig{
+		{Name: "a/b", Location: "/data/a"},
+		{Name: "main", Location: "/data/a", RefreshInterval: &negative},
+		{Name: "main", S3: s3Config{Region: "us-west-2", AccessKeyID: "key"}},
+		{Name: "Main", Location: "/data/c"},
+	}
+	err := cfg.validate()
+	be.Nonzero(t, err)
+	// all errors are reported together
+	for _, want := range []string{
+		"addr: missing listen address",
+		"tls: cert_file and key_file must be set together",
+		`log.format: "xml"`,
+		`log.level: "loud"`,
+		"database.path: missing database path",
+		"database.pool_size: must not be negative",
+		"refresh_interval: must not be negative",
+		"stat_concurrency: must be at least 1",
+		"markdown.max_size: must be at least 1",
+		"events: sqs_region and sqs_endpoint require sqs_queue_url",
+		"watch.delay: must not be negative",
+		`roots[0]: invalid name "a/b"`,
+		`roots[1]: duplicate location "/data/a"`,
+		"roots[1].refresh_interval: must not be negative",
+		`roots[2]: duplicate name "main"`,
+		"roots[2]: missing location",
+		"roots[2].s3: S3 settings are only allowed for s3:// locations",
+		"roots[2].s3: access_key_id and secret_access_key must be set together",
+		`roots[3]: name "Main" has the same environment variables as "main"`,
+	} {
+		be.In(t, want, err.Error())
+	}
+	be.Equal(t, 19, len(strings.Split(err.Error(), "\n")))
+}
+
+func TestConfig_checkSyncMode(t *testing.T) {
+	events := access.SyncOnEvent
+	s3Root := rootConfig{Name: "s3", Location: "s3://bucket/path", SyncMode: &events}
+	localRoot := rootConfig{Name: "local", Location: "/data/root", SyncMode: &events}
+	httpRoot := rootConfig{Name: "http", Location: "https://example.com/root", SyncMode: &events}
+	cfg := defaultConfig()
+	be.Nonzero(t, cfg.checkSyncMode(s3Root))
+	be.Nonzero(t, cfg.checkSyncMode(localRoot))
+	cfg.Events.WebhookToken = "s3cr3t"
+	be.NilErr(t, cfg.checkSyncMode(s3Root))
+	be.Nonzero(t, cfg.checkSyncMode(localRoot))
+	cfg.Watch.Enabled = true
+	be.NilErr(t, cfg.checkSyncMode(localRoot))
+	be.Nonzero(t, cfg.checkSyncMode(httpRoot))
+
+	// the top-level sync mode applies to roots without their own
+	cfg = defaultConfig()
+	cfg.SyncMode = access.SyncOnEvent
+	cfg.Roots = []rootConfig{{Name: "local", Location: "/data/root"}}
+	err := cfg.validate()
+	be.Nonzero(t, err)
+	be.In(t, "roots[0].sync_mode", err.Error())
+}
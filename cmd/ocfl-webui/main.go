@@ -2,33 +2,41 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
 	"net/http"
 	"net/url"
 	"os"
 	"os/signal"
-	"path/filepath"
 	"strings"
 	"syscall"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/srerickson/ocfl-go"
-	ocflfs "github.com/srerickson/ocfl-go/fs"
-	httpfs "github.com/srerickson/ocfl-go/fs/http"
 	"github.com/srerickson/ocfl-go/fs/local"
-	ocflS3 "github.com/srerickson/ocfl-go/fs/s3"
 	"github.com/srerickson/ocfl-services/access"
 	"github.com/srerickson/ocfl-services/access/sqlite"
+	"github.com/srerickson/ocfl-services/ingest"
+	"github.com/srerickson/ocfl-services/internal/fswatch"
+	"github.com/srerickson/ocfl-services/internal/rootloc"
+	"github.com/srerickson/ocfl-services/internal/s3events"
 	"github.com/srerickson/ocfl-services/webui"
+	"github.com/srerickson/ocfl-services/webui/auth"
 )
 
-const envVarRoot = "OCFL_ROOT" // storage root location string
+const (
+	envVarRoot             = "OCFL_ROOT"          // storage root location string
+	envVarOIDCClientSecret = "OIDC_CLIENT_SECRET" // OIDC client secret
+	envVarSessionKey       = "OCFL_SESSION_KEY"   // key for signing OIDC session cookies
+)
+
+// path of the S3 event notification webhook
+const s3WebhookPath = "/events/s3"
 
 var (
 	stopSigs = []os.Signal{syscall.SIGINT, syscall.SIGTERM}
@@ -47,71 +55,274 @@ func runServer(args []string, w io.Writer) error {
 	defer cancel()
 	// Parse command line flags
 	flags := struct {
-		root  string
-		db    string
-		addr  string
-		debug bool
+		root          string
+		config        string
+		db            string
+		addr          string
+		debug         bool
+		indexInterval time.Duration
+		maxArchive    int64
+		policy        string
+		stagingDir    string
+		uploadMaxAge  time.Duration
+		uploadMaxSize int64
+		fixityEvery   time.Duration
+		fixityMaxRate int64
+		auth          authFlags
 	}{}
 	fs := flag.NewFlagSet("ocfl-server", flag.ContinueOnError)
 	fs.SetOutput(w)
 	fs.StringVar(&flags.root, "root", "", "OCFL storage root location (file path, s3://bucket/path")
+	fs.StringVar(&flags.config, "config", "", "TOML configuration file with server settings and named storage roots")
 	fs.StringVar(&flags.db, "db", "", "database file path. Defaults to in-memory databases.")
-	fs.StringVar(&flags.addr, "addr", ":8283", "server listen port")
+	fs.StringVar(&flags.addr, "addr", ":8283", "server listen address")
 	fs.BoolVar(&flags.debug, "debug", false, "more verbose log messages")
+	fs.DurationVar(&flags.indexInterval, "index-interval", time.Hour, "interval between full storage root scans. Use 0 to only scan at startup, or -1 to never scan, e.g. for an index built with ocfl-index.")
+	fs.Int64Var(&flags.maxArchive, "max-archive-size", 4*1024*1024*1024, "max total size in bytes of files in a directory archive download. Use 0 for no limit.")
+	fs.StringVar(&flags.auth.tokenFile, "auth-token-file", "", "file with bearer tokens for API clients. Enables authentication.")
+	fs.StringVar(&flags.auth.htpasswd, "auth-htpasswd", "", "htpasswd file for basic auth (bcrypt or SHA-1 hashes). Enables authentication.")
+	fs.StringVar(&flags.auth.oidcIssuer, "oidc-issuer", "", "OpenID Connect provider issuer URL. Enables authentication.")
+	fs.StringVar(&flags.auth.oidcClientID, "oidc-client-id", "", "OpenID Connect client ID")
+	fs.StringVar(&flags.auth.oidcRedirectURL, "oidc-redirect-url", "", "absolute URL of the server's OpenID Connect callback, e.g. https://example.com/auth/callback")
+	fs.StringVar(&flags.auth.oidcGroupsClaim, "oidc-groups-claim", "groups", "ID token claim with the user's groups")
+	fs.StringVar(&flags.auth.proxyHeader, "auth-proxy-header", "", "header with the principal ID set by a trusted reverse proxy, e.g. X-Remote-User. Enables authentication.")
+	fs.StringVar(&flags.auth.proxyGroupsHeader, "auth-proxy-groups-header", "", "header with the principal's comma-separated groups set by a trusted reverse proxy")
+	fs.StringVar(&flags.auth.proxyTrusted, "auth-proxy-trusted", "127.0.0.1,::1", "comma-separated addresses and CIDR prefixes of trusted reverse proxies")
+	fs.StringVar(&flags.policy, "access-policy", "", "JSON file with the access policy for objects")
+	fs.StringVar(&flags.stagingDir, "staging-dir", "", "directory for uploaded files. Enables uploads, which require authentication and an access policy that allows writes.")
+	fs.DurationVar(&flags.uploadMaxAge, "upload-max-age", 24*time.Hour, "time after which unfinished uploads are deleted")
+	fs.Int64Var(&flags.uploadMaxSize, "upload-max-size", ingest.DefaultMaxUploadSize, "max size in bytes of an uploaded file")
+	fs.DurationVar(&flags.fixityEvery, "fixity-interval", 0, "time after which each content file's fixity is checked again. Use 0 to disable fixity checks.")
+	fs.Int64Var(&flags.fixityMaxRate, "fixity-max-rate", 0, "max bytes per second read from the storage root for fixity checks. Use 0 for no limit.")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
-	var logLevel slog.Level
-	if flags.debug {
-		logLevel = slog.LevelDebug
-	}
-	logger := slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{
-		Level: logLevel,
-	}))
-	if flags.root == "" {
-		flags.root = os.Getenv(envVarRoot)
-	}
-	if flags.root == "" {
-		err := errors.New("missing required -root flag")
+	// until the configuration is loaded, log with the default settings
+	logger := defaultConfig().Log.logger(w)
+	// Load configuration: the config file and environment variables, with
+	// flags that are set overriding both.
+	cfg, err := loadConfig(flags.config, os.Getenv)
+	if err != nil {
 		logger.Error(err.Error())
+		return err
 	}
-	if flags.db == "" {
-		flags.db = "file::memory:?mode=memory&cache=shared"
-	}
-	// Parse and initialize OCFL root
-	fsys, rootPath, err := parseRootFlag(ctx, flags.root, logger)
-	if err != nil {
-		err := fmt.Errorf("failed to parse root location %q: %w", flags.root, err)
+	cfg.applyFlags(fs)
+	if err := cfg.validate(); err != nil {
+		err := fmt.Errorf("invalid configuration: %w", err)
 		logger.Error(err.Error())
 		return err
 	}
-	root, err := ocfl.NewRoot(ctx, fsys, rootPath)
-	if err != nil {
-		err := fmt.Errorf("failed to initialize OCFL root at %q: %w", flags.root, err)
+	logger = cfg.Log.logger(w)
+	// storage roots to serve: a single root from -root, or named roots from
+	// the config file.
+	roots := cfg.Roots
+	switch {
+	case len(roots) > 0 && flags.root != "":
+		err := errors.New("use either -root or roots in the -config file, not both")
 		logger.Error(err.Error())
 		return err
+	case len(roots) == 0:
+		if flags.root == "" {
+			flags.root = os.Getenv(envVarRoot)
+		}
+		if flags.root == "" {
+			err := errors.New("missing required -root flag or roots in the -config file")
+			logger.Error(err.Error())
+			return err
+		}
+		roots = []rootConfig{{Location: flags.root}}
+		if err := cfg.checkSyncMode(roots[0]); err != nil {
+			err := fmt.Errorf("invalid configuration: sync_mode: %w", err)
+			logger.Error(err.Error())
+			return err
+		}
+	}
+	// Parse and initialize OCFL roots
+	ocflRoots := make([]*ocfl.Root, len(roots))
+	for i, r := range roots {
+		fsys, rootPath, err := rootloc.Parse(ctx, r.Location, logger, rootloc.WithS3(r.S3.options()))
+		if err != nil {
+			err := fmt.Errorf("failed to parse root location %q: %w", r.Location, err)
+			logger.Error(err.Error())
+			return err
+		}
+		ocflRoots[i], err = ocfl.NewRoot(ctx, fsys, rootPath)
+		if err != nil {
+			err := fmt.Errorf("failed to initialize OCFL root at %q: %w", r.Location, err)
+			logger.Error(err.Error())
+			return err
+		}
+		logger.Info("using OCFL root", "name", r.Name, "path", r.Location, "ocfl_version", ocflRoots[i].Spec())
 	}
-	logger.Info("using OCFL root", "path", flags.root, "ocfl_version", root.Spec())
 	// Initialize index database
-	db, err := sqlite.NewDB(flags.db)
+	db, err := sqlite.NewDB(cfg.Database.Path,
+		sqlite.WithPoolSize(cfg.Database.PoolSize),
+		sqlite.WithStatConcurrency(cfg.StatConcurrency))
 	if err != nil {
-		err := fmt.Errorf("failed to initialize database at %q: %w", flags.db, err)
+		err := fmt.Errorf("failed to initialize database at %q: %w", cfg.Database.Path, err)
 		logger.Error(err.Error())
 		return err
 	}
 	defer db.Close()
-	logger.Info("database initialized", "path", flags.db)
-	// Create HTTP server
-	service := access.NewService(root, db, flags.root, logger)
+	logger.Info("database initialized", "path", cfg.Database.Path)
+	// Set up authentication
+	authns, err := flags.auth.authenticators(ctx)
+	if err != nil {
+		err := fmt.Errorf("failed to set up authentication: %w", err)
+		logger.Error(err.Error())
+		return err
+	}
+	if len(authns) > 0 {
+		logger.Info("authentication enabled", "methods", len(authns))
+	}
+	// Load access policy
+	var serviceOpts []access.ServiceOption
+	if flags.policy != "" {
+		policy, err := access.LoadPolicy(flags.policy)
+		if err != nil {
+			logger.Error(err.Error())
+			return err
+		}
+		serviceOpts = append(serviceOpts, access.WithPolicy(policy))
+		logger.Info("access policy enabled", "path", flags.policy, "rules", len(policy.Rules))
+	}
+	// Create HTTP server. The roots share the database: each root's objects
+	// are indexed with its location as the root ID, as with ocfl-index.
+	services := make([]*access.Service, len(roots))
+	for i, r := range roots {
+		opts := append(cfg.serviceOptions(r), serviceOpts...)
+		services[i] = access.NewService(ocflRoots[i], db, r.Location, logger, opts...)
+		logger.Info("object sync mode", "name", r.Name, "mode", services[i].SyncMode())
+	}
+	serverOpts := []server.Option{
+		server.WithMaxArchiveSize(flags.maxArchive),
+		server.WithMaxMarkdownSize(cfg.Markdown.MaxSize),
+		server.WithAuthenticators(authns...),
+	}
+	if flags.stagingDir != "" {
+		// uploads are committed as the authenticated principal, and
+		// commits must be allowed by the access policy.
+		if len(authns) == 0 || flags.policy == "" {
+			err := errors.New("-staging-dir requires authentication and an -access-policy that allows writes")
+			logger.Error(err.Error())
+			return err
+		}
+		staging, err := ingest.NewStaging(flags.stagingDir, ingest.WithMaxUploadSize(flags.uploadMaxSize))
+		if err != nil {
+			err := fmt.Errorf("failed to initialize staging directory %q: %w", flags.stagingDir, err)
+			logger.Error(err.Error())
+			return err
+		}
+		serverOpts = append(serverOpts, server.WithStaging(staging))
+		go runUploadCleanup(ctx, services, staging, flags.uploadMaxAge, logger)
+		logger.Info("uploads enabled", "staging_dir", flags.stagingDir, "max_size", flags.uploadMaxSize)
+	}
+	var handler http.Handler
+	if len(cfg.Roots) == 0 {
+		handler = server.New(services[0], serverOpts...)
+	} else {
+		named := make([]server.Root, len(roots))
+		for i, r := range roots {
+			named[i] = server.Root{Name: r.Name, Service: services[i]}
+		}
+		handler = server.NewMulti(named, logger, serverOpts...)
+	}
+	// Update the index of S3 roots from S3 event notifications
+	if cfg.Events.enabled() {
+		var s3Roots []s3events.Root
+		for i, r := range roots {
+			if bucket := s3Bucket(r.Location); bucket != "" {
+				s3Roots = append(s3Roots, s3events.Root{Bucket: bucket, Service: services[i]})
+			}
+		}
+		if len(s3Roots) == 0 {
+			err := errors.New("S3 event notifications require an s3:// storage root")
+			logger.Error(err.Error())
+			return err
+		}
+		events := s3events.NewHandler(s3Roots, logger)
+		if cfg.Events.SQSQueueURL != "" {
+			queue, err := s3events.NewSQSQueue(ctx, cfg.Events.SQSQueueURL, s3events.SQSOptions{
+				Region:   cfg.Events.SQSRegion,
+				Endpoint: cfg.Events.SQSEndpoint,
+			})
+			if err != nil {
+				err := fmt.Errorf("failed to initialize SQS queue %q: %w", cfg.Events.SQSQueueURL, err)
+				logger.Error(err.Error())
+				return err
+			}
+			go events.Poll(ctx, queue)
+			logger.Info("polling for S3 event notifications", "queue_url", cfg.Events.SQSQueueURL)
+		}
+		if cfg.Events.WebhookToken != "" {
+			// the webhook has its own token, so it's outside the server's
+			// authentication.
+			mux := http.NewServeMux()
+			mux.Handle(s3WebhookPath, events.Webhook(cfg.Events.WebhookToken))
+			mux.Handle("/", handler)
+			handler = mux
+			logger.Info("S3 event notification webhook enabled", "path", s3WebhookPath)
+		}
+	}
+	// Update the index of local roots when objects change
+	if cfg.Watch.Enabled {
+		var watched int
+		for i, r := range roots {
+			if _, ok := services[i].Root().FS().(*local.FS); !ok {
+				continue
+			}
+			w, err := fswatch.New(services[i], cfg.Watch.Delay)
+			if err != nil {
+				err := fmt.Errorf("failed to watch storage root %q: %w", r.Location, err)
+				logger.Error(err.Error())
+				return err
+			}
+			go w.Run(ctx)
+			watched++
+			logger.Info("watching storage root for changes", "name", r.Name, "path", r.Location)
+		}
+		if watched == 0 {
+			err := errors.New("watching for changes requires a local storage root")
+			logger.Error(err.Error())
+			return err
+		}
+	}
 	httpServer := &http.Server{
-		Addr:    flags.addr,
-		Handler: server.New(service),
+		Addr:    cfg.Addr,
+		Handler: handler,
+	}
+	if cfg.TLS.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		if err != nil {
+			err := fmt.Errorf("failed to load TLS certificate: %w", err)
+			logger.Error(err.Error())
+			return err
+		}
+		httpServer.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+	}
+	// Index the storage roots in the background
+	for _, service := range services {
+		go runIndexer(ctx, service, flags.indexInterval)
+	}
+	if flags.fixityEvery > 0 {
+		for _, service := range services {
+			go runFixityAudit(ctx, service, access.FixityAuditOptions{
+				Interval: flags.fixityEvery,
+				MaxRate:  flags.fixityMaxRate,
+			})
+		}
+		logger.Info("fixity checks enabled", "interval", flags.fixityEvery, "max_rate", flags.fixityMaxRate)
 	}
 	// Set up signal handling for graceful shutdown
 	serverErrChan := make(chan error, 1)
 	go func() {
-		logger.Info("starting server", "addr", flags.addr)
-		err := httpServer.ListenAndServe()
+		logger.Info("starting server", "addr", cfg.Addr, "tls", httpServer.TLSConfig != nil)
+		var err error
+		if httpServer.TLSConfig != nil {
+			err = httpServer.ListenAndServeTLS("", "")
+		} else {
+			err = httpServer.ListenAndServe()
+		}
 		if err != nil && err != http.ErrServerClosed {
 			serverErrChan <- err
 		}
@@ -136,37 +347,158 @@ func runServer(args []string, w io.Writer) error {
 	}
 }
 
-func parseRootFlag(ctx context.Context, loc string, logger *slog.Logger) (ocflfs.FS, string, error) {
-	if loc == "" {
-		return nil, "", errors.New("location not set")
+// s3Bucket returns the bucket name for an s3:// storage root location, or an
+// empty string for other locations.
+func s3Bucket(loc string) string {
+	u, err := url.Parse(loc)
+	if err != nil || u.Scheme != "s3" {
+		return ""
 	}
-	locUrl, err := url.Parse(loc)
-	if err != nil {
-		return nil, "", err
+	return u.Host
+}
+
+// runIndexer indexes the service's storage root immediately and then again
+// after each interval until ctx is canceled. If interval is zero, the storage
+// root is only indexed once. If interval is negative, the storage root isn't
+// scanned.
+func runIndexer(ctx context.Context, svc *access.Service, interval time.Duration) {
+	if interval < 0 {
+		return
+	}
+	var ticker *time.Ticker
+	if interval > 0 {
+		ticker = time.NewTicker(interval)
+		defer ticker.Stop()
+	}
+	for {
+		if _, err := svc.IndexRoot(ctx, access.IndexOptions{}); err != nil && ctx.Err() == nil {
+			svc.Logger().Error("indexing storage root", "error", err)
+		}
+		if ticker == nil {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runFixityAudit checks the fixity of content files in batches until ctx is
+// canceled. When no files are due for a check, or after an error, it waits
+// before checking again.
+func runFixityAudit(ctx context.Context, svc *access.Service, opts access.FixityAuditOptions) {
+	const idleWait = 10 * time.Minute
+	for {
+		result, err := svc.AuditFixity(ctx, opts)
+		if err != nil && ctx.Err() == nil {
+			svc.Logger().Error("checking fixity", "error", err)
+		}
+		if result.Checked > 0 || result.Errors > 0 {
+			svc.Logger().Info("checked fixity", "files", result.Checked, "failed", result.Failed, "errors", result.Errors, "bytes", result.Bytes)
+		}
+		if err == nil && result.Checked > 0 {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(idleWait):
+		}
+	}
+}
+
+// runUploadCleanup deletes uploads older than maxAge from the staging area
+// every hour until ctx is canceled. Uploads in drafts of any of the services'
+// storage roots aren't deleted.
+func runUploadCleanup(ctx context.Context, services []*access.Service, staging *ingest.Staging, maxAge time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		n, err := removeExpiredUploads(ctx, services, staging, maxAge)
+		if err != nil {
+			logger.Error("removing expired uploads", "error", err)
+		}
+		if n > 0 {
+			logger.Info("removed expired uploads", "uploads", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func removeExpiredUploads(ctx context.Context, services []*access.Service, staging *ingest.Staging, maxAge time.Duration) (int, error) {
+	inDrafts := map[string]bool{}
+	for _, service := range services {
+		ids, err := service.DraftUploads(ctx)
+		if err != nil {
+			return 0, err
+		}
+		maps.Copy(inDrafts, ids)
+	}
+	return staging.RemoveExpired(maxAge, func(id string) bool { return inDrafts[id] })
+}
+
+// authFlags are command line flags for authentication backends.
+type authFlags struct {
+	tokenFile       string
+	htpasswd        string
+	oidcIssuer      string
+	oidcClientID    string
+	oidcRedirectURL string
+	oidcGroupsClaim string
+
+	proxyHeader       string
+	proxyGroupsHeader string
+	proxyTrusted      string
+}
+
+// authenticators returns the authentication backends enabled by the flags.
+// If none are enabled, the server doesn't require authentication. The OIDC
+// client secret and session key are read from environment variables.
+func (f authFlags) authenticators(ctx context.Context) ([]auth.Authenticator, error) {
+	var authns []auth.Authenticator
+	if f.tokenFile != "" {
+		tokens, err := auth.LoadTokenFile(f.tokenFile)
+		if err != nil {
+			return nil, err
+		}
+		authns = append(authns, tokens)
 	}
-	switch locUrl.Scheme {
-	case "s3":
-		bucket := locUrl.Host
-		prefix := strings.TrimPrefix(locUrl.Path, "/")
-		cfg, err := config.LoadDefaultConfig(ctx)
+	if f.htpasswd != "" {
+		users, err := auth.LoadHtpasswd(f.htpasswd)
 		if err != nil {
-			return nil, "", err
-		}
-		s3Client := s3.NewFromConfig(cfg)
-		fsys := &ocflS3.BucketFS{S3: s3Client, Bucket: bucket, Logger: logger}
-		return fsys, prefix, nil
-	case "http", "https":
-		fsys := httpfs.New(loc)
-		return fsys, ".", nil
-	default:
-		absPath, err := filepath.Abs(loc)
+			return nil, err
+		}
+		authns = append(authns, users)
+	}
+	if f.proxyHeader != "" {
+		proxy, err := auth.NewProxyAuth(f.proxyHeader, f.proxyGroupsHeader, strings.Split(f.proxyTrusted, ","))
 		if err != nil {
-			return nil, "", err
+			return nil, err
 		}
-		fsys, err := local.NewFS(absPath)
+		authns = append(authns, proxy)
+	}
+	if f.oidcIssuer != "" {
+		oidc, err := auth.NewOIDC(ctx, auth.OIDCConfig{
+			Issuer:       f.oidcIssuer,
+			ClientID:     f.oidcClientID,
+			ClientSecret: os.Getenv(envVarOIDCClientSecret),
+			RedirectURL:  f.oidcRedirectURL,
+			GroupsClaim:  f.oidcGroupsClaim,
+			SessionKey:   []byte(os.Getenv(envVarSessionKey)),
+		})
 		if err != nil {
-			return nil, "", err
+			return nil, err
 		}
-		return fsys, ".", nil
+		authns = append(authns, oidc)
 	}
+	return authns, nil
 }
@@ -0,0 +1,373 @@
+// Package ingest provides a staging area for files uploaded to create new
+// object versions. Uploads can be written in several requests, so clients can
+// resume interrupted uploads of large files.
+package ingest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/srerickson/ocfl-go"
+	"github.com/srerickson/ocfl-go/digest"
+	ocflfs "github.com/srerickson/ocfl-go/fs"
+)
+
+// file name extension for upload metadata files
+const infoExt = ".json"
+
+// DefaultMaxUploadSize is the default maximum size of an upload in bytes.
+const DefaultMaxUploadSize = 4 * 1024 * 1024 * 1024 // 4 GiB
+
+var (
+	ErrNotFound       = errors.New("upload not found")
+	ErrOffsetMismatch = errors.New("upload offset doesn't match the uploaded size")
+	ErrTooLarge       = errors.New("upload exceeds its length")
+	ErrIncomplete     = errors.New("upload is incomplete")
+	ErrMaxSize        = errors.New("upload exceeds the maximum upload size")
+)
+
+// Upload is a file in the staging area.
+type Upload struct {
+	ID      string    `json:"id"`
+	Owner   string    `json:"owner"`  // ID of the principal that created the upload
+	Length  int64     `json:"length"` // size of the complete upload in bytes
+	Offset  int64     `json:"-"`      // number of bytes uploaded
+	Created time.Time `json:"created"`
+}
+
+// Complete reports whether all of the upload's bytes have been uploaded.
+func (u Upload) Complete() bool { return u.Offset == u.Length }
+
+// upload is an Upload with a lock for writing.
+type upload struct {
+	mu sync.Mutex
+	Upload
+}
+
+// Staging is a directory on the local file system for uploads. Each upload's
+// content is stored in a file named with its ID, next to a JSON file with its
+// metadata.
+type Staging struct {
+	dir     string
+	maxSize int64 // max upload size in bytes
+	mu      sync.Mutex
+	uploads map[string]*upload
+}
+
+// StagingOption is used to configure a Staging created with NewStaging.
+type StagingOption func(*Staging)
+
+// WithMaxUploadSize sets the maximum size in bytes of an upload. The default
+// is DefaultMaxUploadSize.
+func WithMaxUploadSize(size int64) StagingOption {
+	return func(s *Staging) { s.maxSize = size }
+}
+
+// NewStaging returns a *Staging using the directory dir, which is created if
+// it doesn't exist. Uploads from previous uses of the directory can be
+// resumed.
+func NewStaging(dir string, opts ...StagingOption) (*Staging, error) {
+	s := &Staging{dir: dir, maxSize: DefaultMaxUploadSize, uploads: map[string]*upload{}}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.maxSize < 1 {
+		return nil, fmt.Errorf("invalid max upload size: %d", s.maxSize)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		id, isInfo := strings.CutSuffix(entry.Name(), infoExt)
+		if !isInfo || entry.IsDir() {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		u := &upload{}
+		if err := json.Unmarshal(b, &u.Upload); err != nil || u.ID != id {
+			return nil, fmt.Errorf("invalid upload metadata: %s", entry.Name())
+		}
+		info, err := os.Stat(s.contentPath(id))
+		if err != nil {
+			return nil, err
+		}
+		u.Offset = info.Size()
+		s.uploads[id] = u
+	}
+	return s, nil
+}
+
+// Create adds a new upload with the given length to the staging area. If the
+// length is more than the maximum upload size, ErrMaxSize is returned.
+func (s *Staging) Create(owner string, length int64) (Upload, error) {
+	if length < 0 {
+		return Upload{}, fmt.Errorf("invalid upload length: %d", length)
+	}
+	if length > s.maxSize {
+		return Upload{}, fmt.Errorf("%w: %d bytes", ErrMaxSize, s.maxSize)
+	}
+	u := newUpload(owner, length)
+	if err := os.WriteFile(s.contentPath(u.ID), nil, 0o644); err != nil {
+		return Upload{}, err
+	}
+	return s.add(u)
+}
+
+// Put adds a new, complete upload with the contents of r. If r has more bytes
+// than the maximum upload size, the upload isn't added and ErrMaxSize is
+// returned.
+func (s *Staging) Put(owner string, r io.Reader) (Upload, error) {
+	u := newUpload(owner, 0)
+	f, err := os.Create(s.contentPath(u.ID))
+	if err != nil {
+		return Upload{}, err
+	}
+	// read one byte more than the max size to detect content that is too
+	// large.
+	size, err := io.Copy(f, io.LimitReader(r, s.maxSize+1))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && size > s.maxSize {
+		err = fmt.Errorf("%w: %d bytes", ErrMaxSize, s.maxSize)
+	}
+	if err != nil {
+		os.Remove(s.contentPath(u.ID))
+		return Upload{}, err
+	}
+	u.Length, u.Offset = size, size
+	return s.add(u)
+}
+
+func newUpload(owner string, length int64) *upload {
+	return &upload{Upload: Upload{
+		ID:      strings.ToLower(rand.Text()),
+		Owner:   owner,
+		Length:  length,
+		Created: time.Now().UTC(),
+	}}
+}
+
+// add saves the metadata for u, which already has a content file, and adds it
+// to the staging area.
+func (s *Staging) add(u *upload) (Upload, error) {
+	if err := s.writeInfo(u.Upload); err != nil {
+		os.Remove(s.contentPath(u.ID))
+		return Upload{}, err
+	}
+	s.mu.Lock()
+	s.uploads[u.ID] = u
+	s.mu.Unlock()
+	return u.Upload, nil
+}
+
+// Get returns the upload with the ID.
+func (s *Staging) Get(id string) (Upload, error) {
+	u, err := s.get(id)
+	if err != nil {
+		return Upload{}, err
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.Upload, nil
+}
+
+// Write appends the contents of r to the upload with the ID. The offset must
+// be the upload's current offset. If r has more bytes than the rest of the
+// upload, none of them are written and ErrTooLarge is returned. If reading r
+// fails, the bytes read before the error are kept, so the upload can be
+// resumed from the new offset. Concurrent writes to an upload are serialized.
+func (s *Staging) Write(id string, offset int64, r io.Reader) (Upload, error) {
+	u, err := s.get(id)
+	if err != nil {
+		return Upload{}, err
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if offset != u.Offset {
+		return u.Upload, fmt.Errorf("%w: expected offset %d", ErrOffsetMismatch, u.Offset)
+	}
+	f, err := os.OpenFile(s.contentPath(id), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return u.Upload, err
+	}
+	defer f.Close()
+	// read one byte more than the remaining length to detect content that
+	// is too large.
+	n, err := io.Copy(f, io.LimitReader(r, u.Length-u.Offset+1))
+	if u.Offset+n > u.Length {
+		if truncErr := f.Truncate(u.Offset); truncErr != nil {
+			return u.Upload, truncErr
+		}
+		return u.Upload, ErrTooLarge
+	}
+	u.Offset += n
+	return u.Upload, err
+}
+
+// Delete removes the upload with the ID from the staging area.
+func (s *Staging) Delete(id string) error {
+	s.mu.Lock()
+	u, ok := s.uploads[id]
+	delete(s.uploads, id)
+	s.mu.Unlock()
+	if !ok {
+		return ErrNotFound
+	}
+	// wait for writes to finish
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return errors.Join(
+		os.Remove(s.contentPath(id)),
+		os.Remove(s.infoPath(id)))
+}
+
+// RemoveExpired deletes uploads created more than maxAge ago, except uploads
+// for which inUse returns true. It returns the number of deleted uploads.
+// inUse may be nil.
+func (s *Staging) RemoveExpired(maxAge time.Duration, inUse func(id string) bool) (int, error) {
+	s.mu.Lock()
+	var expired []string
+	for id, u := range s.uploads {
+		if time.Since(u.Created) > maxAge && (inUse == nil || !inUse(id)) {
+			expired = append(expired, id)
+		}
+	}
+	s.mu.Unlock()
+	var errs []error
+	for _, id := range expired {
+		if err := s.Delete(id); err != nil && !errors.Is(err, ErrNotFound) {
+			errs = append(errs, err)
+		}
+	}
+	return len(expired), errors.Join(errs...)
+}
+
+// Content returns the uploads with the given IDs as content for a new object
+// version. The files map has upload IDs keyed by logical path. All the uploads
+// must be complete and belong to owner.
+func (s *Staging) Content(owner string, files map[string]string) (*Content, error) {
+	for name, id := range files {
+		if !fs.ValidPath(name) || name == "." {
+			return nil, fmt.Errorf("invalid logical path: %q", name)
+		}
+		u, err := s.Get(id)
+		if err != nil || u.Owner != owner {
+			return nil, fmt.Errorf("upload %q for %q: %w", id, name, ErrNotFound)
+		}
+		if !u.Complete() {
+			return nil, fmt.Errorf("upload %q for %q: %w", id, name, ErrIncomplete)
+		}
+	}
+	return &Content{staging: s, files: files}, nil
+}
+
+func (s *Staging) get(id string) (*upload, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.uploads[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return u, nil
+}
+
+func (s *Staging) writeInfo(u Upload) error {
+	b, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.infoPath(u.ID), b, 0o644)
+}
+
+func (s *Staging) contentPath(id string) string { return filepath.Join(s.dir, id) }
+func (s *Staging) infoPath(id string) string    { return filepath.Join(s.dir, id+infoExt) }
+
+// UploadContent returns the FS and path for the content of the upload with
+// the ID. If the upload doesn't exist, fsys is nil and name is empty. It
+// implements access.UploadSource.
+func (s *Staging) UploadContent(id string) (fsys ocflfs.FS, name string) {
+	if _, err := s.get(id); err != nil {
+		return nil, ""
+	}
+	return ocflfs.DirFS(s.dir), id
+}
+
+// Digest returns the digest of the upload's content using alg.
+func (s *Staging) Digest(id string, alg digest.Algorithm) (string, error) {
+	u, err := s.get(id)
+	if err != nil {
+		return "", err
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	f, err := os.Open(s.contentPath(id))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	digester := alg.Digester()
+	if _, err := io.Copy(digester, f); err != nil {
+		return "", err
+	}
+	return digester.String(), nil
+}
+
+// Content is a set of uploads with logical paths. It implements access.Stager.
+type Content struct {
+	staging *Staging
+	files   map[string]string // upload IDs by logical path
+}
+
+// Stage digests the uploads with alg and returns an *ocfl.Stage for them.
+func (c *Content) Stage(ctx context.Context, alg digest.Algorithm) (*ocfl.Stage, error) {
+	state := ocfl.DigestMap{}
+	source := contentSource{staging: c.staging, files: map[string]string{}}
+	for name, id := range c.files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		sum, err := c.staging.Digest(id, alg)
+		if err != nil {
+			return nil, fmt.Errorf("digesting upload %q: %w", id, err)
+		}
+		state[sum] = append(state[sum], name)
+		source.files[sum] = id
+	}
+	return &ocfl.Stage{
+		State:           state,
+		DigestAlgorithm: alg,
+		ContentSource:   source,
+	}, nil
+}
+
+// contentSource is an ocfl.ContentSource for uploads.
+type contentSource struct {
+	staging *Staging
+	files   map[string]string // upload IDs by digest
+}
+
+func (cs contentSource) GetContent(digest string) (ocflfs.FS, string) {
+	id, ok := cs.files[digest]
+	if !ok {
+		return nil, ""
+	}
+	return cs.staging.UploadContent(id)
+}
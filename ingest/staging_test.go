@@ -0,0 +1,114 @@
+package ingest_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/be"
+	"github.com/srerickson/ocfl-go/digest"
+	"github.com/srerickson/ocfl-services/ingest"
+)
+
+func TestStaging(t *testing.T) {
+	dir := t.TempDir()
+	staging, err := ingest.NewStaging(dir)
+	be.NilErr(t, err)
+
+	t.Run("resumable upload", func(t *testing.T) {
+		u, err := staging.Create("user-1", 11)
+		be.NilErr(t, err)
+		be.False(t, u.Complete())
+		u, err = staging.Write(u.ID, 0, strings.NewReader("hello "))
+		be.NilErr(t, err)
+		be.Equal(t, 6, u.Offset)
+		_, err = staging.Write(u.ID, 0, strings.NewReader("world"))
+		be.True(t, errors.Is(err, ingest.ErrOffsetMismatch))
+		_, err = staging.Write(u.ID, 6, strings.NewReader("world!"))
+		be.True(t, errors.Is(err, ingest.ErrTooLarge))
+		u, err = staging.Write(u.ID, 6, strings.NewReader("world"))
+		be.NilErr(t, err)
+		be.True(t, u.Complete())
+	})
+
+	t.Run("content", func(t *testing.T) {
+		u1, err := staging.Put("user-1", strings.NewReader("content"))
+		be.NilErr(t, err)
+		be.True(t, u1.Complete())
+		u2, err := staging.Create("user-1", 10)
+		be.NilErr(t, err)
+		_, err = staging.Content("user-1", map[string]string{"a.txt": u1.ID, "b.txt": u2.ID})
+		be.True(t, errors.Is(err, ingest.ErrIncomplete))
+		_, err = staging.Content("user-2", map[string]string{"a.txt": u1.ID})
+		be.True(t, errors.Is(err, ingest.ErrNotFound))
+		_, err = staging.Content("user-1", map[string]string{"../a.txt": u1.ID})
+		be.Nonzero(t, err)
+		content, err := staging.Content("user-1", map[string]string{"a.txt": u1.ID, "dir/a.txt": u1.ID})
+		be.NilErr(t, err)
+		stage, err := content.Stage(t.Context(), digest.SHA256)
+		be.NilErr(t, err)
+		be.Equal(t, 1, len(stage.State))
+		for sum, paths := range stage.State {
+			be.Equal(t, 2, len(paths))
+			fsys, name := stage.GetContent(sum)
+			be.Nonzero(t, fsys)
+			be.Equal(t, u1.ID, name)
+		}
+	})
+
+	t.Run("reload", func(t *testing.T) {
+		u, err := staging.Create("user-1", 5)
+		be.NilErr(t, err)
+		_, err = staging.Write(u.ID, 0, strings.NewReader("abc"))
+		be.NilErr(t, err)
+		reloaded, err := ingest.NewStaging(dir)
+		be.NilErr(t, err)
+		got, err := reloaded.Get(u.ID)
+		be.NilErr(t, err)
+		be.Equal(t, 3, got.Offset)
+		be.Equal(t, "user-1", got.Owner)
+	})
+
+	t.Run("max size", func(t *testing.T) {
+		limited, err := ingest.NewStaging(t.TempDir(), ingest.WithMaxUploadSize(4))
+		be.NilErr(t, err)
+		_, err = limited.Create("user-1", 5)
+		be.True(t, errors.Is(err, ingest.ErrMaxSize))
+		_, err = limited.Put("user-1", strings.NewReader("too large"))
+		be.True(t, errors.Is(err, ingest.ErrMaxSize))
+		u, err := limited.Put("user-1", strings.NewReader("fits"))
+		be.NilErr(t, err)
+		be.Equal(t, 4, u.Length)
+		_, err = ingest.NewStaging(t.TempDir(), ingest.WithMaxUploadSize(0))
+		be.Nonzero(t, err)
+	})
+
+	t.Run("delete and expire", func(t *testing.T) {
+		u, err := staging.Put("user-1", strings.NewReader("temp"))
+		be.NilErr(t, err)
+		be.NilErr(t, staging.Delete(u.ID))
+		_, err = staging.Get(u.ID)
+		be.True(t, errors.Is(err, ingest.ErrNotFound))
+		be.True(t, errors.Is(staging.Delete(u.ID), ingest.ErrNotFound))
+		kept, err := staging.Put("user-1", strings.NewReader("kept"))
+		be.NilErr(t, err)
+		inUse := func(id string) bool { return id == kept.ID }
+		n, err := staging.RemoveExpired(time.Hour, inUse)
+		be.NilErr(t, err)
+		be.Equal(t, 0, n)
+		n, err = staging.RemoveExpired(0, inUse)
+		be.NilErr(t, err)
+		be.True(t, n > 0)
+		reloaded, err := ingest.NewStaging(dir)
+		be.NilErr(t, err)
+		n, err = reloaded.RemoveExpired(0, inUse)
+		be.NilErr(t, err)
+		be.Equal(t, 0, n)
+		_, err = reloaded.Get(kept.ID)
+		be.NilErr(t, err)
+		n, err = reloaded.RemoveExpired(0, nil)
+		be.NilErr(t, err)
+		be.Equal(t, 1, n)
+	})
+}
@@ -0,0 +1,235 @@
+// Package fswatch keeps the index of a local storage root up to date by
+// watching the storage root's directories for changes to objects.
+package fswatch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/srerickson/ocfl-go"
+	"github.com/srerickson/ocfl-go/fs/local"
+	"github.com/srerickson/ocfl-services/access"
+)
+
+// DefaultDelay is the default time to wait after the last change to an
+// object before syncing it.
+const DefaultDelay = time.Second
+
+// names of files that are changed when an object is created, updated, or
+// removed.
+const (
+	inventoryFile = "inventory.json"
+	objectDecl    = "0=" + ocfl.NamasteTypeObject
+)
+
+// Watcher watches a local storage root for changes to objects' inventories
+// and declarations and syncs the changed objects with the index. Changes are
+// debounced: an object is synced once its files haven't changed for the
+// watcher's delay, so the writes of a commit result in one sync.
+//
+// Directories in the storage root are watched recursively, except for the
+// contents of object root directories: new directories are watched as they're
+// created, and objects found in them are synced. Each directory uses an
+// inotify watch on Linux, so large storage roots may need a higher
+// fs.inotify.max_user_watches limit.
+type Watcher struct {
+	svc     *access.Service
+	fsDir   string // OS path of the storage root FS's directory
+	delay   time.Duration
+	logger  *slog.Logger
+	watcher *fsnotify.Watcher
+	objects map[string]bool        // OS paths of watched object root directories
+	pending map[string]pendingSync // by object storage path
+}
+
+type pendingSync struct {
+	name string    // changed file, relative to the FS
+	due  time.Time // time to sync the object
+}
+
+// New returns a Watcher for the storage root of svc, which must use a
+// local.FS. It watches the storage root's directories before returning; call
+// Run to handle changes. Objects are synced after delay. Log messages are
+// written to the service's logger.
+func New(svc *access.Service, delay time.Duration) (*Watcher, error) {
+	fsys, ok := svc.Root().FS().(*local.FS)
+	if !ok {
+		return nil, errors.New("storage root isn't in a local directory")
+	}
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{
+		svc:     svc,
+		fsDir:   fsys.Root(),
+		delay:   delay,
+		logger:  svc.Logger(),
+		watcher: fw,
+		objects: map[string]bool{},
+		pending: map[string]pendingSync{},
+	}
+	rootDir := filepath.Join(w.fsDir, filepath.FromSlash(svc.Root().Path()))
+	if err := w.watchTree(rootDir, false); err != nil {
+		fw.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// Run handles changes in the storage root until ctx is canceled, and then
+// stops watching and returns the context's error. Errors syncing objects are
+// logged.
+func (w *Watcher) Run(ctx context.Context) error {
+	defer w.watcher.Close()
+	timer := time.NewTimer(0)
+	<-timer.C
+	for {
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case ev, ok := <-w.watcher.Events:
+			if !ok {
+				return nil
+			}
+			w.handle(ev)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return nil
+			}
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				w.logger.Warn("storage root changes were missed: the index may be stale until the next scan", "error", err)
+				continue
+			}
+			w.logger.Error("watching storage root", "error", err)
+		case <-timer.C:
+			w.syncDue(ctx, time.Now())
+		}
+		if next, ok := w.nextDue(); ok {
+			timer.Reset(time.Until(next))
+		}
+	}
+}
+
+// handle watches new directories and schedules syncs for changed object
+// files.
+func (w *Watcher) handle(ev fsnotify.Event) {
+	if ev.Has(fsnotify.Remove) || ev.Has(fsnotify.Rename) {
+		delete(w.objects, ev.Name)
+	}
+	if ev.Has(fsnotify.Create) {
+		if strings.HasPrefix(filepath.Base(ev.Name), objectDecl) {
+			w.objects[filepath.Dir(ev.Name)] = true
+		}
+		// directories in objects, e.g., new versions, aren't watched.
+		if info, err := os.Lstat(ev.Name); err == nil && info.IsDir() && !w.objects[filepath.Dir(ev.Name)] {
+			if err := w.watchTree(ev.Name, true); err != nil {
+				w.logger.Error("watching new directory", "path", ev.Name, "error", err)
+			}
+			return
+		}
+	}
+	if ev.Has(fsnotify.Create) || ev.Has(fsnotify.Write) || ev.Has(fsnotify.Remove) || ev.Has(fsnotify.Rename) {
+		w.schedule(ev.Name)
+	}
+}
+
+// watchTree watches dir and its subdirectories, not descending into object
+// root directories. If found is true, objects in the tree are synced: the
+// directory is new, and its files may have been written before it was
+// watched.
+func (w *Watcher) watchTree(dir string, found bool) error {
+	return filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil // removed since it was found
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		// watch before reading entries, so files added after the read are
+		// seen as events.
+		if err := w.watcher.Add(name); err != nil {
+			return fmt.Errorf("watching %q: %w", name, err)
+		}
+		entries, err := os.ReadDir(name)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		for _, e := range entries {
+			if !e.IsDir() && strings.HasPrefix(e.Name(), objectDecl) {
+				w.objects[name] = true
+				if found {
+					w.schedule(filepath.Join(name, e.Name()))
+				}
+				return filepath.SkipDir
+			}
+		}
+		return nil
+	})
+}
+
+// schedule schedules a sync of the object changed by the file name, an OS
+// path, if it's an inventory, sidecar, or object declaration.
+func (w *Watcher) schedule(name string) {
+	base := filepath.Base(name)
+	if base != inventoryFile &&
+		!strings.HasPrefix(base, inventoryFile+".") &&
+		!strings.HasPrefix(base, objectDecl) {
+		return
+	}
+	rel, err := filepath.Rel(w.fsDir, name)
+	if err != nil {
+		return
+	}
+	fsName := filepath.ToSlash(rel)
+	w.pending[path.Dir(fsName)] = pendingSync{
+		name: fsName,
+		due:  time.Now().Add(w.delay),
+	}
+}
+
+// nextDue returns the earliest time that a pending sync is due.
+func (w *Watcher) nextDue() (time.Time, bool) {
+	var next time.Time
+	for _, p := range w.pending {
+		if next.IsZero() || p.due.Before(next) {
+			next = p.due
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// syncDue syncs objects with pending syncs that are due at now.
+func (w *Watcher) syncDue(ctx context.Context, now time.Time) {
+	for objPath, p := range w.pending {
+		if p.due.After(now) {
+			continue
+		}
+		delete(w.pending, objPath)
+		obj, err := w.svc.SyncChangedFile(ctx, p.name)
+		switch {
+		case errors.Is(err, access.ErrNotFound):
+			w.logger.Info("object removed from storage root", "storage_path", objPath)
+		case err != nil:
+			w.logger.Error("syncing changed object", "storage_path", objPath, "error", err)
+		case obj != nil:
+			w.logger.Info("object synced after change", "object_id", obj.ID(), "storage_path", objPath)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package fswatch_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/be"
+	"github.com/srerickson/ocfl-go"
+	"github.com/srerickson/ocfl-go/digest"
+	ocflfs "github.com/srerickson/ocfl-go/fs"
+	"github.com/srerickson/ocfl-go/fs/local"
+	"github.com/srerickson/ocfl-services/access"
+	"github.com/srerickson/ocfl-services/internal/fswatch"
+	"github.com/srerickson/ocfl-services/internal/testutil"
+)
+
+const (
+	fixtureObjID   = "ark:123/abc"
+	fixtureObjPath = "reg-extension-dir-root/a47/817/83d/cec/ark%3a123%2fabc"
+	testDelay      = 50 * time.Millisecond
+)
+
+func TestWatcher(t *testing.T) {
+	ctx := t.Context()
+	svc := testService(t)
+	// index the fixture object
+	_, err := svc.SyncObjectPath(ctx, fixtureObjPath)
+	be.NilErr(t, err)
+	be.Equal(t, 1, testutil.NumObjects(t, svc))
+
+	w, err := fswatch.New(svc, testDelay)
+	be.NilErr(t, err)
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() { done <- w.Run(runCtx) }()
+	t.Cleanup(func() {
+		cancel()
+		be.Equal(t, context.Canceled, <-done)
+	})
+
+	t.Run("new object", func(t *testing.T) {
+		obj, err := svc.Root().NewObject(ctx, "new-object")
+		be.NilErr(t, err)
+		stage, err := ocfl.StageBytes(map[string][]byte{"a.txt": []byte("a")}, digest.SHA512)
+		be.NilErr(t, err)
+		_, err = obj.Update(ctx, stage, "new object", ocfl.User{Name: "test"})
+		be.NilErr(t, err)
+		waitFor(t, func() bool { return testutil.NumObjects(t, svc) == 2 })
+	})
+	t.Run("updated object", func(t *testing.T) {
+		obj, err := svc.Root().NewObject(ctx, fixtureObjID)
+		be.NilErr(t, err)
+		stage, err := ocfl.StageBytes(map[string][]byte{"b.txt": []byte("b")}, digest.SHA512)
+		be.NilErr(t, err)
+		_, err = obj.Update(ctx, stage, "update", ocfl.User{Name: "test"})
+		be.NilErr(t, err)
+		waitFor(t, func() bool {
+			obj, err := svc.SyncObject(ctx, fixtureObjID)
+			be.NilErr(t, err)
+			return obj.Head().Num() == 3
+		})
+	})
+	t.Run("removed object", func(t *testing.T) {
+		fsys := svc.Root().FS().(*local.FS)
+		be.NilErr(t, fsys.RemoveAll(ctx, fixtureObjPath))
+		waitFor(t, func() bool { return testutil.NumObjects(t, svc) == 1 })
+	})
+}
+
+func TestNew(t *testing.T) {
+	t.Run("non-local root", func(t *testing.T) {
+		root, err := ocfl.NewRoot(t.Context(), ocflfs.DirFS(filepath.Join("..", "..", "testdata")), "reg-extension-dir-root")
+		be.NilErr(t, err)
+		svc := access.NewService(root, testutil.NewDB(t), "test-root", nil)
+		_, err = fswatch.New(svc, testDelay)
+		be.Nonzero(t, err)
+	})
+}
+
+// waitFor waits up to a few seconds for cond to be true.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the index to be updated")
+		}
+		time.Sleep(testDelay)
+	}
+}
+
+// testService returns a service for a copy of the fixture storage root that
+// hasn't been indexed. Objects are only synced when they change.
+func testService(t *testing.T) *access.Service {
+	t.Helper()
+	return testutil.FixtureService(t, filepath.Join("..", "..", "testdata"), access.WithSyncMode(access.SyncOnEvent))
+}
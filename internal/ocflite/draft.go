@@ -0,0 +1,422 @@
+package ocflite
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"iter"
+	"strings"
+	"time"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// ErrPathConflict is returned when a change to a draft would add a file with
+// the same path as a directory, or vice versa.
+var ErrPathConflict = errors.New("path conflicts with an existing file or directory")
+
+// digest algorithm for drafts of objects that aren't indexed.
+const defaultDraftAlg = "sha512"
+
+// Draft represents a new object version that hasn't been committed.
+type Draft struct {
+	ID              int64     // draft's database ID
+	ObjectID        string    // ID of the object the draft is for
+	Owner           string    // ID of the principal that opened the draft
+	Head            int       // object's head version number when the draft was opened
+	Vpadding        int       // padding for object's version numbering scheme
+	DigestAlgorithm string    // digest algorithm for the draft's files
+	CreatedAt       time.Time // when the draft was opened
+	UpdatedAt       time.Time // when the draft was last changed
+}
+
+// DraftFile represents a file in a draft's state.
+type DraftFile struct {
+	Path     string    // logical path
+	Digest   string    // content digest, using the draft's digest algorithm
+	Size     int64     // size in bytes; only valid if HasSize is true
+	HasSize  bool      // Size is set
+	UploadID string    // ID of the upload with new content; empty if the content is in the object
+	ModVnum  int       // version number in which the file was last modified
+	Modtime  time.Time // when the file was last modified
+}
+
+// CreateDraft adds a draft of the next version of the object, owned by owner,
+// with the state of the object's indexed head version. If the object isn't
+// indexed, the draft is for a new object and its state is empty. If the owner
+// already has a draft for the object, it is returned instead.
+func CreateDraft(conn *sqlite.Conn, root string, objID string, owner string) (*Draft, error) {
+	if err := setRoot(conn, root); err != nil {
+		return nil, err
+	}
+	head, padding, alg := 0, 0, defaultDraftAlg
+	obj, err := GetObjectBrief(conn, root, objID)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		// new object
+	case err != nil:
+		return nil, err
+	default:
+		head, padding, alg = obj.Head, obj.Vpadding, obj.DigestAlgorithm
+	}
+	const qname = `queries/insert_draft.sql`
+	err = sqlitex.ExecuteFS(conn, queries, qname, &sqlitex.ExecOptions{
+		Args: []any{root, objID, owner, head, padding, alg, time.Now().Unix()},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("adding draft: %w", err)
+	}
+	created := conn.Changes() > 0
+	draft, err := getObjectDraft(conn, root, objID, owner)
+	if err != nil {
+		return nil, err
+	}
+	if !created || head < 1 {
+		return draft, nil
+	}
+	for file, err := range ListVersionFiles(conn, root, objID, head, ".") {
+		if err != nil {
+			return nil, fmt.Errorf("copying version state to draft: %w", err)
+		}
+		if file.isDeleted {
+			continue
+		}
+		err := setDraftFile(conn, draft.ID, &DraftFile{
+			Path:    file.Path,
+			Digest:  file.Digest,
+			Size:    file.Size,
+			HasSize: file.HasSize,
+			ModVnum: file.ModVnum,
+			Modtime: file.Modtime,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("copying version state to draft: %w", err)
+		}
+	}
+	return draft, nil
+}
+
+// GetDraft returns the draft with the given ID. It returns ErrNotFound if the
+// draft doesn't exist.
+func GetDraft(conn *sqlite.Conn, root string, id int64) (*Draft, error) {
+	var draft *Draft
+	const qname = `queries/get_draft.sql`
+	err := sqlitex.ExecuteFS(conn, queries, qname, &sqlitex.ExecOptions{
+		Args: []any{root, id},
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			draft = scanDraft(stmt)
+			return nil
+		},
+	})
+	if err == nil && draft == nil {
+		err = fmt.Errorf("draft with root=%q, id=%d: %w", root, id, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return draft, nil
+}
+
+// ListDrafts returns the drafts owned by owner, or all drafts if owner is
+// empty. Drafts are ordered by when they were last changed, most recent
+// first.
+func ListDrafts(conn *sqlite.Conn, root string, owner string) ([]*Draft, error) {
+	var drafts []*Draft
+	const qname = `queries/list_drafts.sql`
+	err := sqlitex.ExecuteFS(conn, queries, qname, &sqlitex.ExecOptions{
+		Args: []any{root, owner},
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			drafts = append(drafts, scanDraft(stmt))
+			return nil
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing drafts: %w", err)
+	}
+	return drafts, nil
+}
+
+// DeleteDraft removes the draft and its files. It returns ErrNotFound if the
+// draft doesn't exist.
+func DeleteDraft(conn *sqlite.Conn, root string, id int64) error {
+	if _, err := GetDraft(conn, root, id); err != nil {
+		return err
+	}
+	const script = `queries/delete_draft.sql`
+	err := sqlitex.ExecuteScriptFS(conn, queries, script, &sqlitex.ExecOptions{
+		Args: []any{root, id},
+	})
+	if err != nil {
+		return fmt.Errorf("deleting draft: %w", err)
+	}
+	return nil
+}
+
+// ListDraftFiles returns the files in the draft's state, ordered by path.
+func ListDraftFiles(conn *sqlite.Conn, root string, id int64) ([]*DraftFile, error) {
+	if _, err := GetDraft(conn, root, id); err != nil {
+		return nil, err
+	}
+	return listDraftFiles(conn, id)
+}
+
+// SetDraftFile adds file to the draft's state, replacing any file with the
+// same path. The file's ModVnum is the draft's version number and its Modtime
+// is the current time.
+func SetDraftFile(conn *sqlite.Conn, root string, id int64, file *DraftFile) error {
+	draft, err := GetDraft(conn, root, id)
+	if err != nil {
+		return err
+	}
+	if !fs.ValidPath(file.Path) || file.Path == "." {
+		return fmt.Errorf("invalid draft file path: %q", file.Path)
+	}
+	files, err := listDraftFiles(conn, id)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if f.Path != file.Path && pathsConflict(f.Path, file.Path) {
+			return fmt.Errorf("adding %q: %w", file.Path, ErrPathConflict)
+		}
+	}
+	now := time.Now()
+	newFile := *file
+	newFile.ModVnum = draft.Head + 1
+	newFile.Modtime = now
+	if err := setDraftFile(conn, id, &newFile); err != nil {
+		return err
+	}
+	return touchDraft(conn, id, now)
+}
+
+// RenameDraftPath moves the file or directory src in the draft's state to
+// dst. It returns ErrNotFound if src doesn't exist and ErrPathConflict if dst
+// exists.
+func RenameDraftPath(conn *sqlite.Conn, root string, id int64, src string, dst string) error {
+	draft, err := GetDraft(conn, root, id)
+	if err != nil {
+		return err
+	}
+	for _, p := range []string{src, dst} {
+		if !fs.ValidPath(p) || p == "." {
+			return fmt.Errorf("invalid draft path: %q", p)
+		}
+	}
+	if src == dst || strings.HasPrefix(dst, src+"/") {
+		return fmt.Errorf("can't move %q to %q: %w", src, dst, ErrPathConflict)
+	}
+	files, err := listDraftFiles(conn, id)
+	if err != nil {
+		return err
+	}
+	var moved, kept []*DraftFile
+	for _, f := range files {
+		if inPath(f.Path, src) {
+			moved = append(moved, f)
+			continue
+		}
+		kept = append(kept, f)
+	}
+	if len(moved) == 0 {
+		return fmt.Errorf("draft path %q: %w", src, ErrNotFound)
+	}
+	now := time.Now()
+	for _, f := range moved {
+		newPath := dst + strings.TrimPrefix(f.Path, src)
+		for _, k := range kept {
+			if pathsConflict(k.Path, newPath) {
+				return fmt.Errorf("moving %q to %q: %w", f.Path, newPath, ErrPathConflict)
+			}
+		}
+		if err := deleteDraftFile(conn, id, f.Path); err != nil {
+			return err
+		}
+		f.Path = newPath
+		f.ModVnum = draft.Head + 1
+		f.Modtime = now
+		if err := setDraftFile(conn, id, f); err != nil {
+			return err
+		}
+	}
+	return touchDraft(conn, id, now)
+}
+
+// RemoveDraftPath removes the file or directory name from the draft's state.
+// It returns ErrNotFound if name doesn't exist.
+func RemoveDraftPath(conn *sqlite.Conn, root string, id int64, name string) error {
+	if _, err := GetDraft(conn, root, id); err != nil {
+		return err
+	}
+	if !fs.ValidPath(name) || name == "." {
+		return fmt.Errorf("invalid draft path: %q", name)
+	}
+	files, err := listDraftFiles(conn, id)
+	if err != nil {
+		return err
+	}
+	var removed bool
+	for _, f := range files {
+		if !inPath(f.Path, name) {
+			continue
+		}
+		if err := deleteDraftFile(conn, id, f.Path); err != nil {
+			return err
+		}
+		removed = true
+	}
+	if !removed {
+		return fmt.Errorf("draft path %q: %w", name, ErrNotFound)
+	}
+	return touchDraft(conn, id, time.Now())
+}
+
+// ReadDraftDir gets entries for a directory in the draft's state.
+func ReadDraftDir(conn *sqlite.Conn, root string, id int64, dir string) ([]*VersionDirEntry, error) {
+	if dir == "" {
+		dir = "."
+	}
+	if !fs.ValidPath(dir) {
+		return nil, fmt.Errorf("invalid draft directory: %q", dir)
+	}
+	files, err := ListDraftFiles(conn, root, id)
+	if err != nil {
+		return nil, err
+	}
+	dirFiles := func(yield func(*VersionFileInfo, error) bool) {
+		for _, f := range files {
+			if dir != "." && !strings.HasPrefix(f.Path, dir+"/") {
+				continue
+			}
+			info := &VersionFileInfo{
+				Path:    f.Path,
+				Digest:  f.Digest,
+				ModVnum: f.ModVnum,
+				Modtime: f.Modtime,
+				Size:    f.Size,
+				HasSize: f.HasSize,
+			}
+			if !yield(info, nil) {
+				return
+			}
+		}
+	}
+	entries, err := dirEntries(dir, iter.Seq2[*VersionFileInfo, error](dirFiles))
+	if err != nil {
+		return nil, fmt.Errorf("reading draft directory %q: %w", dir, err)
+	}
+	if len(entries) < 1 && dir != "." {
+		return nil, fmt.Errorf("with draft directory: id=%d dir=%q: %w", id, dir, ErrNotFound)
+	}
+	return entries, nil
+}
+
+func getObjectDraft(conn *sqlite.Conn, root string, objID string, owner string) (*Draft, error) {
+	var draft *Draft
+	const qname = `queries/get_object_draft.sql`
+	err := sqlitex.ExecuteFS(conn, queries, qname, &sqlitex.ExecOptions{
+		Args: []any{root, objID, owner},
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			draft = scanDraft(stmt)
+			return nil
+		},
+	})
+	if err == nil && draft == nil {
+		err = fmt.Errorf("draft with root=%q, object_id=%q, owner=%q: %w", root, objID, owner, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return draft, nil
+}
+
+func scanDraft(stmt *sqlite.Stmt) *Draft {
+	return &Draft{
+		ID:              stmt.GetInt64("id"),
+		ObjectID:        stmt.GetText("object_id"),
+		Owner:           stmt.GetText("owner"),
+		Head:            int(stmt.GetInt64("head")),
+		Vpadding:        int(stmt.GetInt64("padding")),
+		DigestAlgorithm: stmt.GetText("alg"),
+		CreatedAt:       time.Unix(stmt.GetInt64("created_at"), 0),
+		UpdatedAt:       time.Unix(stmt.GetInt64("updated_at"), 0),
+	}
+}
+
+func listDraftFiles(conn *sqlite.Conn, id int64) ([]*DraftFile, error) {
+	var files []*DraftFile
+	const qname = `queries/list_draft_files.sql`
+	err := sqlitex.ExecuteFS(conn, queries, qname, &sqlitex.ExecOptions{
+		Args: []any{id},
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			f := &DraftFile{
+				Path:     stmt.GetText("path"),
+				Digest:   stmt.GetText("digest"),
+				Size:     stmt.GetInt64("size"),
+				HasSize:  true,
+				UploadID: stmt.GetText("upload_id"),
+				ModVnum:  int(stmt.GetInt64("mod_vnum")),
+				Modtime:  time.Unix(stmt.GetInt64("mod_time"), 0),
+			}
+			if f.Size < 0 {
+				f.Size = 0
+				f.HasSize = false
+			}
+			files = append(files, f)
+			return nil
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing draft files: %w", err)
+	}
+	return files, nil
+}
+
+func setDraftFile(conn *sqlite.Conn, id int64, file *DraftFile) error {
+	size := file.Size
+	if !file.HasSize {
+		size = -1
+	}
+	const qname = `queries/upsert_draft_file.sql`
+	err := sqlitex.ExecuteFS(conn, queries, qname, &sqlitex.ExecOptions{
+		Args: []any{id, file.Path, file.Digest, size, file.UploadID, file.ModVnum, file.Modtime.Unix()},
+	})
+	if err != nil {
+		return fmt.Errorf("setting draft file: %w", err)
+	}
+	return nil
+}
+
+func deleteDraftFile(conn *sqlite.Conn, id int64, name string) error {
+	const qname = `queries/delete_draft_file.sql`
+	err := sqlitex.ExecuteFS(conn, queries, qname, &sqlitex.ExecOptions{
+		Args: []any{id, name},
+	})
+	if err != nil {
+		return fmt.Errorf("deleting draft file: %w", err)
+	}
+	return nil
+}
+
+func touchDraft(conn *sqlite.Conn, id int64, t time.Time) error {
+	const qname = `queries/set_draft_updated_at.sql`
+	err := sqlitex.ExecuteFS(conn, queries, qname, &sqlitex.ExecOptions{
+		Args: []any{id, t.Unix()},
+	})
+	if err != nil {
+		return fmt.Errorf("updating draft: %w", err)
+	}
+	return nil
+}
+
+// inPath reports whether name is the file or directory p or is in it.
+func inPath(name string, p string) bool {
+	return name == p || strings.HasPrefix(name, p+"/")
+}
+
+// pathsConflict reports whether files with paths a and b can't both exist,
+// because they are the same or one is a directory in the other.
+func pathsConflict(a, b string) bool {
+	return inPath(a, b) || inPath(b, a)
+}
@@ -0,0 +1,167 @@
+package ocflite_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/srerickson/ocfl-services/internal/ocflite"
+)
+
+func TestDraft(t *testing.T) {
+	conn := testConn(t)
+	root := "test-root"
+	createTestObjectWithContent(t, conn, root, "object-1",
+		map[string]string{"a.txt": "a", "dir/b.txt": "b"},
+		map[string]string{"a.txt": "a", "dir/b.txt": "b2", "dir/c.txt": "c"},
+	)
+
+	draft, err := ocflite.CreateDraft(conn, root, "object-1", "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if draft.Head != 2 || draft.Vpadding != 3 || draft.DigestAlgorithm != "sha256" {
+		t.Errorf("unexpected draft values: %+v", draft)
+	}
+	files, err := ocflite.ListDraftFiles(conn, root, draft.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := draftPaths(files); !slices.Equal(got, []string{"a.txt", "dir/b.txt", "dir/c.txt"}) {
+		t.Fatalf("draft files = %v", got)
+	}
+	if files[0].ModVnum != 1 || !files[0].HasSize || files[0].Size != 1 {
+		t.Errorf("unexpected copied file values: %+v", files[0])
+	}
+
+	t.Run("create returns existing draft", func(t *testing.T) {
+		again, err := ocflite.CreateDraft(conn, root, "object-1", "user-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if again.ID != draft.ID {
+			t.Errorf("got draft %d, not %d", again.ID, draft.ID)
+		}
+		other, err := ocflite.CreateDraft(conn, root, "object-1", "user-2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if other.ID == draft.ID {
+			t.Error("drafts for different owners have the same ID")
+		}
+		drafts, err := ocflite.ListDrafts(conn, root, "user-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(drafts) != 1 || drafts[0].ID != draft.ID {
+			t.Errorf("ListDrafts() = %v", drafts)
+		}
+		if err := ocflite.DeleteDraft(conn, root, other.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ocflite.GetDraft(conn, root, other.ID); !errors.Is(err, ocflite.ErrNotFound) {
+			t.Errorf("GetDraft() after delete: %v", err)
+		}
+	})
+
+	t.Run("changes", func(t *testing.T) {
+		err := ocflite.SetDraftFile(conn, root, draft.ID, &ocflite.DraftFile{
+			Path:     "new/d.txt",
+			Digest:   "digest-d",
+			Size:     4,
+			HasSize:  true,
+			UploadID: "upload-1",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ocflite.RenameDraftPath(conn, root, draft.ID, "dir", "docs/dir"); err != nil {
+			t.Fatal(err)
+		}
+		if err := ocflite.RemoveDraftPath(conn, root, draft.ID, "a.txt"); err != nil {
+			t.Fatal(err)
+		}
+		files, err := ocflite.ListDraftFiles(conn, root, draft.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := draftPaths(files); !slices.Equal(got, []string{"docs/dir/b.txt", "docs/dir/c.txt", "new/d.txt"}) {
+			t.Fatalf("draft files = %v", got)
+		}
+		for _, f := range files {
+			if f.ModVnum != 3 {
+				t.Errorf("changed file %q has mod_vnum %d", f.Path, f.ModVnum)
+			}
+		}
+		if files[2].UploadID != "upload-1" {
+			t.Errorf("upload ID = %q", files[2].UploadID)
+		}
+		entries, err := ocflite.ReadDraftDir(conn, root, draft.ID, ".")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 2 || entries[0].Name != "docs" || !entries[0].IsDir || entries[1].Name != "new" {
+			t.Errorf("ReadDraftDir() = %+v", entries)
+		}
+		if _, err := ocflite.ReadDraftDir(conn, root, draft.ID, "dir"); !errors.Is(err, ocflite.ErrNotFound) {
+			t.Errorf("ReadDraftDir() for missing directory: %v", err)
+		}
+	})
+
+	t.Run("invalid changes", func(t *testing.T) {
+		err := ocflite.SetDraftFile(conn, root, draft.ID, &ocflite.DraftFile{Path: "docs", Digest: "digest-e"})
+		if !errors.Is(err, ocflite.ErrPathConflict) {
+			t.Errorf("adding file with directory's path: %v", err)
+		}
+		err = ocflite.RenameDraftPath(conn, root, draft.ID, "new/d.txt", "docs/dir/b.txt")
+		if !errors.Is(err, ocflite.ErrPathConflict) {
+			t.Errorf("renaming to existing file: %v", err)
+		}
+		err = ocflite.RenameDraftPath(conn, root, draft.ID, "docs", "docs/sub")
+		if !errors.Is(err, ocflite.ErrPathConflict) {
+			t.Errorf("renaming into itself: %v", err)
+		}
+		err = ocflite.RenameDraftPath(conn, root, draft.ID, "missing", "other")
+		if !errors.Is(err, ocflite.ErrNotFound) {
+			t.Errorf("renaming missing path: %v", err)
+		}
+		err = ocflite.RemoveDraftPath(conn, root, draft.ID, "missing")
+		if !errors.Is(err, ocflite.ErrNotFound) {
+			t.Errorf("removing missing path: %v", err)
+		}
+		if err := ocflite.SetDraftFile(conn, root, draft.ID, &ocflite.DraftFile{Path: "../x", Digest: "digest-e"}); err == nil {
+			t.Error("adding file with invalid path: no error")
+		}
+	})
+
+	t.Run("new object", func(t *testing.T) {
+		draft, err := ocflite.CreateDraft(conn, root, "new-object", "user-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if draft.Head != 0 || draft.DigestAlgorithm != "sha512" {
+			t.Errorf("unexpected draft values: %+v", draft)
+		}
+		entries, err := ocflite.ReadDraftDir(conn, root, draft.ID, ".")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 0 {
+			t.Errorf("new object draft has entries: %v", entries)
+		}
+	})
+
+	t.Run("other roots", func(t *testing.T) {
+		if _, err := ocflite.GetDraft(conn, "other-root", draft.ID); !errors.Is(err, ocflite.ErrNotFound) {
+			t.Errorf("GetDraft() with other root: %v", err)
+		}
+	})
+}
+
+func draftPaths(files []*ocflite.DraftFile) []string {
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.Path
+	}
+	return paths
+}
@@ -0,0 +1,132 @@
+package ocflite
+
+import (
+	"fmt"
+	"time"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// values for FixityFile.Status
+const (
+	FixityPass    = "pass"
+	FixityFail    = "fail"
+	FixityErrored = "error"
+)
+
+// FixityFile is a content file with the result of its most recent fixity
+// check.
+type FixityFile struct {
+	ObjectID        string    // ID of the object the file belongs to
+	StoragePath     string    // object's storage path
+	DigestAlgorithm string    // object's digest algorithm
+	ContentPath     string    // content path relative to object root
+	Digest          string    // expected content digest
+	Size            int64     // content size in bytes
+	HasSize         bool      // if false, Size isn't set.
+	Status          string    // FixityPass, FixityFail, FixityErrored, or "" if not checked
+	Error           string    // reason for a failed check or error
+	CheckedAt       time.Time // zero if not checked
+}
+
+// FixitySummary has counts of content files by fixity status.
+type FixitySummary struct {
+	NumFiles    int       // all content files
+	NumChecked  int       // files that have been checked
+	NumFailed   int       // files that failed their most recent check
+	OldestCheck time.Time // time of the least recent check; zero if any file hasn't been checked
+}
+
+// ListFixityDue returns up to limit content files in the root that haven't
+// been checked since before, least recently checked first. Files that haven't
+// been checked are returned first.
+func ListFixityDue(conn *sqlite.Conn, root string, before time.Time, limit int) ([]*FixityFile, error) {
+	return listFixity(conn, `queries/list_fixity_due.sql`, root, before.Unix(), limit)
+}
+
+// ListObjectFixity returns the object's content files, ordered by content
+// path. If status isn't empty, only files with the status are returned.
+func ListObjectFixity(conn *sqlite.Conn, root string, objID string, status string) ([]*FixityFile, error) {
+	return listFixity(conn, `queries/list_object_fixity.sql`, root, objID, status)
+}
+
+// ListFixityFailures returns up to limit content files in the root that failed
+// their most recent fixity check, most recently checked first.
+func ListFixityFailures(conn *sqlite.Conn, root string, limit int) ([]*FixityFile, error) {
+	return listFixity(conn, `queries/list_fixity_failures.sql`, root, limit)
+}
+
+// SetFixity records the result of a fixity check of the object's content
+// file. Status should be FixityPass, FixityFail, or
+// FixityErrored. If the file isn't indexed,
+// ErrNotFound is returned.
+func SetFixity(conn *sqlite.Conn, root string, objID string, contentPath string, status string, errMsg string, checkedAt time.Time) error {
+	const qname = `queries/set_object_file_fixity.sql`
+	err := sqlitex.ExecuteFS(conn, queries, qname, &sqlitex.ExecOptions{
+		Args: []any{root, objID, contentPath, status, errMsg, checkedAt.Unix()},
+	})
+	if err != nil {
+		return fmt.Errorf("setting object file fixity: %w", err)
+	}
+	if conn.Changes() == 0 {
+		return fmt.Errorf("with root=%q, object_id=%q, path=%q: %w", root, objID, contentPath, ErrNotFound)
+	}
+	return nil
+}
+
+// GetFixitySummary returns counts of content files in the root by fixity
+// status.
+func GetFixitySummary(conn *sqlite.Conn, root string) (*FixitySummary, error) {
+	summary := &FixitySummary{}
+	const qname = `queries/get_fixity_summary.sql`
+	err := sqlitex.ExecuteFS(conn, queries, qname, &sqlitex.ExecOptions{
+		Args: []any{root},
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			summary.NumFiles = int(stmt.GetInt64("num_files"))
+			summary.NumChecked = int(stmt.GetInt64("num_checked"))
+			summary.NumFailed = int(stmt.GetInt64("num_failed"))
+			if oldest := stmt.GetInt64("oldest_check"); oldest > 0 {
+				summary.OldestCheck = time.Unix(oldest, 0)
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting fixity summary: %w", err)
+	}
+	return summary, nil
+}
+
+func listFixity(conn *sqlite.Conn, qname string, args ...any) ([]*FixityFile, error) {
+	var files []*FixityFile
+	err := sqlitex.ExecuteFS(conn, queries, qname, &sqlitex.ExecOptions{
+		Args: args,
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			f := &FixityFile{
+				ObjectID:        stmt.GetText("object_id"),
+				StoragePath:     stmt.GetText("storage_path"),
+				DigestAlgorithm: stmt.GetText("alg"),
+				ContentPath:     stmt.GetText("path"),
+				Digest:          stmt.GetText("digest"),
+				Size:            stmt.GetInt64("size"),
+				Status:          stmt.GetText("fixity_status"),
+				Error:           stmt.GetText("fixity_error"),
+			}
+			if f.Size > -1 {
+				f.HasSize = true
+			} else {
+				f.Size = 0
+			}
+			if checked := stmt.GetInt64("fixity_checked_at"); checked > 0 {
+				f.CheckedAt = time.Unix(checked, 0)
+			}
+			files = append(files, f)
+			return nil
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing content file fixity: %w", err)
+	}
+	return files, nil
+}
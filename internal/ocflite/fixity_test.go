@@ -0,0 +1,116 @@
+package ocflite_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/srerickson/ocfl-services/internal/ocflite"
+)
+
+func TestFixity(t *testing.T) {
+	conn := testConn(t)
+	root := "test-root"
+	obj := createTestObjectWithContent(t, conn, root, "object-1",
+		map[string]string{"a.txt": "a", "b.txt": "b"},
+		map[string]string{"a.txt": "a", "b.txt": "b2", "c.txt": "c"},
+	)
+	numFiles := 0
+	for _, paths := range obj.Manifest {
+		numFiles += len(paths)
+	}
+	now := time.Now()
+	due, err := ocflite.ListFixityDue(conn, root, now, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != numFiles {
+		t.Fatalf("ListFixityDue() returned %d files, not %d", len(due), numFiles)
+	}
+	for _, f := range due {
+		if f.ObjectID != "object-1" || f.DigestAlgorithm != "sha256" || f.Status != "" || !f.CheckedAt.IsZero() {
+			t.Errorf("unexpected unchecked file: %+v", f)
+		}
+	}
+	summary, err := ocflite.GetFixitySummary(conn, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.NumFiles != numFiles || summary.NumChecked != 0 || summary.NumFailed != 0 || !summary.OldestCheck.IsZero() {
+		t.Errorf("unexpected summary before checks: %+v", summary)
+	}
+
+	// record results for all files, with one failure
+	checked := now.Add(-time.Hour)
+	failed := due[0]
+	for _, f := range due {
+		status, msg := ocflite.FixityPass, ""
+		if f == failed {
+			status, msg = ocflite.FixityFail, "digest mismatch"
+		}
+		if err := ocflite.SetFixity(conn, root, f.ObjectID, f.ContentPath, status, msg, checked); err != nil {
+			t.Fatal(err)
+		}
+	}
+	summary, err = ocflite.GetFixitySummary(conn, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.NumChecked != numFiles || summary.NumFailed != 1 || summary.OldestCheck.Unix() != checked.Unix() {
+		t.Errorf("unexpected summary after checks: %+v", summary)
+	}
+	failures, err := ocflite.ListFixityFailures(conn, root, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failures) != 1 || failures[0].ContentPath != failed.ContentPath || failures[0].Error != "digest mismatch" {
+		t.Errorf("ListFixityFailures() = %+v", failures)
+	}
+	files, err := ocflite.ListObjectFixity(conn, root, "object-1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != numFiles {
+		t.Errorf("ListObjectFixity() returned %d files, not %d", len(files), numFiles)
+	}
+	files, err = ocflite.ListObjectFixity(conn, root, "object-1", ocflite.FixityFail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].ContentPath != failed.ContentPath {
+		t.Errorf("ListObjectFixity() with fail status = %+v", files)
+	}
+
+	// files are due again after they were checked
+	due, err = ocflite.ListFixityDue(conn, root, checked, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 0 {
+		t.Errorf("ListFixityDue() returned %d files checked since the cutoff", len(due))
+	}
+	due, err = ocflite.ListFixityDue(conn, root, now, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 2 {
+		t.Errorf("ListFixityDue() with limit 2 returned %d files", len(due))
+	}
+
+	// re-indexing the object keeps the results
+	if err := ocflite.SetObject(conn, root, obj); err != nil {
+		t.Fatal(err)
+	}
+	failures, err = ocflite.ListFixityFailures(conn, root, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failures) != 1 {
+		t.Errorf("re-indexing changed fixity failures: %+v", failures)
+	}
+
+	err = ocflite.SetFixity(conn, root, "object-1", "missing", ocflite.FixityPass, "", now)
+	if !errors.Is(err, ocflite.ErrNotFound) {
+		t.Errorf("SetFixity() for missing file: %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+-- Index for case-insensitive object ID prefix searches (LIKE 'prefix%')
+CREATE INDEX IF NOT EXISTS idx_object_id_nocase
+    ON ocfl_objects (root_id, object_id COLLATE NOCASE);
+
+-- Trigram index of object IDs for substring searches. The table's content is
+-- the ocfl_objects table; it is kept in sync with triggers.
+CREATE VIRTUAL TABLE IF NOT EXISTS ocfl_object_ids_fts USING fts5(
+    object_id,
+    content='ocfl_objects',
+    content_rowid='id',
+    tokenize='trigram'
+);
+
+CREATE TRIGGER IF NOT EXISTS ocfl_object_ids_fts_insert AFTER INSERT ON ocfl_objects
+BEGIN
+    INSERT INTO ocfl_object_ids_fts (rowid, object_id) VALUES (new.id, new.object_id);
+END;
+
+CREATE TRIGGER IF NOT EXISTS ocfl_object_ids_fts_delete AFTER DELETE ON ocfl_objects
+BEGIN
+    INSERT INTO ocfl_object_ids_fts (ocfl_object_ids_fts, rowid, object_id)
+        VALUES ('delete', old.id, old.object_id);
+END;
+
+CREATE TRIGGER IF NOT EXISTS ocfl_object_ids_fts_update AFTER UPDATE OF object_id ON ocfl_objects
+BEGIN
+    INSERT INTO ocfl_object_ids_fts (ocfl_object_ids_fts, rowid, object_id)
+        VALUES ('delete', old.id, old.object_id);
+    INSERT INTO ocfl_object_ids_fts (rowid, object_id) VALUES (new.id, new.object_id);
+END;
+
+-- index objects added before this migration
+INSERT INTO ocfl_object_ids_fts (ocfl_object_ids_fts) VALUES ('rebuild');
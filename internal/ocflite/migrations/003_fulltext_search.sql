@@ -0,0 +1,27 @@
+-- Full-text index of version messages and user names. Row IDs correspond to
+-- ids in ocfl_object_versions. The index is updated by SetObject and
+-- UnsetObject.
+CREATE VIRTUAL TABLE IF NOT EXISTS ocfl_versions_fts USING fts5(
+    message,
+    user_name,
+    content='',
+    contentless_delete=1,
+    tokenize='unicode61 remove_diacritics 2'
+);
+
+-- Trigram index of logical paths added or modified in each version. Row IDs
+-- correspond to ids in ocfl_object_version_files. The index is updated by
+-- SetObject and UnsetObject.
+CREATE VIRTUAL TABLE IF NOT EXISTS ocfl_version_files_fts USING fts5(
+    path,
+    content='',
+    contentless_delete=1,
+    tokenize='trigram'
+);
+
+-- index versions and files added before this migration
+INSERT INTO ocfl_versions_fts (rowid, message, user_name)
+    SELECT id, message, user_name FROM ocfl_object_versions;
+
+INSERT INTO ocfl_version_files_fts (rowid, path)
+    SELECT id, path FROM ocfl_object_version_files WHERE NOT is_deleted;
@@ -0,0 +1,3 @@
+-- media type of content files, detected from the content path's extension and
+-- the file's contents. The empty string means the type hasn't been detected.
+ALTER TABLE ocfl_object_files ADD COLUMN media_type TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,30 @@
+-- Drafts are object versions that are prepared over several requests before
+-- they are committed. A draft's state is copied from the object's head version
+-- when the draft is opened. Each principal has at most one draft per object.
+CREATE TABLE IF NOT EXISTS ocfl_drafts (
+    id INTEGER PRIMARY KEY, -- internal database ID, used as the draft ID
+    root_id INTEGER NOT NULL REFERENCES ocfl_roots(id),
+    object_id TEXT NOT NULL, -- ocfl object id; the object may not exist yet
+    owner TEXT NOT NULL, -- ID of the principal that opened the draft
+    head INTEGER NOT NULL, -- object's head version number when the draft was opened (0 for new objects)
+    padding INTEGER NOT NULL DEFAULT 0, -- version number padding
+    alg TEXT NOT NULL, -- digest algorithm for the draft's files
+    created_at INTEGER NOT NULL,
+    updated_at INTEGER NOT NULL,
+    UNIQUE(root_id, object_id, owner)
+);
+
+CREATE INDEX IF NOT EXISTS idx_drafts_owner ON ocfl_drafts (root_id, owner);
+
+-- Files in a draft's state.
+CREATE TABLE IF NOT EXISTS ocfl_draft_files (
+    id INTEGER PRIMARY KEY,
+    draft_id INTEGER NOT NULL REFERENCES ocfl_drafts(id),
+    path TEXT NOT NULL, -- logical path
+    digest TEXT NOT NULL, -- content digest
+    size INTEGER NOT NULL DEFAULT -1, -- size in bytes, -1 if not known
+    upload_id TEXT NOT NULL DEFAULT '', -- upload with new content; empty if the content is in the object
+    mod_vnum INTEGER NOT NULL, -- version number in which the file was last modified
+    mod_time INTEGER NOT NULL, -- when the file was last modified
+    UNIQUE(draft_id, path)
+);
@@ -0,0 +1,12 @@
+-- results of fixity checks of content files. fixity_checked_at is the unix
+-- time of the most recent check, or 0 if the file hasn't been checked.
+-- fixity_status is 'pass', 'fail', or '' if the file hasn't been checked.
+ALTER TABLE ocfl_object_files ADD COLUMN fixity_status TEXT NOT NULL DEFAULT '';
+ALTER TABLE ocfl_object_files ADD COLUMN fixity_error TEXT NOT NULL DEFAULT '';
+ALTER TABLE ocfl_object_files ADD COLUMN fixity_checked_at INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_object_files_fixity_checked_at
+    ON ocfl_object_files (fixity_checked_at, id);
+
+CREATE INDEX IF NOT EXISTS idx_object_files_fixity_status
+    ON ocfl_object_files (fixity_status);
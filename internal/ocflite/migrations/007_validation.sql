@@ -0,0 +1,27 @@
+-- Reports from full OCFL validation of objects. Each object has at most one
+-- report, which is replaced when the object is validated again. Reports are
+-- kept separately from ocfl_objects so that objects that can't be indexed can
+-- still have reports.
+CREATE TABLE IF NOT EXISTS ocfl_validations (
+    id INTEGER PRIMARY KEY, -- internal database ID
+    root_id INTEGER NOT NULL REFERENCES ocfl_roots(id),
+    object_id TEXT NOT NULL, -- ocfl object id
+    storage_path TEXT NOT NULL, -- object's storage path when it was validated
+    valid BOOLEAN NOT NULL, -- true if validation found no errors
+    validated_at INTEGER NOT NULL,
+    UNIQUE(root_id, object_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_validations_valid ON ocfl_validations (root_id, valid);
+
+-- Errors and warnings from a validation, in the order they were reported.
+CREATE TABLE IF NOT EXISTS ocfl_validation_messages (
+    id INTEGER PRIMARY KEY,
+    validation_id INTEGER NOT NULL REFERENCES ocfl_validations(id),
+    level TEXT NOT NULL, -- 'error' or 'warning'
+    code TEXT NOT NULL, -- OCFL validation code (E001, W004, ...); empty if not known
+    message TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_validation_messages_validation_id
+    ON ocfl_validation_messages (validation_id);
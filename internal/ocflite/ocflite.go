@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io/fs"
 	"iter"
+	"maps"
 	"slices"
 	"strings"
 	"time"
@@ -23,10 +24,32 @@ const (
 	FileAdded ModType = iota
 	FileModified
 	FileDeleted
+	FileRenamed // file's content was moved from another path
+	FileCopied  // file's content was copied from another path
 )
 
-//go:embed migrate.sql
-var migrateSQL string
+// ObjectSort is a field used to order objects returned by ListObjects.
+type ObjectSort uint8
+
+const (
+	SortByID          ObjectSort = iota // sort by object ID
+	SortByHead                          // sort by head version number
+	SortByCreated                       // sort by first version's timestamp
+	SortByUpdated                       // sort by most recent version's timestamp
+	SortByStoragePath                   // sort by object storage path
+)
+
+// order by expressions for list_objects.sql
+var objectSortColumns = map[ObjectSort]string{
+	SortByID:          "o.object_id",
+	SortByHead:        "head",
+	SortByCreated:     "created_at",
+	SortByUpdated:     "updated_at",
+	SortByStoragePath: "o.storage_path",
+}
+
+//go:embed migrations/*.sql
+var migrations embed.FS
 
 //go:embed queries/*.sql
 var queries embed.FS
@@ -144,6 +167,10 @@ type VersionFileInfo struct {
 	// HasSize is true of the Size is known.
 	HasSize bool
 
+	// MediaType is the file's detected media type. It is an empty string if
+	// the type hasn't been detected.
+	MediaType string
+
 	isDeleted bool
 }
 
@@ -152,22 +179,42 @@ type FileChange struct {
 	// Path is the logical path in the version state
 	Path string
 
-	// ModType is the type of change (added/modified/deleted)
+	// ModType is the type of change (added/modified/deleted/renamed/copied)
 	ModType ModType
+
+	// SourcePath is the logical path in the from version state that a renamed
+	// or copied file's content came from. It is empty for other changes.
+	SourcePath string
 }
 
 // Migrate creates tables in a sqlite database used by the package
 func Migrate(conn *sqlite.Conn) error {
-	if err := sqlitex.ExecuteScript(conn, migrateSQL, nil); err != nil {
-		return fmt.Errorf("initializing ocfl database tables: %w", err)
+	for i, script := range Migrations() {
+		if err := sqlitex.ExecuteScript(conn, script, nil); err != nil {
+			return fmt.Errorf("initializing ocfl database tables (migration %d): %w", i+1, err)
+		}
 	}
 	return nil
 }
 
-// MigrateSQL returns the sql query string for creating tables used by the
-// package
-func MigrateSQL() string {
-	return migrateSQL
+// Migrations returns the sql scripts for creating and updating tables used by
+// the package. Scripts should be run in order; new scripts are only ever
+// appended to the list.
+func Migrations() []string {
+	entries, err := fs.ReadDir(migrations, "migrations")
+	if err != nil {
+		panic(fmt.Errorf("reading embedded migrations: %w", err))
+	}
+	// entries are sorted by filename
+	scripts := make([]string, len(entries))
+	for i, entry := range entries {
+		script, err := fs.ReadFile(migrations, "migrations/"+entry.Name())
+		if err != nil {
+			panic(fmt.Errorf("reading embedded migrations: %w", err))
+		}
+		scripts[i] = string(script)
+	}
+	return scripts
 }
 
 // GetRoots returns a slice of all the root names in the database. Roots are
@@ -193,6 +240,10 @@ func SetObject(conn *sqlite.Conn, root string, obj *Object) error {
 	if err := setRoot(conn, root); err != nil {
 		return nil
 	}
+	// existing full-text entries are replaced after the object is updated
+	if err := unsetObjectSearchText(conn, root, obj.ID); err != nil {
+		return fmt.Errorf("removing object from search index: %w", err)
+	}
 	err := sqlitex.ExecuteFS(conn, queries, qname, &sqlitex.ExecOptions{
 		Args: []any{
 			root,
@@ -216,12 +267,18 @@ func SetObject(conn *sqlite.Conn, root string, obj *Object) error {
 	if err != nil {
 		return fmt.Errorf("setting object versions in database: %w", err)
 	}
+	if err := setObjectSearchText(conn, root, obj.ID); err != nil {
+		return fmt.Errorf("adding object to search index: %w", err)
+	}
 	return nil
 }
 
 // UnsetObject removes the object with the given ID from the index. It returns
 // no error if the object doesn't exist to begin with.
 func UnsetObject(conn *sqlite.Conn, root string, objID string) error {
+	if err := unsetObjectSearchText(conn, root, objID); err != nil {
+		return fmt.Errorf("removing object from search index: %w", err)
+	}
 	script := `queries/delete_object.sql`
 	err := sqlitex.ExecuteScriptFS(conn, queries, script, &sqlitex.ExecOptions{
 		Args: []any{root, objID},
@@ -232,6 +289,33 @@ func UnsetObject(conn *sqlite.Conn, root string, objID string) error {
 	return nil
 }
 
+// PruneObjects removes objects that were last indexed at or before indexedBy
+// and for which keep returns false. keep is called with the storage path of
+// each object indexed at or before indexedBy. It returns the IDs of the
+// removed objects. It should be called in a transaction.
+func PruneObjects(conn *sqlite.Conn, root string, indexedBy time.Time, keep func(storagePath string) bool) ([]string, error) {
+	var removeIDs []string
+	const qname = `queries/list_object_paths.sql`
+	err := sqlitex.ExecuteFS(conn, queries, qname, &sqlitex.ExecOptions{
+		Args: []any{root, indexedBy.Unix()},
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			if !keep(stmt.GetText("storage_path")) {
+				removeIDs = append(removeIDs, stmt.GetText("object_id"))
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing object storage paths: %w", err)
+	}
+	for _, id := range removeIDs {
+		if err := UnsetObject(conn, root, id); err != nil {
+			return nil, err
+		}
+	}
+	return removeIDs, nil
+}
+
 // TouchObject updates an indexed object's indexed at timestamp to the current
 // time and returns the updated ObjectBrief.
 func TouchObject(conn *sqlite.Conn, root string, objID string) (*ObjectBrief, error) {
@@ -312,11 +396,29 @@ func GetObjectBriefByPath(conn *sqlite.Conn, root string, storagePath string) (*
 }
 
 // ListObjects returns a paginated list of objects with up the pageSize entries,
-// starting from a given offset.
-func ListObjects(conn *sqlite.Conn, root string, pageSize int, offset int) ([]*ObjectBrief, error) {
+// starting from a given offset. Objects are ordered by the sort field (in
+// descending order if desc is true) and then by object ID.
+func ListObjects(conn *sqlite.Conn, root string, pageSize int, offset int, sort ObjectSort, desc bool) ([]*ObjectBrief, error) {
 	const qname = `queries/list_objects.sql`
+	col, ok := objectSortColumns[sort]
+	if !ok {
+		return nil, fmt.Errorf("invalid object sort value: %d", sort)
+	}
+	dir := " ASC"
+	if desc {
+		dir = " DESC"
+	}
+	baseQuery, err := fs.ReadFile(queries, qname)
+	if err != nil {
+		return nil, err
+	}
+	query := string(baseQuery) + "\nORDER BY " + col + dir
+	if sort != SortByID {
+		query += ", o.object_id" + dir
+	}
+	query += "\nLIMIT ?2 OFFSET ?3"
 	var objects []*ObjectBrief
-	err := sqlitex.ExecuteFS(conn, queries, qname, &sqlitex.ExecOptions{
+	err = sqlitex.Execute(conn, query, &sqlitex.ExecOptions{
 		Args: []any{root, pageSize, offset},
 		ResultFunc: func(stmt *sqlite.Stmt) error {
 			obj := &ObjectBrief{
@@ -337,7 +439,6 @@ func ListObjects(conn *sqlite.Conn, root string, pageSize int, offset int) ([]*O
 	if err != nil {
 		return nil, err
 	}
-	// Check if there are more results by trying to fetch one more item
 	return objects, nil
 }
 
@@ -419,6 +520,19 @@ func SetObjectFileSize(conn *sqlite.Conn, root, objID string, digest string, siz
 	return nil
 }
 
+// SetObjectFileMediaType sets the media type for all content files in the
+// object with the given digest.
+func SetObjectFileMediaType(conn *sqlite.Conn, root, objID string, digest string, mediaType string) error {
+	const qname = `queries/set_object_file_media_type.sql`
+	err := sqlitex.ExecuteFS(conn, queries, qname, &sqlitex.ExecOptions{
+		Args: []any{root, objID, digest, mediaType},
+	})
+	if err != nil {
+		return fmt.Errorf("setting object file media type: %w", err)
+	}
+	return nil
+}
+
 func GetVersion(conn *sqlite.Conn, root, objID string, vn int) (*VersionBrief, error) {
 	var v *VersionBrief
 	qname := `queries/get_object_version.sql`
@@ -489,8 +603,11 @@ func GetVersionState(conn *sqlite.Conn, root string, objID string, vn int) (Dige
 }
 
 // GetVersionChanges compares two versions and returns the changes between them.
-// The changes include files that were added, modified, or deleted when moving
-// from fromVN to toVN. If fromVN is 0, all files in toVN are considered added.
+// The changes include files that were added, modified, deleted, renamed, or
+// copied when moving from fromVN to toVN. A file is renamed if its path is new
+// and its digest was at a deleted path; it is copied if its path is new and its
+// digest was at a path that wasn't deleted. Renamed files replace the deletion
+// of their source path. If fromVN is 0, all files in toVN are considered added.
 // If fromVN or toVN are invalid, an error is returned. If fromVN == toVN, an
 // empty slice is returned with no error.
 func GetVersionChanges(conn *sqlite.Conn, root string, objID string, fromVN int, toVN int) ([]*FileChange, error) {
@@ -517,17 +634,18 @@ func GetVersionChanges(conn *sqlite.Conn, root string, objID string, fromVN int,
 	}
 
 	// Get version states
-	var fromPaths PathMap
+	var fromState DigestMap
 	if fromVN == 0 {
-		// fromVN == 0 represents "no version" - use empty PathMap
-		fromPaths = make(PathMap)
+		// fromVN == 0 represents "no version" - use empty state
+		fromState = make(DigestMap)
 	} else {
-		fromState, err := GetVersionState(conn, root, objID, fromVN)
+		var err error
+		fromState, err = GetVersionState(conn, root, objID, fromVN)
 		if err != nil {
 			return nil, fmt.Errorf("getting from state: %w", err)
 		}
-		fromPaths = fromState.PathMap()
 	}
+	fromPaths := fromState.PathMap()
 
 	toState, err := GetVersionState(conn, root, objID, toVN)
 	if err != nil {
@@ -538,29 +656,59 @@ func GetVersionChanges(conn *sqlite.Conn, root string, objID string, fromVN int,
 	// Compare and categorize changes
 	var changes []*FileChange
 
-	// Find added and modified files
-	for path, toDigest := range toPaths {
-		fromDigest, existed := fromPaths[path]
+	// Find deleted files, grouped by digest so they can be matched with added
+	// files that have the same content.
+	deleted := map[string][]string{}
+	for path, digest := range fromPaths {
+		if _, exists := toPaths[path]; !exists {
+			deleted[digest] = append(deleted[digest], path)
+		}
+	}
+	for _, paths := range deleted {
+		slices.Sort(paths)
+	}
 
-		if !existed {
-			// File was added
+	// Find added, renamed, copied, and modified files. Added paths are visited
+	// in order so that renames are matched consistently.
+	for _, path := range slices.Sorted(maps.Keys(toPaths)) {
+		toDigest := toPaths[path]
+		fromDigest, existed := fromPaths[path]
+		switch {
+		case existed && fromDigest != toDigest:
+			// File was modified
 			changes = append(changes, &FileChange{
 				Path:    path,
-				ModType: FileAdded,
+				ModType: FileModified,
 			})
-		} else if fromDigest != toDigest {
-			// File was modified
+		case existed:
+			// File is unchanged
+		case len(deleted[toDigest]) > 0:
+			// File was renamed: its source path is no longer deleted
+			changes = append(changes, &FileChange{
+				Path:       path,
+				ModType:    FileRenamed,
+				SourcePath: deleted[toDigest][0],
+			})
+			deleted[toDigest] = deleted[toDigest][1:]
+		case len(fromState[toDigest]) > 0:
+			// File was copied from a path in the from state
+			changes = append(changes, &FileChange{
+				Path:       path,
+				ModType:    FileCopied,
+				SourcePath: slices.Min(fromState[toDigest]),
+			})
+		default:
+			// File was added
 			changes = append(changes, &FileChange{
 				Path:    path,
-				ModType: FileModified,
+				ModType: FileAdded,
 			})
 		}
 	}
 
-	// Find deleted files
-	for path := range fromPaths {
-		if _, exists := toPaths[path]; !exists {
-			// File was deleted
+	// Remaining deleted files
+	for _, paths := range deleted {
+		for _, path := range paths {
 			changes = append(changes, &FileChange{
 				Path:    path,
 				ModType: FileDeleted,
@@ -570,13 +718,7 @@ func GetVersionChanges(conn *sqlite.Conn, root string, objID string, fromVN int,
 
 	// Sort results for consistency
 	slices.SortFunc(changes, func(a, b *FileChange) int {
-		if a.Path < b.Path {
-			return -1
-		}
-		if a.Path > b.Path {
-			return 1
-		}
-		return 0
+		return strings.Compare(a.Path, b.Path)
 	})
 
 	return changes, nil
@@ -592,10 +734,27 @@ func ReadVersionDir(conn *sqlite.Conn, root string, objID string, vn int, dir st
 	if !fs.ValidPath(dir) {
 		return nil, fmt.Errorf("invalid object version directory: %q", dir)
 	}
+	entries, err := dirEntries(dir, ListVersionFiles(conn, root, objID, vn, dir))
+	if err != nil {
+		return nil, fmt.Errorf("reading version directory %q: %w", dir, err)
+	}
+	// only the root directory can be empty (i.e., object is empty); otherwise,
+	// it represents a "not found" error
+	if len(entries) < 1 && dir != "." {
+		return nil, fmt.Errorf("with object version directory: object_id=%q v=%d dir=%q: %w", objID, vn, dir, ErrNotFound)
+	}
+	return entries, nil
+
+}
+
+// dirEntries returns entries for the directory dir from files, which must be
+// ordered by path (with existing files before deleted files with the same
+// path) and include all files in dir.
+func dirEntries(dir string, files iter.Seq2[*VersionFileInfo, error]) ([]*VersionDirEntry, error) {
 	var entries []*VersionDirEntry
-	for file, err := range ListVersionFiles(conn, root, objID, vn, dir) {
+	for file, err := range files {
 		if err != nil {
-			return nil, fmt.Errorf("reading version directory %q: %w", dir, err)
+			return nil, err
 		}
 		// files are ordered by path, but some files are deleted.
 		relPath := strings.TrimPrefix(file.Path, dir+"/")
@@ -636,13 +795,35 @@ func ReadVersionDir(conn *sqlite.Conn, root string, objID string, vn int, dir st
 			entries = append(entries, newEntry)
 		}
 	}
-	// only the root directory can be empty (i.e., object is empty); otherwise,
-	// it represents a "not found" error
-	if len(entries) < 1 && dir != "." {
-		return nil, fmt.Errorf("with object version directory: object_id=%q v=%d dir=%q: %w", objID, vn, dir, ErrNotFound)
-	}
 	return entries, nil
+}
 
+// ReadVersionFiles returns information for all files in the directory dir of
+// an object's version state, including files in sub-directories. Files are
+// ordered by path. Use "." for all files in the version state.
+func ReadVersionFiles(conn *sqlite.Conn, root string, objID string, vn int, dir string) ([]*VersionFileInfo, error) {
+	if dir == "" {
+		dir = "."
+	}
+	if !fs.ValidPath(dir) {
+		return nil, fmt.Errorf("invalid object version directory: %q", dir)
+	}
+	var files []*VersionFileInfo
+	for file, err := range ListVersionFiles(conn, root, objID, vn, dir) {
+		if err != nil {
+			return nil, fmt.Errorf("reading version files in %q: %w", dir, err)
+		}
+		// the query's LIKE pattern may match paths that aren't in dir if dir
+		// includes '%' or '_'.
+		if file.isDeleted || (dir != "." && !strings.HasPrefix(file.Path, dir+"/")) {
+			continue
+		}
+		files = append(files, file)
+	}
+	if len(files) < 1 && dir != "." {
+		return nil, fmt.Errorf("with object version directory: object_id=%q v=%d dir=%q: %w", objID, vn, dir, ErrNotFound)
+	}
+	return files, nil
 }
 
 // StatVersionFile file information for the given name, which must be a
@@ -674,6 +855,7 @@ func StatVersionFile(conn *sqlite.Conn, root string, objID string, vn int, name
 				Modtime:     time.Unix(stmt.GetInt64("mod_time"), 0),
 				Size:        stmt.GetInt64("size"),
 				HasSize:     true,
+				MediaType:   stmt.GetText("media_type"),
 			}
 			if info.Size < 0 {
 				info.Size = 0
@@ -641,6 +641,81 @@ func TestReadVersionDir(t *testing.T) {
 	}
 }
 
+func TestReadVersionFiles(t *testing.T) {
+	conn := testConn(t)
+	rootName := "root-01"
+	objID := "object-01"
+	createTestObject(t, conn, rootName, objID,
+		ocflite.PathMap{
+			"readme.txt":        "digest1",
+			"src/utils/lib1.go": "digest2",
+			"src/utils/lib2.go": "digest3",
+			"src_old/lib.go":    "digest4",
+		},
+		ocflite.PathMap{
+			"readme.txt":        "digest1",
+			"src/utils/lib1.go": "digest5",
+			"src/main.go":       "digest6",
+			"src_old/lib.go":    "digest4",
+		},
+	)
+	type test struct {
+		version   int
+		directory string
+		want      []string
+		wantErr   bool
+	}
+	tests := map[string]test{
+		"root-v2": {
+			version:   2,
+			directory: ".",
+			want: []string{
+				"readme.txt",
+				"src/main.go",
+				"src/utils/lib1.go",
+				"src_old/lib.go",
+			},
+		},
+		"subdir-v1": {
+			version:   1,
+			directory: "src",
+			want:      []string{"src/utils/lib1.go", "src/utils/lib2.go"},
+		},
+		"underscore-in-dir": {
+			// '_' shouldn't be treated as a wildcard
+			version:   2,
+			directory: "src_old",
+			want:      []string{"src_old/lib.go"},
+		},
+		"file-not-dir": {
+			version:   2,
+			directory: "readme.txt",
+			wantErr:   true,
+		},
+	}
+	for tname, tt := range tests {
+		t.Run(tname, func(t *testing.T) {
+			files, err := ocflite.ReadVersionFiles(conn, rootName, objID, tt.version, tt.directory)
+			if tt.wantErr {
+				if !errors.Is(err, ocflite.ErrNotFound) {
+					t.Fatal("expected ErrNotFound, got:", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+			got := make([]string, len(files))
+			for i, f := range files {
+				got[i] = f.Path
+			}
+			if !slices.Equal(tt.want, got) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestListObjects(t *testing.T) {
 	conn := testConn(t)
 	rootName := "test-root"
@@ -658,7 +733,7 @@ func TestListObjects(t *testing.T) {
 		allObjects := make([]*ocflite.ObjectBrief, 0, numObjects)
 
 		for offset := 0; offset < numObjects; offset += pageSize {
-			result, err := ocflite.ListObjects(conn, rootName, pageSize, offset)
+			result, err := ocflite.ListObjects(conn, rootName, pageSize, offset, ocflite.SortByID, false)
 			if err != nil {
 				t.Fatalf("ListObjects failed at offset %d: %v", offset, err)
 			}
@@ -703,16 +778,64 @@ func TestListObjects(t *testing.T) {
 	t.Run("pageSize-11", func(t *testing.T) { testPageSize(t, 11) })
 	t.Run("pageSize-10", func(t *testing.T) { testPageSize(t, 101) })
 	t.Run("pageSize-101", func(t *testing.T) { testPageSize(t, 101) })
+
+	t.Run("sorted", func(t *testing.T) {
+		conn := testConn(t)
+		// object-a has three versions, object-b has one, object-c has two
+		createTestObject(t, conn, rootName, "object-a",
+			ocflite.PathMap{"a": "1"}, ocflite.PathMap{"a": "2"}, ocflite.PathMap{"a": "3"})
+		createTestObject(t, conn, rootName, "object-b",
+			ocflite.PathMap{"a": "1"})
+		createTestObject(t, conn, rootName, "object-c",
+			ocflite.PathMap{"a": "1"}, ocflite.PathMap{"a": "2"})
+		ids := func(objs []*ocflite.ObjectBrief) []string {
+			result := make([]string, len(objs))
+			for i, o := range objs {
+				result[i] = o.ID
+			}
+			return result
+		}
+		tests := []struct {
+			sort ocflite.ObjectSort
+			desc bool
+			want []string
+		}{
+			{ocflite.SortByID, false, []string{"object-a", "object-b", "object-c"}},
+			{ocflite.SortByID, true, []string{"object-c", "object-b", "object-a"}},
+			{ocflite.SortByHead, false, []string{"object-b", "object-c", "object-a"}},
+			{ocflite.SortByHead, true, []string{"object-a", "object-c", "object-b"}},
+			{ocflite.SortByUpdated, true, []string{"object-a", "object-c", "object-b"}},
+			{ocflite.SortByCreated, false, []string{"object-a", "object-b", "object-c"}},
+			{ocflite.SortByStoragePath, true, []string{"object-c", "object-b", "object-a"}},
+		}
+		for _, tt := range tests {
+			got, err := ocflite.ListObjects(conn, rootName, 10, 0, tt.sort, tt.desc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(tt.want, ids(got)) {
+				t.Errorf("sort=%d desc=%v: got %v, want %v", tt.sort, tt.desc, ids(got), tt.want)
+			}
+		}
+	})
+
+	t.Run("invalid sort", func(t *testing.T) {
+		_, err := ocflite.ListObjects(conn, rootName, 10, 0, ocflite.ObjectSort(99), false)
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+	})
 }
 
 func TestStatVersionFile(t *testing.T) {
 	type test struct {
-		versions []ocflite.PathMap
-		sizes    map[string]int64
-		version  int
-		name     string
-		want     *ocflite.VersionFileInfo
-		wantErr  bool
+		versions   []ocflite.PathMap
+		sizes      map[string]int64
+		mediaTypes map[string]string
+		version    int
+		name       string
+		want       *ocflite.VersionFileInfo
+		wantErr    bool
 	}
 
 	tests := map[string]test{
@@ -758,6 +881,24 @@ func TestStatVersionFile(t *testing.T) {
 				HasSize:     true,
 			},
 		},
+		"file-with-media-type": {
+			versions: []ocflite.PathMap{
+				{"readme.txt": "digest1", "other.txt": "digest2"},
+			},
+			mediaTypes: map[string]string{
+				"digest1": "text/plain; charset=utf-8",
+				"digest2": "text/csv",
+			},
+			version: 1,
+			name:    "readme.txt",
+			want: &ocflite.VersionFileInfo{
+				Path:        "readme.txt",
+				Digest:      "digest1",
+				ContentPath: "v1/content/readme.txt",
+				ModVnum:     1,
+				MediaType:   "text/plain; charset=utf-8",
+			},
+		},
 		"deleted-file": {
 			versions: []ocflite.PathMap{
 				{"readme.txt": "digest1"},
@@ -798,6 +939,12 @@ func TestStatVersionFile(t *testing.T) {
 					t.Fatal("setting size", err)
 				}
 			}
+			for digest, mediaType := range tt.mediaTypes {
+				err := ocflite.SetObjectFileMediaType(conn, rootName, objID, digest, mediaType)
+				if err != nil {
+					t.Fatal("setting media type", err)
+				}
+			}
 			gotInfo, err := ocflite.StatVersionFile(conn, rootName, objID, tt.version, tt.name)
 			if err != nil && !tt.wantErr {
 				t.Fatal("unexpected error:", err)
@@ -818,6 +965,45 @@ func TestStatVersionFile(t *testing.T) {
 	}
 }
 
+func TestPruneObjects(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		conn := testConn(t)
+		rootName := "test-root"
+		kept := createTestObject(t, conn, rootName, "kept", ocflite.PathMap{"a.txt": "digest-1"})
+		createTestObject(t, conn, rootName, "removed", ocflite.PathMap{"a.txt": "digest-1"})
+		time.Sleep(2 * time.Second)
+		scanStart := time.Now()
+		time.Sleep(time.Second)
+		// indexed after the scan started
+		createTestObject(t, conn, rootName, "new", ocflite.PathMap{"b.txt": "digest-2"})
+		var checked []string
+		keep := func(storagePath string) bool {
+			checked = append(checked, storagePath)
+			return storagePath == kept.StoragePath
+		}
+		removed, err := ocflite.PruneObjects(conn, rootName, scanStart, keep)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(checked) != 2 {
+			t.Errorf("checked paths: got %v, want the paths of kept and removed", checked)
+		}
+		if !slices.Equal(removed, []string{"removed"}) {
+			t.Errorf("removed objects: got %v, want [removed]", removed)
+		}
+		count, err := ocflite.CountObjects(conn, rootName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 2 {
+			t.Errorf("object count: got %d, want 2", count)
+		}
+		if _, err := ocflite.GetObjectBrief(conn, rootName, "removed"); !errors.Is(err, ocflite.ErrNotFound) {
+			t.Errorf("removed object: got err=%v, want ErrNotFound", err)
+		}
+	})
+}
+
 func TestTouchObject(t *testing.T) {
 	synctest.Test(t, func(t *testing.T) {
 		conn := testConn(t)
@@ -1127,7 +1313,7 @@ func TestGetVersionChanges(t *testing.T) {
 		}
 	})
 
-	t.Run("file rename appears as delete and add", func(t *testing.T) {
+	t.Run("file rename", func(t *testing.T) {
 		// v1: {old/path.txt: digest1}
 		// v2: {new/path.txt: digest1}  // same digest, different path
 		content := "same content"
@@ -1145,20 +1331,97 @@ func TestGetVersionChanges(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		if len(changes) != 2 {
-			t.Fatalf("got %d changes, want 2 (rename appears as delete+add)", len(changes))
+		if len(changes) != 1 {
+			t.Fatalf("got %d changes, want 1 (rename)", len(changes))
+		}
+		if changes[0].Path != "new/path.txt" {
+			t.Errorf("got path=%q, want new/path.txt", changes[0].Path)
+		}
+		if changes[0].ModType != ocflite.FileRenamed {
+			t.Errorf("got type=%v, want FileRenamed", changes[0].ModType)
+		}
+		if changes[0].SourcePath != "old/path.txt" {
+			t.Errorf("got source path=%q, want old/path.txt", changes[0].SourcePath)
 		}
 
-		changeMap := make(map[string]ocflite.ModType)
+		// reverse comparison is also a rename
+		reverse, err := ocflite.GetVersionChanges(conn, rootName, obj.ID, 2, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(reverse) != 1 || reverse[0].ModType != ocflite.FileRenamed || reverse[0].SourcePath != "new/path.txt" {
+			t.Errorf("reverse: got %+v, want rename from new/path.txt", reverse)
+		}
+	})
+
+	t.Run("file copy", func(t *testing.T) {
+		// v1: {a.txt: d1, b.txt: d2}
+		// v2: {a.txt: d1, b.txt: d3, c.txt: d1, d.txt: d2}
+		// c.txt is a copy of a.txt, which is unchanged; d.txt is a copy of
+		// b.txt, which is modified
+		obj := createTestObjectWithContent(t, conn, rootName, "obj10b",
+			map[string]string{
+				"a.txt": "content1",
+				"b.txt": "content2",
+			},
+			map[string]string{
+				"a.txt": "content1",
+				"b.txt": "content3",
+				"c.txt": "content1",
+				"d.txt": "content2",
+			},
+		)
+
+		changes, err := ocflite.GetVersionChanges(conn, rootName, obj.ID, 1, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(changes) != 3 {
+			t.Fatalf("got %d changes, want 3", len(changes))
+		}
+		changeMap := make(map[string]*ocflite.FileChange)
 		for _, change := range changes {
-			changeMap[change.Path] = change.ModType
+			changeMap[change.Path] = change
+		}
+		if c := changeMap["b.txt"]; c == nil || c.ModType != ocflite.FileModified || c.SourcePath != "" {
+			t.Errorf("b.txt should be modified, got %+v", c)
+		}
+		if c := changeMap["c.txt"]; c == nil || c.ModType != ocflite.FileCopied || c.SourcePath != "a.txt" {
+			t.Errorf("c.txt should be copied from a.txt, got %+v", c)
+		}
+		if c := changeMap["d.txt"]; c == nil || c.ModType != ocflite.FileCopied || c.SourcePath != "b.txt" {
+			t.Errorf("d.txt should be copied from b.txt, got %+v", c)
 		}
+	})
+
+	t.Run("rename with copies", func(t *testing.T) {
+		// v1: {a.txt: d1}
+		// v2: {b.txt: d1, c.txt: d1}
+		// a.txt is renamed to b.txt and copied to c.txt
+		obj := createTestObjectWithContent(t, conn, rootName, "obj10c",
+			map[string]string{
+				"a.txt": "content1",
+			},
+			map[string]string{
+				"b.txt": "content1",
+				"c.txt": "content1",
+			},
+		)
 
-		if changeMap["new/path.txt"] != ocflite.FileAdded {
-			t.Errorf("new/path.txt should be added")
+		changes, err := ocflite.GetVersionChanges(conn, rootName, obj.ID, 1, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(changes) != 2 {
+			t.Fatalf("got %d changes, want 2", len(changes))
+		}
+		if changes[0].Path != "b.txt" || changes[0].ModType != ocflite.FileRenamed || changes[0].SourcePath != "a.txt" {
+			t.Errorf("got first change %+v, want b.txt renamed from a.txt", changes[0])
 		}
-		if changeMap["old/path.txt"] != ocflite.FileDeleted {
-			t.Errorf("old/path.txt should be deleted")
+		if changes[1].Path != "c.txt" || changes[1].ModType != ocflite.FileCopied || changes[1].SourcePath != "a.txt" {
+			t.Errorf("got second change %+v, want c.txt copied from a.txt", changes[1])
 		}
 	})
 
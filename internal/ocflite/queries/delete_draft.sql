@@ -0,0 +1,15 @@
+-- delete the draft's files
+DELETE FROM ocfl_draft_files
+WHERE draft_id IN (
+    SELECT d.id FROM ocfl_drafts d
+    JOIN ocfl_roots r ON d.root_id = r.id
+    WHERE r.name = ?1 AND d.id = ?2
+);
+
+-- delete the draft
+DELETE FROM ocfl_drafts
+WHERE id IN (
+    SELECT d.id FROM ocfl_drafts d
+    JOIN ocfl_roots r ON d.root_id = r.id
+    WHERE r.name = ?1 AND d.id = ?2
+);
@@ -0,0 +1 @@
+DELETE FROM ocfl_draft_files WHERE draft_id = ?1 AND path = ?2
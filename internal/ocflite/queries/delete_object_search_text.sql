@@ -0,0 +1,19 @@
+-- remove an object's versions and logical paths from the full-text indexes
+DELETE FROM ocfl_versions_fts
+WHERE rowid IN (
+    SELECT v.id
+    FROM ocfl_object_versions v
+    JOIN ocfl_objects o ON v.object_id = o.id
+    JOIN ocfl_roots r ON o.root_id = r.id
+    WHERE r.name = ?1 AND o.object_id = ?2
+);
+
+DELETE FROM ocfl_version_files_fts
+WHERE rowid IN (
+    SELECT vf.id
+    FROM ocfl_object_version_files vf
+    JOIN ocfl_object_versions v ON vf.version_id = v.id
+    JOIN ocfl_objects o ON v.object_id = o.id
+    JOIN ocfl_roots r ON o.root_id = r.id
+    WHERE r.name = ?1 AND o.object_id = ?2
+);
@@ -0,0 +1,11 @@
+-- Deletes the messages in an object's validation report.
+--
+-- Arguments:
+-- 1: root name
+-- 2: object id
+DELETE FROM ocfl_validation_messages
+WHERE validation_id IN (
+    SELECT v.id FROM ocfl_validations v
+    JOIN ocfl_roots r ON v.root_id = r.id
+    WHERE r.name = ?1 AND v.object_id = ?2
+);
@@ -0,0 +1,9 @@
+SELECT
+    count(*) AS num_files,
+    coalesce(sum(f.fixity_checked_at > 0), 0) AS num_checked,
+    coalesce(sum(f.fixity_status = 'fail'), 0) AS num_failed,
+    coalesce(min(f.fixity_checked_at), 0) AS oldest_check
+FROM ocfl_object_files f
+JOIN ocfl_objects o ON f.object_id = o.id
+JOIN ocfl_roots r ON o.root_id = r.id
+WHERE r.name = ?1;
@@ -0,0 +1,12 @@
+SELECT
+    d.id,
+    d.object_id,
+    d.owner,
+    d.head,
+    d.padding,
+    d.alg,
+    d.created_at,
+    d.updated_at
+FROM ocfl_drafts d
+JOIN ocfl_roots r ON d.root_id = r.id
+WHERE r.name = ?1 AND d.object_id = ?2 AND d.owner = ?3
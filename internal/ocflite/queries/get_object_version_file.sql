@@ -2,6 +2,7 @@ SELECT
     ofs.path as content_path,
     ofs.digest as digest,
     ofs.size as size,
+    ofs.media_type as media_type,
     vfs.is_deleted as is_deleted,
     v.created_at as mod_time,
     v.vnum as mod_vnum
@@ -0,0 +1,13 @@
+-- Returns an object's validation report, without its messages.
+--
+-- Arguments:
+-- 1: root name
+-- 2: object id
+SELECT
+    v.object_id,
+    v.storage_path,
+    v.valid,
+    v.validated_at
+FROM ocfl_validations v
+JOIN ocfl_roots r ON v.root_id = r.id
+WHERE r.name = ?1 AND v.object_id = ?2;
@@ -0,0 +1,24 @@
+-- Adds a draft for an object. Nothing is inserted if the owner already has a
+-- draft for the object.
+--
+-- Arguments:
+-- 1: root name
+-- 2: object id
+-- 3: owner
+-- 4: head version number
+-- 5: version number padding
+-- 6: digest algorithm
+-- 7: created/updated timestamp
+INSERT INTO ocfl_drafts (
+    root_id,
+    object_id,
+    owner,
+    head,
+    padding,
+    alg,
+    created_at,
+    updated_at
+) VALUES (
+    (SELECT id FROM ocfl_roots WHERE name = ?1),
+    ?2, ?3, ?4, ?5, ?6, ?7, ?7
+) ON CONFLICT (root_id, object_id, owner) DO NOTHING;
@@ -0,0 +1,15 @@
+-- add an object's versions and logical paths to the full-text indexes
+INSERT INTO ocfl_versions_fts (rowid, message, user_name)
+    SELECT v.id, v.message, v.user_name
+    FROM ocfl_object_versions v
+    JOIN ocfl_objects o ON v.object_id = o.id
+    JOIN ocfl_roots r ON o.root_id = r.id
+    WHERE r.name = ?1 AND o.object_id = ?2;
+
+INSERT INTO ocfl_version_files_fts (rowid, path)
+    SELECT vf.id, vf.path
+    FROM ocfl_object_version_files vf
+    JOIN ocfl_object_versions v ON vf.version_id = v.id
+    JOIN ocfl_objects o ON v.object_id = o.id
+    JOIN ocfl_roots r ON o.root_id = r.id
+    WHERE r.name = ?1 AND o.object_id = ?2 AND NOT vf.is_deleted;
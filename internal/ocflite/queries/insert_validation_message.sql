@@ -0,0 +1,19 @@
+-- Adds a message to an object's validation report.
+--
+-- Arguments:
+-- 1: root name
+-- 2: object id
+-- 3: level
+-- 4: code
+-- 5: message
+INSERT INTO ocfl_validation_messages (
+    validation_id,
+    level,
+    code,
+    message
+) VALUES (
+    (SELECT v.id FROM ocfl_validations v
+        JOIN ocfl_roots r ON v.root_id = r.id
+        WHERE r.name = ?1 AND v.object_id = ?2),
+    ?3, ?4, ?5
+);
@@ -0,0 +1,14 @@
+-- Lists a draft's files, ordered by path.
+--
+-- Arguments:
+-- 1: draft id
+SELECT
+    path,
+    digest,
+    size,
+    upload_id,
+    mod_vnum,
+    mod_time
+FROM ocfl_draft_files
+WHERE draft_id = ?1
+ORDER BY path
@@ -0,0 +1,18 @@
+-- Lists drafts in a root, most recently updated first.
+--
+-- Arguments:
+-- 1: root name
+-- 2: owner (use "" for all drafts)
+SELECT
+    d.id,
+    d.object_id,
+    d.owner,
+    d.head,
+    d.padding,
+    d.alg,
+    d.created_at,
+    d.updated_at
+FROM ocfl_drafts d
+JOIN ocfl_roots r ON d.root_id = r.id
+WHERE r.name = ?1 AND (?2 = '' OR d.owner = ?2)
+ORDER BY d.updated_at DESC, d.id DESC
@@ -0,0 +1,18 @@
+-- content files that haven't been checked since ?2 (unix time), least recently
+-- checked first.
+SELECT
+    o.object_id,
+    o.storage_path,
+    o.alg,
+    f.path,
+    f.digest,
+    f.size,
+    f.fixity_status,
+    f.fixity_error,
+    f.fixity_checked_at
+FROM ocfl_object_files f
+JOIN ocfl_objects o ON f.object_id = o.id
+JOIN ocfl_roots r ON o.root_id = r.id
+WHERE r.name = ?1 AND f.fixity_checked_at < ?2
+ORDER BY f.fixity_checked_at, f.id
+LIMIT ?3;
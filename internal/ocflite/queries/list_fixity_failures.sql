@@ -0,0 +1,18 @@
+-- content files that failed their most recent fixity check, most recently
+-- checked first.
+SELECT
+    o.object_id,
+    o.storage_path,
+    o.alg,
+    f.path,
+    f.digest,
+    f.size,
+    f.fixity_status,
+    f.fixity_error,
+    f.fixity_checked_at
+FROM ocfl_object_files f
+JOIN ocfl_objects o ON f.object_id = o.id
+JOIN ocfl_roots r ON o.root_id = r.id
+WHERE r.name = ?1 AND f.fixity_status = 'fail'
+ORDER BY f.fixity_checked_at DESC, o.object_id, f.path
+LIMIT ?2;
@@ -0,0 +1,16 @@
+-- Lists reports for objects that failed their most recent validation, most
+-- recently validated first. Messages aren't included.
+--
+-- Arguments:
+-- 1: root name
+-- 2: limit
+SELECT
+    v.object_id,
+    v.storage_path,
+    v.valid,
+    v.validated_at
+FROM ocfl_validations v
+JOIN ocfl_roots r ON v.root_id = r.id
+WHERE r.name = ?1 AND NOT v.valid
+ORDER BY v.validated_at DESC, v.object_id
+LIMIT ?2;
@@ -0,0 +1,17 @@
+-- the object's content files; if ?3 isn't empty, only files with that fixity
+-- status are included.
+SELECT
+    o.object_id,
+    o.storage_path,
+    o.alg,
+    f.path,
+    f.digest,
+    f.size,
+    f.fixity_status,
+    f.fixity_error,
+    f.fixity_checked_at
+FROM ocfl_object_files f
+JOIN ocfl_objects o ON f.object_id = o.id
+JOIN ocfl_roots r ON o.root_id = r.id
+WHERE r.name = ?1 AND o.object_id = ?2 AND (?3 = '' OR f.fixity_status = ?3)
+ORDER BY f.path;
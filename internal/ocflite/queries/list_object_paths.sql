@@ -0,0 +1,5 @@
+SELECT o.object_id, o.storage_path
+FROM ocfl_objects o
+JOIN ocfl_roots r ON o.root_id = r.id
+WHERE r.name = ?1 AND o.indexed_at <= ?2
+ORDER BY o.object_id
@@ -21,5 +21,4 @@ FROM ocfl_objects o
 JOIN ocfl_roots r ON o.root_id = r.id
 LEFT JOIN versions v ON v.object_id = o.id
 WHERE r.name = ?1
-ORDER BY o.object_id
-LIMIT ?2 OFFSET ?3
\ No newline at end of file
+-- ORDER BY, LIMIT ?2, and OFFSET ?3 are added by ListObjects
\ No newline at end of file
@@ -0,0 +1,15 @@
+-- Lists the messages in an object's validation report, in the order they were
+-- added.
+--
+-- Arguments:
+-- 1: root name
+-- 2: object id
+SELECT
+    m.level,
+    m.code,
+    m.message
+FROM ocfl_validation_messages m
+JOIN ocfl_validations v ON m.validation_id = v.id
+JOIN ocfl_roots r ON v.root_id = r.id
+WHERE r.name = ?1 AND v.object_id = ?2
+ORDER BY m.id;
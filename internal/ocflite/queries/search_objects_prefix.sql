@@ -0,0 +1,23 @@
+-- Search for objects with IDs matching a GLOB pattern (case-sensitive). With a
+-- pattern like 'prefix*', the search uses the (root_id, object_id) index.
+--
+-- Arguments:
+-- 1: root name
+-- 2: GLOB pattern
+-- 3: limit
+-- 4: offset
+SELECT
+    o.object_id,
+    o.storage_path,
+    o.padding,
+    o.alg,
+    o.inventory_digest,
+    o.indexed_at,
+    COALESCE((SELECT MAX(v.vnum) FROM ocfl_object_versions v WHERE v.object_id = o.id), 0) as head,
+    COALESCE((SELECT MIN(v.created_at) FROM ocfl_object_versions v WHERE v.object_id = o.id), 0) as created_at,
+    COALESCE((SELECT MAX(v.created_at) FROM ocfl_object_versions v WHERE v.object_id = o.id), 0) as updated_at
+FROM ocfl_objects o
+JOIN ocfl_roots r ON o.root_id = r.id
+WHERE r.name = ?1 AND o.object_id GLOB ?2
+ORDER BY o.object_id
+LIMIT ?3 OFFSET ?4
@@ -0,0 +1,23 @@
+-- Search for objects with IDs matching a LIKE pattern (case-insensitive). With
+-- a pattern like 'prefix%', the search uses the idx_object_id_nocase index.
+--
+-- Arguments:
+-- 1: root name
+-- 2: LIKE pattern using '\' as the escape character
+-- 3: limit
+-- 4: offset
+SELECT
+    o.object_id,
+    o.storage_path,
+    o.padding,
+    o.alg,
+    o.inventory_digest,
+    o.indexed_at,
+    COALESCE((SELECT MAX(v.vnum) FROM ocfl_object_versions v WHERE v.object_id = o.id), 0) as head,
+    COALESCE((SELECT MIN(v.created_at) FROM ocfl_object_versions v WHERE v.object_id = o.id), 0) as created_at,
+    COALESCE((SELECT MAX(v.created_at) FROM ocfl_object_versions v WHERE v.object_id = o.id), 0) as updated_at
+FROM ocfl_objects o
+JOIN ocfl_roots r ON o.root_id = r.id
+WHERE r.name = ?1 AND o.object_id LIKE ?2 ESCAPE '\'
+ORDER BY o.object_id
+LIMIT ?3 OFFSET ?4
@@ -0,0 +1,31 @@
+-- Search for objects with IDs that include a substring. Candidates are found
+-- using the trigram index in ocfl_object_ids_fts and then filtered using
+-- instr() so that wildcard characters in the substring are matched literally.
+--
+-- Arguments:
+-- 1: root name
+-- 2: substring
+-- 3: case-sensitive match (boolean)
+-- 4: limit
+-- 5: offset
+SELECT
+    o.object_id,
+    o.storage_path,
+    o.padding,
+    o.alg,
+    o.inventory_digest,
+    o.indexed_at,
+    COALESCE((SELECT MAX(v.vnum) FROM ocfl_object_versions v WHERE v.object_id = o.id), 0) as head,
+    COALESCE((SELECT MIN(v.created_at) FROM ocfl_object_versions v WHERE v.object_id = o.id), 0) as created_at,
+    COALESCE((SELECT MAX(v.created_at) FROM ocfl_object_versions v WHERE v.object_id = o.id), 0) as updated_at
+FROM ocfl_object_ids_fts f
+JOIN ocfl_objects o ON o.id = f.rowid
+JOIN ocfl_roots r ON o.root_id = r.id
+WHERE f.object_id LIKE '%' || ?2 || '%'
+    AND r.name = ?1
+    AND CASE
+        WHEN ?3 THEN instr(o.object_id, ?2)
+        ELSE instr(lower(o.object_id), lower(?2))
+    END > 0
+ORDER BY o.object_id
+LIMIT ?4 OFFSET ?5
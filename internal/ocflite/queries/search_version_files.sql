@@ -0,0 +1,35 @@
+-- Full-text search of logical paths. Each result is a version in which a
+-- matching path was added or modified.
+--
+-- Arguments:
+-- 1: root name
+-- 2: FTS5 query expression
+-- 3: only include paths in the object's head version (boolean)
+-- 4: limit
+-- 5: offset
+SELECT
+    o.object_id,
+    o.padding,
+    v.vnum,
+    v.message,
+    v.user_name,
+    v.created_at,
+    vf.path
+FROM ocfl_version_files_fts f
+JOIN ocfl_object_version_files vf ON vf.id = f.rowid
+JOIN ocfl_object_versions v ON vf.version_id = v.id
+JOIN ocfl_objects o ON v.object_id = o.id
+JOIN ocfl_roots r ON o.root_id = r.id
+WHERE ocfl_version_files_fts MATCH ?2
+    AND r.name = ?1
+    AND (NOT ?3 OR NOT EXISTS (
+        -- the path was modified or deleted in a later version
+        SELECT 1
+        FROM ocfl_object_version_files later_vf
+        JOIN ocfl_object_versions later_v ON later_vf.version_id = later_v.id
+        WHERE later_v.object_id = o.id
+            AND later_vf.path = vf.path
+            AND later_v.vnum > v.vnum
+    ))
+ORDER BY o.object_id, vf.path, v.vnum
+LIMIT ?4 OFFSET ?5
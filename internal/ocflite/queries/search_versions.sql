@@ -0,0 +1,21 @@
+-- Full-text search of version messages and user names.
+--
+-- Arguments:
+-- 1: root name
+-- 2: FTS5 query expression
+-- 3: limit
+-- 4: offset
+SELECT
+    o.object_id,
+    o.padding,
+    v.vnum,
+    v.message,
+    v.user_name,
+    v.created_at
+FROM ocfl_versions_fts f
+JOIN ocfl_object_versions v ON v.id = f.rowid
+JOIN ocfl_objects o ON v.object_id = o.id
+JOIN ocfl_roots r ON o.root_id = r.id
+WHERE ocfl_versions_fts MATCH ?2 AND r.name = ?1
+ORDER BY f.rank, o.object_id, v.vnum
+LIMIT ?3 OFFSET ?4
@@ -0,0 +1 @@
+UPDATE ocfl_drafts SET updated_at = ?2 WHERE id = ?1
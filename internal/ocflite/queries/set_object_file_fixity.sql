@@ -0,0 +1,8 @@
+UPDATE ocfl_object_files 
+SET fixity_status = ?4, fixity_error = ?5, fixity_checked_at = ?6
+WHERE object_id = (
+    SELECT o.id 
+    FROM ocfl_objects o
+    JOIN ocfl_roots r ON r.id = o.root_id 
+    WHERE r.name = ?1 AND o.object_id = ?2
+) AND path = ?3;
\ No newline at end of file
@@ -0,0 +1,15 @@
+INSERT INTO ocfl_draft_files (
+    draft_id,
+    path,
+    digest,
+    size,
+    upload_id,
+    mod_vnum,
+    mod_time
+) VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7)
+ON CONFLICT (draft_id, path) DO UPDATE SET
+    digest = excluded.digest,
+    size = excluded.size,
+    upload_id = excluded.upload_id,
+    mod_vnum = excluded.mod_vnum,
+    mod_time = excluded.mod_time;
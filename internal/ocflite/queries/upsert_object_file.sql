@@ -21,5 +21,14 @@ INSERT INTO ocfl_object_files (
         -- set new size if previous size < 0
         WHEN excluded.size < 0 THEN size
         ELSE excluded.size
-    END
+    END,
+    media_type = CASE
+        -- media type must be detected again if the content changed
+        WHEN excluded.digest = digest THEN media_type
+        ELSE ''
+    END,
+    -- fixity must be checked again if the content changed
+    fixity_status = CASE WHEN excluded.digest = digest THEN fixity_status ELSE '' END,
+    fixity_error = CASE WHEN excluded.digest = digest THEN fixity_error ELSE '' END,
+    fixity_checked_at = CASE WHEN excluded.digest = digest THEN fixity_checked_at ELSE 0 END
 ;
\ No newline at end of file
@@ -0,0 +1,22 @@
+-- Adds or replaces an object's validation report. The report's existing
+-- messages should be deleted first.
+--
+-- Arguments:
+-- 1: root name
+-- 2: object id
+-- 3: storage path
+-- 4: valid
+-- 5: validated at timestamp
+INSERT INTO ocfl_validations (
+    root_id,
+    object_id,
+    storage_path,
+    valid,
+    validated_at
+) VALUES (
+    (SELECT id FROM ocfl_roots WHERE name = ?1),
+    ?2, ?3, ?4, ?5
+) ON CONFLICT (root_id, object_id) DO UPDATE SET
+    storage_path = excluded.storage_path,
+    valid = excluded.valid,
+    validated_at = excluded.validated_at;
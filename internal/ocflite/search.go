@@ -0,0 +1,199 @@
+package ocflite
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// SearchMode determines how SearchObjects matches object IDs.
+type SearchMode uint8
+
+const (
+	SearchPrefix    SearchMode = iota // object IDs starting with the query
+	SearchSubstring                   // object IDs that include the query
+)
+
+// ObjectSearch represents parameters for SearchObjects.
+type ObjectSearch struct {
+	Query      string     // string to match against object IDs
+	Mode       SearchMode // prefix or substring matching
+	IgnoreCase bool       // case-insensitive matching (ASCII only)
+	Limit      int        // max number of results
+	Offset     int        // number of results to skip
+}
+
+// SearchObjects returns objects with IDs matching the search query, ordered by
+// object ID. Wildcard characters in the query are matched literally. Prefix
+// searches use indexes on the object ID column; substring searches use a
+// trigram index, which is only effective for queries of three or more
+// characters.
+func SearchObjects(conn *sqlite.Conn, root string, search ObjectSearch) ([]*ObjectBrief, error) {
+	var qname string
+	var args []any
+	switch search.Mode {
+	case SearchPrefix:
+		if search.IgnoreCase {
+			qname = `queries/search_objects_prefix_nocase.sql`
+			args = []any{root, escapeLike(search.Query) + "%", search.Limit, search.Offset}
+			break
+		}
+		qname = `queries/search_objects_prefix.sql`
+		args = []any{root, escapeGlob(search.Query) + "*", search.Limit, search.Offset}
+	case SearchSubstring:
+		qname = `queries/search_objects_substring.sql`
+		args = []any{root, search.Query, !search.IgnoreCase, search.Limit, search.Offset}
+	default:
+		return nil, fmt.Errorf("invalid search mode: %d", search.Mode)
+	}
+	var objects []*ObjectBrief
+	err := sqlitex.ExecuteFS(conn, queries, qname, &sqlitex.ExecOptions{
+		Args: args,
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			objects = append(objects, objectBriefFromStmt(stmt))
+			return nil
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("searching objects: %w", err)
+	}
+	return objects, nil
+}
+
+// objectBriefFromStmt returns an *ObjectBrief using columns from the current
+// row in stmt.
+func objectBriefFromStmt(stmt *sqlite.Stmt) *ObjectBrief {
+	return &ObjectBrief{
+		ID:              stmt.GetText("object_id"),
+		StoragePath:     stmt.GetText("storage_path"),
+		DigestAlgorithm: stmt.GetText("alg"),
+		Head:            int(stmt.GetInt64("head")),
+		Vpadding:        int(stmt.GetInt64("padding")),
+		InventoryDigest: stmt.GetText("inventory_digest"),
+		IndexedAt:       time.Unix(stmt.GetInt64("indexed_at"), 0),
+		CreatedAt:       time.Unix(stmt.GetInt64("created_at"), 0),
+		UpdatedAt:       time.Unix(stmt.GetInt64("updated_at"), 0),
+	}
+}
+
+// escapeLike escapes special characters in s for use in a LIKE pattern with
+// '\' as the escape character.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// escapeGlob escapes special characters in s for use in a GLOB pattern.
+func escapeGlob(s string) string {
+	return strings.NewReplacer(`*`, `[*]`, `?`, `[?]`, `[`, `[[]`).Replace(s)
+}
+
+// SearchField is an indexed field searched by SearchContent.
+type SearchField uint8
+
+const (
+	SearchMessage  SearchField = iota // version messages
+	SearchUserName                    // version user names
+	SearchPath                        // logical paths added or modified in a version
+)
+
+// ContentSearch represents parameters for SearchContent.
+type ContentSearch struct {
+	Query    string      // whitespace-separated terms; all terms must match
+	Field    SearchField // field to search
+	HeadOnly bool        // for path searches, only match paths in objects' head versions
+	Limit    int         // max number of results
+	Offset   int         // number of results to skip
+}
+
+// ContentSearchResult is an object version matching a ContentSearch.
+type ContentSearchResult struct {
+	ObjectID string    // matching object's ID
+	Vnum     int       // matching version number
+	Vpadding int       // version number padding
+	Path     string    // matching logical path (path searches only)
+	Message  string    // version message
+	UserName string    // version user name
+	Created  time.Time // version created timestamp
+}
+
+// SearchContent returns object versions with messages, user names, or logical
+// paths matching the search query. Message and user name searches match whole
+// words (case-insensitive) and results are ordered by relevance. Path searches
+// match substrings of logical paths (case-insensitive); each term must include
+// three or more characters. Path results are ordered by object ID, path, and
+// version number.
+func SearchContent(conn *sqlite.Conn, root string, search ContentSearch) ([]*ContentSearchResult, error) {
+	var qname string
+	var args []any
+	switch search.Field {
+	case SearchMessage:
+		qname = `queries/search_versions.sql`
+		args = []any{root, ftsQuery("message", search.Query), search.Limit, search.Offset}
+	case SearchUserName:
+		qname = `queries/search_versions.sql`
+		args = []any{root, ftsQuery("user_name", search.Query), search.Limit, search.Offset}
+	case SearchPath:
+		qname = `queries/search_version_files.sql`
+		args = []any{root, ftsQuery("path", search.Query), search.HeadOnly, search.Limit, search.Offset}
+	default:
+		return nil, fmt.Errorf("invalid search field: %d", search.Field)
+	}
+	if len(strings.Fields(search.Query)) == 0 {
+		return nil, nil
+	}
+	var results []*ContentSearchResult
+	err := sqlitex.ExecuteFS(conn, queries, qname, &sqlitex.ExecOptions{
+		Args: args,
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			result := &ContentSearchResult{
+				ObjectID: stmt.GetText("object_id"),
+				Vnum:     int(stmt.GetInt64("vnum")),
+				Vpadding: int(stmt.GetInt64("padding")),
+				Message:  stmt.GetText("message"),
+				UserName: stmt.GetText("user_name"),
+				Created:  time.Unix(stmt.GetInt64("created_at"), 0),
+			}
+			if search.Field == SearchPath {
+				result.Path = stmt.GetText("path")
+			}
+			results = append(results, result)
+			return nil
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("searching content: %w", err)
+	}
+	return results, nil
+}
+
+// ftsQuery returns an FTS5 query expression matching rows where column
+// includes every whitespace-separated term in query. Terms are quoted so FTS5
+// operators in query are matched literally.
+func ftsQuery(column string, query string) string {
+	terms := strings.Fields(query)
+	for i, term := range terms {
+		terms[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+	}
+	return column + ` : (` + strings.Join(terms, " ") + `)`
+}
+
+// setObjectSearchText adds the object's version messages, user names, and
+// logical paths to the full-text indexes.
+func setObjectSearchText(conn *sqlite.Conn, root string, objID string) error {
+	script := `queries/insert_object_search_text.sql`
+	return sqlitex.ExecuteScriptFS(conn, queries, script, &sqlitex.ExecOptions{
+		Args: []any{root, objID},
+	})
+}
+
+// unsetObjectSearchText removes the object's version messages, user names, and
+// logical paths from the full-text indexes.
+func unsetObjectSearchText(conn *sqlite.Conn, root string, objID string) error {
+	script := `queries/delete_object_search_text.sql`
+	return sqlitex.ExecuteScriptFS(conn, queries, script, &sqlitex.ExecOptions{
+		Args: []any{root, objID},
+	})
+}
@@ -0,0 +1,280 @@
+package ocflite_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/srerickson/ocfl-services/internal/ocflite"
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+func TestSearchObjects(t *testing.T) {
+	conn := testConn(t)
+	rootName := "test-root"
+	objIDs := []string{
+		"ark:123/abc",
+		"ark:123/ABD",
+		"ark:124/xyz",
+		"urn:abc_100%",
+		"urn:abc-100",
+	}
+	for _, id := range objIDs {
+		createTestObject(t, conn, rootName, id, ocflite.PathMap{"file.txt": "digest"})
+	}
+	// object in a different root shouldn't be included in results
+	createTestObject(t, conn, "other-root", "ark:123/abc-other", ocflite.PathMap{"file.txt": "digest"})
+
+	tests := map[string]struct {
+		search ocflite.ObjectSearch
+		want   []string
+	}{
+		"prefix": {
+			search: ocflite.ObjectSearch{Query: "ark:123/a", Mode: ocflite.SearchPrefix},
+			want:   []string{"ark:123/abc"},
+		},
+		"prefix ignore case": {
+			search: ocflite.ObjectSearch{Query: "ark:123/a", Mode: ocflite.SearchPrefix, IgnoreCase: true},
+			want:   []string{"ark:123/ABD", "ark:123/abc"},
+		},
+		"prefix with glob characters": {
+			search: ocflite.ObjectSearch{Query: "ark:*", Mode: ocflite.SearchPrefix},
+		},
+		"prefix with like characters": {
+			search: ocflite.ObjectSearch{Query: "urn:abc_", Mode: ocflite.SearchPrefix, IgnoreCase: true},
+			want:   []string{"urn:abc_100%"},
+		},
+		"substring": {
+			search: ocflite.ObjectSearch{Query: "ab", Mode: ocflite.SearchSubstring},
+			want:   []string{"ark:123/abc", "urn:abc-100", "urn:abc_100%"},
+		},
+		"substring ignore case": {
+			search: ocflite.ObjectSearch{Query: "AB", Mode: ocflite.SearchSubstring, IgnoreCase: true},
+			want:   []string{"ark:123/ABD", "ark:123/abc", "urn:abc-100", "urn:abc_100%"},
+		},
+		"substring with wildcard": {
+			search: ocflite.ObjectSearch{Query: "c_1", Mode: ocflite.SearchSubstring},
+			want:   []string{"urn:abc_100%"},
+		},
+		"substring with trigrams": {
+			search: ocflite.ObjectSearch{Query: "124/x", Mode: ocflite.SearchSubstring},
+			want:   []string{"ark:124/xyz"},
+		},
+		"limit and offset": {
+			search: ocflite.ObjectSearch{Query: "ark", Mode: ocflite.SearchPrefix, Limit: 1, Offset: 1},
+			want:   []string{"ark:123/abc"},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if tt.search.Limit == 0 {
+				tt.search.Limit = 100
+			}
+			results, err := ocflite.SearchObjects(conn, rootName, tt.search)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, r := range results {
+				got = append(got, r.ID)
+			}
+			if !slices.Equal(tt.want, got) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("unset object is removed from search", func(t *testing.T) {
+		conn := testConn(t)
+		createTestObject(t, conn, rootName, "object-1", ocflite.PathMap{"file.txt": "digest"})
+		if err := ocflite.UnsetObject(conn, rootName, "object-1"); err != nil {
+			t.Fatal(err)
+		}
+		results, err := ocflite.SearchObjects(conn, rootName, ocflite.ObjectSearch{
+			Query: "ject", Mode: ocflite.SearchSubstring, Limit: 10,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) > 0 {
+			t.Errorf("expected no results, got %d", len(results))
+		}
+	})
+
+	t.Run("prefix searches use indexes", func(t *testing.T) {
+		plans := []string{
+			`SELECT object_id FROM ocfl_objects WHERE root_id = 1 AND object_id GLOB 'ark*'`,
+			`SELECT object_id FROM ocfl_objects WHERE root_id = 1 AND object_id LIKE 'ark%' ESCAPE '\'`,
+		}
+		for _, q := range plans {
+			var plan string
+			err := sqlitex.Execute(conn, "EXPLAIN QUERY PLAN "+q, &sqlitex.ExecOptions{
+				ResultFunc: func(stmt *sqlite.Stmt) error {
+					plan += stmt.GetText("detail") + "\n"
+					return nil
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(plan, "INDEX") {
+				t.Errorf("query doesn't use an index: %s\nplan: %s", q, plan)
+			}
+		}
+	})
+}
+
+func TestSearchContent(t *testing.T) {
+	conn := testConn(t)
+	rootName := "test-root"
+	createTestObject(t, conn, rootName, "object-1",
+		ocflite.PathMap{"data/manifest.xml": "digest-1", "readme.txt": "digest-2"},
+		ocflite.PathMap{"readme.txt": "digest-2"},
+	)
+	createTestObject(t, conn, rootName, "object-2",
+		ocflite.PathMap{"a.txt": "digest-1"},
+		ocflite.PathMap{"a.txt": "digest-1", "MANIFEST.XML": "digest-2"},
+		ocflite.PathMap{"a.txt": "digest-3", "manifest.xml": "digest-2"},
+	)
+	// object in a different root shouldn't be included in results
+	createTestObject(t, conn, "other-root", "object-3",
+		ocflite.PathMap{"manifest.xml": "digest-1"},
+	)
+	type result struct {
+		id   string
+		vnum int
+		path string
+	}
+	tests := map[string]struct {
+		search ocflite.ContentSearch
+		want   []result
+	}{
+		"message": {
+			search: ocflite.ContentSearch{Query: "message-2-object-2", Field: ocflite.SearchMessage},
+			want:   []result{{id: "object-2", vnum: 2}},
+		},
+		"message multiple terms": {
+			search: ocflite.ContentSearch{Query: "message 3", Field: ocflite.SearchMessage},
+			want:   []result{{id: "object-2", vnum: 3}},
+		},
+		"message no match": {
+			search: ocflite.ContentSearch{Query: "user-name", Field: ocflite.SearchMessage},
+		},
+		"user name": {
+			search: ocflite.ContentSearch{Query: "USER-NAME-3", Field: ocflite.SearchUserName},
+			want:   []result{{id: "object-2", vnum: 3}},
+		},
+		"query syntax is literal": {
+			search: ocflite.ContentSearch{Query: `user* OR "`, Field: ocflite.SearchUserName},
+		},
+		"empty query": {
+			search: ocflite.ContentSearch{Query: "  ", Field: ocflite.SearchUserName},
+		},
+		"path": {
+			search: ocflite.ContentSearch{Query: "manifest.xml", Field: ocflite.SearchPath},
+			want: []result{
+				{id: "object-1", vnum: 1, path: "data/manifest.xml"},
+				{id: "object-2", vnum: 2, path: "MANIFEST.XML"},
+				{id: "object-2", vnum: 3, path: "manifest.xml"},
+			},
+		},
+		"path head only": {
+			search: ocflite.ContentSearch{Query: "manifest.xml", Field: ocflite.SearchPath, HeadOnly: true},
+			want: []result{
+				{id: "object-2", vnum: 3, path: "manifest.xml"},
+			},
+		},
+		"path modified": {
+			search: ocflite.ContentSearch{Query: "a.txt", Field: ocflite.SearchPath},
+			want: []result{
+				{id: "object-2", vnum: 1, path: "a.txt"},
+				{id: "object-2", vnum: 3, path: "a.txt"},
+			},
+		},
+		"path modified head only": {
+			search: ocflite.ContentSearch{Query: "a.txt", Field: ocflite.SearchPath, HeadOnly: true},
+			want: []result{
+				{id: "object-2", vnum: 3, path: "a.txt"},
+			},
+		},
+		"limit and offset": {
+			search: ocflite.ContentSearch{Query: "txt", Field: ocflite.SearchPath, Limit: 2, Offset: 1},
+			want: []result{
+				{id: "object-2", vnum: 1, path: "a.txt"},
+				{id: "object-2", vnum: 3, path: "a.txt"},
+			},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if tt.search.Limit == 0 {
+				tt.search.Limit = 100
+			}
+			results, err := ocflite.SearchContent(conn, rootName, tt.search)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []result
+			for _, r := range results {
+				got = append(got, result{id: r.ObjectID, vnum: r.Vnum, path: r.Path})
+			}
+			if !slices.Equal(tt.want, got) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("invalid field", func(t *testing.T) {
+		_, err := ocflite.SearchContent(conn, rootName, ocflite.ContentSearch{Query: "a", Field: 99})
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+
+	t.Run("search index follows object updates", func(t *testing.T) {
+		conn := testConn(t)
+		search := ocflite.ContentSearch{Query: "old.txt", Field: ocflite.SearchPath, Limit: 10}
+		createTestObject(t, conn, rootName, "object-1", ocflite.PathMap{"old.txt": "digest-1"})
+		results, err := ocflite.SearchContent(conn, rootName, search)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 1 {
+			t.Fatalf("expected 1 result, got %d", len(results))
+		}
+		// replace the object's content
+		createTestObject(t, conn, rootName, "object-1", ocflite.PathMap{"new.txt": "digest-1"})
+		results, err = ocflite.SearchContent(conn, rootName, search)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) > 0 {
+			t.Errorf("expected no results after update, got %d", len(results))
+		}
+		search.Query = "new.txt"
+		results, err = ocflite.SearchContent(conn, rootName, search)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 1 {
+			t.Fatalf("expected 1 result, got %d", len(results))
+		}
+		// remove the object
+		if err := ocflite.UnsetObject(conn, rootName, "object-1"); err != nil {
+			t.Fatal(err)
+		}
+		for _, search := range []ocflite.ContentSearch{
+			{Query: "new.txt", Field: ocflite.SearchPath, Limit: 10},
+			{Query: "message-1-object-1", Field: ocflite.SearchMessage, Limit: 10},
+		} {
+			results, err := ocflite.SearchContent(conn, rootName, search)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(results) > 0 {
+				t.Errorf("expected no results after unset, got %d", len(results))
+			}
+		}
+	})
+}
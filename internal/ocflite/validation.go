@@ -0,0 +1,115 @@
+package ocflite
+
+import (
+	"fmt"
+	"time"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// values for ValidationMessage.Level
+const (
+	ValidationError   = "error"
+	ValidationWarning = "warning"
+)
+
+// Validation is a report from a full OCFL validation of an object.
+type Validation struct {
+	ObjectID    string               // ID of the validated object
+	StoragePath string               // object's storage path when it was validated
+	Valid       bool                 // true if validation found no errors
+	ValidatedAt time.Time            // when the object was validated
+	Messages    []*ValidationMessage // errors and warnings, in the order they were reported
+}
+
+// ValidationMessage is an error or warning in a Validation.
+type ValidationMessage struct {
+	Level   string // ValidationError or ValidationWarning
+	Code    string // OCFL validation code (E001, W004, ...); empty if not known
+	Message string
+}
+
+// SetValidation adds the validation report for the object, replacing any
+// existing report for the object. It should be called in a transaction.
+func SetValidation(conn *sqlite.Conn, root string, v *Validation) error {
+	if err := setRoot(conn, root); err != nil {
+		return err
+	}
+	err := sqlitex.ExecuteFS(conn, queries, `queries/delete_validation_messages.sql`, &sqlitex.ExecOptions{
+		Args: []any{root, v.ObjectID},
+	})
+	if err != nil {
+		return fmt.Errorf("removing validation messages: %w", err)
+	}
+	err = sqlitex.ExecuteFS(conn, queries, `queries/upsert_validation.sql`, &sqlitex.ExecOptions{
+		Args: []any{root, v.ObjectID, v.StoragePath, v.Valid, v.ValidatedAt.Unix()},
+	})
+	if err != nil {
+		return fmt.Errorf("setting validation: %w", err)
+	}
+	for _, msg := range v.Messages {
+		err := sqlitex.ExecuteFS(conn, queries, `queries/insert_validation_message.sql`, &sqlitex.ExecOptions{
+			Args: []any{root, v.ObjectID, msg.Level, msg.Code, msg.Message},
+		})
+		if err != nil {
+			return fmt.Errorf("adding validation message: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetValidation returns the object's validation report with its messages. It
+// returns ErrNotFound if the object hasn't been validated.
+func GetValidation(conn *sqlite.Conn, root string, objID string) (*Validation, error) {
+	vals, err := listValidations(conn, `queries/get_validation.sql`, root, objID)
+	if err != nil {
+		return nil, err
+	}
+	if len(vals) == 0 {
+		return nil, fmt.Errorf("validation with root=%q, object_id=%q: %w", root, objID, ErrNotFound)
+	}
+	v := vals[0]
+	err = sqlitex.ExecuteFS(conn, queries, `queries/list_validation_messages.sql`, &sqlitex.ExecOptions{
+		Args: []any{root, objID},
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			v.Messages = append(v.Messages, &ValidationMessage{
+				Level:   stmt.GetText("level"),
+				Code:    stmt.GetText("code"),
+				Message: stmt.GetText("message"),
+			})
+			return nil
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing validation messages: %w", err)
+	}
+	return v, nil
+}
+
+// ListInvalidObjects returns up to limit validation reports for objects in
+// the root that failed their most recent validation, most recently validated
+// first. The reports' messages aren't included.
+func ListInvalidObjects(conn *sqlite.Conn, root string, limit int) ([]*Validation, error) {
+	return listValidations(conn, `queries/list_invalid_objects.sql`, root, limit)
+}
+
+func listValidations(conn *sqlite.Conn, qname string, args ...any) ([]*Validation, error) {
+	var vals []*Validation
+	err := sqlitex.ExecuteFS(conn, queries, qname, &sqlitex.ExecOptions{
+		Args: args,
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			vals = append(vals, &Validation{
+				ObjectID:    stmt.GetText("object_id"),
+				StoragePath: stmt.GetText("storage_path"),
+				Valid:       stmt.GetBool("valid"),
+				ValidatedAt: time.Unix(stmt.GetInt64("validated_at"), 0),
+			})
+			return nil
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting validations: %w", err)
+	}
+	return vals, nil
+}
@@ -0,0 +1,76 @@
+package ocflite_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/srerickson/ocfl-services/internal/ocflite"
+)
+
+func TestValidation(t *testing.T) {
+	conn := testConn(t)
+	root := "test-root"
+	_, err := ocflite.GetValidation(conn, root, "object-1")
+	if !errors.Is(err, ocflite.ErrNotFound) {
+		t.Fatalf("GetValidation() for object that wasn't validated: err=%v", err)
+	}
+	validated := time.Now().Add(-time.Hour)
+	invalid := &ocflite.Validation{
+		ObjectID:    "object-1",
+		StoragePath: "object-1",
+		ValidatedAt: validated,
+		Messages: []*ocflite.ValidationMessage{
+			{Level: ocflite.ValidationError, Code: "E034", Message: "content file not in manifest"},
+			{Level: ocflite.ValidationWarning, Code: "W004", Message: "sha256 digest"},
+		},
+	}
+	if err := ocflite.SetValidation(conn, root, invalid); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ocflite.GetValidation(conn, root, "object-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Valid || got.StoragePath != "object-1" || got.ValidatedAt.Unix() != validated.Unix() {
+		t.Errorf("unexpected validation: %+v", got)
+	}
+	if len(got.Messages) != 2 {
+		t.Fatalf("GetValidation() returned %d messages, not 2", len(got.Messages))
+	}
+	if *got.Messages[0] != *invalid.Messages[0] || *got.Messages[1] != *invalid.Messages[1] {
+		t.Errorf("unexpected messages: %+v, %+v", got.Messages[0], got.Messages[1])
+	}
+	listed, err := ocflite.ListInvalidObjects(conn, root, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 1 || listed[0].ObjectID != "object-1" {
+		t.Errorf("unexpected invalid objects: %+v", listed)
+	}
+
+	// validating again replaces the report
+	valid := &ocflite.Validation{
+		ObjectID:    "object-1",
+		StoragePath: "object-1",
+		Valid:       true,
+		ValidatedAt: time.Now(),
+	}
+	if err := ocflite.SetValidation(conn, root, valid); err != nil {
+		t.Fatal(err)
+	}
+	got, err = ocflite.GetValidation(conn, root, "object-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Valid || len(got.Messages) != 0 {
+		t.Errorf("unexpected validation after replacing report: %+v", got)
+	}
+	listed, err = ocflite.ListInvalidObjects(conn, root, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 0 {
+		t.Errorf("ListInvalidObjects() returned %d reports, not 0", len(listed))
+	}
+}
@@ -0,0 +1,113 @@
+// Package rootloc parses storage root locations given to the commands in cmd/.
+package rootloc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	ocflfs "github.com/srerickson/ocfl-go/fs"
+	httpfs "github.com/srerickson/ocfl-go/fs/http"
+	"github.com/srerickson/ocfl-go/fs/local"
+	ocflS3 "github.com/srerickson/ocfl-go/fs/s3"
+)
+
+// S3Options are settings for S3 storage roots. Empty values are read from the
+// AWS environment.
+type S3Options struct {
+	Region          string // AWS region
+	Endpoint        string // base URL of an S3-compatible service
+	Profile         string // shared config profile
+	AccessKeyID     string // static credentials; requires SecretAccessKey
+	SecretAccessKey string
+	PathStyle       bool // use path-style bucket addressing
+}
+
+// options holds settings for Parse.
+type options struct {
+	s3 S3Options
+}
+
+// Option is used to configure Parse.
+type Option func(*options)
+
+// WithS3 sets the settings used for S3 storage roots.
+func WithS3(s3Opts S3Options) Option {
+	return func(o *options) { o.s3 = s3Opts }
+}
+
+// Parse returns the FS and the path in the FS for the storage root location
+// loc: a local file path, an S3 bucket and prefix (s3://bucket/prefix), or an
+// http(s) URL. S3 settings and credentials not set with WithS3 are read from
+// the AWS environment.
+func Parse(ctx context.Context, loc string, logger *slog.Logger, opts ...Option) (ocflfs.FS, string, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if loc == "" {
+		return nil, "", errors.New("location not set")
+	}
+	locUrl, err := url.Parse(loc)
+	if err != nil {
+		return nil, "", err
+	}
+	switch locUrl.Scheme {
+	case "s3":
+		bucket := locUrl.Host
+		prefix := strings.TrimPrefix(locUrl.Path, "/")
+		s3Client, err := newS3Client(ctx, o.s3)
+		if err != nil {
+			return nil, "", err
+		}
+		fsys := &ocflS3.BucketFS{S3: s3Client, Bucket: bucket, Logger: logger}
+		return fsys, prefix, nil
+	case "http", "https":
+		fsys := httpfs.New(loc)
+		return fsys, ".", nil
+	default:
+		absPath, err := filepath.Abs(loc)
+		if err != nil {
+			return nil, "", err
+		}
+		fsys, err := local.NewFS(absPath)
+		if err != nil {
+			return nil, "", err
+		}
+		return fsys, ".", nil
+	}
+}
+
+func newS3Client(ctx context.Context, opts S3Options) (*s3.Client, error) {
+	var loadOpts []func(*config.LoadOptions) error
+	if opts.Region != "" {
+		loadOpts = append(loadOpts, config.WithRegion(opts.Region))
+	}
+	if opts.Profile != "" {
+		loadOpts = append(loadOpts, config.WithSharedConfigProfile(opts.Profile))
+	}
+	if opts.AccessKeyID != "" || opts.SecretAccessKey != "" {
+		if opts.AccessKeyID == "" || opts.SecretAccessKey == "" {
+			return nil, errors.New("S3 access key ID and secret access key must be set together")
+		}
+		creds := credentials.NewStaticCredentialsProvider(opts.AccessKeyID, opts.SecretAccessKey, "")
+		loadOpts = append(loadOpts, config.WithCredentialsProvider(creds))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, loadOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if opts.Endpoint != "" {
+			o.BaseEndpoint = aws.String(opts.Endpoint)
+		}
+		o.UsePathStyle = opts.PathStyle
+	}), nil
+}
@@ -0,0 +1,124 @@
+package s3events
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+)
+
+// time to wait after an error receiving messages
+const receiveRetryWait = 10 * time.Second
+
+// Message is a message from a Queue.
+type Message struct {
+	Body   string // S3 event notification message
+	Handle string // queue-specific value for deleting the message
+}
+
+// Queue is a queue of S3 event notification messages.
+type Queue interface {
+	// Receive returns messages from the queue. It waits for messages, but
+	// may return none.
+	Receive(ctx context.Context) ([]Message, error)
+	// Delete removes a handled message from the queue.
+	Delete(ctx context.Context, msg Message) error
+}
+
+// Poll receives messages from queue and handles their events until ctx is
+// canceled, returning the context's error. Messages are deleted after their
+// events are handled, or if they can't be parsed. If objects couldn't be
+// synced, the message is left in the queue to be received again.
+func (h *Handler) Poll(ctx context.Context, queue Queue) error {
+	for {
+		msgs, err := queue.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			h.logger.Error("receiving S3 event messages", "error", err)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(receiveRetryWait):
+			}
+			continue
+		}
+		for _, msg := range msgs {
+			events, err := ParseNotification([]byte(msg.Body))
+			if err != nil {
+				h.logger.Error("discarding S3 event message", "error", err)
+			} else if err := h.HandleEvents(ctx, events); err != nil {
+				continue
+			}
+			if err := queue.Delete(ctx, msg); err != nil {
+				h.logger.Error("deleting S3 event message", "error", err)
+			}
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// SQSQueue is a Queue for an Amazon SQS queue or a queue with an
+// SQS-compatible API.
+type SQSQueue struct {
+	Client *sqs.Client
+	URL    string // queue URL
+}
+
+// SQSOptions are settings for NewSQSQueue. Empty values are read from the AWS
+// environment.
+type SQSOptions struct {
+	Region   string // AWS region
+	Endpoint string // base URL of an SQS-compatible service
+}
+
+// NewSQSQueue returns an SQSQueue for the queue URL.
+func NewSQSQueue(ctx context.Context, url string, opts SQSOptions) (*SQSQueue, error) {
+	var loadOpts []func(*config.LoadOptions) error
+	if opts.Region != "" {
+		loadOpts = append(loadOpts, config.WithRegion(opts.Region))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, loadOpts...)
+	if err != nil {
+		return nil, err
+	}
+	client := sqs.NewFromConfig(cfg, func(o *sqs.Options) {
+		if opts.Endpoint != "" {
+			o.BaseEndpoint = aws.String(opts.Endpoint)
+		}
+	})
+	return &SQSQueue{Client: client, URL: url}, nil
+}
+
+// Receive waits up to 20 seconds for up to 10 messages.
+func (q *SQSQueue) Receive(ctx context.Context) ([]Message, error) {
+	out, err := q.Client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+		QueueUrl:            aws.String(q.URL),
+		MaxNumberOfMessages: 10,
+		WaitTimeSeconds:     20,
+	})
+	if err != nil {
+		return nil, err
+	}
+	msgs := make([]Message, len(out.Messages))
+	for i, m := range out.Messages {
+		msgs[i] = Message{
+			Body:   aws.ToString(m.Body),
+			Handle: aws.ToString(m.ReceiptHandle),
+		}
+	}
+	return msgs, nil
+}
+
+func (q *SQSQueue) Delete(ctx context.Context, msg Message) error {
+	_, err := q.Client.DeleteMessage(ctx, &sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(q.URL),
+		ReceiptHandle: aws.String(msg.Handle),
+	})
+	return err
+}
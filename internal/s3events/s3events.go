@@ -0,0 +1,120 @@
+// Package s3events keeps the index of storage roots in S3 buckets up to date
+// using S3 event notifications, received from an SQS queue (see Poll) or a
+// webhook (see Handler.Webhook).
+package s3events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"github.com/srerickson/ocfl-services/access"
+)
+
+// Event is an S3 event for an object in a bucket.
+type Event struct {
+	Name   string // event name without the "s3:" prefix, e.g., "ObjectCreated:Put"
+	Bucket string
+	Key    string // object key, unescaped
+}
+
+// notification is an S3 event notification message. Messages delivered
+// through SNS are wrapped in an SNS notification with the S3 message in
+// Message.
+type notification struct {
+	Records []struct {
+		EventName string `json:"eventName"`
+		S3        struct {
+			Bucket struct {
+				Name string `json:"name"`
+			} `json:"bucket"`
+			Object struct {
+				Key string `json:"key"`
+			} `json:"object"`
+		} `json:"s3"`
+	} `json:"Records"`
+
+	Type    string `json:"Type"`    // "Notification" for SNS messages
+	Message string `json:"Message"` // SNS message
+}
+
+// ParseNotification returns the events in an S3 event notification message,
+// as sent by S3 to an SQS queue or an SNS topic, or by S3-compatible services
+// to a webhook. Test events, which have no records, return no events.
+func ParseNotification(data []byte) ([]Event, error) {
+	var msg notification
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, fmt.Errorf("parsing S3 event notification: %w", err)
+	}
+	if msg.Type == "Notification" && len(msg.Records) == 0 {
+		return ParseNotification([]byte(msg.Message))
+	}
+	events := make([]Event, len(msg.Records))
+	for i, rec := range msg.Records {
+		// keys are URL-encoded, with '+' for spaces
+		key, err := url.QueryUnescape(rec.S3.Object.Key)
+		if err != nil {
+			return nil, fmt.Errorf("parsing S3 event notification: invalid object key %q: %w", rec.S3.Object.Key, err)
+		}
+		events[i] = Event{
+			Name:   strings.TrimPrefix(rec.EventName, "s3:"),
+			Bucket: rec.S3.Bucket.Name,
+			Key:    key,
+		}
+	}
+	return events, nil
+}
+
+// Root is a storage root in an S3 bucket that's kept in sync by a Handler.
+type Root struct {
+	Bucket  string          // bucket with the storage root
+	Service *access.Service // service for the storage root
+}
+
+// Handler syncs the index of storage roots with the changes in S3 events.
+type Handler struct {
+	roots  []Root
+	logger *slog.Logger
+}
+
+// NewHandler returns a Handler for events in the roots' buckets. Synced
+// objects and errors are logged with logger; if it's nil, nothing is logged.
+func NewHandler(roots []Root, logger *slog.Logger) *Handler {
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+	return &Handler{roots: roots, logger: logger}
+}
+
+// HandleEvents syncs the objects changed by events for objects created or
+// removed in the handler's roots: see access.Service.SyncChangedFile. Other
+// events are ignored. If objects can't be synced, the errors are logged and
+// returned after all events are handled, so the events can be retried.
+func (h *Handler) HandleEvents(ctx context.Context, events []Event) error {
+	var errs []error
+	for _, ev := range events {
+		if !strings.HasPrefix(ev.Name, "ObjectCreated:") && !strings.HasPrefix(ev.Name, "ObjectRemoved:") {
+			continue
+		}
+		for _, root := range h.roots {
+			if root.Bucket != ev.Bucket {
+				continue
+			}
+			obj, err := root.Service.SyncChangedFile(ctx, ev.Key)
+			switch {
+			case errors.Is(err, access.ErrNotFound):
+				h.logger.Info("object removed by S3 event", "bucket", ev.Bucket, "key", ev.Key)
+			case err != nil:
+				h.logger.Error("syncing object for S3 event", "bucket", ev.Bucket, "key", ev.Key, "error", err)
+				errs = append(errs, err)
+			case obj != nil:
+				h.logger.Info("object synced by S3 event", "object_id", obj.ID(), "bucket", ev.Bucket, "key", ev.Key)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,148 @@
+package s3events_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/carlmjohnson/be"
+	"github.com/srerickson/ocfl-services/access"
+	"github.com/srerickson/ocfl-services/internal/s3events"
+	"github.com/srerickson/ocfl-services/internal/testutil"
+)
+
+const (
+	fixtureObjPath = "reg-extension-dir-root/a47/817/83d/cec/ark%3a123%2fabc"
+	testBucket     = "test-bucket"
+)
+
+// testNotification is an S3 event notification for the fixture object's
+// inventory.
+var testNotification = `{"Records":[{
+	"eventName": "ObjectCreated:Put",
+	"s3": {
+		"bucket": {"name": "` + testBucket + `"},
+		"object": {"key": "` + strings.ReplaceAll(fixtureObjPath, "%", "%25") + `/inventory.json"}
+	}
+}]}`
+
+func TestParseNotification(t *testing.T) {
+	t.Run("S3", func(t *testing.T) {
+		events, err := s3events.ParseNotification([]byte(testNotification))
+		be.NilErr(t, err)
+		be.Equal(t, 1, len(events))
+		be.Equal(t, s3events.Event{
+			Name:   "ObjectCreated:Put",
+			Bucket: testBucket,
+			Key:    fixtureObjPath + "/inventory.json",
+		}, events[0])
+	})
+	t.Run("SNS", func(t *testing.T) {
+		msg, err := json.Marshal(map[string]string{"Type": "Notification", "Message": testNotification})
+		be.NilErr(t, err)
+		events, err := s3events.ParseNotification(msg)
+		be.NilErr(t, err)
+		be.Equal(t, 1, len(events))
+		be.Equal(t, fixtureObjPath+"/inventory.json", events[0].Key)
+	})
+	t.Run("webhook", func(t *testing.T) {
+		msg := `{"EventName":"s3:ObjectRemoved:Delete","Key":"b/a b/inventory.json","Records":[{
+			"eventName":"s3:ObjectRemoved:Delete",
+			"s3":{"bucket":{"name":"b"},"object":{"key":"a+b%2Binventory.json"}}}]}`
+		events, err := s3events.ParseNotification([]byte(msg))
+		be.NilErr(t, err)
+		be.Equal(t, s3events.Event{Name: "ObjectRemoved:Delete", Bucket: "b", Key: "a b+inventory.json"}, events[0])
+	})
+	t.Run("test event", func(t *testing.T) {
+		events, err := s3events.ParseNotification([]byte(`{"Service":"Amazon S3","Event":"s3:TestEvent"}`))
+		be.NilErr(t, err)
+		be.Equal(t, 0, len(events))
+	})
+	t.Run("invalid", func(t *testing.T) {
+		_, err := s3events.ParseNotification([]byte(`Records`))
+		be.Nonzero(t, err)
+	})
+}
+
+func TestWebhook(t *testing.T) {
+	svc := testService(t)
+	h := s3events.NewHandler([]s3events.Root{{Bucket: testBucket, Service: svc}}, nil).Webhook("s3cr3t")
+	post := func(token string, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+	be.Equal(t, http.StatusUnauthorized, post("", testNotification).Code)
+	be.Equal(t, http.StatusUnauthorized, post("wrong", testNotification).Code)
+	be.Equal(t, http.StatusBadRequest, post("s3cr3t", "{").Code)
+	be.Equal(t, 0, testutil.NumObjects(t, svc))
+
+	// events for other buckets are ignored
+	other := strings.Replace(testNotification, testBucket, "other-bucket", 1)
+	be.Equal(t, http.StatusNoContent, post("s3cr3t", other).Code)
+	be.Equal(t, 0, testutil.NumObjects(t, svc))
+
+	be.Equal(t, http.StatusNoContent, post("s3cr3t", testNotification).Code)
+	be.Equal(t, 1, testutil.NumObjects(t, svc))
+}
+
+func TestPoll(t *testing.T) {
+	svc := testService(t)
+	h := s3events.NewHandler([]s3events.Root{{Bucket: testBucket, Service: svc}}, nil)
+	ctx, cancel := context.WithCancel(t.Context())
+	queue := &testQueue{
+		msgs: []s3events.Message{
+			{Body: "invalid", Handle: "1"},
+			{Body: testNotification, Handle: "2"},
+		},
+		cancel: cancel,
+	}
+	err := h.Poll(ctx, queue)
+	be.Equal(t, context.Canceled, err)
+	be.AllEqual(t, []string{"1", "2"}, queue.deleted)
+	be.Equal(t, 1, testutil.NumObjects(t, svc))
+}
+
+// testQueue is a stand-in s3events.Queue. Its messages are returned by the
+// first call to Receive, and later calls cancel the context used for Poll.
+type testQueue struct {
+	mu      sync.Mutex
+	msgs    []s3events.Message
+	deleted []string // handles of deleted messages
+	cancel  context.CancelFunc
+}
+
+func (q *testQueue) Receive(ctx context.Context) ([]s3events.Message, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	msgs := q.msgs
+	q.msgs = nil
+	if len(msgs) == 0 {
+		q.cancel()
+		return nil, ctx.Err()
+	}
+	return msgs, nil
+}
+
+func (q *testQueue) Delete(_ context.Context, msg s3events.Message) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.deleted = append(q.deleted, msg.Handle)
+	return nil
+}
+
+// testService returns a service for a copy of the fixture storage root that
+// hasn't been indexed.
+func testService(t *testing.T) *access.Service {
+	t.Helper()
+	return testutil.FixtureService(t, filepath.Join("..", "..", "testdata"), access.WithSyncMode(access.SyncOnEvent))
+}
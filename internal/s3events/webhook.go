@@ -0,0 +1,45 @@
+package s3events
+
+import (
+	"crypto/subtle"
+	"io"
+	"net/http"
+)
+
+// max size of a webhook request body
+const maxWebhookBody = 1024 * 1024 // 1 MiB
+
+// Webhook returns a handler for POST requests with S3 event notification
+// messages in the body, e.g., from an S3-compatible service's webhook target.
+// Requests must have the header "Authorization: Bearer {token}". It responds
+// with status 204 if the events were handled, 400 if the message can't be
+// parsed, and 500 if objects couldn't be synced, so the sender can retry.
+func (h *Handler) Webhook(token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		got := []byte(r.Header.Get("Authorization"))
+		if token == "" || subtle.ConstantTimeCompare(got, []byte("Bearer "+token)) != 1 {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+		if err != nil {
+			http.Error(w, "reading request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		events, err := ParseNotification(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := h.HandleEvents(r.Context(), events); err != nil {
+			http.Error(w, "some objects couldn't be synced", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
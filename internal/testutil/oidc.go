@@ -0,0 +1,163 @@
+package testutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+// OIDCProvider is a stub OpenID Connect provider for testing. It approves
+// every authorization request without a login page and issues ES256-signed
+// ID tokens.
+type OIDCProvider struct {
+	*httptest.Server
+	ClientID     string
+	ClientSecret string
+	Claims       map[string]any // claims added to issued ID tokens, e.g., "sub"
+
+	key   *ecdsa.PrivateKey
+	mu    sync.Mutex
+	codes map[string]oidcAuthRequest // pending authorization codes
+}
+
+type oidcAuthRequest struct {
+	redirectURI string
+	nonce       string
+	challenge   string
+}
+
+// NewOIDCProvider starts a stub OpenID Connect provider that is closed when
+// the test ends. Its issuer URL is the server's URL.
+func NewOIDCProvider(t *testing.T) *OIDCProvider {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &OIDCProvider{
+		ClientID:     "test-client",
+		ClientSecret: "test-secret",
+		Claims:       map[string]any{"sub": "user-1"},
+		key:          key,
+		codes:        map[string]oidcAuthRequest{},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", p.handleDiscovery)
+	mux.HandleFunc("GET /authorize", p.handleAuthorize)
+	mux.HandleFunc("POST /token", p.handleToken)
+	mux.HandleFunc("GET /jwks", p.handleKeys)
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+func (p *OIDCProvider) handleDiscovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                 p.URL,
+		"authorization_endpoint": p.URL + "/authorize",
+		"token_endpoint":         p.URL + "/token",
+		"jwks_uri":               p.URL + "/jwks",
+
+		"id_token_signing_alg_values_supported": []string{"ES256"},
+	})
+}
+
+// handleAuthorize redirects to the client's redirect URI with an
+// authorization code.
+func (p *OIDCProvider) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != p.ClientID || q.Get("response_type") != "code" ||
+		q.Get("code_challenge_method") != "S256" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+	code := rand.Text()
+	p.mu.Lock()
+	p.codes[code] = oidcAuthRequest{
+		redirectURI: q.Get("redirect_uri"),
+		nonce:       q.Get("nonce"),
+		challenge:   q.Get("code_challenge"),
+	}
+	p.mu.Unlock()
+	redirect := q.Get("redirect_uri") + "?" + url.Values{"code": {code}, "state": {q.Get("state")}}.Encode()
+	http.Redirect(w, r, redirect, http.StatusFound)
+}
+
+// handleToken exchanges an authorization code for an ID token.
+func (p *OIDCProvider) handleToken(w http.ResponseWriter, r *http.Request) {
+	id, secret, _ := r.BasicAuth()
+	if id != p.ClientID || secret != p.ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+	p.mu.Lock()
+	req, ok := p.codes[r.FormValue("code")]
+	delete(p.codes, r.FormValue("code"))
+	p.mu.Unlock()
+	verifier := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+	if !ok || r.FormValue("redirect_uri") != req.redirectURI ||
+		base64.RawURLEncoding.EncodeToString(verifier[:]) != req.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+	claims := map[string]any{
+		"iss":   p.URL,
+		"aud":   p.ClientID,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"iat":   time.Now().Unix(),
+		"nonce": req.nonce,
+	}
+	for k, v := range p.Claims {
+		claims[k] = v
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": rand.Text(),
+		"token_type":   "Bearer",
+		"id_token":     p.signJWT(claims),
+	})
+}
+
+func (p *OIDCProvider) handleKeys(w http.ResponseWriter, r *http.Request) {
+	point, _ := p.key.PublicKey.Bytes()
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "EC",
+			"kid": "test-key",
+			"use": "sig",
+			"alg": "ES256",
+			"crv": "P-256",
+			"x":   base64.RawURLEncoding.EncodeToString(point[1:33]),
+			"y":   base64.RawURLEncoding.EncodeToString(point[33:]),
+		}},
+	})
+}
+
+func (p *OIDCProvider) signJWT(claims map[string]any) string {
+	header, _ := json.Marshal(map[string]string{"alg": "ES256", "kid": "test-key", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	r, s, err := ecdsa.Sign(rand.Reader, p.key, digest[:])
+	if err != nil {
+		panic(err)
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -10,6 +10,8 @@ import (
 
 	"github.com/srerickson/ocfl-go"
 	"github.com/srerickson/ocfl-go/fs/local"
+	"github.com/srerickson/ocfl-services/access"
+	"github.com/srerickson/ocfl-services/access/sqlite"
 )
 
 // make a copy of the reg-extension-dir-root fixture root in a temporary
@@ -45,7 +47,35 @@ func LocalFS(t *testing.T, srcDirs ...string) *local.FS {
 	return fsys
 }
 
-// TestDatabase
+// NewDB returns a new database in a temporary directory. The database is
+// closed when the test ends.
+func NewDB(t *testing.T) *sqlite.DB {
+	t.Helper()
+	db, err := sqlite.NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal("setting up test db:", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// FixtureService returns a service for a copy of the fixture root in
+// testData (see FixtureRootCopy) with a new database, in which the fixture
+// root hasn't been indexed. The root ID is "test-root".
+func FixtureService(t *testing.T, testData string, opts ...access.ServiceOption) *access.Service {
+	t.Helper()
+	return access.NewService(FixtureRootCopy(t, testData), NewDB(t), "test-root", nil, opts...)
+}
+
+// NumObjects returns the number of objects indexed for the service's root.
+func NumObjects(t *testing.T, svc *access.Service) int {
+	t.Helper()
+	m, err := svc.Metrics(t.Context())
+	if err != nil {
+		t.Fatal("getting index metrics:", err)
+	}
+	return m.NumObjects
+}
 
 func DigestSHA256(b []byte) string {
 	h := sha256.New()
@@ -0,0 +1,237 @@
+// Package textdiff computes line-level differences between two texts.
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Op is a line's edit operation.
+type Op int
+
+const (
+	Equal  Op = iota // line is in both texts
+	Delete           // line is only in the old text
+	Insert           // line is only in the new text
+)
+
+// maxEdits is the max number of inserted and deleted lines for which Diff
+// finds a minimal edit script. Beyond this, the texts are treated as entirely
+// different.
+const maxEdits = 1000
+
+// Line is a line in an edit script.
+type Line struct {
+	Op     Op
+	Text   string
+	OldNum int // line number in the old text (starting from 1), or 0 for inserted lines
+	NewNum int // line number in the new text (starting from 1), or 0 for deleted lines
+}
+
+// Hunk is a group of changed lines with surrounding unchanged lines.
+type Hunk struct {
+	OldStart int // first line number in the old text
+	OldLines int // number of lines from the old text
+	NewStart int // first line number in the new text
+	NewLines int // number of lines from the new text
+	Lines    []Line
+}
+
+// Header returns the hunk's range information in unified diff format, e.g.,
+// "@@ -1,4 +1,5 @@".
+func (h Hunk) Header() string {
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+}
+
+// SplitLines splits text into lines, without line endings. A final line ending
+// doesn't start a new line.
+func SplitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	text = strings.TrimSuffix(text, "\n")
+	lines := strings.Split(text, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSuffix(l, "\r")
+	}
+	return lines
+}
+
+// Diff returns an edit script for transforming the old lines into the new
+// lines. The script includes every line from both texts.
+func Diff(old, new []string) []Line {
+	// common prefix and suffix aren't part of the search
+	prefix := 0
+	for prefix < len(old) && prefix < len(new) && old[prefix] == new[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(old)-prefix && suffix < len(new)-prefix &&
+		old[len(old)-1-suffix] == new[len(new)-1-suffix] {
+		suffix++
+	}
+	ops := make([]Op, 0, len(old)+len(new))
+	for range prefix {
+		ops = append(ops, Equal)
+	}
+	ops = append(ops, editOps(old[prefix:len(old)-suffix], new[prefix:len(new)-suffix])...)
+	for range suffix {
+		ops = append(ops, Equal)
+	}
+	lines := make([]Line, len(ops))
+	var i, j int
+	for n, op := range ops {
+		switch op {
+		case Equal:
+			lines[n] = Line{Op: Equal, Text: new[j], OldNum: i + 1, NewNum: j + 1}
+			i++
+			j++
+		case Delete:
+			lines[n] = Line{Op: Delete, Text: old[i], OldNum: i + 1}
+			i++
+		case Insert:
+			lines[n] = Line{Op: Insert, Text: new[j], NewNum: j + 1}
+			j++
+		}
+	}
+	return lines
+}
+
+// editOps returns a minimal sequence of operations for transforming a into b
+// using Myers' algorithm. If more than maxEdits operations are needed, all
+// lines in a are deleted and all lines in b are inserted.
+func editOps(a, b []string) []Op {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return replaceOps(n, m)
+	}
+	limit := min(n+m, maxEdits)
+	offset := limit + 1
+	v := make([]int, 2*limit+3) // furthest x for each diagonal k, at v[offset+k]
+	// trace[d] is the part of v used by step d: diagonals -d-1 to d+1.
+	var trace [][]int
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, n, m)
+			}
+		}
+	}
+	return replaceOps(n, m)
+}
+
+func backtrack(trace [][]int, n, m int) []Op {
+	var ops []Op
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		at := func(k int) int { return v[k+d+1] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			ops = append(ops, Equal)
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				ops = append(ops, Insert)
+			} else {
+				ops = append(ops, Delete)
+			}
+		}
+		x, y = prevX, prevY
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+func replaceOps(n, m int) []Op {
+	ops := make([]Op, 0, n+m)
+	for range n {
+		ops = append(ops, Delete)
+	}
+	for range m {
+		ops = append(ops, Insert)
+	}
+	return ops
+}
+
+// Hunks groups changed lines in an edit script into hunks with up to context
+// unchanged lines before and after each change. Changes separated by no more
+// than twice the context are in the same hunk.
+func Hunks(lines []Line, context int) []Hunk {
+	var hunks []Hunk
+	for i := 0; i < len(lines); {
+		if lines[i].Op == Equal {
+			i++
+			continue
+		}
+		start, end := max(0, i-context), i+1
+		for j := end; j < len(lines); j++ {
+			if lines[j].Op != Equal {
+				end = j + 1
+				continue
+			}
+			if j-end >= 2*context {
+				break
+			}
+		}
+		end = min(len(lines), end+context)
+		hunks = append(hunks, newHunk(lines, start, end))
+		i = end
+	}
+	return hunks
+}
+
+func newHunk(lines []Line, start, end int) Hunk {
+	h := Hunk{Lines: lines[start:end]}
+	// last line numbers before the hunk
+	for _, l := range lines[:start] {
+		if l.OldNum > 0 {
+			h.OldStart = l.OldNum
+		}
+		if l.NewNum > 0 {
+			h.NewStart = l.NewNum
+		}
+	}
+	for _, l := range h.Lines {
+		if l.OldNum > 0 {
+			h.OldLines++
+		}
+		if l.NewNum > 0 {
+			h.NewLines++
+		}
+	}
+	// As in unified diffs, if a hunk has no lines from a text, its start is
+	// the line before the hunk.
+	if h.OldLines > 0 {
+		h.OldStart++
+	}
+	if h.NewLines > 0 {
+		h.NewStart++
+	}
+	return h
+}
@@ -0,0 +1,138 @@
+package textdiff_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/carlmjohnson/be"
+	"github.com/srerickson/ocfl-services/internal/textdiff"
+)
+
+// unified formats an edit script like the body of a unified diff.
+func unified(lines []textdiff.Line) string {
+	var b strings.Builder
+	for _, l := range lines {
+		switch l.Op {
+		case textdiff.Equal:
+			b.WriteString(" ")
+		case textdiff.Delete:
+			b.WriteString("-")
+		case textdiff.Insert:
+			b.WriteString("+")
+		}
+		b.WriteString(l.Text + "\n")
+	}
+	return b.String()
+}
+
+func TestSplitLines(t *testing.T) {
+	be.Equal(t, 0, len(textdiff.SplitLines("")))
+	be.AllEqual(t, []string{"a", "b"}, textdiff.SplitLines("a\nb\n"))
+	be.AllEqual(t, []string{"a", "", "b"}, textdiff.SplitLines("a\r\n\r\nb"))
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     string
+	}{
+		{
+			name: "equal",
+			old:  "a\nb\n",
+			new:  "a\nb\n",
+			want: " a\n b\n",
+		},
+		{
+			name: "added file",
+			old:  "",
+			new:  "a\nb\n",
+			want: "+a\n+b\n",
+		},
+		{
+			name: "deleted file",
+			old:  "a\nb\n",
+			new:  "",
+			want: "-a\n-b\n",
+		},
+		{
+			name: "modified line",
+			old:  "a\nb\nc\n",
+			new:  "a\nB\nc\n",
+			want: " a\n-b\n+B\n c\n",
+		},
+		{
+			name: "insert and delete",
+			old:  "a\nb\nc\nd\ne\n",
+			new:  "b\nc\nx\nd\ne\nf\n",
+			want: "-a\n b\n c\n+x\n d\n e\n+f\n",
+		},
+		{
+			name: "myers example",
+			old:  "A\nB\nC\nA\nB\nB\nA\n",
+			new:  "C\nB\nA\nB\nA\nC\n",
+			want: "-A\n-B\n C\n+B\n A\n B\n-B\n A\n+C\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := textdiff.Diff(textdiff.SplitLines(tt.old), textdiff.SplitLines(tt.new))
+			be.Equal(t, tt.want, unified(lines))
+			// line numbers
+			var oldNum, newNum int
+			for _, l := range lines {
+				if l.Op != textdiff.Insert {
+					oldNum++
+					be.Equal(t, oldNum, l.OldNum)
+				}
+				if l.Op != textdiff.Delete {
+					newNum++
+					be.Equal(t, newNum, l.NewNum)
+				}
+			}
+		})
+	}
+}
+
+func TestDiffTooManyEdits(t *testing.T) {
+	var old, new []string
+	for i := range 2000 {
+		old = append(old, fmt.Sprint("old", i))
+		new = append(new, fmt.Sprint("new", i))
+		if i%2 == 0 {
+			old = append(old, "same")
+			new = append(new, "same")
+		}
+	}
+	lines := textdiff.Diff(old, new)
+	be.Equal(t, len(old)+len(new), len(lines))
+	be.Equal(t, textdiff.Delete, lines[0].Op)
+	be.Equal(t, textdiff.Insert, lines[len(lines)-1].Op)
+}
+
+func TestHunks(t *testing.T) {
+	var old []string
+	for i := 1; i <= 20; i++ {
+		old = append(old, fmt.Sprint(i))
+	}
+	new := append([]string(nil), old...)
+	new[1] = "two"   // line 2
+	new[4] = "five"  // line 5: within 2*context of line 2
+	new[16] = "17.0" // line 17: separate hunk
+	new = append(new, "21")
+	hunks := textdiff.Hunks(textdiff.Diff(old, new), 3)
+	be.Equal(t, 2, len(hunks))
+	be.Equal(t, "@@ -1,8 +1,8 @@", hunks[0].Header())
+	be.Equal(t, "@@ -14,7 +14,8 @@", hunks[1].Header())
+	be.Equal(t, "14", hunks[1].Lines[0].Text)
+	be.Equal(t, "21", hunks[1].Lines[len(hunks[1].Lines)-1].Text)
+
+	// file added
+	hunks = textdiff.Hunks(textdiff.Diff(nil, []string{"a"}), 3)
+	be.Equal(t, 1, len(hunks))
+	be.Equal(t, "@@ -0,0 +1,1 @@", hunks[0].Header())
+
+	// no changes
+	be.Equal(t, 0, len(textdiff.Hunks(textdiff.Diff(old, old), 3)))
+}
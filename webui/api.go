@@ -0,0 +1,620 @@
+package server
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/srerickson/ocfl-go"
+	"github.com/srerickson/ocfl-services/access"
+	"github.com/srerickson/ocfl-services/ingest"
+	"github.com/srerickson/ocfl-services/webui/utils"
+)
+
+// base path for JSON API routes
+const apiBasePath = "/api/v1"
+
+// default and max number of objects in an API object list response
+const (
+	apiObjectListLimit    = 50
+	apiObjectListMaxLimit = 1000
+)
+
+// default and max number of failures in an API fixity response
+const (
+	apiFixityFailureLimit    = 50
+	apiFixityFailureMaxLimit = 1000
+)
+
+//go:embed openapi.json
+var openAPIDoc []byte
+
+// newAPIMux returns a handler for the JSON API routes. Paths are relative to
+// the API's base path (/api/v1). Routes for uploads, drafts, and creating
+// object versions are only included if staging isn't nil.
+func newAPIMux(svc *access.Service, staging *ingest.Staging) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /openapi.json", HandleAPIOpenAPI())
+	mux.HandleFunc("GET /metrics", HandleAPIMetrics(svc))
+	mux.HandleFunc("GET /objects", HandleAPIListObjects(svc))
+	mux.HandleFunc("GET /objects/{id}", HandleAPIGetObject(svc))
+	mux.HandleFunc("GET /objects/{id}/versions", HandleAPIListVersions(svc))
+	mux.HandleFunc("GET /objects/{id}/versions/{version}", HandleAPIGetVersion(svc))
+	mux.HandleFunc("GET /objects/{id}/versions/{version}/changes", HandleAPIGetVersionChanges(svc))
+	mux.HandleFunc("GET /objects/{id}/versions/{version}/dir/{path...}", HandleAPIReadVersionDir(svc))
+	mux.HandleFunc("GET /objects/{id}/versions/{version}/file/{path...}", HandleAPIStatVersionFile(svc))
+	mux.HandleFunc("GET /objects/{id}/fixity", HandleAPIGetObjectFixity(svc))
+	mux.HandleFunc("GET /fixity", HandleAPIGetFixity(svc))
+	mux.HandleFunc("GET /objects/{id}/validation", HandleAPIGetObjectValidation(svc))
+	mux.Handle("POST /objects/{id}/validation", http.NewCrossOriginProtection().Handler(
+		HandleAPIValidateObject(svc)))
+	mux.HandleFunc("GET /validation", HandleAPIListInvalidObjects(svc))
+	mux.Handle("POST /validation", http.NewCrossOriginProtection().Handler(
+		HandleAPIValidateRoot(svc)))
+	if staging != nil {
+		csrf := http.NewCrossOriginProtection()
+		mux.Handle("POST /uploads", csrf.Handler(HandleAPICreateUpload(svc, staging)))
+		mux.HandleFunc("GET /uploads/{upload}", HandleAPIGetUpload(svc, staging))
+		mux.Handle("PATCH /uploads/{upload}", csrf.Handler(HandleAPIWriteUpload(svc, staging)))
+		mux.Handle("DELETE /uploads/{upload}", csrf.Handler(HandleAPIDeleteUpload(svc, staging)))
+		mux.Handle("POST /objects/{id}/versions", csrf.Handler(HandleAPICommitVersion(svc, staging)))
+		mux.HandleFunc("GET /drafts", HandleAPIListDrafts(svc))
+		mux.Handle("POST /drafts", csrf.Handler(HandleAPIOpenDraft(svc)))
+		mux.HandleFunc("GET /drafts/{draft}", HandleAPIGetDraft(svc))
+		mux.Handle("DELETE /drafts/{draft}", csrf.Handler(HandleAPIDiscardDraft(svc, staging)))
+		mux.HandleFunc("GET /drafts/{draft}/files", HandleAPIListDraftFiles(svc))
+		mux.Handle("PUT /drafts/{draft}/files/{path...}", csrf.Handler(HandleAPIPutDraftFile(svc, staging)))
+		mux.Handle("DELETE /drafts/{draft}/files/{path...}", csrf.Handler(HandleAPIRemoveDraftPath(svc)))
+		mux.HandleFunc("GET /drafts/{draft}/dir/{path...}", HandleAPIReadDraftDir(svc))
+		mux.Handle("POST /drafts/{draft}/rename", csrf.Handler(HandleAPIRenameDraftPath(svc)))
+		mux.Handle("POST /drafts/{draft}/commit", csrf.Handler(HandleAPICommitDraft(svc, staging)))
+	}
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeAPIError(w, http.StatusNotFound, "no API route for "+r.URL.Path)
+	})
+	return mux
+}
+
+// apiError is the error object included in all API error responses.
+type apiError struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
+
+type apiMetrics struct {
+	NumObjects *int `json:"num_objects"` // nil if hidden by the access policy
+}
+
+type apiObject struct {
+	ID              string    `json:"id"`
+	StoragePath     string    `json:"storage_path"`
+	Head            string    `json:"head"`
+	DigestAlgorithm string    `json:"digest_algorithm"`
+	InventoryDigest string    `json:"inventory_digest"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	IndexedAt       time.Time `json:"indexed_at"`
+}
+
+type apiObjectList struct {
+	Offset  int          `json:"offset"`
+	Limit   int          `json:"limit"`
+	Total   *int         `json:"total"` // nil if hidden by the access policy
+	Objects []*apiObject `json:"objects"`
+}
+
+type apiVersion struct {
+	Version     string    `json:"version"`
+	Created     time.Time `json:"created"`
+	Message     string    `json:"message"`
+	UserName    string    `json:"user_name"`
+	UserAddress string    `json:"user_address"`
+}
+
+type apiVersionList struct {
+	ObjectID string        `json:"object_id"`
+	Versions []*apiVersion `json:"versions"`
+}
+
+type apiFileChange struct {
+	Path   string `json:"path"`
+	Type   string `json:"type"`
+	Source string `json:"source,omitempty"` // path of renamed or copied content
+}
+
+type apiVersionChanges struct {
+	ObjectID string           `json:"object_id"`
+	From     string           `json:"from"` // empty if changes are from an empty state
+	To       string           `json:"to"`
+	Changes  []*apiFileChange `json:"changes"`
+}
+
+type apiDirEntry struct {
+	Name            string    `json:"name"`
+	IsDir           bool      `json:"is_dir"`
+	Digest          string    `json:"digest,omitempty"`
+	Size            *int64    `json:"size,omitempty"` // nil if the size isn't known
+	ModifiedVersion int       `json:"modified_version"`
+	Modified        time.Time `json:"modified"`
+}
+
+type apiVersionDir struct {
+	ObjectID string         `json:"object_id"`
+	Version  string         `json:"version"`
+	Path     string         `json:"path"`
+	Entries  []*apiDirEntry `json:"entries"`
+}
+
+type apiVersionFile struct {
+	ObjectID        string    `json:"object_id"`
+	Version         string    `json:"version"`
+	Path            string    `json:"path"`
+	Digest          string    `json:"digest"`
+	ContentPath     string    `json:"content_path"`
+	Size            *int64    `json:"size,omitempty"` // nil if the size isn't known
+	MediaType       string    `json:"media_type,omitempty"`
+	ModifiedVersion int       `json:"modified_version"`
+	Modified        time.Time `json:"modified"`
+}
+
+type apiFixityFile struct {
+	ObjectID    string     `json:"object_id,omitempty"`
+	ContentPath string     `json:"content_path"`
+	Digest      string     `json:"digest"`
+	Size        *int64     `json:"size,omitempty"`  // nil if the size isn't known
+	Status      string     `json:"status"`          // "pass", "fail", "error", or "" if not checked
+	Error       string     `json:"error,omitempty"` // reason for a failed check or error
+	CheckedAt   *time.Time `json:"checked_at"`      // nil if not checked
+}
+
+type apiObjectFixity struct {
+	ObjectID string           `json:"object_id"`
+	Files    []*apiFixityFile `json:"files"`
+}
+
+type apiFixity struct {
+	NumFiles    *int             `json:"num_files"` // counts are nil if hidden by the access policy
+	NumChecked  *int             `json:"num_checked"`
+	NumFailed   *int             `json:"num_failed"`
+	OldestCheck *time.Time       `json:"oldest_check"` // nil if any file hasn't been checked
+	Failures    []*apiFixityFile `json:"failures"`
+}
+
+func HandleAPIOpenAPI() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Length", strconv.Itoa(len(openAPIDoc)))
+		w.Write(openAPIDoc)
+	}
+}
+
+func HandleAPIMetrics(svc *access.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		metrics, err := svc.Metrics(r.Context())
+		if err != nil && !errors.Is(err, access.ErrCountsHidden) {
+			apiServiceError(w, r, svc, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, &apiMetrics{NumObjects: apiCount(metrics.NumObjects, err)})
+	}
+}
+
+func HandleAPIListObjects(svc *access.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		query := r.URL.Query()
+		sort, err := access.ParseObjectSort(query.Get("sort"))
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		var desc bool
+		switch order := query.Get("order"); order {
+		case "", "asc":
+		case "desc":
+			desc = true
+		default:
+			writeAPIError(w, http.StatusBadRequest, fmt.Sprintf("invalid sort order: %q", order))
+			return
+		}
+		list := &apiObjectList{Limit: apiObjectListLimit}
+		if val := query.Get("offset"); val != "" {
+			list.Offset, err = strconv.Atoi(val)
+			if err != nil || list.Offset < 0 {
+				writeAPIError(w, http.StatusBadRequest, fmt.Sprintf("invalid offset: %q", val))
+				return
+			}
+		}
+		if val := query.Get("limit"); val != "" {
+			list.Limit, err = strconv.Atoi(val)
+			if err != nil || list.Limit < 1 || list.Limit > apiObjectListMaxLimit {
+				writeAPIError(w, http.StatusBadRequest, fmt.Sprintf("invalid limit: %q", val))
+				return
+			}
+		}
+		metrics, err := svc.Metrics(ctx)
+		if err != nil && !errors.Is(err, access.ErrCountsHidden) {
+			apiServiceError(w, r, svc, err)
+			return
+		}
+		list.Total = apiCount(metrics.NumObjects, err)
+		objects, err := svc.ListObjects(ctx, access.ListObjectOptions{
+			Offset: list.Offset,
+			Limit:  list.Limit,
+			Sort:   sort,
+			Desc:   desc,
+		})
+		if err != nil {
+			apiServiceError(w, r, svc, err)
+			return
+		}
+		list.Objects = make([]*apiObject, len(objects))
+		for i, obj := range objects {
+			list.Objects[i] = newAPIObject(obj)
+		}
+		writeJSON(w, http.StatusOK, list)
+	}
+}
+
+func HandleAPIGetObject(svc *access.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		obj, err := svc.SyncObject(r.Context(), r.PathValue("id"))
+		if err != nil {
+			apiServiceError(w, r, svc, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, newAPIObject(obj))
+	}
+}
+
+func HandleAPIListVersions(svc *access.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		versions, err := svc.ListVersions(r.Context(), id)
+		if err != nil {
+			apiServiceError(w, r, svc, err)
+			return
+		}
+		list := &apiVersionList{
+			ObjectID: id,
+			Versions: make([]*apiVersion, len(versions)),
+		}
+		for i, v := range versions {
+			list.Versions[i] = newAPIVersion(v)
+		}
+		writeJSON(w, http.StatusOK, list)
+	}
+}
+
+func HandleAPIGetVersion(svc *access.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vn, err := parseVersionRef(r.PathValue("version"))
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		version, err := svc.GetVersionInfo(r.Context(), r.PathValue("id"), vn.Num())
+		if err != nil {
+			apiServiceError(w, r, svc, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, newAPIVersion(version))
+	}
+}
+
+// HandleAPIGetVersionChanges responds with changes between a version and the
+// version given by the "from" query parameter, which defaults to the previous
+// version.
+func HandleAPIGetVersionChanges(svc *access.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		id := r.PathValue("id")
+		toV, err := parseVersionRef(r.PathValue("version"))
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		var fromV ocfl.VNum
+		if val := r.URL.Query().Get("from"); val != "" {
+			if err := ocfl.ParseVNum(val, &fromV); err != nil {
+				writeAPIError(w, http.StatusBadRequest, fmt.Sprintf("invalid from version: %q", val))
+				return
+			}
+		}
+		to, err := svc.GetVersionInfo(ctx, id, toV.Num())
+		if err != nil {
+			apiServiceError(w, r, svc, err)
+			return
+		}
+		toV = to.VNum()
+		if fromV.IsZero() && toV.Num() > 1 {
+			fromV = ocfl.V(toV.Num()-1, toV.Padding())
+		}
+		changes, err := svc.GetVersionChanges(ctx, id, fromV.Num(), toV.Num())
+		if err != nil {
+			apiServiceError(w, r, svc, err)
+			return
+		}
+		result := &apiVersionChanges{
+			ObjectID: id,
+			To:       toV.String(),
+			Changes:  make([]*apiFileChange, len(changes)),
+		}
+		if !fromV.IsZero() {
+			result.From = fromV.String()
+		}
+		for i, c := range changes {
+			result.Changes[i] = &apiFileChange{Path: c.Path(), Type: c.Type(), Source: c.SourcePath()}
+		}
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+func HandleAPIReadVersionDir(svc *access.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		id := r.PathValue("id")
+		vn, err := parseVersionRef(r.PathValue("version"))
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		dir := path.Clean(r.PathValue("path"))
+		if !fs.ValidPath(dir) {
+			writeAPIError(w, http.StatusBadRequest, fmt.Sprintf("invalid path: %q", r.PathValue("path")))
+			return
+		}
+		if vn.IsZero() {
+			obj, err := svc.SyncObject(ctx, id)
+			if err != nil {
+				apiServiceError(w, r, svc, err)
+				return
+			}
+			vn = obj.Head()
+		}
+		entries, err := svc.ReadVersionDir(ctx, id, vn.Num(), dir)
+		if err != nil {
+			apiServiceError(w, r, svc, err)
+			return
+		}
+		sortVersionDirEntries(entries)
+		result := &apiVersionDir{
+			ObjectID: id,
+			Version:  vn.String(),
+			Path:     dir,
+			Entries:  make([]*apiDirEntry, len(entries)),
+		}
+		for i, entry := range entries {
+			result.Entries[i] = &apiDirEntry{
+				Name:            entry.Name(),
+				IsDir:           entry.IsDir(),
+				Digest:          entry.Digest(),
+				Size:            apiSize(entry.Size(), entry.HasSize()),
+				ModifiedVersion: entry.ModVNum(),
+				Modified:        entry.Modtime(),
+			}
+		}
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+func HandleAPIStatVersionFile(svc *access.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		id := r.PathValue("id")
+		vn, err := parseVersionRef(r.PathValue("version"))
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		name := r.PathValue("path")
+		if !fs.ValidPath(name) || name == "." {
+			writeAPIError(w, http.StatusBadRequest, fmt.Sprintf("invalid path: %q", name))
+			return
+		}
+		if vn.IsZero() {
+			obj, err := svc.SyncObject(ctx, id)
+			if err != nil {
+				apiServiceError(w, r, svc, err)
+				return
+			}
+			vn = obj.Head()
+		}
+		info, err := svc.StatVersionFile(ctx, id, vn.Num(), name)
+		if err != nil {
+			apiServiceError(w, r, svc, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, &apiVersionFile{
+			ObjectID:        id,
+			Version:         vn.String(),
+			Path:            info.Path(),
+			Digest:          info.Digest(),
+			ContentPath:     info.ContentPath(),
+			Size:            apiSize(info.Size(), info.HasSize()),
+			MediaType:       info.MediaType(),
+			ModifiedVersion: info.ModVNum(),
+			Modified:        info.Modtime(),
+		})
+	}
+}
+
+func HandleAPIGetObjectFixity(svc *access.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		var status access.FixityStatus
+		switch val := r.URL.Query().Get("status"); val {
+		case "":
+		case string(access.FixityPass), string(access.FixityFail), string(access.FixityErrored):
+			status = access.FixityStatus(val)
+		default:
+			writeAPIError(w, http.StatusBadRequest, fmt.Sprintf("invalid status: %q", val))
+			return
+		}
+		files, err := svc.ObjectFixity(r.Context(), id, status)
+		if err != nil {
+			apiServiceError(w, r, svc, err)
+			return
+		}
+		result := &apiObjectFixity{
+			ObjectID: id,
+			Files:    make([]*apiFixityFile, len(files)),
+		}
+		for i, f := range files {
+			result.Files[i] = newAPIFixityFile(f)
+			result.Files[i].ObjectID = ""
+		}
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+func HandleAPIGetFixity(svc *access.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		limit := apiFixityFailureLimit
+		if val := r.URL.Query().Get("limit"); val != "" {
+			var err error
+			limit, err = strconv.Atoi(val)
+			if err != nil || limit < 1 || limit > apiFixityFailureMaxLimit {
+				writeAPIError(w, http.StatusBadRequest, fmt.Sprintf("invalid limit: %q", val))
+				return
+			}
+		}
+		summary, summaryErr := svc.FixitySummary(ctx)
+		if summaryErr != nil && !errors.Is(summaryErr, access.ErrCountsHidden) {
+			apiServiceError(w, r, svc, summaryErr)
+			return
+		}
+		failures, err := svc.FixityFailures(ctx, limit)
+		if err != nil {
+			apiServiceError(w, r, svc, err)
+			return
+		}
+		result := &apiFixity{
+			NumFiles:    apiCount(summary.NumFiles, summaryErr),
+			NumChecked:  apiCount(summary.NumChecked, summaryErr),
+			NumFailed:   apiCount(summary.NumFailed, summaryErr),
+			OldestCheck: apiTime(summary.OldestCheck),
+			Failures:    make([]*apiFixityFile, len(failures)),
+		}
+		for i, f := range failures {
+			result.Failures[i] = newAPIFixityFile(f)
+		}
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+func newAPIObject(obj access.ObjectInfo) *apiObject {
+	return &apiObject{
+		ID:              obj.ID(),
+		StoragePath:     obj.StoragePath(),
+		Head:            obj.Head().String(),
+		DigestAlgorithm: obj.Alg(),
+		InventoryDigest: obj.InventoryDigest(),
+		CreatedAt:       obj.CreatedAt(),
+		UpdatedAt:       obj.UpdatedAt(),
+		IndexedAt:       obj.IndexedAt(),
+	}
+}
+
+func newAPIVersion(v access.VersionInfo) *apiVersion {
+	return &apiVersion{
+		Version:     v.VNum().String(),
+		Created:     v.Created(),
+		Message:     v.Message(),
+		UserName:    v.UserName(),
+		UserAddress: v.UserAddr(),
+	}
+}
+
+func newAPIFixityFile(f access.FixityInfo) *apiFixityFile {
+	return &apiFixityFile{
+		ObjectID:    f.ObjectID(),
+		ContentPath: f.ContentPath(),
+		Digest:      f.Digest(),
+		Size:        apiSize(f.Size(), f.HasSize()),
+		Status:      string(f.FixityStatus()),
+		Error:       f.FixityError(),
+		CheckedAt:   apiTime(f.FixityCheckedAt()),
+	}
+}
+
+// apiTime returns a pointer to t if it isn't zero.
+func apiTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// apiLocation returns the URL path for p, a path relative to the API's base
+// path, in the API of the storage root for ctx.
+func apiLocation(ctx context.Context, p string) string {
+	return utils.RootPath(ctx) + apiBasePath + p
+}
+
+// apiCount returns a pointer to n, or nil if err (from access.Service.Metrics
+// or FixitySummary) is access.ErrCountsHidden.
+func apiCount(n int, err error) *int {
+	if errors.Is(err, access.ErrCountsHidden) {
+		return nil
+	}
+	return &n
+}
+
+// apiSize returns a pointer to size if hasSize is true.
+func apiSize(size int64, hasSize bool) *int64 {
+	if !hasSize {
+		return nil
+	}
+	return &size
+}
+
+// parseVersionRef parses a version reference from a request path: "head" is
+// parsed as the zero value.
+func parseVersionRef(ref string) (ocfl.VNum, error) {
+	var vn ocfl.VNum
+	if ref == "head" {
+		return vn, nil
+	}
+	if err := ocfl.ParseVNum(ref, &vn); err != nil {
+		return vn, fmt.Errorf("invalid version: %q", ref)
+	}
+	return vn, nil
+}
+
+// apiServiceError writes an error response for an error returned by the
+// access service. ErrNotFound is a 404; other errors are logged and reported
+// as a 500.
+func apiServiceError(w http.ResponseWriter, r *http.Request, svc *access.Service, err error) {
+	if errors.Is(err, access.ErrNotFound) {
+		writeAPIError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	svc.Logger().LogAttrs(r.Context(), slog.LevelError, err.Error(),
+		slog.String("path", r.URL.Path))
+	writeAPIError(w, http.StatusInternalServerError, err.Error())
+}
+
+func writeAPIError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]*apiError{
+		"error": {Status: status, Message: msg},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
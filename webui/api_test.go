@@ -0,0 +1,193 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/carlmjohnson/be"
+)
+
+func apiPath(parts ...string) string {
+	p := "/api/v1"
+	for _, part := range parts {
+		p += "/" + part
+	}
+	return p
+}
+
+// decodeAPI checks the response status and content type and decodes the JSON
+// body into v.
+func decodeAPI(t *testing.T, h http.Handler, path string, status int, v any) {
+	t.Helper()
+	w := doRequest(t, h, http.MethodGet, path)
+	be.Equal(t, status, w.Code)
+	be.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatal("decoding response body:", err)
+	}
+}
+
+type apiErrorBody struct {
+	Error struct {
+		Status  int    `json:"status"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func TestAPI(t *testing.T) {
+	h := testHandler(t)
+	escapedID := url.PathEscape(fixtureObjectID)
+
+	t.Run("openapi document", func(t *testing.T) {
+		var doc struct {
+			OpenAPI string         `json:"openapi"`
+			Paths   map[string]any `json:"paths"`
+		}
+		decodeAPI(t, h, apiPath("openapi.json"), http.StatusOK, &doc)
+		be.Equal(t, "3.0.3", doc.OpenAPI)
+		be.Nonzero(t, doc.Paths["/objects/{id}"])
+	})
+
+	t.Run("get object", func(t *testing.T) {
+		var obj struct {
+			ID   string `json:"id"`
+			Head string `json:"head"`
+		}
+		decodeAPI(t, h, apiPath("objects", escapedID), http.StatusOK, &obj)
+		be.Equal(t, fixtureObjectID, obj.ID)
+		be.Equal(t, "v2", obj.Head)
+	})
+
+	t.Run("list objects and metrics", func(t *testing.T) {
+		var list struct {
+			Total   int `json:"total"`
+			Limit   int `json:"limit"`
+			Objects []struct {
+				ID string `json:"id"`
+			} `json:"objects"`
+		}
+		decodeAPI(t, h, apiPath("objects")+"?sort=updated&order=desc", http.StatusOK, &list)
+		be.Equal(t, 1, list.Total)
+		be.Equal(t, 50, list.Limit)
+		be.Equal(t, 1, len(list.Objects))
+		be.Equal(t, fixtureObjectID, list.Objects[0].ID)
+		var metrics struct {
+			NumObjects int `json:"num_objects"`
+		}
+		decodeAPI(t, h, apiPath("metrics"), http.StatusOK, &metrics)
+		be.Equal(t, 1, metrics.NumObjects)
+	})
+
+	t.Run("list versions", func(t *testing.T) {
+		var list struct {
+			ObjectID string `json:"object_id"`
+			Versions []struct {
+				Version  string `json:"version"`
+				UserName string `json:"user_name"`
+			} `json:"versions"`
+		}
+		decodeAPI(t, h, apiPath("objects", escapedID, "versions"), http.StatusOK, &list)
+		be.Equal(t, fixtureObjectID, list.ObjectID)
+		be.Equal(t, 2, len(list.Versions))
+		be.Equal(t, "v1", list.Versions[0].Version)
+		be.Equal(t, "A Person", list.Versions[0].UserName)
+	})
+
+	t.Run("get version", func(t *testing.T) {
+		var version struct {
+			Version string `json:"version"`
+			Message string `json:"message"`
+		}
+		decodeAPI(t, h, apiPath("objects", escapedID, "versions", "v1"), http.StatusOK, &version)
+		be.Equal(t, "v1", version.Version)
+		be.Equal(t, "An version with one file", version.Message)
+		decodeAPI(t, h, apiPath("objects", escapedID, "versions", "head"), http.StatusOK, &version)
+		be.Equal(t, "v2", version.Version)
+	})
+
+	t.Run("version changes", func(t *testing.T) {
+		type changes struct {
+			From    string `json:"from"`
+			To      string `json:"to"`
+			Changes []struct {
+				Path string `json:"path"`
+				Type string `json:"type"`
+			} `json:"changes"`
+		}
+		var v1 changes
+		decodeAPI(t, h, apiPath("objects", escapedID, "versions", "v1", "changes"), http.StatusOK, &v1)
+		be.Equal(t, "", v1.From)
+		be.Equal(t, "v1", v1.To)
+		be.Equal(t, 1, len(v1.Changes))
+		be.Equal(t, "a_file.txt", v1.Changes[0].Path)
+		be.Equal(t, "added", v1.Changes[0].Type)
+		var head changes
+		decodeAPI(t, h, apiPath("objects", escapedID, "versions", "head", "changes"), http.StatusOK, &head)
+		be.Equal(t, "v1", head.From)
+		be.Equal(t, "v2", head.To)
+		var fromV1 changes
+		decodeAPI(t, h, apiPath("objects", escapedID, "versions", "v2", "changes")+"?from=v1", http.StatusOK, &fromV1)
+		be.Equal(t, len(head.Changes), len(fromV1.Changes))
+	})
+
+	t.Run("read directory", func(t *testing.T) {
+		var dir struct {
+			Version string `json:"version"`
+			Path    string `json:"path"`
+			Entries []struct {
+				Name  string `json:"name"`
+				IsDir bool   `json:"is_dir"`
+			} `json:"entries"`
+		}
+		decodeAPI(t, h, apiPath("objects", escapedID, "versions", "head", "dir")+"/", http.StatusOK, &dir)
+		be.Equal(t, "v2", dir.Version)
+		be.Equal(t, ".", dir.Path)
+		be.True(t, len(dir.Entries) > 0)
+		be.Equal(t, "exampl", dir.Entries[0].Name)
+		be.True(t, dir.Entries[0].IsDir)
+		decodeAPI(t, h, apiPath("objects", escapedID, "versions", "v2", "dir", "exampl", "folder"), http.StatusOK, &dir)
+		be.Equal(t, "exampl/folder", dir.Path)
+		be.Equal(t, 1, len(dir.Entries))
+		be.Equal(t, "justfile", dir.Entries[0].Name)
+	})
+
+	t.Run("stat file", func(t *testing.T) {
+		var file struct {
+			Path            string `json:"path"`
+			Digest          string `json:"digest"`
+			Size            *int64 `json:"size"`
+			ModifiedVersion int    `json:"modified_version"`
+		}
+		decodeAPI(t, h, apiPath("objects", escapedID, "versions", "head", "file", "a_file.txt"), http.StatusOK, &file)
+		be.Equal(t, "a_file.txt", file.Path)
+		be.Nonzero(t, file.Digest)
+		be.Equal(t, 1, file.ModifiedVersion)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			path   string
+			status int
+		}{
+			{apiPath("objects", "nonexistent"), http.StatusNotFound},
+			{apiPath("objects", escapedID, "versions", "v99"), http.StatusNotFound},
+			{apiPath("objects", escapedID, "versions", "vX"), http.StatusBadRequest},
+			{apiPath("objects", escapedID, "versions", "head", "dir", "missing"), http.StatusNotFound},
+			{apiPath("objects", escapedID, "versions", "head", "file", "missing.txt"), http.StatusNotFound},
+			{apiPath("objects", escapedID, "versions", "v2", "changes") + "?from=bad", http.StatusBadRequest},
+			{apiPath("objects") + "?limit=0", http.StatusBadRequest},
+			{apiPath("objects") + "?sort=size", http.StatusBadRequest},
+			{apiPath("unknown"), http.StatusNotFound},
+		}
+		for _, tt := range tests {
+			t.Run(tt.path, func(t *testing.T) {
+				var body apiErrorBody
+				decodeAPI(t, h, tt.path, tt.status, &body)
+				be.Equal(t, tt.status, body.Error.Status)
+				be.Nonzero(t, body.Error.Message)
+			})
+		}
+	})
+}
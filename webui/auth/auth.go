@@ -0,0 +1,105 @@
+// Package auth provides authentication backends for the web UI: OpenID
+// Connect login with session cookies, bearer tokens loaded from a file, basic
+// auth with an htpasswd file, and headers set by a trusted reverse proxy.
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// Authentication methods
+const (
+	MethodOIDC  = "oidc"
+	MethodToken = "token"
+	MethodBasic = "basic"
+	MethodProxy = "proxy"
+)
+
+// ErrInvalidCredentials is returned by an Authenticator if a request includes
+// credentials for it that aren't valid.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// ErrNotApplicable is returned by an Authenticator if a request includes
+// something that looks like its credentials but that it can't use, such as a
+// proxy header from an address that isn't a trusted proxy. Other
+// authenticators may still accept the request.
+var ErrNotApplicable = errors.New("authenticator doesn't apply")
+
+// Principal is an authenticated user or client.
+type Principal struct {
+	ID     string   // unique ID, prefixed with the authentication method (see PrincipalID)
+	Name   string   // display name; may be empty
+	Email  string   // may be empty
+	Groups []string // groups the principal belongs to; may be empty
+	Method string   // authentication method: "oidc", "token", "basic", or "proxy"
+}
+
+// PrincipalID returns the ID for a principal authenticated with the method,
+// "{method}:{name}". IDs from different methods never collide, so a user name
+// from an htpasswd file can't be used to act as a token or OIDC user with the
+// same name. OIDC principals are named "{issuer}#{subject}":
+//
+//	basic:alice
+//	token:harvester
+//	proxy:alice
+//	oidc:https://accounts.example.com#10769150350006150715113082367
+func PrincipalID(method string, name string) string {
+	return method + ":" + name
+}
+
+// DisplayName returns the principal's name, email, or ID: whichever is set
+// first.
+func (p *Principal) DisplayName() string {
+	switch {
+	case p.Name != "":
+		return p.Name
+	case p.Email != "":
+		return p.Email
+	default:
+		return p.ID
+	}
+}
+
+// Authenticator identifies the principal making a request.
+type Authenticator interface {
+	// Authenticate returns the principal for the request's credentials. If
+	// the request doesn't include credentials for the authenticator, it
+	// returns nil and either no error or an error that wraps
+	// ErrNotApplicable. If the credentials aren't valid, the error wraps
+	// ErrInvalidCredentials.
+	Authenticate(r *http.Request) (*Principal, error)
+
+	// Challenge returns a WWW-Authenticate header value for responses to
+	// requests that aren't authenticated, or an empty string if the
+	// authenticator doesn't use the header.
+	Challenge() string
+}
+
+// LoginRedirector is implemented by authenticators with a login page that
+// browsers are redirected to if they aren't authenticated.
+type LoginRedirector interface {
+	// LoginURL returns the URL of the login page for a request. After
+	// logging in, the user is returned to the request's URL.
+	LoginURL(r *http.Request) string
+}
+
+// Router is implemented by authenticators that serve their own endpoints,
+// like login callbacks. The endpoints don't require authentication.
+type Router interface {
+	RegisterRoutes(mux *http.ServeMux)
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx with the principal.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom returns the principal in ctx, or nil if ctx doesn't have one.
+func PrincipalFrom(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
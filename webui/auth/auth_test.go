@@ -0,0 +1,313 @@
+package auth_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/carlmjohnson/be"
+	"github.com/srerickson/ocfl-services/internal/testutil"
+	"github.com/srerickson/ocfl-services/webui/auth"
+)
+
+func TestTokenAuth(t *testing.T) {
+	tokens, err := auth.ParseTokens(strings.NewReader(`
+# API clients
+harvester  s3cr3t  staff readers
+reporting  sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08
+`))
+	be.NilErr(t, err)
+	request := func(header string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if header != "" {
+			r.Header.Set("Authorization", header)
+		}
+		return r
+	}
+
+	t.Run("valid token", func(t *testing.T) {
+		p, err := tokens.Authenticate(request("Bearer s3cr3t"))
+		be.NilErr(t, err)
+		be.Equal(t, "token:harvester", p.ID)
+		be.Equal(t, "harvester", p.Name)
+		be.Equal(t, auth.MethodToken, p.Method)
+		be.AllEqual(t, []string{"staff", "readers"}, p.Groups)
+	})
+
+	t.Run("hashed token", func(t *testing.T) {
+		// sha256 of "test"
+		p, err := tokens.Authenticate(request("bearer test"))
+		be.NilErr(t, err)
+		be.Equal(t, "token:reporting", p.ID)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		p, err := tokens.Authenticate(request("Bearer wrong"))
+		be.Zero(t, p)
+		be.True(t, errors.Is(err, auth.ErrInvalidCredentials))
+	})
+
+	t.Run("no token", func(t *testing.T) {
+		for _, header := range []string{"", "Basic dXNlcjpwYXNz"} {
+			p, err := tokens.Authenticate(request(header))
+			be.NilErr(t, err)
+			be.Zero(t, p)
+		}
+	})
+
+	t.Run("invalid file", func(t *testing.T) {
+		for _, content := range []string{
+			"name-without-token",
+			"name sha256:1234",
+			"a same\nb same",
+		} {
+			_, err := auth.ParseTokens(strings.NewReader(content))
+			be.Nonzero(t, err)
+		}
+	})
+}
+
+func TestBasicAuth(t *testing.T) {
+	// passwords are "secret"
+	users, err := auth.ParseHtpasswd(strings.NewReader(`
+alice:$2a$05$KOOrwBar7hW2W4lHzHUKtOVDcOqfliveejrp.CSENCDy8XJIwE7Vm
+bob:{SHA}5en6G6MezRroT3XKqkdPOmY/BfQ=
+`))
+	be.NilErr(t, err)
+	request := func(user, password string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.SetBasicAuth(user, password)
+		return r
+	}
+
+	t.Run("bcrypt", func(t *testing.T) {
+		p, err := users.Authenticate(request("alice", "secret"))
+		be.NilErr(t, err)
+		be.Equal(t, "basic:alice", p.ID)
+		be.Equal(t, "alice", p.DisplayName())
+		be.Equal(t, auth.MethodBasic, p.Method)
+	})
+
+	t.Run("sha1", func(t *testing.T) {
+		p, err := users.Authenticate(request("bob", "secret"))
+		be.NilErr(t, err)
+		be.Equal(t, "basic:bob", p.ID)
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		for _, user := range []string{"alice", "bob", "carol"} {
+			p, err := users.Authenticate(request(user, "wrong"))
+			be.Zero(t, p)
+			be.True(t, errors.Is(err, auth.ErrInvalidCredentials))
+		}
+	})
+
+	t.Run("no credentials", func(t *testing.T) {
+		p, err := users.Authenticate(httptest.NewRequest(http.MethodGet, "/", nil))
+		be.NilErr(t, err)
+		be.Zero(t, p)
+	})
+
+	t.Run("unsupported hash", func(t *testing.T) {
+		_, err := auth.ParseHtpasswd(strings.NewReader("carol:$apr1$salt$hash"))
+		be.Nonzero(t, err)
+	})
+}
+
+func TestOIDC(t *testing.T) {
+	provider := testutil.NewOIDCProvider(t)
+	provider.Claims = map[string]any{
+		"sub":    "user-1",
+		"name":   "Test User",
+		"email":  "test@example.com",
+		"groups": []string{"staff"},
+	}
+	oidc, err := auth.NewOIDC(t.Context(), auth.OIDCConfig{
+		Issuer:       provider.URL,
+		ClientID:     provider.ClientID,
+		ClientSecret: provider.ClientSecret,
+		RedirectURL:  "http://webui.test" + auth.CallbackPath,
+	})
+	be.NilErr(t, err)
+	mux := http.NewServeMux()
+	oidc.RegisterRoutes(mux)
+
+	t.Run("login", func(t *testing.T) {
+		session := login(t, mux, provider, "/objects?page=2")
+		r := httptest.NewRequest(http.MethodGet, "/objects", nil)
+		r.AddCookie(session)
+		p, err := oidc.Authenticate(r)
+		be.NilErr(t, err)
+		be.Equal(t, "oidc:"+provider.URL+"#user-1", p.ID)
+		be.Equal(t, "Test User", p.Name)
+		be.Equal(t, "test@example.com", p.Email)
+		be.AllEqual(t, []string{"staff"}, p.Groups)
+		be.Equal(t, auth.MethodOIDC, p.Method)
+	})
+
+	t.Run("login redirects to local paths only", func(t *testing.T) {
+		login(t, mux, provider, "https://evil.example/")
+	})
+
+	t.Run("login url", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/object/abc/head/?x=1", nil)
+		be.Equal(t, "/auth/login?next=%2Fobject%2Fabc%2Fhead%2F%3Fx%3D1", oidc.LoginURL(r))
+	})
+
+	t.Run("invalid session", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.AddCookie(&http.Cookie{Name: "ocfl_session", Value: "e30.invalid"})
+		p, err := oidc.Authenticate(r)
+		be.NilErr(t, err)
+		be.Zero(t, p)
+	})
+
+	t.Run("callback with wrong state", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, auth.LoginPath, nil))
+		be.Equal(t, http.StatusFound, w.Code)
+		r := httptest.NewRequest(http.MethodGet, auth.CallbackPath+"?code=abc&state=wrong", nil)
+		for _, c := range w.Result().Cookies() {
+			r.AddCookie(c)
+		}
+		w = httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		be.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("logout", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, auth.LogoutPath, nil))
+		be.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		be.Equal(t, 0, len(w.Result().Cookies()))
+
+		// cross-origin requests are rejected
+		r := httptest.NewRequest(http.MethodPost, auth.LogoutPath, nil)
+		r.Header.Set("Sec-Fetch-Site", "cross-site")
+		w = httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		be.Equal(t, http.StatusForbidden, w.Code)
+		be.Equal(t, 0, len(w.Result().Cookies()))
+
+		w = httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, auth.LogoutPath, nil))
+		be.Equal(t, http.StatusSeeOther, w.Code)
+		cookies := w.Result().Cookies()
+		be.Equal(t, 1, len(cookies))
+		be.Equal(t, "ocfl_session", cookies[0].Name)
+		be.Equal(t, -1, cookies[0].MaxAge)
+	})
+
+	t.Run("short session key", func(t *testing.T) {
+		_, err := auth.NewOIDC(t.Context(), auth.OIDCConfig{
+			Issuer:      provider.URL,
+			ClientID:    provider.ClientID,
+			RedirectURL: "http://webui.test" + auth.CallbackPath,
+			SessionKey:  []byte("too-short"),
+		})
+		be.Nonzero(t, err)
+	})
+
+	t.Run("wrong issuer", func(t *testing.T) {
+		_, err := auth.NewOIDC(t.Context(), auth.OIDCConfig{
+			Issuer:      provider.URL + "/other",
+			ClientID:    provider.ClientID,
+			RedirectURL: "http://webui.test" + auth.CallbackPath,
+		})
+		be.Nonzero(t, err)
+	})
+}
+
+// login completes the OIDC login flow using mux for the web UI's endpoints
+// and returns the session cookie. It checks that the user is returned to next
+// after logging in, or to "/" if next isn't a local path.
+func login(t *testing.T, mux http.Handler, provider *testutil.OIDCProvider, next string) *http.Cookie {
+	t.Helper()
+	// login redirects to the provider
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, auth.LoginPath+"?next="+url.QueryEscape(next), nil))
+	be.Equal(t, http.StatusFound, w.Code)
+	stateCookies := w.Result().Cookies()
+	// provider redirects to the callback
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(w.Header().Get("Location"))
+	be.NilErr(t, err)
+	resp.Body.Close()
+	be.Equal(t, http.StatusFound, resp.StatusCode)
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	be.NilErr(t, err)
+	be.Equal(t, auth.CallbackPath, callback.Path)
+	// callback sets the session cookie
+	r := httptest.NewRequest(http.MethodGet, callback.RequestURI(), nil)
+	for _, c := range stateCookies {
+		r.AddCookie(c)
+	}
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	be.Equal(t, http.StatusFound, w.Code)
+	if strings.HasPrefix(next, "/") {
+		be.Equal(t, next, w.Header().Get("Location"))
+	} else {
+		be.Equal(t, "/", w.Header().Get("Location"))
+	}
+	for _, c := range w.Result().Cookies() {
+		if c.Name == "ocfl_session" && c.Value != "" {
+			return c
+		}
+	}
+	t.Fatal("callback didn't set a session cookie")
+	return nil
+}
+
+func TestProxyAuth(t *testing.T) {
+	proxy, err := auth.NewProxyAuth("X-Remote-User", "X-Remote-Groups", []string{"10.0.0.0/8", "::1"})
+	be.NilErr(t, err)
+	request := func(remoteAddr string, headers map[string]string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = remoteAddr
+		for k, v := range headers {
+			r.Header.Set(k, v)
+		}
+		return r
+	}
+
+	t.Run("trusted proxy", func(t *testing.T) {
+		for _, addr := range []string{"10.1.2.3:5000", "[::1]:5000", "[::ffff:10.1.2.3]:5000"} {
+			p, err := proxy.Authenticate(request(addr, map[string]string{
+				"X-Remote-User":   "alice",
+				"X-Remote-Groups": "staff, readers",
+			}))
+			be.NilErr(t, err)
+			be.Equal(t, "proxy:alice", p.ID)
+			be.Equal(t, auth.MethodProxy, p.Method)
+			be.AllEqual(t, []string{"staff", "readers"}, p.Groups)
+		}
+	})
+
+	t.Run("untrusted address", func(t *testing.T) {
+		p, err := proxy.Authenticate(request("192.168.1.1:5000", map[string]string{"X-Remote-User": "alice"}))
+		be.Zero(t, p)
+		be.True(t, errors.Is(err, auth.ErrNotApplicable))
+		be.False(t, errors.Is(err, auth.ErrInvalidCredentials))
+	})
+
+	t.Run("no header", func(t *testing.T) {
+		p, err := proxy.Authenticate(request("10.1.2.3:5000", nil))
+		be.NilErr(t, err)
+		be.Zero(t, p)
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		_, err := auth.NewProxyAuth("X-Remote-User", "", nil)
+		be.Nonzero(t, err)
+		_, err = auth.NewProxyAuth("X-Remote-User", "", []string{"localhost"})
+		be.Nonzero(t, err)
+		_, err = auth.NewProxyAuth("", "", []string{"127.0.0.1"})
+		be.Nonzero(t, err)
+	})
+}
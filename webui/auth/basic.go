@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// BasicAuth authenticates requests with HTTP basic auth, using password
+// hashes from an htpasswd file.
+type BasicAuth struct {
+	users map[string]string // password hashes by user name
+}
+
+var _ Authenticator = (*BasicAuth)(nil)
+
+// LoadHtpasswd reads user names and password hashes from the htpasswd file
+// name. See ParseHtpasswd for supported hashes.
+func LoadHtpasswd(name string) (*BasicAuth, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	users, err := ParseHtpasswd(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading htpasswd file %q: %w", name, err)
+	}
+	return users, nil
+}
+
+// ParseHtpasswd reads user names and password hashes from r, in the format
+// used by Apache's htpasswd: "user:hash" on each line. Hashes must use bcrypt
+// (htpasswd -B) or SHA-1 (htpasswd -s). Blank lines and lines starting with
+// "#" are ignored.
+func ParseHtpasswd(r io.Reader) (*BasicAuth, error) {
+	auth := &BasicAuth{users: map[string]string{}}
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		user, hash, ok := strings.Cut(line, ":")
+		if !ok || user == "" {
+			return nil, fmt.Errorf("line %d: expected user:hash", lineNum)
+		}
+		switch {
+		case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+			if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+				return nil, fmt.Errorf("line %d: invalid bcrypt hash: %w", lineNum, err)
+			}
+		case strings.HasPrefix(hash, "{SHA}"):
+		default:
+			return nil, fmt.Errorf("line %d: unsupported password hash for %q; use bcrypt or SHA-1", lineNum, user)
+		}
+		auth.users[user] = hash
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return auth, nil
+}
+
+func (a *BasicAuth) Authenticate(r *http.Request) (*Principal, error) {
+	user, password, ok := r.BasicAuth()
+	if !ok {
+		return nil, nil
+	}
+	hash, exists := a.users[user]
+	if !exists || !checkPassword(hash, password) {
+		return nil, fmt.Errorf("basic auth for %q: %w", user, ErrInvalidCredentials)
+	}
+	return &Principal{ID: PrincipalID(MethodBasic, user), Name: user, Method: MethodBasic}, nil
+}
+
+func (a *BasicAuth) Challenge() string { return `Basic realm="ocfl-webui", charset="UTF-8"` }
+
+func checkPassword(hash string, password string) bool {
+	if sha, ok := strings.CutPrefix(hash, "{SHA}"); ok {
+		sum := sha1.Sum([]byte(password))
+		expected := base64.StdEncoding.EncodeToString(sum[:])
+		return subtle.ConstantTimeCompare([]byte(sha), []byte(expected)) == 1
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
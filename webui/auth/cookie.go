@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var errInvalidCookie = errors.New("invalid cookie value")
+
+// cookieCodec encodes values as JSON, signed with an HMAC so that they can be
+// stored in cookies without being modified by clients. The signature includes
+// the cookie name, so a value can't be used in a different cookie.
+type cookieCodec struct {
+	key []byte
+}
+
+func (c cookieCodec) encode(name string, v any) (string, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(c.sign(name, encoded)), nil
+}
+
+func (c cookieCodec) decode(name string, value string, v any) error {
+	encoded, sig, ok := strings.Cut(value, ".")
+	if !ok {
+		return errInvalidCookie
+	}
+	gotSig, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(gotSig, c.sign(name, encoded)) {
+		return errInvalidCookie
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return errInvalidCookie
+	}
+	return json.Unmarshal(payload, v)
+}
+
+func (c cookieCodec) sign(name string, encoded string) []byte {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write([]byte(name + "=" + encoded))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,340 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// Paths for endpoints served by OIDC.
+const (
+	LoginPath    = "/auth/login"
+	CallbackPath = "/auth/callback"
+	LogoutPath   = "/auth/logout"
+)
+
+const (
+	sessionCookie     = "ocfl_session"
+	oidcStateCookie   = "ocfl_oidc_state"
+	oidcStateMaxAge   = 10 * time.Minute
+	defaultSessionTTL = 12 * time.Hour
+)
+
+// MinSessionKeyLen is the minimum length of OIDCConfig.SessionKey.
+const MinSessionKeyLen = 32
+
+// OIDCConfig configures an OIDC authenticator.
+type OIDCConfig struct {
+	// Issuer is the OpenID provider's issuer URL. Provider metadata is
+	// discovered from {Issuer}/.well-known/openid-configuration.
+	Issuer string
+
+	// ClientID and ClientSecret are the credentials registered with the
+	// provider for the web UI.
+	ClientID     string
+	ClientSecret string
+
+	// RedirectURL is the absolute URL of the web UI's callback endpoint
+	// (CallbackPath), as registered with the provider.
+	RedirectURL string
+
+	// Scopes requested in addition to "openid". Defaults to "profile" and
+	// "email".
+	Scopes []string
+
+	// GroupsClaim is the ID token claim with the user's groups. Defaults to
+	// "groups".
+	GroupsClaim string
+
+	// SessionKey is used to sign session cookies. It must be at least
+	// MinSessionKeyLen bytes. If it's empty, a random key is used and sessions
+	// end when the server restarts.
+	SessionKey []byte
+
+	// SessionTTL is how long users stay logged in. Defaults to 12 hours.
+	SessionTTL time.Duration
+
+	// HTTPClient is used for requests to the provider. Defaults to
+	// http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// OIDC authenticates users with an OpenID Connect provider. Users log in
+// with the authorization code flow and then are identified by a signed
+// session cookie. Provider discovery, token exchange, and ID token
+// verification use github.com/coreos/go-oidc and golang.org/x/oauth2.
+type OIDC struct {
+	cfg      OIDCConfig
+	cookies  cookieCodec
+	oauth    *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+	secure   bool // set the cookie Secure attribute
+}
+
+var (
+	_ Authenticator   = (*OIDC)(nil)
+	_ LoginRedirector = (*OIDC)(nil)
+	_ Router          = (*OIDC)(nil)
+)
+
+// oidcSession is the content of the session cookie.
+type oidcSession struct {
+	Subject string   `json:"sub"`
+	Name    string   `json:"name,omitempty"`
+	Email   string   `json:"email,omitempty"`
+	Groups  []string `json:"groups,omitempty"`
+	Expiry  int64    `json:"exp"`
+}
+
+// oidcState is the content of the cookie used during login.
+type oidcState struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"` // PKCE code verifier
+	Next     string `json:"next"`     // path to return to after login
+	Expiry   int64  `json:"exp"`
+}
+
+// NewOIDC returns an OIDC authenticator for the provider in cfg, using the
+// provider's discovery document. The provider's signing keys are fetched as
+// needed until ctx is canceled.
+func NewOIDC(ctx context.Context, cfg OIDCConfig) (*OIDC, error) {
+	if cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, errors.New("OIDC issuer, client ID, and redirect URL are required")
+	}
+	redirect, err := url.Parse(cfg.RedirectURL)
+	if err != nil || !redirect.IsAbs() {
+		return nil, fmt.Errorf("OIDC redirect URL must be absolute: %q", cfg.RedirectURL)
+	}
+	if cfg.Scopes == nil {
+		cfg.Scopes = []string{"profile", "email"}
+	}
+	if cfg.GroupsClaim == "" {
+		cfg.GroupsClaim = "groups"
+	}
+	if cfg.SessionTTL <= 0 {
+		cfg.SessionTTL = defaultSessionTTL
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+	switch {
+	case len(cfg.SessionKey) == 0:
+		cfg.SessionKey = make([]byte, MinSessionKeyLen)
+		rand.Read(cfg.SessionKey)
+	case len(cfg.SessionKey) < MinSessionKeyLen:
+		return nil, fmt.Errorf("OIDC session key must be at least %d bytes", MinSessionKeyLen)
+	}
+	provider, err := oidc.NewProvider(oidc.ClientContext(ctx, cfg.HTTPClient), cfg.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("OIDC discovery: %w", err)
+	}
+	return &OIDC{
+		cfg:     cfg,
+		cookies: cookieCodec{key: cfg.SessionKey},
+		oauth: &oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			Endpoint:     provider.Endpoint(),
+			RedirectURL:  cfg.RedirectURL,
+			Scopes:       append([]string{oidc.ScopeOpenID}, cfg.Scopes...),
+		},
+		verifier: provider.Verifier(&oidc.Config{ClientID: cfg.ClientID}),
+		secure:   redirect.Scheme == "https",
+	}, nil
+}
+
+func (o *OIDC) Authenticate(r *http.Request) (*Principal, error) {
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return nil, nil
+	}
+	// Invalid and expired sessions are ignored so that the user is asked to
+	// log in again.
+	var sess oidcSession
+	if err := o.cookies.decode(sessionCookie, cookie.Value, &sess); err != nil {
+		return nil, nil
+	}
+	if time.Now().Unix() >= sess.Expiry {
+		return nil, nil
+	}
+	return &Principal{
+		ID:     PrincipalID(MethodOIDC, o.cfg.Issuer+"#"+sess.Subject),
+		Name:   sess.Name,
+		Email:  sess.Email,
+		Groups: sess.Groups,
+		Method: MethodOIDC,
+	}, nil
+}
+
+// Challenge returns an empty string: browsers are redirected to the login
+// page instead.
+func (o *OIDC) Challenge() string { return "" }
+
+func (o *OIDC) LoginURL(r *http.Request) string {
+	return LoginPath + "?" + url.Values{"next": {r.URL.RequestURI()}}.Encode()
+}
+
+func (o *OIDC) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET "+LoginPath, o.handleLogin)
+	mux.HandleFunc("GET "+CallbackPath, o.handleCallback)
+	// Logging out changes state, so it requires a same-origin POST.
+	mux.Handle("POST "+LogoutPath, http.NewCrossOriginProtection().Handler(
+		http.HandlerFunc(o.handleLogout)))
+}
+
+// handleLogin redirects to the provider's authorization endpoint.
+func (o *OIDC) handleLogin(w http.ResponseWriter, r *http.Request) {
+	state := &oidcState{
+		State:    randomString(),
+		Nonce:    randomString(),
+		Verifier: oauth2.GenerateVerifier(),
+		Next:     localPath(r.URL.Query().Get("next")),
+		Expiry:   time.Now().Add(oidcStateMaxAge).Unix(),
+	}
+	value, err := o.cookies.encode(oidcStateCookie, state)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	o.setCookie(w, oidcStateCookie, value, oidcStateMaxAge)
+	authURL := o.oauth.AuthCodeURL(state.State,
+		oidc.Nonce(state.Nonce),
+		oauth2.S256ChallengeOption(state.Verifier))
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// handleCallback exchanges the authorization code for an ID token, starts a
+// session, and redirects to the page the user was on before logging in.
+func (o *OIDC) handleCallback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if errCode := query.Get("error"); errCode != "" {
+		http.Error(w, "login failed: "+errCode+" "+query.Get("error_description"), http.StatusUnauthorized)
+		return
+	}
+	var state oidcState
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil || o.cookies.decode(oidcStateCookie, cookie.Value, &state) != nil ||
+		time.Now().Unix() >= state.Expiry || query.Get("state") != state.State {
+		http.Error(w, "login failed: invalid or expired login state", http.StatusBadRequest)
+		return
+	}
+	o.setCookie(w, oidcStateCookie, "", -1)
+	idToken, err := o.exchange(r.Context(), query.Get("code"), state.Verifier)
+	if err != nil {
+		http.Error(w, "login failed: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if idToken.Nonce != state.Nonce {
+		http.Error(w, "login failed: ID token nonce doesn't match", http.StatusUnauthorized)
+		return
+	}
+	var claims struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	}
+	var rawClaims map[string]json.RawMessage
+	if err := errors.Join(idToken.Claims(&claims), idToken.Claims(&rawClaims)); err != nil {
+		http.Error(w, "login failed: ID token claims: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+	sess := &oidcSession{
+		Subject: idToken.Subject,
+		Name:    claims.Name,
+		Email:   claims.Email,
+		Groups:  stringList(rawClaims[o.cfg.GroupsClaim]),
+		Expiry:  time.Now().Add(o.cfg.SessionTTL).Unix(),
+	}
+	value, err := o.cookies.encode(sessionCookie, sess)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	o.setCookie(w, sessionCookie, value, o.cfg.SessionTTL)
+	http.Redirect(w, r, state.Next, http.StatusFound)
+}
+
+// handleLogout ends the session.
+func (o *OIDC) handleLogout(w http.ResponseWriter, r *http.Request) {
+	o.setCookie(w, sessionCookie, "", -1)
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// exchange requests tokens from the provider's token endpoint and returns the
+// verified ID token.
+func (o *OIDC) exchange(ctx context.Context, code string, verifier string) (*oidc.IDToken, error) {
+	if code == "" {
+		return nil, errors.New("missing authorization code")
+	}
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, o.cfg.HTTPClient)
+	token, err := o.oauth.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("token request: %w", err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, errors.New("token response doesn't include an ID token")
+	}
+	return o.verifier.Verify(oidc.ClientContext(ctx, o.cfg.HTTPClient), rawIDToken)
+}
+
+func (o *OIDC) setCookie(w http.ResponseWriter, name string, value string, maxAge time.Duration) {
+	path := "/"
+	if name == oidcStateCookie {
+		path = "/auth/"
+	}
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		MaxAge:   int(maxAge.Seconds()),
+		HttpOnly: true,
+		Secure:   o.secure,
+		SameSite: http.SameSiteLaxMode,
+	}
+	if maxAge < 0 {
+		cookie.MaxAge = -1
+	}
+	http.SetCookie(w, cookie)
+}
+
+// stringList decodes a claim that may be a string or an array of strings.
+func stringList(raw json.RawMessage) []string {
+	if raw == nil {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return []string{s}
+	}
+	var list []string
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil
+	}
+	return list
+}
+
+// localPath returns p if it is a path on this server, or "/" otherwise, so
+// that login can't redirect to another site.
+func localPath(p string) string {
+	if !strings.HasPrefix(p, "/") || strings.HasPrefix(p, "//") || strings.HasPrefix(p, "/\\") {
+		return "/"
+	}
+	return p
+}
+
+func randomString() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ProxyAuth authenticates requests using a principal identifier in a header
+// set by a trusted reverse proxy. The header is only accepted from the proxy's
+// addresses: from other addresses, ProxyAuth doesn't apply and the header is
+// ignored.
+type ProxyAuth struct {
+	userHeader   string
+	groupsHeader string
+	trusted      []netip.Prefix
+}
+
+var _ Authenticator = (*ProxyAuth)(nil)
+
+// NewProxyAuth returns a *ProxyAuth that reads the principal's ID from
+// userHeader and, if groupsHeader isn't empty, a comma-separated list of the
+// principal's groups from groupsHeader. The headers are only accepted from
+// requests with remote addresses in trusted, which lists IP addresses and
+// CIDR prefixes (e.g., "10.0.0.0/8").
+func NewProxyAuth(userHeader, groupsHeader string, trusted []string) (*ProxyAuth, error) {
+	if userHeader == "" {
+		return nil, errors.New("proxy auth: missing user header")
+	}
+	if len(trusted) == 0 {
+		return nil, errors.New("proxy auth: no trusted proxy addresses")
+	}
+	a := &ProxyAuth{
+		userHeader:   http.CanonicalHeaderKey(userHeader),
+		groupsHeader: http.CanonicalHeaderKey(groupsHeader),
+	}
+	for _, val := range trusted {
+		val = strings.TrimSpace(val)
+		prefix, err := netip.ParsePrefix(val)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(val)
+			if addrErr != nil {
+				return nil, fmt.Errorf("proxy auth: invalid trusted address: %q", val)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		a.trusted = append(a.trusted, prefix.Masked())
+	}
+	return a, nil
+}
+
+func (a *ProxyAuth) Authenticate(r *http.Request) (*Principal, error) {
+	id := strings.TrimSpace(r.Header.Get(a.userHeader))
+	if id == "" {
+		return nil, nil
+	}
+	if !a.fromTrusted(r) {
+		return nil, fmt.Errorf("%s header from untrusted address: %w", a.userHeader, ErrNotApplicable)
+	}
+	p := &Principal{ID: PrincipalID(MethodProxy, id), Name: id, Method: MethodProxy}
+	if a.groupsHeader != "" {
+		for group := range strings.SplitSeq(r.Header.Get(a.groupsHeader), ",") {
+			if group = strings.TrimSpace(group); group != "" {
+				p.Groups = append(p.Groups, group)
+			}
+		}
+	}
+	return p, nil
+}
+
+func (a *ProxyAuth) Challenge() string { return "" }
+
+// fromTrusted reports whether the request's remote address is a trusted
+// proxy.
+func (a *ProxyAuth) fromTrusted(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range a.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
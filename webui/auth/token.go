@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// hashedTokenPrefix marks a token in a token file that is given as the
+// hex-encoded SHA-256 digest of the token.
+const hashedTokenPrefix = "sha256:"
+
+// TokenAuth authenticates requests with bearer tokens in the Authorization
+// header.
+type TokenAuth struct {
+	tokens map[[sha256.Size]byte]*Principal // by token digest
+}
+
+var _ Authenticator = (*TokenAuth)(nil)
+
+// LoadTokenFile reads bearer tokens from the file name. See ParseTokens for
+// the file format.
+func LoadTokenFile(name string) (*TokenAuth, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	tokens, err := ParseTokens(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading token file %q: %w", name, err)
+	}
+	return tokens, nil
+}
+
+// ParseTokens reads bearer tokens from r. Each line has a principal name, a
+// token, and optionally the principal's groups, separated by whitespace:
+//
+//	# comment
+//	harvester  s3cr3t-t0ken  staff
+//	reporting  sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08
+//
+// Tokens with the "sha256:" prefix are given as the hex-encoded SHA-256 digest
+// of the token. Blank lines and lines starting with "#" are ignored.
+func ParseTokens(r io.Reader) (*TokenAuth, error) {
+	auth := &TokenAuth{tokens: map[[sha256.Size]byte]*Principal{}}
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: expected a name and a token", lineNum)
+		}
+		var digest [sha256.Size]byte
+		if hexDigest, ok := strings.CutPrefix(fields[1], hashedTokenPrefix); ok {
+			b, err := hex.DecodeString(hexDigest)
+			if err != nil || len(b) != sha256.Size {
+				return nil, fmt.Errorf("line %d: invalid sha256 token digest", lineNum)
+			}
+			copy(digest[:], b)
+		} else {
+			digest = sha256.Sum256([]byte(fields[1]))
+		}
+		if _, exists := auth.tokens[digest]; exists {
+			return nil, fmt.Errorf("line %d: duplicate token", lineNum)
+		}
+		auth.tokens[digest] = &Principal{
+			ID:     PrincipalID(MethodToken, fields[0]),
+			Name:   fields[0],
+			Groups: fields[2:],
+			Method: MethodToken,
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return auth, nil
+}
+
+func (a *TokenAuth) Authenticate(r *http.Request) (*Principal, error) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return nil, nil
+	}
+	// Tokens are looked up by digest, so lookup time doesn't depend on how
+	// much of a token is correct.
+	p, ok := a.tokens[sha256.Sum256([]byte(strings.TrimSpace(token)))]
+	if !ok {
+		return nil, fmt.Errorf("bearer token: %w", ErrInvalidCredentials)
+	}
+	principal := *p
+	return &principal, nil
+}
+
+func (a *TokenAuth) Challenge() string { return `Bearer realm="ocfl-webui"` }
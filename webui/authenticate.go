@@ -0,0 +1,101 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/srerickson/ocfl-services/access"
+	"github.com/srerickson/ocfl-services/webui/auth"
+	"github.com/srerickson/ocfl-services/webui/utils"
+)
+
+// authMiddleware identifies the principal for each request using the first
+// of authns that accepts the request's credentials and adds the principal to
+// the request context. Authenticators that don't apply to the request are
+// skipped; if one that applies rejects the request's credentials, the request
+// isn't authenticated. The principal is also added as an access.Principal, so
+// the service's access policy is evaluated for it. Requests handled by public
+// don't need to be authenticated; other requests that aren't authenticated
+// are refused.
+func authMiddleware(authns []auth.Authenticator, public *http.ServeMux) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var principal *auth.Principal
+			var authErr error
+			for _, authn := range authns {
+				p, err := authn.Authenticate(r)
+				if err != nil && !errors.Is(err, auth.ErrInvalidCredentials) {
+					// the authenticator doesn't apply to the request
+					continue
+				}
+				principal, authErr = p, err
+				if principal != nil || authErr != nil {
+					break
+				}
+			}
+			if principal != nil {
+				ctx := auth.WithPrincipal(r.Context(), principal)
+				ctx = access.WithPrincipal(ctx, &access.Principal{
+					ID:     principal.ID,
+					Groups: principal.Groups,
+				})
+				r = r.WithContext(ctx)
+				if rec, ok := w.(*responseRecorder); ok {
+					rec.principal = principal
+				}
+			}
+			if h, pattern := public.Handler(r); pattern != "" {
+				h.ServeHTTP(w, r)
+				return
+			}
+			if principal == nil {
+				unauthorized(w, r, authns, authErr)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// unauthorized responds to a request that isn't authenticated. If the request
+// has no credentials, browsers are redirected to a login page if one of
+// authns has one. Otherwise, the response is 401 Unauthorized with challenges
+// from authns.
+func unauthorized(w http.ResponseWriter, r *http.Request, authns []auth.Authenticator, authErr error) {
+	isAPI := isAPIPath(r.URL.Path)
+	if authErr == nil && !isAPI && (r.Method == http.MethodGet || r.Method == http.MethodHead) && !wantsJSON(r) {
+		for _, authn := range authns {
+			if login, ok := authn.(auth.LoginRedirector); ok {
+				http.Redirect(w, r, login.LoginURL(r), http.StatusFound)
+				return
+			}
+		}
+	}
+	for _, authn := range authns {
+		if challenge := authn.Challenge(); challenge != "" {
+			w.Header().Add("WWW-Authenticate", challenge)
+		}
+	}
+	msg := "authentication required"
+	if authErr != nil {
+		msg = authErr.Error()
+	}
+	if isAPI {
+		writeAPIError(w, http.StatusUnauthorized, msg)
+		return
+	}
+	httpError(w, r, msg, http.StatusUnauthorized)
+}
+
+// isAPIPath reports whether p is the path of a JSON API route, including the
+// API routes of storage roots served by NewMulti.
+func isAPIPath(p string) bool {
+	if rest, ok := strings.CutPrefix(p, utils.RootsPath); ok {
+		// skip the root's name
+		if _, rest, ok = strings.Cut(rest, "/"); ok {
+			p = "/" + rest
+		}
+	}
+	return strings.HasPrefix(p, apiBasePath+"/")
+}
@@ -0,0 +1,196 @@
+package server_test
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/carlmjohnson/be"
+	"github.com/srerickson/ocfl-services/access"
+	"github.com/srerickson/ocfl-services/access/sqlite"
+	"github.com/srerickson/ocfl-services/internal/testutil"
+	server "github.com/srerickson/ocfl-services/webui"
+	"github.com/srerickson/ocfl-services/webui/auth"
+)
+
+func TestAuthentication(t *testing.T) {
+	provider := testutil.NewOIDCProvider(t)
+	provider.Claims = map[string]any{"sub": "user-1", "name": "Test User"}
+	oidc, err := auth.NewOIDC(t.Context(), auth.OIDCConfig{
+		Issuer:       provider.URL,
+		ClientID:     provider.ClientID,
+		ClientSecret: provider.ClientSecret,
+		RedirectURL:  "http://webui.test" + auth.CallbackPath,
+	})
+	be.NilErr(t, err)
+	tokens, err := auth.ParseTokens(strings.NewReader("harvester s3cr3t"))
+	be.NilErr(t, err)
+	// password is "secret"
+	users, err := auth.ParseHtpasswd(strings.NewReader("alice:{SHA}5en6G6MezRroT3XKqkdPOmY/BfQ="))
+	be.NilErr(t, err)
+
+	var logs bytes.Buffer
+	db, err := sqlite.NewDB(filepath.Join(t.TempDir(), "test.db"))
+	be.NilErr(t, err)
+	t.Cleanup(func() { db.Close() })
+	root := testutil.FixtureRootCopy(t, filepath.Join("..", "testdata"))
+	svc := access.NewService(root, db, "test", slog.New(slog.NewTextHandler(&logs, nil)))
+	h := server.New(svc, server.WithAuthenticators(tokens, users, oidc))
+
+	get := func(path string, setup func(*http.Request)) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		if setup != nil {
+			setup(r)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("browsers are redirected to login", func(t *testing.T) {
+		w := get("/objects?page=1", nil)
+		be.Equal(t, http.StatusFound, w.Code)
+		be.Equal(t, "/auth/login?next="+url.QueryEscape("/objects?page=1"), w.Header().Get("Location"))
+	})
+
+	t.Run("api requires credentials", func(t *testing.T) {
+		w := get(apiPath("objects"), nil)
+		be.Equal(t, http.StatusUnauthorized, w.Code)
+		be.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		be.AllEqual(t,
+			[]string{`Bearer realm="ocfl-webui"`, `Basic realm="ocfl-webui", charset="UTF-8"`},
+			w.Header().Values("WWW-Authenticate"))
+	})
+
+	t.Run("json requests aren't redirected", func(t *testing.T) {
+		w := get("/objects", func(r *http.Request) { r.Header.Set("Accept", "application/json") })
+		be.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("static files are public", func(t *testing.T) {
+		w := get("/static/app.css", nil)
+		be.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("bearer token", func(t *testing.T) {
+		w := get(apiPath("objects"), func(r *http.Request) { r.Header.Set("Authorization", "Bearer s3cr3t") })
+		be.Equal(t, http.StatusOK, w.Code)
+		be.In(t, "principal=token:harvester auth_method=token", logs.String())
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		w := get("/objects", func(r *http.Request) { r.Header.Set("Authorization", "Bearer wrong") })
+		be.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("basic auth", func(t *testing.T) {
+		w := get("/objects", func(r *http.Request) { r.SetBasicAuth("alice", "secret") })
+		be.Equal(t, http.StatusOK, w.Code)
+		be.In(t, `<span>alice</span>`, w.Body.String())
+		be.NotIn(t, `action="/auth/logout"`, w.Body.String())
+		w = get("/objects", func(r *http.Request) { r.SetBasicAuth("alice", "wrong") })
+		be.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("oidc login", func(t *testing.T) {
+		// login redirects to the provider, which redirects to the callback
+		w := get(auth.LoginPath+"?next=/objects", nil)
+		be.Equal(t, http.StatusFound, w.Code)
+		client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}}
+		resp, err := client.Get(w.Header().Get("Location"))
+		be.NilErr(t, err)
+		resp.Body.Close()
+		callback, err := url.Parse(resp.Header.Get("Location"))
+		be.NilErr(t, err)
+		w = get(callback.RequestURI(), func(r *http.Request) {
+			for _, c := range w.Result().Cookies() {
+				r.AddCookie(c)
+			}
+		})
+		be.Equal(t, http.StatusFound, w.Code)
+		be.Equal(t, "/objects", w.Header().Get("Location"))
+		cookies := w.Result().Cookies()
+		w = get("/objects", func(r *http.Request) {
+			for _, c := range cookies {
+				r.AddCookie(c)
+			}
+		})
+		be.Equal(t, http.StatusOK, w.Code)
+		be.In(t, `<span>Test User</span>`, w.Body.String())
+		be.In(t, `action="/auth/logout"`, w.Body.String())
+		be.In(t, "principal=oidc:"+provider.URL+"#user-1 auth_method=oidc", logs.String())
+	})
+}
+
+func TestAccessPolicy(t *testing.T) {
+	policy, err := access.ParsePolicy(strings.NewReader(`{
+		"default": "deny",
+		"rules": [{"effect": "allow", "groups": ["staff"]}]
+	}`))
+	be.NilErr(t, err)
+	proxy, err := auth.NewProxyAuth("X-Remote-User", "X-Remote-Groups", []string{"192.0.2.0/24"})
+	be.NilErr(t, err)
+	tokens, err := auth.ParseTokens(strings.NewReader("harvester s3cr3t staff"))
+	be.NilErr(t, err)
+	db, err := sqlite.NewDB(filepath.Join(t.TempDir(), "test.db"))
+	be.NilErr(t, err)
+	t.Cleanup(func() { db.Close() })
+	root := testutil.FixtureRootCopy(t, filepath.Join("..", "testdata"))
+	svc := access.NewService(root, db, "test", nil, access.WithPolicy(policy))
+	h := server.New(svc, server.WithAuthenticators(proxy))
+	get := func(path string, groups string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil) // from 192.0.2.1
+		r.Header.Set("X-Remote-User", "user-1")
+		r.Header.Set("X-Remote-Groups", groups)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("allowed", func(t *testing.T) {
+		w := get(objectPath(fixtureObjectID, "v1", "")+"/", "staff")
+		be.Equal(t, http.StatusOK, w.Code)
+		w = get("/inventory/"+url.PathEscape(fixtureObjectID), "staff")
+		be.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("counts are hidden", func(t *testing.T) {
+		w := get("/objects", "staff")
+		be.Equal(t, http.StatusOK, w.Code)
+		be.In(t, `<h1 class="object-id">Objects</h1>`, w.Body.String())
+		be.In(t, "<span>Page 1</span>", w.Body.String())
+		w = get(apiPath("metrics"), "staff")
+		be.Equal(t, http.StatusOK, w.Code)
+		be.In(t, `"num_objects": null`, w.Body.String())
+	})
+
+	t.Run("proxy header from untrusted address", func(t *testing.T) {
+		h := server.New(svc, server.WithAuthenticators(proxy, tokens))
+		r := httptest.NewRequest(http.MethodGet, "/objects", nil)
+		r.RemoteAddr = "198.51.100.1:1234"
+		r.Header.Set("X-Remote-User", "user-1")
+		r.Header.Set("Accept", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		be.Equal(t, http.StatusUnauthorized, w.Code)
+		// other authenticators still apply
+		r.Header.Set("Authorization", "Bearer s3cr3t")
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		be.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("denied objects are not found", func(t *testing.T) {
+		w := get(objectPath(fixtureObjectID, "v1", "")+"/", "guests")
+		be.Equal(t, http.StatusNotFound, w.Code)
+		w = get("/inventory/"+url.PathEscape(fixtureObjectID), "guests")
+		be.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,659 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/srerickson/ocfl-go"
+	"github.com/srerickson/ocfl-go/digest"
+	"github.com/srerickson/ocfl-services/access"
+	"github.com/srerickson/ocfl-services/ingest"
+	"github.com/srerickson/ocfl-services/webui/auth"
+	"github.com/srerickson/ocfl-services/webui/template"
+	"github.com/srerickson/ocfl-services/webui/utils"
+)
+
+type apiDraft struct {
+	ID              int64     `json:"id"`
+	ObjectID        string    `json:"object_id"`
+	Head            string    `json:"head"`    // object's head when the draft was opened; empty for new objects
+	Version         string    `json:"version"` // version created by committing the draft
+	DigestAlgorithm string    `json:"digest_algorithm"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+type apiDraftList struct {
+	Drafts []*apiDraft `json:"drafts"`
+}
+
+type apiDraftFile struct {
+	Path   string `json:"path"`
+	Digest string `json:"digest"`
+	Size   *int64 `json:"size,omitempty"`   // nil if the size isn't known
+	Upload string `json:"upload,omitempty"` // empty if the content is in the object
+}
+
+type apiDraftFiles struct {
+	DraftID int64           `json:"draft_id"`
+	Files   []*apiDraftFile `json:"files"`
+}
+
+type apiDraftDir struct {
+	DraftID  int64          `json:"draft_id"`
+	ObjectID string         `json:"object_id"`
+	Path     string         `json:"path"`
+	Entries  []*apiDirEntry `json:"entries"`
+}
+
+// apiOpenDraft is the JSON request body for opening a draft.
+type apiOpenDraft struct {
+	ObjectID string `json:"object_id"`
+}
+
+// apiDraftFileUpload is the JSON request body for adding a file to a draft.
+type apiDraftFileUpload struct {
+	Upload string `json:"upload"`
+}
+
+// apiDraftRename is the JSON request body for renaming a path in a draft.
+type apiDraftRename struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// apiDraftCommit is the JSON request body for committing a draft.
+type apiDraftCommit struct {
+	Message string `json:"message"`
+}
+
+func HandleAPIListDrafts(svc *access.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		drafts, err := svc.ListDrafts(r.Context())
+		if err != nil {
+			writeAPIError(w, draftErrorStatus(r, svc, err), err.Error())
+			return
+		}
+		result := &apiDraftList{Drafts: make([]*apiDraft, len(drafts))}
+		for i, d := range drafts {
+			result.Drafts[i] = newAPIDraft(d)
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+// HandleAPIOpenDraft returns the principal's draft for the object in the
+// request body, opening a new draft if necessary.
+func HandleAPIOpenDraft(svc *access.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body apiOpenDraft
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeAPIError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+			return
+		}
+		if body.ObjectID == "" {
+			writeAPIError(w, http.StatusBadRequest, "missing object_id")
+			return
+		}
+		draft, err := svc.OpenDraft(r.Context(), body.ObjectID)
+		if err != nil {
+			writeAPIError(w, draftErrorStatus(r, svc, err), err.Error())
+			return
+		}
+		w.Header().Set("Location", apiDraftPath(r.Context(), draft.ID()))
+		writeJSON(w, http.StatusOK, newAPIDraft(draft))
+	}
+}
+
+func HandleAPIGetDraft(svc *access.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		draft, err := svc.GetDraft(r.Context(), draftIDParam(r))
+		if err != nil {
+			writeAPIError(w, draftErrorStatus(r, svc, err), err.Error())
+			return
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		writeJSON(w, http.StatusOK, newAPIDraft(draft))
+	}
+}
+
+// HandleAPIDiscardDraft deletes a draft without committing it.
+func HandleAPIDiscardDraft(svc *access.Service, staging *ingest.Staging) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := discardDraft(r, svc, staging, draftIDParam(r)); err != nil {
+			writeAPIError(w, draftErrorStatus(r, svc, err), err.Error())
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func HandleAPIListDraftFiles(svc *access.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		draftID := draftIDParam(r)
+		files, err := svc.ListDraftFiles(r.Context(), draftID)
+		if err != nil {
+			writeAPIError(w, draftErrorStatus(r, svc, err), err.Error())
+			return
+		}
+		result := &apiDraftFiles{DraftID: draftID, Files: make([]*apiDraftFile, len(files))}
+		for i, f := range files {
+			result.Files[i] = &apiDraftFile{
+				Path:   f.Path(),
+				Digest: f.Digest(),
+				Size:   apiSize(f.Size(), f.HasSize()),
+				Upload: f.Upload(),
+			}
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+func HandleAPIReadDraftDir(svc *access.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		draftID := draftIDParam(r)
+		dir := path.Clean(r.PathValue("path"))
+		if !fs.ValidPath(dir) {
+			writeAPIError(w, http.StatusBadRequest, fmt.Sprintf("invalid path: %q", r.PathValue("path")))
+			return
+		}
+		draft, err := svc.GetDraft(ctx, draftID)
+		if err != nil {
+			writeAPIError(w, draftErrorStatus(r, svc, err), err.Error())
+			return
+		}
+		entries, err := svc.ReadDraftDir(ctx, draftID, dir)
+		if err != nil {
+			writeAPIError(w, draftErrorStatus(r, svc, err), err.Error())
+			return
+		}
+		sortVersionDirEntries(entries)
+		result := &apiDraftDir{
+			DraftID:  draftID,
+			ObjectID: draft.ObjectID(),
+			Path:     dir,
+			Entries:  make([]*apiDirEntry, len(entries)),
+		}
+		for i, entry := range entries {
+			result.Entries[i] = &apiDirEntry{
+				Name:            entry.Name(),
+				IsDir:           entry.IsDir(),
+				Digest:          entry.Digest(),
+				Size:            apiSize(entry.Size(), entry.HasSize()),
+				ModifiedVersion: entry.ModVNum(),
+				Modified:        entry.Modtime(),
+			}
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+// HandleAPIPutDraftFile adds a file to a draft with the content of an upload
+// from the staging area, replacing any file with the same path.
+func HandleAPIPutDraftFile(svc *access.Service, staging *ingest.Staging) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body apiDraftFileUpload
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeAPIError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+			return
+		}
+		err := addDraftFile(r, svc, staging, draftIDParam(r), r.PathValue("path"), body.Upload)
+		if err != nil {
+			writeAPIError(w, draftErrorStatus(r, svc, err), err.Error())
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// HandleAPIRemoveDraftPath removes a file or directory from a draft.
+func HandleAPIRemoveDraftPath(svc *access.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := svc.RemoveDraftPath(r.Context(), draftIDParam(r), r.PathValue("path"))
+		if err != nil {
+			writeAPIError(w, draftErrorStatus(r, svc, err), err.Error())
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// HandleAPIRenameDraftPath moves a file or directory in a draft.
+func HandleAPIRenameDraftPath(svc *access.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body apiDraftRename
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeAPIError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+			return
+		}
+		err := svc.RenameDraftPath(r.Context(), draftIDParam(r), body.From, body.To)
+		if err != nil {
+			writeAPIError(w, draftErrorStatus(r, svc, err), err.Error())
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// HandleAPICommitDraft creates a new object version with the draft's state.
+func HandleAPICommitDraft(svc *access.Service, staging *ingest.Staging) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body apiDraftCommit
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeAPIError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+			return
+		}
+		obj, err := commitDraft(r, svc, staging, draftIDParam(r), body.Message)
+		if err != nil {
+			writeAPIError(w, draftErrorStatus(r, svc, err), err.Error())
+			return
+		}
+		w.Header().Set("Location", apiLocation(r.Context(), "/objects/"+url.PathEscape(obj.ID())+"/versions/"+obj.Head().String()))
+		writeJSON(w, http.StatusCreated, newAPIObject(obj))
+	}
+}
+
+// HandleListDrafts renders the principal's drafts and the form for opening a
+// draft, filled in with the object ID in the id query parameter.
+func HandleListDrafts(svc *access.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		page := &template.DraftList{ObjectID: r.URL.Query().Get("id")}
+		renderDraftList(w, r, svc, page, http.StatusOK)
+	}
+}
+
+// HandleOpenDraft opens a draft for the object in the form's id field and
+// redirects to it.
+func HandleOpenDraft(svc *access.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		objID := r.FormValue("id")
+		if objID == "" {
+			page := &template.DraftList{Error: "missing object ID"}
+			renderDraftList(w, r, svc, page, http.StatusBadRequest)
+			return
+		}
+		draft, err := svc.OpenDraft(r.Context(), objID)
+		if err != nil {
+			page := &template.DraftList{ObjectID: objID, Error: err.Error()}
+			renderDraftList(w, r, svc, page, draftErrorStatus(r, svc, err))
+			return
+		}
+		http.Redirect(w, r, string(utils.LinkDraft(r.Context(), draft.ID(), ".")), http.StatusSeeOther)
+	}
+}
+
+// HandleGetDraft renders a directory in the draft's state.
+func HandleGetDraft(svc *access.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		dir := path.Clean(r.PathValue("path"))
+		if !fs.ValidPath(dir) {
+			http.Error(w, fmt.Sprintf("invalid path: %q", r.PathValue("path")), http.StatusBadRequest)
+			return
+		}
+		renderDraft(w, r, svc, draftIDParam(r), dir, nil)
+	}
+}
+
+// HandleAddDraftFiles adds the files in a multipart upload form to a draft.
+func HandleAddDraftFiles(svc *access.Service, staging *ingest.Staging) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		draftID := draftIDParam(r)
+		p := auth.PrincipalFrom(r.Context())
+		if p == nil {
+			http.Error(w, access.ErrNoPrincipal.Error(), http.StatusForbidden)
+			return
+		}
+		form, err := readUploadForm(r, svc, staging, p.ID)
+		if err != nil {
+			err = fmt.Errorf("%w: %w", access.ErrInvalidChange, err)
+		}
+		for name, id := range form.files {
+			if err != nil {
+				break
+			}
+			err = addDraftFile(r, svc, staging, draftID, name, id)
+		}
+		if err != nil {
+			// uploads that were added to the draft are kept until the
+			// draft is committed or discarded.
+			deleteUnusedUploads(r, svc, staging, form.uploads)
+			renderDraft(w, r, svc, draftID, form.dir, err)
+			return
+		}
+		redirectToDraft(w, r, svc, draftID, form.dir)
+	}
+}
+
+// HandleRenameDraftPath moves the form's from path to its to path in a draft.
+func HandleRenameDraftPath(svc *access.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		draftID := draftIDParam(r)
+		dir := r.FormValue("dir")
+		err := svc.RenameDraftPath(r.Context(), draftID, r.FormValue("from"), r.FormValue("to"))
+		if err != nil {
+			renderDraft(w, r, svc, draftID, dir, err)
+			return
+		}
+		redirectToDraft(w, r, svc, draftID, dir)
+	}
+}
+
+// HandleRemoveDraftPath removes the form's path from a draft.
+func HandleRemoveDraftPath(svc *access.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		draftID := draftIDParam(r)
+		dir := r.FormValue("dir")
+		if err := svc.RemoveDraftPath(r.Context(), draftID, r.FormValue("path")); err != nil {
+			renderDraft(w, r, svc, draftID, dir, err)
+			return
+		}
+		redirectToDraft(w, r, svc, draftID, dir)
+	}
+}
+
+// HandleCommitDraft commits a draft and redirects to the new version's
+// changes.
+func HandleCommitDraft(svc *access.Service, staging *ingest.Staging) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		draftID := draftIDParam(r)
+		obj, err := commitDraft(r, svc, staging, draftID, r.FormValue("message"))
+		if err != nil {
+			renderDraft(w, r, svc, draftID, ".", err)
+			return
+		}
+		link := utils.LinkVersionChanges(r.Context(), obj.ID(), obj.Head().String())
+		http.Redirect(w, r, string(link), http.StatusSeeOther)
+	}
+}
+
+// HandleDiscardDraft discards a draft and redirects to the list of drafts.
+func HandleDiscardDraft(svc *access.Service, staging *ingest.Staging) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		draftID := draftIDParam(r)
+		if err := discardDraft(r, svc, staging, draftID); err != nil {
+			renderDraft(w, r, svc, draftID, ".", err)
+			return
+		}
+		http.Redirect(w, r, string(utils.LinkDrafts(r.Context(), "")), http.StatusSeeOther)
+	}
+}
+
+// renderDraftList renders the principal's drafts with the page's form values.
+func renderDraftList(w http.ResponseWriter, r *http.Request, svc *access.Service, page *template.DraftList, status int) {
+	drafts, err := svc.ListDrafts(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), draftErrorStatus(r, svc, err))
+		return
+	}
+	for _, d := range drafts {
+		page.Drafts = append(page.Drafts, &template.DraftListItem{
+			ID:        d.ID(),
+			ObjectID:  d.ObjectID(),
+			Head:      d.Head(),
+			UpdatedAt: d.UpdatedAt(),
+		})
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	template.DraftListPage(page).Render(r.Context(), w)
+}
+
+// renderDraft renders the directory dir in the draft's state. If changeErr
+// isn't nil, it's an error from changing the draft, which is shown with the
+// directory listing.
+func renderDraft(w http.ResponseWriter, r *http.Request, svc *access.Service, draftID int64, dir string, changeErr error) {
+	ctx := r.Context()
+	status := http.StatusOK
+	if changeErr != nil {
+		status = draftErrorStatus(r, svc, changeErr)
+		if status != http.StatusBadRequest && status != http.StatusConflict {
+			http.Error(w, changeErr.Error(), status)
+			return
+		}
+	}
+	draft, err := svc.GetDraft(ctx, draftID)
+	if err != nil {
+		http.Error(w, err.Error(), draftErrorStatus(r, svc, err))
+		return
+	}
+	dir = path.Clean(dir)
+	entries, err := svc.ReadDraftDir(ctx, draftID, dir)
+	if err != nil && changeErr != nil && dir != "." {
+		// show the top-level directory with the error
+		dir = "."
+		entries, err = svc.ReadDraftDir(ctx, draftID, dir)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), draftErrorStatus(r, svc, err))
+		return
+	}
+	sortVersionDirEntries(entries)
+	page := &template.Draft{
+		ID:               draftID,
+		ObjectID:         draft.ObjectID(),
+		Head:             draft.Head(),
+		CurrentPath:      dir,
+		DirectoryEntries: make([]*template.DirectoryEntry, 0, len(entries)),
+	}
+	if changeErr != nil {
+		page.Error = changeErr.Error()
+	}
+	if dir != "." {
+		page.DirectoryEntries = append(page.DirectoryEntries, &template.DirectoryEntry{
+			Name:  "..",
+			Href:  utils.LinkDraft(ctx, draftID, path.Dir(dir)),
+			IsDir: true,
+		})
+	}
+	for _, entry := range entries {
+		// draft files aren't linked: their content may only be in the
+		// staging area.
+		var href templ.SafeURL
+		if entry.IsDir() {
+			href = utils.LinkDraft(ctx, draftID, path.Join(dir, entry.Name()))
+		}
+		page.DirectoryEntries = append(page.DirectoryEntries, &template.DirectoryEntry{
+			Name:    entry.Name(),
+			Href:    href,
+			Digest:  entry.Digest(),
+			IsDir:   entry.IsDir(),
+			Size:    entry.Size(),
+			HasSize: entry.HasSize(),
+			Modtime: entry.Modtime(),
+		})
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	template.DraftPage(page).Render(ctx, w)
+}
+
+// redirectToDraft redirects to the directory dir in the draft's state, or to
+// the draft's top-level directory if dir no longer exists.
+func redirectToDraft(w http.ResponseWriter, r *http.Request, svc *access.Service, draftID int64, dir string) {
+	dir = path.Clean(dir)
+	if !fs.ValidPath(dir) {
+		dir = "."
+	}
+	if dir != "." {
+		if _, err := svc.ReadDraftDir(r.Context(), draftID, dir); err != nil {
+			dir = "."
+		}
+	}
+	http.Redirect(w, r, string(utils.LinkDraft(r.Context(), draftID, dir)), http.StatusSeeOther)
+}
+
+// addDraftFile adds the upload with the ID to a draft as the file name. The
+// upload must be complete and belong to the request's principal.
+func addDraftFile(r *http.Request, svc *access.Service, staging *ingest.Staging, draftID int64, name string, uploadID string) error {
+	ctx := r.Context()
+	p := auth.PrincipalFrom(ctx)
+	if p == nil {
+		return access.ErrNoPrincipal
+	}
+	draft, err := svc.GetDraft(ctx, draftID)
+	if err != nil {
+		return err
+	}
+	u, err := staging.Get(uploadID)
+	if err != nil || u.Owner != p.ID {
+		return fmt.Errorf("upload %q for %q: %w", uploadID, name, ingest.ErrNotFound)
+	}
+	if !u.Complete() {
+		return fmt.Errorf("upload %q for %q: %w", uploadID, name, ingest.ErrIncomplete)
+	}
+	alg, err := digest.DefaultRegistry().Get(draft.Alg())
+	if err != nil {
+		return err
+	}
+	sum, err := staging.Digest(u.ID, alg)
+	if err != nil {
+		return err
+	}
+	return svc.AddDraftFile(ctx, draftID, name, access.DraftFile{
+		Digest: sum,
+		Size:   u.Length,
+		Upload: u.ID,
+	})
+}
+
+// commitDraft commits a draft as a new object version. The version's user is
+// the request's principal. Uploads that were added to the draft are deleted
+// from the staging area.
+func commitDraft(r *http.Request, svc *access.Service, staging *ingest.Staging, draftID int64, message string) (access.ObjectInfo, error) {
+	ctx := r.Context()
+	p := auth.PrincipalFrom(ctx)
+	if p == nil {
+		return nil, access.ErrNoPrincipal
+	}
+	uploads, err := draftUploads(r, svc, draftID)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := svc.CommitDraft(ctx, draftID, message, versionUser(p), staging)
+	if err != nil {
+		return nil, err
+	}
+	deleteUnusedUploads(r, svc, staging, uploads)
+	return obj, nil
+}
+
+// discardDraft deletes a draft and the uploads that were added to it.
+func discardDraft(r *http.Request, svc *access.Service, staging *ingest.Staging, draftID int64) error {
+	uploads, err := draftUploads(r, svc, draftID)
+	if err != nil {
+		return err
+	}
+	if err := svc.DiscardDraft(r.Context(), draftID); err != nil {
+		return err
+	}
+	deleteUnusedUploads(r, svc, staging, uploads)
+	return nil
+}
+
+// draftUploads returns the IDs of the uploads used by the draft's files.
+func draftUploads(r *http.Request, svc *access.Service, draftID int64) ([]string, error) {
+	files, err := svc.ListDraftFiles(r.Context(), draftID)
+	if err != nil {
+		return nil, err
+	}
+	var uploads []string
+	for _, f := range files {
+		if f.Upload() != "" {
+			uploads = append(uploads, f.Upload())
+		}
+	}
+	return uploads, nil
+}
+
+// deleteUnusedUploads deletes the uploads with the given IDs from the staging
+// area, except uploads used by drafts. If the drafts' uploads can't be read,
+// nothing is deleted: unused uploads are removed when they expire.
+func deleteUnusedUploads(r *http.Request, svc *access.Service, staging *ingest.Staging, ids []string) {
+	inUse, err := svc.DraftUploads(r.Context())
+	if err != nil {
+		svc.Logger().LogAttrs(r.Context(), slog.LevelError, "reading draft uploads: "+err.Error())
+		return
+	}
+	for _, id := range ids {
+		if !inUse[id] {
+			staging.Delete(id)
+		}
+	}
+}
+
+// draftErrorStatus returns the HTTP status code for an error from a draft
+// method. Internal errors are logged.
+func draftErrorStatus(r *http.Request, svc *access.Service, err error) int {
+	switch {
+	case errors.Is(err, access.ErrNoPrincipal),
+		errors.Is(err, access.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, access.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, access.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, ingest.ErrMaxSize):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, access.ErrInvalidChange),
+		errors.Is(err, access.ErrInvalidCommit),
+		errors.Is(err, ingest.ErrNotFound),
+		errors.Is(err, ingest.ErrIncomplete):
+		return http.StatusBadRequest
+	}
+	svc.Logger().LogAttrs(r.Context(), slog.LevelError, err.Error(),
+		slog.String("path", r.URL.Path))
+	return http.StatusInternalServerError
+}
+
+// draftIDParam returns the draft ID in the request path, or 0 (which isn't a
+// draft) if it isn't valid.
+func draftIDParam(r *http.Request) int64 {
+	id, _ := strconv.ParseInt(r.PathValue("draft"), 10, 64)
+	return id
+}
+
+// versionUser returns the version user for commits by p.
+func versionUser(p *auth.Principal) ocfl.User {
+	user := ocfl.User{Name: p.DisplayName()}
+	if p.Email != "" {
+		user.Address = "mailto:" + p.Email
+	}
+	return user
+}
+
+func apiDraftPath(ctx context.Context, draftID int64) string {
+	return apiLocation(ctx, "/drafts/"+strconv.FormatInt(draftID, 10))
+}
+
+func newAPIDraft(d access.DraftInfo) *apiDraft {
+	result := &apiDraft{
+		ID:              d.ID(),
+		ObjectID:        d.ObjectID(),
+		DigestAlgorithm: d.Alg(),
+		CreatedAt:       d.CreatedAt(),
+		UpdatedAt:       d.UpdatedAt(),
+	}
+	if !d.Head().IsZero() {
+		result.Head = d.Head().String()
+	}
+	if next, err := d.Head().Next(); err == nil {
+		result.Version = next.String()
+	}
+	return result
+}
@@ -0,0 +1,244 @@
+package server_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/carlmjohnson/be"
+	"github.com/srerickson/ocfl-services/ingest"
+	server "github.com/srerickson/ocfl-services/webui"
+	"github.com/srerickson/ocfl-services/webui/auth"
+)
+
+func TestDrafts(t *testing.T) {
+	tokens, err := auth.ParseTokens(strings.NewReader("depositor s3cr3t\nother 0th3r"))
+	be.NilErr(t, err)
+	staging, err := ingest.NewStaging(t.TempDir())
+	be.NilErr(t, err)
+	h := testWriteHandler(t, server.WithAuthenticators(tokens), server.WithStaging(staging))
+
+	do := func(method, path, token string, body io.Reader, headers map[string]string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, body)
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		for k, v := range headers {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+	doJSON := func(method, path, token string, body any) *httptest.ResponseRecorder {
+		b, err := json.Marshal(body)
+		be.NilErr(t, err)
+		return do(method, path, token, bytes.NewReader(b), map[string]string{"Content-Type": "application/json"})
+	}
+	putFile := func(draftPath, name, content string) *httptest.ResponseRecorder {
+		u, err := staging.Put("token:depositor", strings.NewReader(content))
+		be.NilErr(t, err)
+		return doJSON(http.MethodPut, draftPath+"/files/"+name, "s3cr3t", map[string]string{"upload": u.ID})
+	}
+	decode := func(w *httptest.ResponseRecorder, v any) {
+		t.Helper()
+		be.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		be.NilErr(t, json.Unmarshal(w.Body.Bytes(), v))
+	}
+	type draft struct {
+		ID       int64  `json:"id"`
+		ObjectID string `json:"object_id"`
+		Head     string `json:"head"`
+		Version  string `json:"version"`
+	}
+	var draftPath string
+
+	t.Run("api", func(t *testing.T) {
+		w := doJSON(http.MethodPost, apiPath("drafts"), "s3cr3t", map[string]string{"object_id": fixtureObjectID})
+		be.Equal(t, http.StatusOK, w.Code)
+		var d draft
+		decode(w, &d)
+		be.Equal(t, fixtureObjectID, d.ObjectID)
+		be.Equal(t, "v2", d.Head)
+		be.Equal(t, "v3", d.Version)
+		draftPath = w.Header().Get("Location")
+
+		// opening the draft again returns the same draft
+		w = doJSON(http.MethodPost, apiPath("drafts"), "s3cr3t", map[string]string{"object_id": fixtureObjectID})
+		be.Equal(t, http.StatusOK, w.Code)
+		be.Equal(t, draftPath, w.Header().Get("Location"))
+
+		// the draft starts with the head version's files
+		w = do(http.MethodGet, draftPath+"/files", "s3cr3t", nil, nil)
+		be.Equal(t, http.StatusOK, w.Code)
+		var files struct {
+			Files []struct {
+				Path   string `json:"path"`
+				Upload string `json:"upload"`
+			} `json:"files"`
+		}
+		decode(w, &files)
+		be.True(t, len(files.Files) > 0)
+		headFiles := len(files.Files)
+
+		// changes over several requests
+		be.Equal(t, http.StatusNoContent, putFile(draftPath, "new/a.txt", "a").Code)
+		be.Equal(t, http.StatusNoContent, putFile(draftPath, "new/b.txt", "b").Code)
+		w = doJSON(http.MethodPost, draftPath+"/rename", "s3cr3t", map[string]string{"from": "new", "to": "added"})
+		be.Equal(t, http.StatusNoContent, w.Code)
+		w = do(http.MethodDelete, draftPath+"/files/added/b.txt", "s3cr3t", nil, nil)
+		be.Equal(t, http.StatusNoContent, w.Code)
+
+		// invalid changes
+		w = putFile(draftPath, "added/a.txt/c.txt", "c")
+		be.Equal(t, http.StatusBadRequest, w.Code)
+		w = doJSON(http.MethodPost, draftPath+"/rename", "s3cr3t", map[string]string{"from": "missing", "to": "other"})
+		be.Equal(t, http.StatusNotFound, w.Code)
+		w = doJSON(http.MethodPut, draftPath+"/files/x.txt", "s3cr3t", map[string]string{"upload": "missing"})
+		be.Equal(t, http.StatusBadRequest, w.Code)
+
+		// preview the draft's state
+		w = do(http.MethodGet, draftPath+"/dir/added", "s3cr3t", nil, nil)
+		be.Equal(t, http.StatusOK, w.Code)
+		var dir struct {
+			Entries []struct {
+				Name string `json:"name"`
+			} `json:"entries"`
+		}
+		decode(w, &dir)
+		be.Equal(t, 1, len(dir.Entries))
+		be.Equal(t, "a.txt", dir.Entries[0].Name)
+		w = do(http.MethodGet, draftPath+"/files", "s3cr3t", nil, nil)
+		decode(w, &files)
+		be.Equal(t, headFiles+1, len(files.Files))
+
+		// other principals can't see the draft
+		w = do(http.MethodGet, draftPath, "0th3r", nil, nil)
+		be.Equal(t, http.StatusNotFound, w.Code)
+		w = do(http.MethodGet, apiPath("drafts"), "0th3r", nil, nil)
+		var list struct {
+			Drafts []draft `json:"drafts"`
+		}
+		decode(w, &list)
+		be.Equal(t, 0, len(list.Drafts))
+		w = do(http.MethodGet, apiPath("drafts"), "s3cr3t", nil, nil)
+		decode(w, &list)
+		be.Equal(t, 1, len(list.Drafts))
+
+		// commit
+		w = doJSON(http.MethodPost, draftPath+"/commit", "s3cr3t", map[string]string{"message": "from draft"})
+		be.Equal(t, http.StatusCreated, w.Code)
+		be.Equal(t, apiPath("objects", url.PathEscape(fixtureObjectID), "versions", "v3"), w.Header().Get("Location"))
+		w = do(http.MethodGet, objectPath(fixtureObjectID, "v3", "added/a.txt")+"?download", "s3cr3t", nil, nil)
+		be.Equal(t, http.StatusOK, w.Code)
+		be.Equal(t, "a", w.Body.String())
+		w = do(http.MethodGet, draftPath, "s3cr3t", nil, nil)
+		be.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("stale draft", func(t *testing.T) {
+		w := doJSON(http.MethodPost, apiPath("drafts"), "s3cr3t", map[string]string{"object_id": "new-object"})
+		be.Equal(t, http.StatusOK, w.Code)
+		var d draft
+		decode(w, &d)
+		be.Equal(t, "", d.Head)
+		be.Equal(t, "v1", d.Version)
+		stalePath := w.Header().Get("Location")
+		be.Equal(t, http.StatusNoContent, putFile(stalePath, "a.txt", "a").Code)
+
+		// another commit creates the object first
+		u, err := staging.Put("token:depositor", strings.NewReader("b"))
+		be.NilErr(t, err)
+		w = doJSON(http.MethodPost, apiPath("objects", "new-object", "versions"), "s3cr3t", map[string]any{
+			"message": "first",
+			"files":   map[string]string{"b.txt": u.ID},
+		})
+		be.Equal(t, http.StatusCreated, w.Code)
+
+		w = doJSON(http.MethodPost, stalePath+"/commit", "s3cr3t", map[string]string{"message": "stale"})
+		be.Equal(t, http.StatusConflict, w.Code)
+
+		// discarding the draft deletes its uploads
+		w = do(http.MethodGet, stalePath+"/files", "s3cr3t", nil, nil)
+		var files struct {
+			Files []struct {
+				Upload string `json:"upload"`
+			} `json:"files"`
+		}
+		decode(w, &files)
+		be.Equal(t, 1, len(files.Files))
+		w = do(http.MethodDelete, stalePath, "s3cr3t", nil, nil)
+		be.Equal(t, http.StatusNoContent, w.Code)
+		_, err = staging.Get(files.Files[0].Upload)
+		be.True(t, errors.Is(err, ingest.ErrNotFound))
+		w = do(http.MethodGet, stalePath, "s3cr3t", nil, nil)
+		be.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("html", func(t *testing.T) {
+		form := func(path string, vals url.Values) *httptest.ResponseRecorder {
+			return do(http.MethodPost, path, "s3cr3t", strings.NewReader(vals.Encode()),
+				map[string]string{"Content-Type": "application/x-www-form-urlencoded"})
+		}
+		w := do(http.MethodGet, "/drafts?id="+url.QueryEscape("new-object"), "s3cr3t", nil, nil)
+		be.Equal(t, http.StatusOK, w.Code)
+		be.In(t, `value="new-object"`, w.Body.String())
+
+		w = form("/drafts", url.Values{"id": {"new-object"}})
+		be.Equal(t, http.StatusSeeOther, w.Code)
+		draftLink := w.Header().Get("Location")
+		be.True(t, strings.HasPrefix(draftLink, "/draft/"))
+
+		body, contentType := testUploadForm(t, map[string]string{"dir": "docs"},
+			map[string]string{"c.txt": "c", "d.txt": "d"})
+		w = do(http.MethodPost, draftLink+"files", "s3cr3t", body, map[string]string{"Content-Type": contentType})
+		be.Equal(t, http.StatusSeeOther, w.Code)
+		be.Equal(t, draftLink+"docs/", w.Header().Get("Location"))
+
+		w = do(http.MethodGet, draftLink+"docs/", "s3cr3t", nil, nil)
+		be.Equal(t, http.StatusOK, w.Code)
+		be.In(t, "c.txt", w.Body.String())
+		be.In(t, "d.txt", w.Body.String())
+
+		w = form(draftLink+"remove", url.Values{"path": {"docs/d.txt"}, "dir": {"docs"}})
+		be.Equal(t, http.StatusSeeOther, w.Code)
+		w = form(draftLink+"rename", url.Values{"from": {"docs"}, "to": {"b.txt"}, "dir": {"docs"}})
+		be.Equal(t, http.StatusBadRequest, w.Code)
+		be.In(t, "form-error", w.Body.String())
+
+		// other principals can't see the draft
+		w = do(http.MethodGet, draftLink, "0th3r", nil, nil)
+		be.Equal(t, http.StatusNotFound, w.Code)
+
+		// cross-origin changes are rejected
+		w = do(http.MethodPost, draftLink+"remove", "s3cr3t", strings.NewReader("path=b.txt"), map[string]string{
+			"Content-Type":   "application/x-www-form-urlencoded",
+			"Sec-Fetch-Site": "cross-site",
+		})
+		be.Equal(t, http.StatusForbidden, w.Code)
+
+		w = form(draftLink+"commit", url.Values{"message": {"from draft form"}})
+		be.Equal(t, http.StatusSeeOther, w.Code)
+		be.Equal(t, historyPath("new-object", "v2"), w.Header().Get("Location"))
+		w = do(http.MethodGet, objectPath("new-object", "v2", "docs/c.txt")+"?download", "s3cr3t", nil, nil)
+		be.Equal(t, http.StatusOK, w.Code)
+		w = do(http.MethodGet, objectPath("new-object", "v2", "b.txt")+"?download", "s3cr3t", nil, nil)
+		be.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("require authentication", func(t *testing.T) {
+		w := do(http.MethodGet, apiPath("drafts"), "", nil, nil)
+		be.Equal(t, http.StatusUnauthorized, w.Code)
+		h := testHandler(t, server.WithStaging(staging))
+		w = doRequest(t, h, http.MethodGet, apiPath("drafts"))
+		be.Equal(t, http.StatusForbidden, w.Code)
+		w = doRequest(t, h, http.MethodGet, "/drafts")
+		be.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
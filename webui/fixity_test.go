@@ -0,0 +1,93 @@
+package server_test
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/be"
+	ocflfs "github.com/srerickson/ocfl-go/fs"
+	"github.com/srerickson/ocfl-services/access"
+	"github.com/srerickson/ocfl-services/access/sqlite"
+	"github.com/srerickson/ocfl-services/internal/testutil"
+	server "github.com/srerickson/ocfl-services/webui"
+)
+
+func TestFixity(t *testing.T) {
+	ctx := t.Context()
+	db, err := sqlite.NewDB(filepath.Join(t.TempDir(), "test.db"))
+	be.NilErr(t, err)
+	t.Cleanup(func() { db.Close() })
+	root := testutil.FixtureRootCopy(t, filepath.Join("..", "testdata"))
+	svc := access.NewService(root, db, "test", nil)
+	h := server.New(svc)
+	escapedID := url.PathEscape(fixtureObjectID)
+
+	obj, err := svc.SyncObject(ctx, fixtureObjectID)
+	be.NilErr(t, err)
+	_, err = ocflfs.Write(ctx, root.FS(), path.Join(obj.StoragePath(), "v2/content/README.md"), strings.NewReader("corrupt"))
+	be.NilErr(t, err)
+	result, err := svc.AuditFixity(ctx, access.FixityAuditOptions{Interval: time.Hour})
+	be.NilErr(t, err)
+	be.Equal(t, 1, result.Failed)
+
+	type fixityFile struct {
+		ObjectID    string     `json:"object_id"`
+		ContentPath string     `json:"content_path"`
+		Status      string     `json:"status"`
+		Error       string     `json:"error"`
+		CheckedAt   *time.Time `json:"checked_at"`
+	}
+
+	t.Run("object fixity", func(t *testing.T) {
+		var body struct {
+			ObjectID string        `json:"object_id"`
+			Files    []*fixityFile `json:"files"`
+		}
+		decodeAPI(t, h, apiPath("objects", escapedID, "fixity"), http.StatusOK, &body)
+		be.Equal(t, fixtureObjectID, body.ObjectID)
+		be.Equal(t, 3, len(body.Files))
+		for _, f := range body.Files {
+			be.True(t, f.CheckedAt != nil)
+		}
+		decodeAPI(t, h, apiPath("objects", escapedID, "fixity?status=fail"), http.StatusOK, &body)
+		be.Equal(t, 1, len(body.Files))
+		be.Equal(t, "v2/content/README.md", body.Files[0].ContentPath)
+		be.Equal(t, "fail", body.Files[0].Status)
+		be.In(t, "digest mismatch", body.Files[0].Error)
+
+		var errBody apiErrorBody
+		decodeAPI(t, h, apiPath("objects", escapedID, "fixity?status=bad"), http.StatusBadRequest, &errBody)
+		decodeAPI(t, h, apiPath("objects", "missing", "fixity"), http.StatusNotFound, &errBody)
+	})
+
+	t.Run("summary", func(t *testing.T) {
+		var body struct {
+			NumFiles    int           `json:"num_files"`
+			NumChecked  int           `json:"num_checked"`
+			NumFailed   int           `json:"num_failed"`
+			OldestCheck *time.Time    `json:"oldest_check"`
+			Failures    []*fixityFile `json:"failures"`
+		}
+		decodeAPI(t, h, apiPath("fixity"), http.StatusOK, &body)
+		be.Equal(t, 3, body.NumFiles)
+		be.Equal(t, 3, body.NumChecked)
+		be.Equal(t, 1, body.NumFailed)
+		be.True(t, body.OldestCheck != nil)
+		be.Equal(t, 1, len(body.Failures))
+		be.Equal(t, fixtureObjectID, body.Failures[0].ObjectID)
+		var errBody apiErrorBody
+		decodeAPI(t, h, apiPath("fixity?limit=0"), http.StatusBadRequest, &errBody)
+	})
+
+	t.Run("object page", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, objectPath(fixtureObjectID, "head", "")+"/")
+		be.Equal(t, http.StatusOK, w.Code)
+		be.In(t, "Fixity Check Failures", w.Body.String())
+		be.In(t, "v2/content/README.md", w.Body.String())
+	})
+}
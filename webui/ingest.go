@@ -0,0 +1,458 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/srerickson/ocfl-go"
+	"github.com/srerickson/ocfl-services/access"
+	"github.com/srerickson/ocfl-services/ingest"
+	"github.com/srerickson/ocfl-services/webui/auth"
+	"github.com/srerickson/ocfl-services/webui/template"
+	"github.com/srerickson/ocfl-services/webui/utils"
+)
+
+// version of the tus resumable upload protocol used for uploads
+const tusVersion = "1.0.0"
+
+// media type for PATCH requests that append to an upload
+const uploadChunkType = "application/offset+octet-stream"
+
+// max size of a non-file field in a multipart upload form
+const maxFormFieldSize = 64 * 1024
+
+type apiUpload struct {
+	ID      string    `json:"id"`
+	Length  int64     `json:"length"`
+	Offset  int64     `json:"offset"`
+	Created time.Time `json:"created"`
+}
+
+// apiCommit is the JSON request body for creating an object version.
+type apiCommit struct {
+	Head    string            `json:"head"` // expected head version; empty for new objects
+	Message string            `json:"message"`
+	Files   map[string]string `json:"files"`  // upload IDs by logical path
+	Remove  []string          `json:"remove"` // logical paths to remove
+}
+
+// uploadForm is a multipart form for creating an object version. Its files
+// are added to the staging area as they are read.
+type uploadForm struct {
+	id      string
+	head    string
+	message string
+	dir     string
+	remove  []string
+	files   map[string]string // upload IDs by logical path
+	uploads []string          // IDs of uploads to delete after committing
+}
+
+func HandleAPICreateUpload(svc *access.Service, staging *ingest.Staging) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Tus-Resumable", tusVersion)
+		p := auth.PrincipalFrom(r.Context())
+		if p == nil {
+			writeAPIError(w, http.StatusForbidden, "uploads require authentication")
+			return
+		}
+		if err := svc.AuthorizeUploads(r.Context()); err != nil {
+			uploadError(w, r, svc, err)
+			return
+		}
+		length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+		if err != nil || length < 0 {
+			writeAPIError(w, http.StatusBadRequest, "missing or invalid Upload-Length header")
+			return
+		}
+		u, err := staging.Create(p.ID, length)
+		if err != nil {
+			uploadError(w, r, svc, err)
+			return
+		}
+		w.Header().Set("Location", apiLocation(r.Context(), "/uploads/"+u.ID))
+		w.Header().Set("Upload-Offset", strconv.FormatInt(u.Offset, 10))
+		writeJSON(w, http.StatusCreated, newAPIUpload(u))
+	}
+}
+
+// HandleAPIGetUpload also responds to HEAD requests, which tus clients use to
+// get the offset for resuming an upload.
+func HandleAPIGetUpload(svc *access.Service, staging *ingest.Staging) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Tus-Resumable", tusVersion)
+		u, err := ownUpload(r, staging)
+		if err != nil {
+			uploadError(w, r, svc, err)
+			return
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Upload-Offset", strconv.FormatInt(u.Offset, 10))
+		w.Header().Set("Upload-Length", strconv.FormatInt(u.Length, 10))
+		writeJSON(w, http.StatusOK, newAPIUpload(u))
+	}
+}
+
+// HandleAPIWriteUpload appends the request body to an upload, starting at the
+// offset in the Upload-Offset header.
+func HandleAPIWriteUpload(svc *access.Service, staging *ingest.Staging) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Tus-Resumable", tusVersion)
+		if r.Header.Get("Content-Type") != uploadChunkType {
+			writeAPIError(w, http.StatusUnsupportedMediaType, "Content-Type must be "+uploadChunkType)
+			return
+		}
+		offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, "missing or invalid Upload-Offset header")
+			return
+		}
+		u, err := ownUpload(r, staging)
+		if err != nil {
+			uploadError(w, r, svc, err)
+			return
+		}
+		u, err = staging.Write(u.ID, offset, r.Body)
+		if err != nil {
+			uploadError(w, r, svc, err)
+			return
+		}
+		w.Header().Set("Upload-Offset", strconv.FormatInt(u.Offset, 10))
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func HandleAPIDeleteUpload(svc *access.Service, staging *ingest.Staging) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Tus-Resumable", tusVersion)
+		u, err := ownUpload(r, staging)
+		if err == nil {
+			err = staging.Delete(u.ID)
+		}
+		if err != nil {
+			uploadError(w, r, svc, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// HandleAPICommitVersion creates a new object version with content from the
+// staging area. The request body is either a JSON apiCommit with upload IDs
+// or a multipart form with the files.
+func HandleAPICommitVersion(svc *access.Service, staging *ingest.Staging) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		p := auth.PrincipalFrom(ctx)
+		if p == nil {
+			writeAPIError(w, http.StatusForbidden, "uploads require authentication")
+			return
+		}
+		objID := r.PathValue("id")
+		form := &uploadForm{}
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		switch mediaType {
+		case "application/json":
+			var body apiCommit
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				writeAPIError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+				return
+			}
+			form.head, form.message, form.remove = body.Head, body.Message, body.Remove
+			form.files = body.Files
+			for _, id := range body.Files {
+				form.uploads = append(form.uploads, id)
+			}
+		case "multipart/form-data":
+			var err error
+			form, err = readUploadForm(r, svc, staging, p.ID)
+			defer form.deleteUploads(staging)
+			if err != nil {
+				writeAPIError(w, uploadFormStatus(err), err.Error())
+				return
+			}
+		default:
+			writeAPIError(w, http.StatusUnsupportedMediaType, "Content-Type must be application/json or multipart/form-data")
+			return
+		}
+		obj, err := commitUploadForm(r, svc, staging, objID, form)
+		if err != nil {
+			writeAPIError(w, commitErrorStatus(r, svc, objID, err), err.Error())
+			return
+		}
+		// JSON commits' uploads are kept if the commit fails, so it can be
+		// retried.
+		form.deleteUploads(staging)
+		w.Header().Set("Location", apiLocation(r.Context(), "/objects/"+url.PathEscape(objID)+"/versions/"+obj.Head().String()))
+		writeJSON(w, http.StatusCreated, newAPIObject(obj))
+	}
+}
+
+// HandleUploadForm renders the form for uploading files to a new version of
+// the object in the id query parameter, or to a new object.
+func HandleUploadForm(svc *access.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		form := &template.UploadForm{ObjectID: r.URL.Query().Get("id")}
+		if form.ObjectID != "" {
+			obj, err := svc.SyncObject(r.Context(), form.ObjectID)
+			switch {
+			case errors.Is(err, access.ErrNotFound):
+				// new object
+			case err != nil:
+				svc.Logger().LogAttrs(r.Context(), slog.LevelError, err.Error(),
+					slog.String("object_id", form.ObjectID))
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			default:
+				form.Head = obj.Head()
+			}
+		}
+		template.UploadPage(form).Render(r.Context(), w)
+	}
+}
+
+// HandleUpload creates a new object version with files from the upload form
+// and redirects to the version's changes. If the version can't be created,
+// the form is shown again with the error.
+func HandleUpload(svc *access.Service, staging *ingest.Staging) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p := auth.PrincipalFrom(r.Context())
+		if p == nil {
+			http.Error(w, "uploads require authentication", http.StatusForbidden)
+			return
+		}
+		form, err := readUploadForm(r, svc, staging, p.ID)
+		defer form.deleteUploads(staging)
+		if err == nil && form.id == "" {
+			err = errors.New("missing object ID")
+		}
+		var status int
+		var obj access.ObjectInfo
+		if err != nil {
+			status = uploadFormStatus(err)
+		} else {
+			obj, err = commitUploadForm(r, svc, staging, form.id, form)
+			if err != nil {
+				status = commitErrorStatus(r, svc, form.id, err)
+			}
+		}
+		if err != nil {
+			page := &template.UploadForm{
+				ObjectID: form.id,
+				Message:  form.message,
+				Dir:      form.dir,
+				Error:    err.Error(),
+			}
+			ocfl.ParseVNum(form.head, &page.Head)
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.WriteHeader(status)
+			template.UploadPage(page).Render(r.Context(), w)
+			return
+		}
+		link := utils.LinkVersionChanges(r.Context(), obj.ID(), obj.Head().String())
+		http.Redirect(w, r, string(link), http.StatusSeeOther)
+	}
+}
+
+// readUploadForm reads a multipart upload form from the request body. Files
+// are added to the staging area, owned by owner, as they are read; they
+// should be deleted with deleteUploads after the form is committed. If the
+// request's principal can't commit to any object, nothing is read. The
+// returned form is never nil. A file's logical path is the form's directory
+// joined with the file name, which may include sub-directories.
+func readUploadForm(r *http.Request, svc *access.Service, staging *ingest.Staging, owner string) (*uploadForm, error) {
+	form := &uploadForm{files: map[string]string{}}
+	if err := svc.AuthorizeUploads(r.Context()); err != nil {
+		return form, err
+	}
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return form, err
+	}
+	var names []string // file names, in the same order as form.uploads
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return form, err
+		}
+		name := part.FormName()
+		if name == "file" {
+			fileName := rawFileName(part.Header.Get("Content-Disposition"))
+			if fileName == "" {
+				// empty file input
+				continue
+			}
+			u, err := staging.Put(owner, part)
+			if err != nil {
+				return form, fmt.Errorf("uploading %q: %w", fileName, err)
+			}
+			names = append(names, fileName)
+			form.uploads = append(form.uploads, u.ID)
+			continue
+		}
+		b, err := io.ReadAll(io.LimitReader(part, maxFormFieldSize))
+		if err != nil {
+			return form, err
+		}
+		val := strings.TrimSpace(string(b))
+		switch name {
+		case "id":
+			form.id = val
+		case "head":
+			form.head = val
+		case "message":
+			form.message = val
+		case "dir":
+			form.dir = strings.Trim(val, "/")
+		case "remove":
+			form.remove = append(form.remove, val)
+		}
+	}
+	for i, fileName := range names {
+		logicalPath := path.Join(form.dir, strings.TrimLeft(path.Clean(fileName), "/"))
+		if !fs.ValidPath(logicalPath) || logicalPath == "." {
+			return form, fmt.Errorf("invalid file path: %q", logicalPath)
+		}
+		if _, exists := form.files[logicalPath]; exists {
+			return form, fmt.Errorf("duplicate file path: %q", logicalPath)
+		}
+		form.files[logicalPath] = form.uploads[i]
+	}
+	if len(form.files) == 0 && len(form.remove) == 0 {
+		return form, errors.New("no files were uploaded")
+	}
+	return form, nil
+}
+
+// rawFileName returns the filename parameter from a Content-Disposition
+// header. Unlike multipart.Part.FileName, it includes directory names.
+func rawFileName(contentDisposition string) string {
+	_, params, err := mime.ParseMediaType(contentDisposition)
+	if err != nil {
+		return ""
+	}
+	return params["filename"]
+}
+
+// deleteUploads removes the form's uploads from the staging area.
+func (form *uploadForm) deleteUploads(staging *ingest.Staging) {
+	for _, id := range form.uploads {
+		staging.Delete(id)
+	}
+}
+
+// commitUploadForm creates a new version of the object objID from the form.
+// The version's user is the request's principal.
+func commitUploadForm(r *http.Request, svc *access.Service, staging *ingest.Staging, objID string, form *uploadForm) (access.ObjectInfo, error) {
+	p := auth.PrincipalFrom(r.Context())
+	commit := &access.Commit{
+		Message: form.message,
+		User:    versionUser(p),
+		Remove:  form.remove,
+	}
+	if form.head != "" {
+		var head ocfl.VNum
+		if err := ocfl.ParseVNum(form.head, &head); err != nil {
+			return nil, fmt.Errorf("%w: invalid head version: %q", access.ErrInvalidCommit, form.head)
+		}
+		commit.Head = head.Num()
+	}
+	if len(form.files) > 0 {
+		content, err := staging.Content(p.ID, form.files)
+		if err != nil {
+			return nil, err
+		}
+		commit.Content = content
+	}
+	return svc.CommitVersion(r.Context(), objID, commit)
+}
+
+// commitErrorStatus returns the HTTP status code for an error from
+// commitUploadForm. Internal errors are logged.
+func commitErrorStatus(r *http.Request, svc *access.Service, objID string, err error) int {
+	switch {
+	case errors.Is(err, access.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, access.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, access.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, access.ErrInvalidCommit),
+		errors.Is(err, ingest.ErrNotFound),
+		errors.Is(err, ingest.ErrIncomplete):
+		return http.StatusBadRequest
+	}
+	svc.Logger().LogAttrs(r.Context(), slog.LevelError, "committing object version: "+err.Error(),
+		slog.String("object_id", objID))
+	return http.StatusInternalServerError
+}
+
+// ownUpload returns the upload in the request path if it belongs to the
+// request's principal.
+func ownUpload(r *http.Request, staging *ingest.Staging) (ingest.Upload, error) {
+	p := auth.PrincipalFrom(r.Context())
+	u, err := staging.Get(r.PathValue("upload"))
+	if err != nil {
+		return u, err
+	}
+	if p == nil || u.Owner != p.ID {
+		return ingest.Upload{}, ingest.ErrNotFound
+	}
+	return u, nil
+}
+
+// uploadError writes an API error response for an error from the staging
+// area.
+func uploadError(w http.ResponseWriter, r *http.Request, svc *access.Service, err error) {
+	switch {
+	case errors.Is(err, access.ErrForbidden):
+		writeAPIError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, ingest.ErrMaxSize):
+		writeAPIError(w, http.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, ingest.ErrNotFound):
+		writeAPIError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, ingest.ErrOffsetMismatch):
+		writeAPIError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, ingest.ErrTooLarge):
+		writeAPIError(w, http.StatusRequestEntityTooLarge, err.Error())
+	default:
+		svc.Logger().LogAttrs(r.Context(), slog.LevelError, err.Error(),
+			slog.String("path", r.URL.Path))
+		writeAPIError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// uploadFormStatus returns the response status for an error from
+// readUploadForm.
+func uploadFormStatus(err error) int {
+	switch {
+	case errors.Is(err, access.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, ingest.ErrMaxSize):
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+func newAPIUpload(u ingest.Upload) *apiUpload {
+	return &apiUpload{
+		ID:      u.ID,
+		Length:  u.Length,
+		Offset:  u.Offset,
+		Created: u.Created,
+	}
+}
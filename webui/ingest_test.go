@@ -0,0 +1,243 @@
+package server_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/carlmjohnson/be"
+	"github.com/srerickson/ocfl-services/access"
+	"github.com/srerickson/ocfl-services/access/sqlite"
+	"github.com/srerickson/ocfl-services/ingest"
+	"github.com/srerickson/ocfl-services/internal/testutil"
+	server "github.com/srerickson/ocfl-services/webui"
+	"github.com/srerickson/ocfl-services/webui/auth"
+)
+
+func TestUploads(t *testing.T) {
+	tokens, err := auth.ParseTokens(strings.NewReader("depositor s3cr3t\nother 0th3r"))
+	be.NilErr(t, err)
+	staging, err := ingest.NewStaging(t.TempDir())
+	be.NilErr(t, err)
+	h := testWriteHandler(t, server.WithAuthenticators(tokens), server.WithStaging(staging))
+
+	do := func(method, path, token string, body io.Reader, headers map[string]string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, body)
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		for k, v := range headers {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+	commitJSON := func(objID, token string, body any) *httptest.ResponseRecorder {
+		b, err := json.Marshal(body)
+		be.NilErr(t, err)
+		return do(http.MethodPost, apiPath("objects", objID, "versions"), token, bytes.NewReader(b),
+			map[string]string{"Content-Type": "application/json"})
+	}
+
+	t.Run("resumable upload and commit", func(t *testing.T) {
+		w := do(http.MethodPost, apiPath("uploads"), "s3cr3t", nil, map[string]string{"Upload-Length": "11"})
+		be.Equal(t, http.StatusCreated, w.Code)
+		loc := w.Header().Get("Location")
+		be.True(t, strings.HasPrefix(loc, apiPath("uploads")+"/"))
+		chunk := map[string]string{"Content-Type": "application/offset+octet-stream", "Upload-Offset": "0"}
+		w = do(http.MethodPatch, loc, "s3cr3t", strings.NewReader("hello "), chunk)
+		be.Equal(t, http.StatusNoContent, w.Code)
+		be.Equal(t, "6", w.Header().Get("Upload-Offset"))
+
+		// resume from the offset reported by HEAD
+		w = do(http.MethodHead, loc, "s3cr3t", nil, nil)
+		be.Equal(t, http.StatusOK, w.Code)
+		be.Equal(t, "6", w.Header().Get("Upload-Offset"))
+		be.Equal(t, "11", w.Header().Get("Upload-Length"))
+		w = do(http.MethodPatch, loc, "s3cr3t", strings.NewReader("world"), chunk)
+		be.Equal(t, http.StatusConflict, w.Code)
+		chunk["Upload-Offset"] = "6"
+		w = do(http.MethodPatch, loc, "s3cr3t", strings.NewReader("world"), chunk)
+		be.Equal(t, http.StatusNoContent, w.Code)
+
+		// other principals can't see the upload
+		w = do(http.MethodGet, loc, "0th3r", nil, nil)
+		be.Equal(t, http.StatusNotFound, w.Code)
+
+		uploadID := loc[strings.LastIndex(loc, "/")+1:]
+		w = commitJSON("new-object", "s3cr3t", map[string]any{
+			"message": "first version",
+			"files":   map[string]string{"hello.txt": uploadID},
+		})
+		be.Equal(t, http.StatusCreated, w.Code)
+		be.Equal(t, apiPath("objects", "new-object", "versions", "v1"), w.Header().Get("Location"))
+		var obj struct {
+			ID   string `json:"id"`
+			Head string `json:"head"`
+		}
+		be.NilErr(t, json.NewDecoder(w.Body).Decode(&obj))
+		be.Equal(t, "new-object", obj.ID)
+		be.Equal(t, "v1", obj.Head)
+
+		w = do(http.MethodGet, objectPath("new-object", "v1", "hello.txt")+"?download", "s3cr3t", nil, nil)
+		be.Equal(t, http.StatusOK, w.Code)
+		be.Equal(t, "hello world", w.Body.String())
+
+		// committed uploads are removed
+		w = do(http.MethodGet, loc, "s3cr3t", nil, nil)
+		be.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("multipart commit", func(t *testing.T) {
+		body, contentType := testUploadForm(t, map[string]string{"head": "v1", "message": "second version", "dir": "docs"},
+			map[string]string{"sub/a.txt": "a", "b.txt": "b"})
+		w := do(http.MethodPost, apiPath("objects", "new-object", "versions"), "s3cr3t", body,
+			map[string]string{"Content-Type": contentType})
+		be.Equal(t, http.StatusCreated, w.Code)
+		w = do(http.MethodGet, objectPath("new-object", "v2", "docs/sub/a.txt")+"?download", "s3cr3t", nil, nil)
+		be.Equal(t, http.StatusOK, w.Code)
+		be.Equal(t, "a", w.Body.String())
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		u, err := staging.Put("token:depositor", strings.NewReader("stale"))
+		be.NilErr(t, err)
+		w := commitJSON("new-object", "s3cr3t", map[string]any{
+			"head":  "v1",
+			"files": map[string]string{"stale.txt": u.ID},
+		})
+		be.Equal(t, http.StatusConflict, w.Code)
+		// the upload is kept so the commit can be retried
+		_, err = staging.Get(u.ID)
+		be.NilErr(t, err)
+	})
+
+	t.Run("upload form", func(t *testing.T) {
+		w := do(http.MethodGet, "/upload?id=new-object", "s3cr3t", nil, nil)
+		be.Equal(t, http.StatusOK, w.Code)
+		be.In(t, `name="head" value="v2"`, w.Body.String())
+
+		body, contentType := testUploadForm(t, map[string]string{"id": "new-object", "head": "v2", "message": "from form"},
+			map[string]string{"c.txt": "c"})
+		w = do(http.MethodPost, "/upload", "s3cr3t", body, map[string]string{"Content-Type": contentType})
+		be.Equal(t, http.StatusSeeOther, w.Code)
+		be.Equal(t, historyPath("new-object", "v3"), w.Header().Get("Location"))
+
+		// a stale head shows the form with the error
+		body, contentType = testUploadForm(t, map[string]string{"id": "new-object", "head": "v2"},
+			map[string]string{"d.txt": "d"})
+		w = do(http.MethodPost, "/upload", "s3cr3t", body, map[string]string{"Content-Type": contentType})
+		be.Equal(t, http.StatusConflict, w.Code)
+		be.In(t, "form-error", w.Body.String())
+	})
+
+	t.Run("write permission", func(t *testing.T) {
+		// without an access policy, all writes are denied
+		h := testHandler(t, server.WithAuthenticators(tokens), server.WithStaging(staging))
+		u, err := staging.Put("token:depositor", strings.NewReader("denied"))
+		be.NilErr(t, err)
+		b, err := json.Marshal(map[string]any{"files": map[string]string{"a.txt": u.ID}})
+		be.NilErr(t, err)
+		r := httptest.NewRequest(http.MethodPost, apiPath("objects", "other-object", "versions"), bytes.NewReader(b))
+		r.Header.Set("Authorization", "Bearer s3cr3t")
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		be.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("cross-origin form", func(t *testing.T) {
+		body, contentType := testUploadForm(t, map[string]string{"id": "new-object", "head": "v3"},
+			map[string]string{"e.txt": "e"})
+		w := do(http.MethodPost, "/upload", "s3cr3t", body, map[string]string{
+			"Content-Type":   contentType,
+			"Sec-Fetch-Site": "cross-site",
+		})
+		be.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
+
+func TestUploadLimits(t *testing.T) {
+	tokens, err := auth.ParseTokens(strings.NewReader("depositor s3cr3t\nreader r34d3r"))
+	be.NilErr(t, err)
+	staging, err := ingest.NewStaging(t.TempDir(), ingest.WithMaxUploadSize(4))
+	be.NilErr(t, err)
+	db, err := sqlite.NewDB(filepath.Join(t.TempDir(), "test.db"))
+	be.NilErr(t, err)
+	t.Cleanup(func() { db.Close() })
+	policy := &access.Policy{
+		Rules: []access.Rule{{
+			Effect:   access.Allow,
+			Subjects: access.Subjects{Principals: []string{auth.PrincipalID(auth.MethodToken, "depositor")}},
+			Actions:  []access.Action{access.Read, access.Write},
+		}},
+	}
+	root := testutil.FixtureRootCopy(t, filepath.Join("..", "testdata"))
+	svc := access.NewService(root, db, "test", nil, access.WithPolicy(policy))
+	h := server.New(svc, server.WithAuthenticators(tokens), server.WithStaging(staging))
+	uploads, versions := apiPath("uploads"), apiPath("objects", "new-object", "versions")
+	do := func(path, token string, body io.Reader, headers map[string]string) int {
+		r := httptest.NewRequest(http.MethodPost, path, body)
+		r.Header.Set("Authorization", "Bearer "+token)
+		for k, v := range headers {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	// principals that can't write can't stage uploads
+	be.Equal(t, http.StatusForbidden, do(uploads, "r34d3r", nil, map[string]string{"Upload-Length": "1"}))
+	body, contentType := testUploadForm(t, map[string]string{"message": "m"}, map[string]string{"a.txt": "a"})
+	be.Equal(t, http.StatusForbidden, do(versions, "r34d3r", body, map[string]string{"Content-Type": contentType}))
+
+	// uploads can't exceed the max upload size
+	be.Equal(t, http.StatusRequestEntityTooLarge, do(uploads, "s3cr3t", nil, map[string]string{"Upload-Length": "5"}))
+	be.Equal(t, http.StatusCreated, do(uploads, "s3cr3t", nil, map[string]string{"Upload-Length": "4"}))
+	body, contentType = testUploadForm(t, map[string]string{"message": "m"}, map[string]string{"a.txt": "too large"})
+	be.Equal(t, http.StatusRequestEntityTooLarge, do(versions, "s3cr3t", body, map[string]string{"Content-Type": contentType}))
+}
+
+func TestUploadsRequireAuthentication(t *testing.T) {
+	staging, err := ingest.NewStaging(t.TempDir())
+	be.NilErr(t, err)
+	h := testHandler(t, server.WithStaging(staging))
+	r := httptest.NewRequest(http.MethodPost, apiPath("uploads"), nil)
+	r.Header.Set("Upload-Length", "1")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	be.Equal(t, http.StatusForbidden, w.Code)
+
+	// uploads aren't shown without a staging area
+	w = doRequest(t, testHandler(t), http.MethodGet, "/upload")
+	be.Equal(t, http.StatusNotFound, w.Code)
+	w = doRequest(t, testHandler(t), http.MethodGet, objectPath(fixtureObjectID, "v1", ""))
+	be.NotIn(t, "Upload", w.Body.String())
+}
+
+// testUploadForm returns a multipart upload form body with the fields and
+// files (contents by file name), and its content type.
+func testUploadForm(t *testing.T, fields map[string]string, files map[string]string) (io.Reader, string) {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for name, val := range fields {
+		be.NilErr(t, mw.WriteField(name, val))
+	}
+	for name, content := range files {
+		fw, err := mw.CreateFormFile("file", name)
+		be.NilErr(t, err)
+		_, err = io.WriteString(fw, content)
+		be.NilErr(t, err)
+	}
+	be.NilErr(t, mw.Close())
+	return &body, mw.FormDataContentType()
+}
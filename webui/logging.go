@@ -4,10 +4,12 @@ import (
 	"log/slog"
 	"net/http"
 	"time"
+
+	"github.com/srerickson/ocfl-services/webui/auth"
 )
 
-// loggingMiddleware logs HTTP requests with method, path, status code and
-// duration
+// loggingMiddleware logs HTTP requests with method, path, status code,
+// duration, and the authenticated principal, if any
 func loggingMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -18,21 +20,27 @@ func loggingMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
 			}
 			next.ServeHTTP(recorder, r)
 			duration := time.Since(start)
-			logger.Info("http",
+			attrs := []any{
 				"method", r.Method,
 				"path", r.URL.Path,
 				"status", recorder.statusCode,
 				"duration", duration,
 				"remote_addr", r.RemoteAddr,
-			)
+			}
+			if p := recorder.principal; p != nil {
+				attrs = append(attrs, "principal", p.ID, "auth_method", p.Method)
+			}
+			logger.Info("http", attrs...)
 		})
 	}
 }
 
-// responseRecorder wraps http.ResponseWriter to capture status code
+// responseRecorder wraps http.ResponseWriter to capture status code and the
+// principal set by authMiddleware
 type responseRecorder struct {
 	http.ResponseWriter
 	statusCode int
+	principal  *auth.Principal
 }
 
 func (r *responseRecorder) Unwrap() http.ResponseWriter {
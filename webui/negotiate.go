@@ -0,0 +1,83 @@
+package server
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/a-h/templ"
+)
+
+// wantsJSON reports whether the request's Accept header prefers JSON to HTML.
+// An explicit application/json range is preferred to wildcards (*/*, text/*)
+// with the same quality value; HTML is preferred if both types are given
+// explicitly with the same quality value.
+func wantsJSON(r *http.Request) bool {
+	var jsonQ, htmlQ, wildQ float64
+	var hasHTML bool
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if val, ok := params["q"]; ok {
+			q, err = strconv.ParseFloat(val, 64)
+			if err != nil {
+				continue
+			}
+		}
+		switch mediaType {
+		case "application/json":
+			jsonQ = max(jsonQ, q)
+		case "text/html":
+			hasHTML = true
+			htmlQ = max(htmlQ, q)
+		case "*/*", "text/*":
+			wildQ = max(wildQ, q)
+		}
+	}
+	if jsonQ <= 0 {
+		return false
+	}
+	if hasHTML {
+		return jsonQ > htmlQ
+	}
+	return jsonQ >= wildQ
+}
+
+// varyAccept adds "Accept" to the response's Vary header, so caches don't mix
+// up HTML and JSON responses for the same URL.
+func varyAccept(w http.ResponseWriter) {
+	for _, val := range w.Header().Values("Vary") {
+		for _, field := range strings.Split(val, ",") {
+			if strings.EqualFold(strings.TrimSpace(field), "Accept") {
+				return
+			}
+		}
+	}
+	w.Header().Add("Vary", "Accept")
+}
+
+// renderPage writes page as JSON if the client prefers it, or else renders the
+// page's HTML component.
+func renderPage(w http.ResponseWriter, r *http.Request, page any, component templ.Component) {
+	varyAccept(w)
+	if wantsJSON(r) {
+		writeJSON(w, http.StatusOK, page)
+		return
+	}
+	component.Render(r.Context(), w)
+}
+
+// httpError writes an error response, formatted as a JSON API error object if
+// the client prefers JSON.
+func httpError(w http.ResponseWriter, r *http.Request, msg string, code int) {
+	varyAccept(w)
+	if wantsJSON(r) {
+		writeAPIError(w, code, msg)
+		return
+	}
+	http.Error(w, msg, code)
+}
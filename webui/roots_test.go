@@ -0,0 +1,123 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/carlmjohnson/be"
+	"github.com/srerickson/ocfl-services/access"
+	"github.com/srerickson/ocfl-services/access/sqlite"
+	"github.com/srerickson/ocfl-services/internal/testutil"
+	server "github.com/srerickson/ocfl-services/webui"
+	"github.com/srerickson/ocfl-services/webui/auth"
+)
+
+// testRoots returns two roots, "main" and "private", for copies of the test
+// fixture root with a shared database. The private root has an access policy,
+// so its object count is hidden.
+func testRoots(t *testing.T) []server.Root {
+	t.Helper()
+	db, err := sqlite.NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal("setting up test db:", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	policy := &access.Policy{Rules: []access.Rule{{Effect: access.Allow}}}
+	roots := []server.Root{
+		{Name: "main", Service: access.NewService(testutil.FixtureRootCopy(t, filepath.Join("..", "testdata")), db, "main", nil)},
+		{Name: "private", Service: access.NewService(testutil.FixtureRootCopy(t, filepath.Join("..", "testdata")), db, "private", nil, access.WithPolicy(policy))},
+	}
+	for _, r := range roots {
+		_, err := r.Service.IndexRoot(t.Context(), access.IndexOptions{})
+		be.NilErr(t, err)
+	}
+	return roots
+}
+
+func TestMultipleRoots(t *testing.T) {
+	h := server.NewMulti(testRoots(t), nil)
+	escapedID := url.PathEscape(fixtureObjectID)
+
+	t.Run("root list", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, "/")
+		be.Equal(t, http.StatusOK, w.Code)
+		be.In(t, `href="/r/main/"`, w.Body.String())
+		be.In(t, `href="/r/private/"`, w.Body.String())
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", "application/json")
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		be.Equal(t, http.StatusOK, w.Code)
+		var page struct {
+			Roots []struct {
+				Name       string `json:"name"`
+				NumObjects *int   `json:"num_objects"`
+			} `json:"roots"`
+		}
+		be.NilErr(t, json.Unmarshal(w.Body.Bytes(), &page))
+		be.Equal(t, 2, len(page.Roots))
+		be.Equal(t, "main", page.Roots[0].Name)
+		be.True(t, page.Roots[0].NumObjects != nil)
+		be.Equal(t, 1, *page.Roots[0].NumObjects)
+		be.Equal(t, "private", page.Roots[1].Name)
+		be.True(t, page.Roots[1].NumObjects == nil)
+	})
+
+	t.Run("root pages", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, "/r/main/")
+		be.Equal(t, http.StatusOK, w.Code)
+		be.In(t, `action="/r/main/search"`, w.Body.String())
+		be.In(t, `href="/r/main/objects"`, w.Body.String())
+
+		w = doRequest(t, h, http.MethodGet, "/r/main/objects")
+		be.Equal(t, http.StatusOK, w.Code)
+		be.In(t, `href="/r/main/object/`+escapedID+`/head/"`, w.Body.String())
+
+		w = doRequest(t, h, http.MethodGet, "/r/main/object/"+escapedID)
+		be.Equal(t, http.StatusFound, w.Code)
+		be.Equal(t, "/r/main/object/"+escapedID+"/head/", w.Header().Get("Location"))
+
+		w = doRequest(t, h, http.MethodGet, "/r/private/history/"+escapedID)
+		be.Equal(t, http.StatusOK, w.Code)
+		be.In(t, `href="/r/private/history/`+escapedID+`/v1"`, w.Body.String())
+
+		w = doRequest(t, h, http.MethodGet, "/r/missing/")
+		be.Equal(t, http.StatusNotFound, w.Code)
+		w = doRequest(t, h, http.MethodGet, "/static/app.css")
+		be.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("root API", func(t *testing.T) {
+		var obj struct {
+			ID string `json:"id"`
+		}
+		decodeAPI(t, h, "/r/main"+apiPath("objects", escapedID), http.StatusOK, &obj)
+		be.Equal(t, fixtureObjectID, obj.ID)
+		var metrics struct {
+			NumObjects *int `json:"num_objects"`
+		}
+		decodeAPI(t, h, "/r/private"+apiPath("metrics"), http.StatusOK, &metrics)
+		be.True(t, metrics.NumObjects == nil)
+	})
+
+	t.Run("authentication", func(t *testing.T) {
+		tokens, err := auth.ParseTokens(strings.NewReader("reader r3ad3r"))
+		be.NilErr(t, err)
+		h := server.NewMulti(testRoots(t), nil, server.WithAuthenticators(tokens))
+		w := doRequest(t, h, http.MethodGet, "/r/main"+apiPath("objects"))
+		be.Equal(t, http.StatusUnauthorized, w.Code)
+		be.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+		r := httptest.NewRequest(http.MethodGet, "/r/main/objects", nil)
+		r.Header.Set("Authorization", "Bearer r3ad3r")
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		be.Equal(t, http.StatusOK, w.Code)
+	})
+}
@@ -2,17 +2,22 @@ package server
 
 import (
 	"bytes"
+	"context"
 	"embed"
+	"encoding/csv"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"log/slog"
+	"mime"
 	"net/http"
 	"path"
 	"slices"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/a-h/templ"
 	"github.com/gomarkdown/markdown"
@@ -20,42 +25,219 @@ import (
 	"github.com/gomarkdown/markdown/parser"
 	"github.com/srerickson/ocfl-go"
 	"github.com/srerickson/ocfl-services/access"
+	"github.com/srerickson/ocfl-services/ingest"
+	"github.com/srerickson/ocfl-services/internal/textdiff"
+	"github.com/srerickson/ocfl-services/webui/auth"
 	"github.com/srerickson/ocfl-services/webui/template"
+	"github.com/srerickson/ocfl-services/webui/utils"
 )
 
-// max size for markdown files we will render
-const maxMarkdownSize = 1024 * 1024 * 2 // 2 MiB
+// default max size for markdown files we will render
+const defaultMaxMarkdownSize = 1024 * 1024 * 2 // 2 MiB
+
+// max size for text, JSON, and CSV files we will preview
+const maxPreviewSize = 1024 * 1024 * 2 // 2 MiB
+
+// max size for text files we will compare line-by-line
+const maxDiffSize = 1024 * 1024 // 1 MiB
+
+// number of unchanged lines shown around changes in file diffs
+const diffContextLines = 3
+
+// number of objects per page in the object list
+const objectListPageSize = 50
+
+// number of objects per page in search results
+const searchPageSize = 50
+
+// max number of suggestions for the object lookup form
+const maxSearchSuggestions = 10
+
+// default max total size of files in a directory archive download
+const defaultMaxArchiveSize = 1024 * 1024 * 1024 * 4 // 4 GiB
 
 //go:embed static/dst/*
 var staticFiles embed.FS
 
+// config holds settings for the handlers returned by New and NewMulti.
+type config struct {
+	maxArchiveSize  int64
+	maxMarkdownSize int64
+	authenticators  []auth.Authenticator
+	staging         *ingest.Staging
+}
+
+// Option is used to configure the handlers returned by New and NewMulti.
+type Option func(*config)
+
+// WithMaxArchiveSize sets the max total size in bytes of files in a directory
+// archive download. Use 0 for no limit.
+func WithMaxArchiveSize(size int64) Option {
+	return func(c *config) { c.maxArchiveSize = size }
+}
+
+// WithMaxMarkdownSize sets the max size in bytes of README files rendered as
+// markdown in directory listings. Larger files aren't rendered.
+func WithMaxMarkdownSize(size int64) Option {
+	return func(c *config) { c.maxMarkdownSize = size }
+}
+
+// WithAuthenticators requires requests to be authenticated by one of the
+// authenticators, which are tried in order. Static files and endpoints served
+// by the authenticators (see auth.Router) don't require authentication.
+func WithAuthenticators(authns ...auth.Authenticator) Option {
+	return func(c *config) { c.authenticators = append(c.authenticators, authns...) }
+}
+
+// WithStaging enables the upload form and the API endpoints for uploading
+// files and creating object versions, using staging for uploaded files.
+// Uploads require an authenticated principal (see WithAuthenticators), who is
+// recorded as the new versions' user.
+func WithStaging(staging *ingest.Staging) Option {
+	return func(c *config) { c.staging = staging }
+}
+
 // New creates handler for serving from accessService's OCFL storage root.
-func New(accessService *access.Service) http.Handler {
+func New(accessService *access.Service, opts ...Option) http.Handler {
+	cfg := newConfig(opts)
+	root := rootHandler(accessService, cfg, "", "")
+	return serve(root, accessService.Logger(), cfg)
+}
+
+// Root is a named OCFL storage root served by the handler returned by
+// NewMulti.
+type Root struct {
+	Name    string // URL path segment for the root's pages
+	Service *access.Service
+}
+
+// NewMulti creates a handler for serving several OCFL storage roots. Each
+// root's pages and API are served under /r/{name}, and the home page lists
+// the roots. Options apply to all roots. Requests are logged with logger; if
+// it's nil, requests aren't logged.
+func NewMulti(roots []Root, logger *slog.Logger, opts ...Option) http.Handler {
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+	cfg := newConfig(opts)
 	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", HandleListRoots(roots))
+	for _, r := range roots {
+		prefix := utils.RootsPath + r.Name
+		root := rootHandler(r.Service, cfg, r.Name, prefix)
+		mux.Handle(prefix+"/", http.StripPrefix(prefix, root))
+	}
+	return serve(mux, logger, cfg)
+}
 
-	// static files: css and js
-	staticFS, _ := fs.Sub(staticFiles, "static/dst")
-	mux.Handle("GET /static/", http.StripPrefix("/static/", http.FileServer(http.FS(staticFS))))
+func newConfig(opts []Option) config {
+	cfg := config{
+		maxArchiveSize:  defaultMaxArchiveSize,
+		maxMarkdownSize: defaultMaxMarkdownSize,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// rootHandler returns a handler for the pages and API of accessService's
+// storage root. The root's pages are rendered with links prefixed by prefix,
+// which is empty if the root is the only one.
+func rootHandler(accessService *access.Service, cfg config, name string, prefix string) http.Handler {
+	mux := http.NewServeMux()
 
 	// homepage
 	mux.HandleFunc("GET /{$}", HandleIndex())
 
+	// list of all indexed objects
+	mux.HandleFunc("GET /objects", HandleListObjects(accessService))
+
+	// object and version search
+	mux.HandleFunc("GET /search", HandleSearch(accessService))
+	mux.HandleFunc("GET /search/suggest", HandleSearchSuggest(accessService))
+
 	// object files view
-	mux.HandleFunc("GET /object/{id}/{version}/{path...}", HandleGetObjectFiles(accessService))
+	mux.HandleFunc("GET /object/{id}/{version}/{path...}", HandleGetObjectFiles(accessService, cfg.maxArchiveSize, cfg.maxMarkdownSize))
 	mux.HandleFunc("GET /object/{id}/{version}", redirectToDefaultObjectFiles)
 	mux.HandleFunc("GET /object/{id}/", redirectToDefaultObjectFiles)
 	mux.HandleFunc("GET /object/{id}", redirectToDefaultObjectFiles)
 
 	mux.HandleFunc("GET /history/{id}", HandleGetObjectHistory(accessService))
 	mux.HandleFunc("GET /history/{id}/{version}", HandleGetVersionChanges(accessService))
+	mux.HandleFunc("GET /history/{id}/compare", HandleCompareVersionsForm(accessService))
+	mux.HandleFunc("GET /history/{id}/compare/{versions}", HandleCompareVersions(accessService))
+	mux.HandleFunc("GET /history/{id}/{version}/diff/{path...}", HandleGetFileDiff(accessService))
 
 	mux.HandleFunc("GET /inventory/{id}", HandleGetObjectInventory(accessService))
 
+	// validation reports; admins can validate objects again
+	mux.HandleFunc("GET /validation/{id}", HandleGetValidation(accessService))
+	mux.Handle("POST /validation/{id}", http.NewCrossOriginProtection().Handler(
+		HandleValidateObject(accessService)))
+
+	// upload form
+	if cfg.staging != nil {
+		mux.HandleFunc("GET /upload", HandleUploadForm(accessService))
+		mux.Handle("POST /upload", http.NewCrossOriginProtection().Handler(
+			HandleUpload(accessService, cfg.staging)))
+
+		// drafts of new object versions
+		csrf := http.NewCrossOriginProtection()
+		mux.HandleFunc("GET /drafts", HandleListDrafts(accessService))
+		mux.Handle("POST /drafts", csrf.Handler(HandleOpenDraft(accessService)))
+		mux.HandleFunc("GET /draft/{draft}/{path...}", HandleGetDraft(accessService))
+		mux.Handle("POST /draft/{draft}/files", csrf.Handler(HandleAddDraftFiles(accessService, cfg.staging)))
+		mux.Handle("POST /draft/{draft}/rename", csrf.Handler(HandleRenameDraftPath(accessService)))
+		mux.Handle("POST /draft/{draft}/remove", csrf.Handler(HandleRemoveDraftPath(accessService)))
+		mux.Handle("POST /draft/{draft}/commit", csrf.Handler(HandleCommitDraft(accessService, cfg.staging)))
+		mux.Handle("POST /draft/{draft}/discard", csrf.Handler(HandleDiscardDraft(accessService, cfg.staging)))
+	}
+
+	// JSON API
+	mux.Handle(apiBasePath+"/", http.StripPrefix(apiBasePath, newAPIMux(accessService, cfg.staging)))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := utils.WithRoot(r.Context(), name, prefix)
+		if cfg.staging != nil {
+			ctx = template.WithUploads(ctx)
+		}
+		mux.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// serve wraps handler with routes for static files, authentication, and
+// request logging.
+func serve(handler http.Handler, logger *slog.Logger, cfg config) http.Handler {
+	// static files: css and js
+	staticFS, _ := fs.Sub(staticFiles, "static/dst")
+	staticHandler := http.StripPrefix("/static/", http.FileServer(http.FS(staticFS)))
+	mux := http.NewServeMux()
+	mux.Handle("GET /static/", staticHandler)
+	mux.Handle("/", handler)
+
+	handler = mux
+	if len(cfg.authenticators) > 0 {
+		// routes that don't require authentication
+		public := http.NewServeMux()
+		public.Handle("GET /static/", staticHandler)
+		for _, authn := range cfg.authenticators {
+			if router, ok := authn.(auth.Router); ok {
+				router.RegisterRoutes(public)
+			}
+		}
+		handler = authMiddleware(cfg.authenticators, public)(handler)
+	}
+
 	// wrap with logging middleware
-	return loggingMiddleware(accessService.Logger())(mux)
+	return loggingMiddleware(logger)(handler)
 }
 
-func HandleGetObjectFiles(svc *access.Service) http.HandlerFunc {
+// HandleGetObjectFiles serves files and directory listings for object
+// versions. Directory archive downloads larger than maxArchiveSize bytes are
+// refused; if maxArchiveSize is 0, there is no limit. README files larger
+// than maxMarkdownSize bytes aren't rendered.
+func HandleGetObjectFiles(svc *access.Service, maxArchiveSize, maxMarkdownSize int64) http.HandlerFunc {
 
 	// request parameters
 	type params struct {
@@ -65,7 +247,9 @@ func HandleGetObjectFiles(svc *access.Service) http.HandlerFunc {
 		path   string    // clean path for request: file or directory in version state
 		isDir  bool      // if requested path is "." or ends with "/" this is true
 
-		renderReadme bool // render readme file, don't download it
+		renderReadme bool                 // render readme file, don't download it
+		preview      bool                 // show file preview page, don't download it
+		archive      access.ArchiveFormat // download directory as an archive
 	}
 
 	// returned error means "bad request"
@@ -75,6 +259,7 @@ func HandleGetObjectFiles(svc *access.Service) http.HandlerFunc {
 			verRef:       r.PathValue("version"),
 			path:         r.PathValue("path"),
 			renderReadme: r.URL.Query().Has("render"),
+			preview:      r.URL.Query().Has("preview"),
 		}
 		if p.verRef != "head" {
 			// must be valid version number (v1, v002)
@@ -91,9 +276,10 @@ func HandleGetObjectFiles(svc *access.Service) http.HandlerFunc {
 		if !fs.ValidPath(p.path) {
 			err = fmt.Errorf("path %q: %w", p.path, fs.ErrInvalid)
 		}
-		// ignore render for HEAD requests
+		// ignore render and preview for HEAD requests
 		if r.Method == http.MethodHead {
 			p.renderReadme = false
+			p.preview = false
 		}
 		// if render is set, path must be readme
 		if p.renderReadme {
@@ -101,6 +287,16 @@ func HandleGetObjectFiles(svc *access.Service) http.HandlerFunc {
 				err = errors.New("invalid path for markdown rendering")
 			}
 		}
+		if p.preview && p.isDir {
+			err = errors.New("invalid path for file preview")
+		}
+		if r.URL.Query().Has("archive") {
+			if !p.isDir {
+				err = errors.New("invalid path for archive download")
+				return
+			}
+			p.archive, err = access.ParseArchiveFormat(r.URL.Query().Get("archive"))
+		}
 		return
 	}
 
@@ -110,7 +306,7 @@ func HandleGetObjectFiles(svc *access.Service) http.HandlerFunc {
 			return
 		}
 		if errors.Is(err, access.ErrNotFound) {
-			http.Error(w, err.Error(), http.StatusNotFound)
+			httpError(w, r, err.Error(), http.StatusNotFound)
 			return
 		}
 		logAttrs := []slog.Attr{
@@ -119,34 +315,68 @@ func HandleGetObjectFiles(svc *access.Service) http.HandlerFunc {
 			slog.String("version", p.verRef),
 			slog.Bool("is_dir", p.isDir),
 			slog.Bool("render", p.renderReadme),
+			slog.Bool("preview", p.preview),
+			slog.String("archive", string(p.archive)),
 		}
 		svc.Logger().LogAttrs(r.Context(), slog.LevelError, err.Error(), logAttrs...)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		httpError(w, r, err.Error(), http.StatusInternalServerError)
 	}
 
-	// handle file requests: download file
+	// handle file requests: download file. Range requests and conditional
+	// requests are handled by http.ServeContent. Content is immutable, so the
+	// file's digest is used as its ETag.
 	handleFile := func(p *params) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
 			ctx := r.Context()
-			f, err := svc.OpenVersionFile(ctx, p.objID, p.ver.Num(), p.path)
+			f, err := svc.OpenVersionFileReader(ctx, p.objID, p.ver.Num(), p.path)
 			if err != nil {
 				logErr(w, r, p, err)
 				return
 			}
 			defer f.Close()
-			info, err := f.Stat()
+			info := f.Info()
+			w.Header().Set("ETag", `"`+info.Digest()+`"`)
+			w.Header().Set("Content-Type", f.MediaType())
+			w.Header().Set("Content-Disposition", contentDisposition(f.MediaType(), path.Base(p.path)))
+			w.Header().Set("X-Content-Type-Options", "nosniff")
+			http.ServeContent(w, r, path.Base(p.path), info.Modtime(), f)
+		}
+	}
+
+	// handle file requests: preview file in object page layout
+	handlePreview := func(p *params) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			f, err := svc.OpenVersionFileReader(ctx, p.objID, p.ver.Num(), p.path)
 			if err != nil {
 				logErr(w, r, p, err)
 				return
 			}
-			w.Header().Add("Content-Length", strconv.FormatInt(info.Size(), 10))
-			if r.Method == http.MethodHead {
-				return
+			defer f.Close()
+			page := &template.FilePreview{
+				ObjectID:     p.objID,
+				VersionRef:   p.verRef,
+				CurrentPath:  p.path,
+				MediaType:    f.MediaType(),
+				Size:         f.Size(),
+				Digest:       f.Info().Digest(),
+				Kind:         previewKind(f.MediaType()),
+				DownloadHref: utils.LinkObjectFiles(ctx, p.objID, p.verRef, p.path, false),
 			}
-			if _, err := io.Copy(w, f); err != nil {
-				logErr(w, r, p, err)
-				return
+			switch page.Kind {
+			case template.PreviewText, template.PreviewCSV:
+				if f.Size() > maxPreviewSize {
+					page.TooLarge = true
+					break
+				}
+				content, err := io.ReadAll(f)
+				if err != nil {
+					logErr(w, r, p, err)
+					return
+				}
+				setPreviewContent(page, content)
 			}
+			template.FilePreviewPage(page).Render(ctx, w)
 		}
 	}
 
@@ -181,10 +411,51 @@ func HandleGetObjectFiles(svc *access.Service) http.HandlerFunc {
 		}
 	}
 
-	// handle directory requests
+	// handle directory requests: download the directory as an archive. The
+	// archive is streamed as files are read from storage, so errors after the
+	// response has started can only be logged.
+	handleArchive := func(p *params) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			archive, err := svc.NewVersionArchive(ctx, p.objID, p.ver.Num(), p.path)
+			if err != nil {
+				logErr(w, r, p, err)
+				return
+			}
+			if size := archive.Size(); maxArchiveSize > 0 && size > maxArchiveSize {
+				msg := fmt.Sprintf("archive size (%s) exceeds the limit (%s)",
+					utils.FileSize(size), utils.FileSize(maxArchiveSize))
+				httpError(w, r, msg, http.StatusRequestEntityTooLarge)
+				return
+			}
+			name := archiveName(p.objID, p.verRef, p.path, p.archive)
+			w.Header().Set("Content-Type", p.archive.MediaType())
+			w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+			if r.Method == http.MethodHead {
+				return
+			}
+			if err := archive.Write(ctx, w, p.archive); err != nil {
+				if ctx.Err() != nil {
+					svc.Logger().LogAttrs(ctx, slog.LevelWarn, "archive download canceled",
+						slog.String("object_id", p.objID),
+						slog.String("path", p.path),
+						slog.String("version", p.verRef))
+					return
+				}
+				svc.Logger().LogAttrs(ctx, slog.LevelError, "writing archive: "+err.Error(),
+					slog.String("object_id", p.objID),
+					slog.String("path", p.path),
+					slog.String("version", p.verRef))
+			}
+		}
+	}
+
+	// handle directory requests: HTML or JSON listing, depending on the
+	// Accept header.
 	handleDir := func(p *params) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
 			ctx := r.Context()
+			varyAccept(w)
 			obj, err := svc.SyncObject(ctx, p.objID)
 			if err != nil {
 				logErr(w, r, p, err)
@@ -229,6 +500,8 @@ func HandleGetObjectFiles(svc *access.Service) http.HandlerFunc {
 				href := entry.Name()
 				if entry.IsDir() {
 					href += "/"
+				} else {
+					href += "?preview"
 				}
 				page.DirectoryEntries = append(page.DirectoryEntries, &template.DirectoryEntry{
 					Name:    entry.Name(),
@@ -244,22 +517,38 @@ func HandleGetObjectFiles(svc *access.Service) http.HandlerFunc {
 					page.ReadmeHref = entry.Name() + "?render=1"
 				}
 			}
-			template.ObjectFilesPage(page).Render(r.Context(), w)
+			failures, err := svc.ObjectFixity(ctx, p.objID, access.FixityFail)
+			if err != nil {
+				logErr(w, r, p, err)
+				return
+			}
+			for _, f := range failures {
+				page.FixityFailures = append(page.FixityFailures, &template.FixityFailure{
+					ContentPath: f.ContentPath(),
+					Error:       f.FixityError(),
+					CheckedAt:   f.FixityCheckedAt(),
+				})
+			}
+			renderPage(w, r, page, template.ObjectFilesPage(page))
 		}
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
 		p, err := getParams(r)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			httpError(w, r, err.Error(), http.StatusBadRequest)
 			return
 		}
 		var next http.HandlerFunc
 		switch {
+		case p.archive != "":
+			next = handleArchive(p)
 		case p.isDir:
 			next = handleDir(p)
 		case p.renderReadme:
 			next = handleReadme(p)
+		case p.preview:
+			next = handlePreview(p)
 		default:
 			next = handleFile(p)
 		}
@@ -279,6 +568,7 @@ func buildFileTree(changes []access.VersionFileChange) *template.FileTreeNode {
 	for _, change := range changes {
 		filePath := change.Path()
 		modType := change.Type()
+		source := change.SourcePath()
 
 		// Split path into segments
 		segments := strings.Split(strings.Trim(filePath, "/"), "/")
@@ -298,6 +588,7 @@ func buildFileTree(changes []access.VersionFileChange) *template.FileTreeNode {
 					Path:    filePath,
 					IsDir:   false,
 					ModType: modType,
+					Source:  source,
 				}
 				current.Children = append(current.Children, fileNode)
 			} else {
@@ -364,18 +655,19 @@ func HandleGetObjectHistory(svc *access.Service) http.HandlerFunc {
 			return
 		}
 		if errors.Is(err, access.ErrNotFound) {
-			http.Error(w, err.Error(), http.StatusNotFound)
+			httpError(w, r, err.Error(), http.StatusNotFound)
 			return
 		}
 		logAttrs := []slog.Attr{
 			slog.String("object_id", id),
 		}
 		svc.Logger().LogAttrs(r.Context(), slog.LevelError, err.Error(), logAttrs...)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		httpError(w, r, err.Error(), http.StatusInternalServerError)
 	}
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		id := r.PathValue("id")
+		varyAccept(w)
 		versions, err := svc.ListVersions(ctx, id)
 		if err != nil {
 			logErr(w, r, id, err)
@@ -395,7 +687,7 @@ func HandleGetObjectHistory(svc *access.Service) http.HandlerFunc {
 				UserAddr: v.UserAddr(),
 			}
 		}
-		template.ObjectHistoryPage(page).Render(ctx, w)
+		renderPage(w, r, page, template.ObjectHistoryPage(page))
 	}
 }
 
@@ -405,7 +697,7 @@ func HandleGetVersionChanges(svc *access.Service) http.HandlerFunc {
 			return
 		}
 		if errors.Is(err, access.ErrNotFound) {
-			http.Error(w, err.Error(), http.StatusNotFound)
+			httpError(w, r, err.Error(), http.StatusNotFound)
 			return
 		}
 		logAttrs := []slog.Attr{
@@ -413,19 +705,20 @@ func HandleGetVersionChanges(svc *access.Service) http.HandlerFunc {
 			slog.String("version", version),
 		}
 		svc.Logger().LogAttrs(r.Context(), slog.LevelError, err.Error(), logAttrs...)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		httpError(w, r, err.Error(), http.StatusInternalServerError)
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		id := r.PathValue("id")
 		version := r.PathValue("version")
+		varyAccept(w)
 
 		// Parse version number
 		var vn ocfl.VNum
 		err := ocfl.ParseVNum(version, &vn)
 		if err != nil {
-			http.Error(w, "invalid version format", http.StatusBadRequest)
+			httpError(w, r, "invalid version format", http.StatusBadRequest)
 			return
 		}
 
@@ -482,8 +775,359 @@ func HandleGetVersionChanges(svc *access.Service) http.HandlerFunc {
 			page.PrevVNum = ocfl.V(vn.Num()-1, vn.Padding())
 		}
 
-		template.VersionChangesPage(page).Render(ctx, w)
+		renderPage(w, r, page, template.VersionChangesPage(page))
+	}
+}
+
+// HandleCompareVersionsForm redirects requests from the version picker form,
+// with "from" and "to" query parameters, to the comparison page for the
+// versions. Without the parameters, the head version is compared with the
+// previous version.
+func HandleCompareVersionsForm(svc *access.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		id := r.PathValue("id")
+		query := r.URL.Query()
+		var fromV, toV ocfl.VNum
+		if err := ocfl.ParseVNum(query.Get("from"), &fromV); err != nil && query.Has("from") {
+			httpError(w, r, "invalid from version format", http.StatusBadRequest)
+			return
+		}
+		if err := ocfl.ParseVNum(query.Get("to"), &toV); err != nil && query.Has("to") {
+			httpError(w, r, "invalid to version format", http.StatusBadRequest)
+			return
+		}
+		obj, err := svc.SyncObject(ctx, id)
+		if err != nil {
+			if errors.Is(err, access.ErrNotFound) {
+				httpError(w, r, err.Error(), http.StatusNotFound)
+				return
+			}
+			svc.Logger().LogAttrs(ctx, slog.LevelError, err.Error(), slog.String("object_id", id))
+			httpError(w, r, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		head := obj.Head()
+		if toV.IsZero() {
+			toV = head
+		}
+		if fromV.IsZero() {
+			fromV = ocfl.V(max(toV.Num()-1, 1), head.Padding())
+		}
+		// use the object's version padding
+		fromV = ocfl.V(fromV.Num(), head.Padding())
+		toV = ocfl.V(toV.Num(), head.Padding())
+		link := utils.LinkCompareVersions(ctx, id, fromV.String(), toV.String())
+		http.Redirect(w, r, string(link), http.StatusFound)
+	}
+}
+
+// HandleCompareVersions shows the file changes between two versions of an
+// object, given in the path as "{from}...{to}". If the versions don't use the
+// object's version padding (e.g., "v1" for "v001"), the client is redirected
+// to the URL with the padded versions.
+func HandleCompareVersions(svc *access.Service) http.HandlerFunc {
+	logErr := func(w http.ResponseWriter, r *http.Request, id string, versions string, err error) {
+		if errors.Is(err, access.ErrNotFound) {
+			httpError(w, r, err.Error(), http.StatusNotFound)
+			return
+		}
+		svc.Logger().LogAttrs(r.Context(), slog.LevelError, err.Error(),
+			slog.String("object_id", id),
+			slog.String("versions", versions))
+		httpError(w, r, err.Error(), http.StatusInternalServerError)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		id := r.PathValue("id")
+		versions := r.PathValue("versions")
+		varyAccept(w)
+		var fromV, toV ocfl.VNum
+		from, to, ok := strings.Cut(versions, "...")
+		if !ok || ocfl.ParseVNum(from, &fromV) != nil || ocfl.ParseVNum(to, &toV) != nil {
+			httpError(w, r, "invalid version range format", http.StatusBadRequest)
+			return
+		}
+		allVersions, err := svc.ListVersions(ctx, id)
+		if err != nil {
+			logErr(w, r, id, versions, err)
+			return
+		}
+		for _, vn := range []ocfl.VNum{fromV, toV} {
+			if vn.Num() > len(allVersions) {
+				logErr(w, r, id, versions, fmt.Errorf("version %s: %w", vn, access.ErrNotFound))
+				return
+			}
+		}
+		padding := allVersions[len(allVersions)-1].VNum().Padding()
+		if fromV.Padding() != padding || toV.Padding() != padding {
+			fromV, toV = ocfl.V(fromV.Num(), padding), ocfl.V(toV.Num(), padding)
+			link := utils.LinkCompareVersions(ctx, id, fromV.String(), toV.String())
+			http.Redirect(w, r, string(link), http.StatusMovedPermanently)
+			return
+		}
+		changes, err := svc.GetVersionChanges(ctx, id, fromV.Num(), toV.Num())
+		if err != nil {
+			logErr(w, r, id, versions, err)
+			return
+		}
+		slices.Reverse(allVersions) // most recent first
+		page := &template.VersionCompare{
+			ObjectID: id,
+			FromVNum: fromV,
+			ToVNum:   toV,
+			Versions: make([]*template.VersionBrief, len(allVersions)),
+			FileTree: buildFileTree(changes),
+		}
+		for i, v := range allVersions {
+			page.Versions[i] = &template.VersionBrief{
+				VNum:     v.VNum(),
+				Created:  v.Created(),
+				Message:  v.Message(),
+				UserName: v.UserName(),
+				UserAddr: v.UserAddr(),
+			}
+		}
+		renderPage(w, r, page, template.VersionComparePage(page))
+	}
+}
+
+// HandleGetFileDiff shows line-level changes to a file in an object version,
+// compared to the version given by the "from" query parameter, which defaults
+// to the previous version. The "view=split" query parameter selects a
+// side-by-side view.
+func HandleGetFileDiff(svc *access.Service) http.HandlerFunc {
+	logErr := func(w http.ResponseWriter, r *http.Request, id string, version string, name string, err error) {
+		if errors.Is(err, access.ErrNotFound) {
+			httpError(w, r, err.Error(), http.StatusNotFound)
+			return
+		}
+		svc.Logger().LogAttrs(r.Context(), slog.LevelError, err.Error(),
+			slog.String("object_id", id),
+			slog.String("version", version),
+			slog.String("path", name))
+		httpError(w, r, err.Error(), http.StatusInternalServerError)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		id := r.PathValue("id")
+		version := r.PathValue("version")
+		name := r.PathValue("path")
+		varyAccept(w)
+		var vn ocfl.VNum
+		if err := ocfl.ParseVNum(version, &vn); err != nil {
+			httpError(w, r, "invalid version format", http.StatusBadRequest)
+			return
+		}
+		if !fs.ValidPath(name) || name == "." {
+			httpError(w, r, fmt.Sprintf("invalid path: %q", name), http.StatusBadRequest)
+			return
+		}
+		var fromV ocfl.VNum
+		from := r.URL.Query().Get("from")
+		switch {
+		case from != "":
+			if err := ocfl.ParseVNum(from, &fromV); err != nil {
+				httpError(w, r, "invalid from version format", http.StatusBadRequest)
+				return
+			}
+		case vn.Num() > 1:
+			fromV = ocfl.V(vn.Num()-1, vn.Padding())
+		}
+		page, err := newFileDiff(ctx, svc, id, fromV, vn, name)
+		if err != nil {
+			logErr(w, r, id, version, name, err)
+			return
+		}
+		page.Split = r.URL.Query().Get("view") == "split"
+		page.UnifiedHref = utils.LinkFileDiff(ctx, id, from, version, name)
+		page.SplitHref = page.UnifiedHref + "?view=split"
+		page.ChangesHref = utils.LinkVersionChanges(ctx, id, version)
+		if from != "" {
+			page.SplitHref = page.UnifiedHref + "&view=split"
+			page.ChangesHref = utils.LinkCompareVersions(ctx, id, from, version)
+		}
+		renderPage(w, r, page, template.FileDiffPage(page))
+	}
+}
+
+// newFileDiff compares a file's content in two versions of an object. If from
+// is zero, the file in version to is compared with an empty state. If the file
+// was renamed or copied, it is compared with its source file. Text files are
+// compared line-by-line; otherwise only digests and sizes are included.
+func newFileDiff(ctx context.Context, svc *access.Service, objID string, from, to ocfl.VNum, name string) (*template.FileDiff, error) {
+	obj, err := svc.SyncObject(ctx, objID)
+	if err != nil {
+		return nil, err
+	}
+	for _, vn := range []ocfl.VNum{from, to} {
+		if vn.Num() > obj.Head().Num() {
+			return nil, fmt.Errorf("version %s: %w", vn, access.ErrNotFound)
+		}
+	}
+	oldSide, err := openDiffSide(ctx, svc, objID, from, name)
+	if err != nil {
+		return nil, err
+	}
+	newSide, err := openDiffSide(ctx, svc, objID, to, name)
+	if err != nil {
+		return nil, err
+	}
+	if oldSide.file == nil && newSide.file == nil {
+		return nil, fmt.Errorf("file %q: %w", name, access.ErrNotFound)
+	}
+	var source string
+	if oldSide.file == nil && !from.IsZero() {
+		source, err = changeSource(ctx, svc, objID, from, to, name)
+		if err != nil {
+			return nil, err
+		}
+		if source != "" {
+			oldSide, err = openDiffSide(ctx, svc, objID, from, source)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	page := &template.FileDiff{
+		ObjectID: objID,
+		Path:     name,
+		Source:   source,
+		FromVNum: from,
+		ToVNum:   to,
+		From:     oldSide.file,
+		To:       newSide.file,
+	}
+	switch {
+	case oldSide.binary || newSide.binary:
+		page.Binary = true
+		return page, nil
+	case oldSide.tooLarge || newSide.tooLarge:
+		page.TooLarge = true
+		return page, nil
+	}
+	mediaType := ""
+	if newSide.file != nil {
+		mediaType = newSide.file.MediaType
+	} else {
+		mediaType = oldSide.file.MediaType
+	}
+	lang := utils.HighlightLanguage(name, mediaType)
+	lines := textdiff.Diff(textdiff.SplitLines(oldSide.text), textdiff.SplitLines(newSide.text))
+	for _, hunk := range textdiff.Hunks(lines, diffContextLines) {
+		diffHunk := &template.DiffHunk{
+			Header: hunk.Header(),
+			Lines:  make([]*template.DiffLine, len(hunk.Lines)),
+		}
+		for i, line := range hunk.Lines {
+			op := template.DiffContext
+			switch line.Op {
+			case textdiff.Insert:
+				op = template.DiffAdd
+				page.Added++
+			case textdiff.Delete:
+				op = template.DiffDelete
+				page.Deleted++
+			}
+			diffHunk.Lines[i] = &template.DiffLine{
+				Op:     op,
+				OldNum: line.OldNum,
+				NewNum: line.NewNum,
+				Text:   line.Text,
+				Tokens: utils.HighlightLine(lang, line.Text),
+			}
+		}
+		page.Hunks = append(page.Hunks, diffHunk)
+	}
+	return page, nil
+}
+
+// changeSource returns the path that the file name in version to was renamed
+// or copied from, or an empty string if it wasn't renamed or copied.
+func changeSource(ctx context.Context, svc *access.Service, objID string, from, to ocfl.VNum, name string) (string, error) {
+	changes, err := svc.GetVersionChanges(ctx, objID, from.Num(), to.Num())
+	if err != nil {
+		return "", err
+	}
+	for _, c := range changes {
+		if c.Path() == name {
+			return c.SourcePath(), nil
+		}
+	}
+	return "", nil
+}
+
+// diffSide is a file's content in one of the versions compared by a file diff.
+type diffSide struct {
+	file     *template.DiffFile // nil if the file isn't in the version
+	text     string
+	binary   bool // file isn't UTF-8 text
+	tooLarge bool // file is larger than maxDiffSize
+}
+
+func openDiffSide(ctx context.Context, svc *access.Service, objID string, vn ocfl.VNum, name string) (*diffSide, error) {
+	side := &diffSide{}
+	if vn.IsZero() {
+		return side, nil
+	}
+	f, err := svc.OpenVersionFileReader(ctx, objID, vn.Num(), name)
+	if err != nil {
+		if errors.Is(err, access.ErrNotFound) {
+			return side, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	side.file = &template.DiffFile{
+		Digest:    f.Info().Digest(),
+		Size:      f.Size(),
+		MediaType: f.MediaType(),
+		Href:      utils.LinkObjectFiles(ctx, objID, vn.String(), name, false) + "?preview",
+	}
+	if f.Size() == 0 {
+		return side, nil
+	}
+	switch previewKind(f.MediaType()) {
+	case template.PreviewText, template.PreviewCSV:
+	default:
+		side.binary = true
+		return side, nil
+	}
+	if f.Size() > maxDiffSize {
+		side.tooLarge = true
+		return side, nil
+	}
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	if !utf8.Valid(content) || bytes.IndexByte(content, 0) >= 0 {
+		side.binary = true
+		return side, nil
+	}
+	side.text = string(content)
+	return side, nil
+}
+
+// archiveName returns a file name for an archive of the directory dir in the
+// object version.
+func archiveName(objID string, verRef string, dir string, format access.ArchiveFormat) string {
+	name := objID + "_" + verRef
+	if dir != "." {
+		name += "_" + dir
 	}
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9',
+			r == '-', r == '_', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+	return name + "." + string(format)
 }
 
 // isReadmeFile checks if the file has a markdown extension
@@ -492,6 +1136,97 @@ func isReadmeFile(name string) bool {
 	return lower == "readme.md" || lower == "readme.txt"
 }
 
+// inlineMediaTypes are media types that are safe for browsers to display
+// directly. Other types (including HTML and SVG) are served as attachments.
+var inlineMediaTypes = []string{
+	"application/json",
+	"application/pdf",
+	"image/avif",
+	"image/bmp",
+	"image/gif",
+	"image/jpeg",
+	"image/png",
+	"image/webp",
+	"text/csv",
+	"text/plain",
+}
+
+// textMediaTypes are non-"text/" media types that are previewed as text.
+var textMediaTypes = []string{
+	"application/javascript",
+	"application/toml",
+	"application/x-sh",
+	"application/x-yaml",
+	"application/xml",
+	"application/yaml",
+}
+
+// mediaTypeBase returns mediaType without parameters, in lower case.
+func mediaTypeBase(mediaType string) string {
+	base, _, _ := strings.Cut(mediaType, ";")
+	return strings.ToLower(strings.TrimSpace(base))
+}
+
+// contentDisposition returns a Content-Disposition header value for a file
+// download.
+func contentDisposition(mediaType string, name string) string {
+	disposition := "attachment"
+	if slices.Contains(inlineMediaTypes, mediaTypeBase(mediaType)) {
+		disposition = "inline"
+	}
+	return mime.FormatMediaType(disposition, map[string]string{"filename": name})
+}
+
+// previewKind returns the kind of preview used for files with the media type,
+// or an empty string if the files can't be previewed.
+func previewKind(mediaType string) string {
+	base := mediaTypeBase(mediaType)
+	switch {
+	case base == "image/svg+xml":
+		// SVG may include scripts
+		return ""
+	case strings.HasPrefix(base, "image/"):
+		if slices.Contains(inlineMediaTypes, base) {
+			return template.PreviewImage
+		}
+		return ""
+	case base == "application/pdf":
+		return template.PreviewPDF
+	case base == "text/csv":
+		return template.PreviewCSV
+	case strings.HasPrefix(base, "text/"),
+		base == "application/json",
+		strings.HasSuffix(base, "+json"),
+		strings.HasSuffix(base, "+xml"),
+		slices.Contains(textMediaTypes, base):
+		return template.PreviewText
+	}
+	return ""
+}
+
+// setPreviewContent sets page.Text or page.Rows for the file content. JSON is
+// indented; CSV that can't be parsed is shown as text.
+func setPreviewContent(page *template.FilePreview, content []byte) {
+	base := mediaTypeBase(page.MediaType)
+	if page.Kind == template.PreviewCSV {
+		reader := csv.NewReader(bytes.NewReader(content))
+		reader.FieldsPerRecord = -1
+		rows, err := reader.ReadAll()
+		if err == nil {
+			page.Rows = rows
+			return
+		}
+		page.Kind = template.PreviewText
+	}
+	if base == "application/json" || strings.HasSuffix(base, "+json") {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, content, "", "  "); err == nil {
+			content = indented.Bytes()
+		}
+	}
+	page.Text = string(content)
+}
+
 // markdownToHTML converts markdown content to HTML using gomarkdown
 func markdownToHTML(md []byte) []byte {
 	// Create markdown parser with extensions
@@ -514,6 +1249,261 @@ func HandleIndex() http.HandlerFunc {
 	}
 }
 
+// HandleListRoots renders the list of storage roots with their object counts.
+// A root's count is omitted if it's hidden by the root's access policy or
+// can't be read; errors are logged.
+func HandleListRoots(roots []Root) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		page := &template.RootList{Roots: make([]*template.RootListItem, len(roots))}
+		for i, root := range roots {
+			item := &template.RootListItem{Name: root.Name}
+			metrics, err := root.Service.Metrics(r.Context())
+			switch {
+			case err == nil:
+				item.NumObjects = &metrics.NumObjects
+			case !errors.Is(err, access.ErrCountsHidden):
+				root.Service.Logger().LogAttrs(r.Context(), slog.LevelError, err.Error(),
+					slog.String("root", root.Name))
+			}
+			page.Roots[i] = item
+		}
+		renderPage(w, r, page, template.RootListPage(page))
+	}
+}
+
+func HandleListObjects(svc *access.Service) http.HandlerFunc {
+	logErr := func(w http.ResponseWriter, r *http.Request, err error) {
+		svc.Logger().LogAttrs(r.Context(), slog.LevelError, err.Error(),
+			slog.String("query", r.URL.RawQuery))
+		httpError(w, r, err.Error(), http.StatusInternalServerError)
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		query := r.URL.Query()
+		sort, err := access.ParseObjectSort(query.Get("sort"))
+		if err != nil {
+			httpError(w, r, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var desc bool
+		switch order := query.Get("order"); order {
+		case "", "asc":
+		case "desc":
+			desc = true
+		default:
+			httpError(w, r, fmt.Sprintf("invalid sort order: %q", order), http.StatusBadRequest)
+			return
+		}
+		pageNum := 1
+		if val := query.Get("page"); val != "" {
+			pageNum, err = strconv.Atoi(val)
+			if err != nil || pageNum < 1 {
+				httpError(w, r, fmt.Sprintf("invalid page number: %q", val), http.StatusBadRequest)
+				return
+			}
+		}
+		metrics, err := svc.Metrics(ctx)
+		hideCounts := errors.Is(err, access.ErrCountsHidden)
+		if err != nil && !hideCounts {
+			logErr(w, r, err)
+			return
+		}
+		numPages := (metrics.NumObjects + objectListPageSize - 1) / objectListPageSize
+		if !hideCounts && pageNum > max(numPages, 1) {
+			httpError(w, r, fmt.Sprintf("page %d: %s", pageNum, access.ErrNotFound), http.StatusNotFound)
+			return
+		}
+		// without counts, an extra object is requested to check for a next page
+		limit := objectListPageSize
+		if hideCounts {
+			limit++
+		}
+		objects, err := svc.ListObjects(ctx, access.ListObjectOptions{
+			Offset: (pageNum - 1) * objectListPageSize,
+			Limit:  limit,
+			Sort:   sort,
+			Desc:   desc,
+		})
+		if err != nil {
+			logErr(w, r, err)
+			return
+		}
+		if hideCounts {
+			numPages = pageNum
+			if len(objects) > objectListPageSize {
+				numPages++
+				objects = objects[:objectListPageSize]
+			}
+		}
+		page := &template.ObjectList{
+			Objects:    make([]*template.ObjectListItem, len(objects)),
+			Sort:       string(sort),
+			Desc:       desc,
+			Page:       pageNum,
+			NumPages:   numPages,
+			NumObjects: metrics.NumObjects,
+			HideCounts: hideCounts,
+		}
+		for i, obj := range objects {
+			page.Objects[i] = &template.ObjectListItem{
+				ID:          obj.ID(),
+				Head:        obj.Head(),
+				CreatedAt:   obj.CreatedAt(),
+				UpdatedAt:   obj.UpdatedAt(),
+				StoragePath: obj.StoragePath(),
+			}
+		}
+		template.ObjectListPage(page).Render(ctx, w)
+	}
+}
+
+func HandleSearch(svc *access.Service) http.HandlerFunc {
+	logErr := func(w http.ResponseWriter, r *http.Request, err error) {
+		svc.Logger().LogAttrs(r.Context(), slog.LevelError, err.Error(),
+			slog.String("query", r.URL.RawQuery))
+		httpError(w, r, err.Error(), http.StatusInternalServerError)
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		query := r.URL.Query()
+		page := &template.SearchResults{
+			Query:     query.Get("q"),
+			In:        query.Get("in"),
+			Mode:      query.Get("mode"),
+			MatchCase: query.Get("matchcase") != "",
+			HeadOnly:  query.Get("head") != "",
+			Page:      1,
+		}
+		if page.In == "" {
+			page.In = "id"
+		}
+		if page.Mode == "" {
+			page.Mode = string(access.SearchSubstring)
+		}
+		mode, err := access.ParseSearchMode(page.Mode)
+		if err != nil {
+			httpError(w, r, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var field access.SearchField
+		if page.In != "id" {
+			field, err = access.ParseSearchField(page.In)
+			if err != nil {
+				httpError(w, r, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		if val := query.Get("page"); val != "" {
+			page.Page, err = strconv.Atoi(val)
+			if err != nil || page.Page < 1 {
+				httpError(w, r, fmt.Sprintf("invalid page number: %q", val), http.StatusBadRequest)
+				return
+			}
+		}
+		// requests from the lookup form go directly to the object if it
+		// exists.
+		if query.Get("lookup") != "" && page.Query != "" && page.In == "id" {
+			_, err := svc.SyncObject(ctx, page.Query)
+			if err == nil {
+				redirect := string(utils.LinkObjectFiles(ctx, page.Query, "head", "", true))
+				http.Redirect(w, r, redirect, http.StatusFound)
+				return
+			}
+			if !errors.Is(err, access.ErrNotFound) {
+				logErr(w, r, err)
+				return
+			}
+		}
+		switch {
+		case page.Query == "":
+		case page.In != "id":
+			versions, err := svc.SearchContent(ctx, page.Query, access.SearchContentOptions{
+				Field:    field,
+				HeadOnly: page.HeadOnly,
+				Offset:   (page.Page - 1) * searchPageSize,
+				Limit:    searchPageSize + 1,
+			})
+			if err != nil {
+				logErr(w, r, err)
+				return
+			}
+			if len(versions) > searchPageSize {
+				page.HasMore = true
+				versions = versions[:searchPageSize]
+			}
+			page.Versions = make([]*template.VersionSearchItem, len(versions))
+			for i, v := range versions {
+				page.Versions[i] = &template.VersionSearchItem{
+					ObjectID: v.ObjectID(),
+					Version:  v.VNum(),
+					Path:     v.Path(),
+					Message:  v.Message(),
+					UserName: v.UserName(),
+					Created:  v.Created(),
+				}
+			}
+		default:
+			objects, err := svc.SearchObjects(ctx, page.Query, access.SearchObjectOptions{
+				Mode:       mode,
+				IgnoreCase: !page.MatchCase,
+				Offset:     (page.Page - 1) * searchPageSize,
+				Limit:      searchPageSize + 1,
+			})
+			if err != nil {
+				logErr(w, r, err)
+				return
+			}
+			if len(objects) > searchPageSize {
+				page.HasMore = true
+				objects = objects[:searchPageSize]
+			}
+			page.Results = make([]*template.ObjectListItem, len(objects))
+			for i, obj := range objects {
+				page.Results[i] = &template.ObjectListItem{
+					ID:          obj.ID(),
+					Head:        obj.Head(),
+					CreatedAt:   obj.CreatedAt(),
+					UpdatedAt:   obj.UpdatedAt(),
+					StoragePath: obj.StoragePath(),
+				}
+			}
+		}
+		template.SearchPage(page).Render(ctx, w)
+	}
+}
+
+// HandleSearchSuggest responds with a list of <option> elements for object IDs
+// matching the "q" query parameter. IDs starting with q are listed before IDs
+// that only include it.
+func HandleSearchSuggest(svc *access.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		q := r.URL.Query().Get("q")
+		var ids []string
+		if q != "" {
+			for _, mode := range []access.SearchMode{access.SearchPrefix, access.SearchSubstring} {
+				objects, err := svc.SearchObjects(ctx, q, access.SearchObjectOptions{
+					Mode:       mode,
+					IgnoreCase: true,
+					Limit:      maxSearchSuggestions,
+				})
+				if err != nil {
+					svc.Logger().LogAttrs(ctx, slog.LevelError, err.Error(),
+						slog.String("query", q))
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				for _, obj := range objects {
+					if len(ids) < maxSearchSuggestions && !slices.Contains(ids, obj.ID()) {
+						ids = append(ids, obj.ID())
+					}
+				}
+			}
+		}
+		template.SearchSuggestions(ids).Render(ctx, w)
+	}
+}
+
 func HandleGetObjectInventory(svc *access.Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
@@ -544,6 +1534,13 @@ func redirectToDefaultObjectFiles(w http.ResponseWriter, r *http.Request) {
 	// since the object id may include escape sequences update both Path and
 	// RawPath
 	redirect := *r.URL
+	// the request path doesn't include the storage root's path prefix
+	if prefix := utils.RootPath(r.Context()); prefix != "" {
+		redirect.Path = prefix + redirect.Path
+		if redirect.RawPath != "" {
+			redirect.RawPath = prefix + redirect.RawPath
+		}
+	}
 	if version == "" {
 		// redirect url without version to head/
 		redirect.Path = redirect.Path + "/head/"
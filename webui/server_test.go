@@ -1,6 +1,11 @@
 package server_test
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -9,6 +14,8 @@ import (
 	"testing"
 
 	"github.com/carlmjohnson/be"
+	"github.com/srerickson/ocfl-go"
+	"github.com/srerickson/ocfl-go/digest"
 	"github.com/srerickson/ocfl-services/access"
 	"github.com/srerickson/ocfl-services/access/sqlite"
 	"github.com/srerickson/ocfl-services/internal/testutil"
@@ -17,7 +24,7 @@ import (
 
 const fixtureObjectID = "ark:123/abc"
 
-func testHandler(t *testing.T) http.Handler {
+func testHandler(t *testing.T, opts ...server.Option) http.Handler {
 	t.Helper()
 	tempDir := t.TempDir()
 	dbPath := filepath.Join(tempDir, "test.db")
@@ -28,6 +35,59 @@ func testHandler(t *testing.T) http.Handler {
 	t.Cleanup(func() { db.Close() })
 	root := testutil.FixtureRootCopy(t, filepath.Join("..", "testdata"))
 	svc := access.NewService(root, db, "test", nil)
+	return server.New(svc, opts...)
+}
+
+// testWriteHandler is like testHandler, but the service has an access policy
+// that allows all reads and writes.
+func testWriteHandler(t *testing.T, opts ...server.Option) http.Handler {
+	t.Helper()
+	db, err := sqlite.NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal("setting up test db:", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	root := testutil.FixtureRootCopy(t, filepath.Join("..", "testdata"))
+	policy := &access.Policy{
+		Rules: []access.Rule{{Effect: access.Allow, Actions: []access.Action{access.Read, access.Write}}},
+	}
+	svc := access.NewService(root, db, "test", nil, access.WithPolicy(policy))
+	return server.New(svc, opts...)
+}
+
+// testHandlerWithObject returns a handler for a copy of the test fixture root
+// that includes an additional object with the given content.
+func testHandlerWithObject(t *testing.T, objID string, content map[string][]byte) http.Handler {
+	t.Helper()
+	return testHandlerWithVersions(t, objID, content)
+}
+
+// testHandlerWithVersions returns a handler for a copy of the test fixture
+// root that includes an additional object with a version for each of the
+// given states.
+func testHandlerWithVersions(t *testing.T, objID string, states ...map[string][]byte) http.Handler {
+	t.Helper()
+	ctx := t.Context()
+	db, err := sqlite.NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal("setting up test db:", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	root := testutil.FixtureRootCopy(t, filepath.Join("..", "testdata"))
+	obj, err := root.NewObject(ctx, objID)
+	if err != nil {
+		t.Fatal("creating test object:", err)
+	}
+	for _, content := range states {
+		stage, err := ocfl.StageBytes(content, digest.SHA512)
+		if err != nil {
+			t.Fatal("staging test object content:", err)
+		}
+		if _, err := obj.Update(ctx, stage, "test object", ocfl.User{Name: "tester"}); err != nil {
+			t.Fatal("creating test object:", err)
+		}
+	}
+	svc := access.NewService(root, db, "test", nil)
 	return server.New(svc)
 }
 
@@ -255,6 +315,264 @@ func TestObjectFilesDownload(t *testing.T) {
 		be.Equal(t, http.StatusOK, w.Code)
 		be.True(t, w.Body.Len() > 0)
 	})
+
+	const fileDigest = "43a43fe8a8a082d3b5343dfaf2fd0c8b8e370675b1f376e92e9994612c33ea255b11298269d72f797399ebb94edeefe53df243643676548f584fb8603ca53a0f"
+	filePath := objectPath(fixtureObjectID, "v1", "a_file.txt")
+	doRequestWithHeader := func(t *testing.T, key, val string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, filePath, nil)
+		req.Header.Set(key, val)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("GET file sets ETag from digest", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, filePath)
+		be.Equal(t, http.StatusOK, w.Code)
+		be.Equal(t, `"`+fileDigest+`"`, w.Header().Get("ETag"))
+		be.Equal(t, "bytes", w.Header().Get("Accept-Ranges"))
+	})
+
+	t.Run("single range", func(t *testing.T) {
+		w := doRequestWithHeader(t, "Range", "bytes=7-10")
+		be.Equal(t, http.StatusPartialContent, w.Code)
+		be.Equal(t, "bytes 7-10/20", w.Header().Get("Content-Range"))
+		be.Equal(t, "I am", w.Body.String())
+	})
+
+	t.Run("suffix range", func(t *testing.T) {
+		w := doRequestWithHeader(t, "Range", "bytes=-6")
+		be.Equal(t, http.StatusPartialContent, w.Code)
+		be.Equal(t, "file.\n", w.Body.String())
+	})
+
+	t.Run("multiple ranges", func(t *testing.T) {
+		w := doRequestWithHeader(t, "Range", "bytes=0-5,7-10")
+		be.Equal(t, http.StatusPartialContent, w.Code)
+		be.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), "multipart/byteranges"))
+		be.In(t, "Hello!", w.Body.String())
+		be.In(t, "I am", w.Body.String())
+	})
+
+	t.Run("unsatisfiable range returns 416", func(t *testing.T) {
+		w := doRequestWithHeader(t, "Range", "bytes=100-200")
+		be.Equal(t, http.StatusRequestedRangeNotSatisfiable, w.Code)
+	})
+
+	t.Run("If-None-Match with matching ETag returns 304", func(t *testing.T) {
+		w := doRequestWithHeader(t, "If-None-Match", `"`+fileDigest+`"`)
+		be.Equal(t, http.StatusNotModified, w.Code)
+		be.Equal(t, 0, w.Body.Len())
+	})
+
+	t.Run("If-None-Match with other ETag returns content", func(t *testing.T) {
+		w := doRequestWithHeader(t, "If-None-Match", `"other"`)
+		be.Equal(t, http.StatusOK, w.Code)
+		be.Equal(t, 20, w.Body.Len())
+	})
+
+	t.Run("If-Range with stale ETag returns full content", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, filePath, nil)
+		req.Header.Set("Range", "bytes=7-10")
+		req.Header.Set("If-Range", `"other"`)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		be.Equal(t, http.StatusOK, w.Code)
+		be.Equal(t, 20, w.Body.Len())
+		req.Header.Set("If-Range", `"`+fileDigest+`"`)
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		be.Equal(t, http.StatusPartialContent, w.Code)
+		be.Equal(t, "I am", w.Body.String())
+	})
+}
+
+func TestObjectFilesPreview(t *testing.T) {
+	const objID = "preview-object"
+	h := testHandlerWithObject(t, objID, map[string][]byte{
+		"data.csv":   []byte("name,count\nalpha,1\nbeta,2\n"),
+		"bad.csv":    []byte("a,\"b\n"),
+		"data.json":  []byte(`{"key":["value"]}`),
+		"image.png":  []byte("\x89PNG\r\n\x1a\n"),
+		"page.html":  []byte("<script>alert(1)</script>"),
+		"main.go":    []byte("package main\n\nfunc main() {}\n"),
+		"large.txt":  bytes.Repeat([]byte("a"), 3*1024*1024),
+		"binary.dat": {0x00, 0x01, 0x02},
+	})
+
+	t.Run("text file", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, objectPath(fixtureObjectID, "v1", "a_file.txt")+"?preview")
+		be.Equal(t, http.StatusOK, w.Code)
+		body := w.Body.String()
+		be.In(t, "Hello! I am a file.", body)
+		be.In(t, `class="preview-text"`, body)
+		be.In(t, `href="`+objectPath(fixtureObjectID, "v1", "a_file.txt")+`"`, body)
+	})
+
+	t.Run("source code", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, objectPath(objID, "head", "main.go")+"?preview")
+		be.Equal(t, http.StatusOK, w.Code)
+		be.In(t, "func main() {}", w.Body.String())
+	})
+
+	t.Run("csv file", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, objectPath(objID, "head", "data.csv")+"?preview")
+		be.Equal(t, http.StatusOK, w.Code)
+		body := w.Body.String()
+		be.In(t, `<th scope="col">count</th>`, body)
+		be.In(t, `<td>beta</td>`, body)
+	})
+
+	t.Run("invalid csv is shown as text", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, objectPath(objID, "head", "bad.csv")+"?preview")
+		be.Equal(t, http.StatusOK, w.Code)
+		be.In(t, `class="preview-text"`, w.Body.String())
+	})
+
+	t.Run("json file is indented", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, objectPath(objID, "head", "data.json")+"?preview")
+		be.Equal(t, http.StatusOK, w.Code)
+		be.In(t, "&#34;key&#34;: [\n    &#34;value&#34;\n  ]", w.Body.String())
+	})
+
+	t.Run("image file", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, objectPath(objID, "head", "image.png")+"?preview")
+		be.Equal(t, http.StatusOK, w.Code)
+		be.In(t, `<img src="`+objectPath(objID, "head", "image.png")+`"`, w.Body.String())
+	})
+
+	t.Run("html isn't rendered", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, objectPath(objID, "head", "page.html")+"?preview")
+		be.Equal(t, http.StatusOK, w.Code)
+		body := w.Body.String()
+		be.In(t, "&lt;script&gt;", body)
+		be.False(t, strings.Contains(body, "<script>alert"))
+	})
+
+	t.Run("large text file", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, objectPath(objID, "head", "large.txt")+"?preview")
+		be.Equal(t, http.StatusOK, w.Code)
+		be.In(t, "too large to preview", w.Body.String())
+	})
+
+	t.Run("binary file", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, objectPath(objID, "head", "binary.dat")+"?preview")
+		be.Equal(t, http.StatusOK, w.Code)
+		be.In(t, "Preview isn't available", w.Body.String())
+	})
+
+	t.Run("preview on directory returns 400", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, objectPath(fixtureObjectID, "v2", "exampl/")+"?preview")
+		be.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("missing file returns 404", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, objectPath(objID, "head", "missing.txt")+"?preview")
+		be.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("directory listing links to previews", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, objectPath(objID, "head", "")+"/")
+		be.Equal(t, http.StatusOK, w.Code)
+		be.In(t, `href="data.csv?preview"`, w.Body.String())
+	})
+
+	t.Run("download headers", func(t *testing.T) {
+		tests := []struct {
+			name        string
+			contentType string
+			disposition string
+		}{
+			{"data.csv", "text/csv; charset=utf-8", `inline; filename=data.csv`},
+			{"image.png", "image/png", `inline; filename=image.png`},
+			{"page.html", "text/html; charset=utf-8", `attachment; filename=page.html`},
+			{"binary.dat", "application/octet-stream", `attachment; filename=binary.dat`},
+		}
+		for _, tt := range tests {
+			w := doRequest(t, h, http.MethodGet, objectPath(objID, "head", tt.name))
+			be.Equal(t, http.StatusOK, w.Code)
+			be.Equal(t, tt.contentType, w.Header().Get("Content-Type"))
+			be.Equal(t, tt.disposition, w.Header().Get("Content-Disposition"))
+			be.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+		}
+	})
+}
+
+func TestObjectFilesArchive(t *testing.T) {
+	h := testHandler(t)
+
+	t.Run("zip of version state", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, objectPath(fixtureObjectID, "v2", "")+"/?archive=zip")
+		be.Equal(t, http.StatusOK, w.Code)
+		be.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+		be.Equal(t, `attachment; filename=ark_123_abc_v2.zip`, w.Header().Get("Content-Disposition"))
+		zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+		be.NilErr(t, err)
+		var names []string
+		for _, f := range zr.File {
+			names = append(names, f.Name)
+		}
+		be.AllEqual(t, []string{"README.md", "a_file.txt", "exampl/folder/justfile"}, names)
+	})
+
+	t.Run("tar.gz of sub-directory", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, objectPath(fixtureObjectID, "v2", "exampl")+"/?archive=tar.gz")
+		be.Equal(t, http.StatusOK, w.Code)
+		be.Equal(t, "application/gzip", w.Header().Get("Content-Type"))
+		be.In(t, "ark_123_abc_v2_exampl.tar.gz", w.Header().Get("Content-Disposition"))
+		gr, err := gzip.NewReader(w.Body)
+		be.NilErr(t, err)
+		header, err := tar.NewReader(gr).Next()
+		be.NilErr(t, err)
+		be.Equal(t, "exampl/folder/justfile", header.Name)
+	})
+
+	t.Run("HEAD returns headers only", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodHead, objectPath(fixtureObjectID, "v2", "")+"/?archive=zip")
+		be.Equal(t, http.StatusOK, w.Code)
+		be.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+		be.Equal(t, 0, w.Body.Len())
+	})
+
+	t.Run("invalid format returns 400", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, objectPath(fixtureObjectID, "v2", "")+"/?archive=rar")
+		be.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("archive of file returns 400", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, objectPath(fixtureObjectID, "v2", "a_file.txt")+"?archive=zip")
+		be.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("missing directory returns 404", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, objectPath(fixtureObjectID, "v2", "missing")+"/?archive=zip")
+		be.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("archive larger than max size returns 413", func(t *testing.T) {
+		h := testHandler(t, server.WithMaxArchiveSize(10))
+		w := doRequest(t, h, http.MethodGet, objectPath(fixtureObjectID, "v2", "")+"/?archive=zip")
+		be.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+
+	t.Run("archive size error is json when requested", func(t *testing.T) {
+		h := testHandler(t, server.WithMaxArchiveSize(10))
+		req := httptest.NewRequest(http.MethodGet, objectPath(fixtureObjectID, "v2", "")+"/?archive=zip", nil)
+		req.Header.Set("Accept", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		be.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		be.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		be.In(t, "exceeds the limit", w.Body.String())
+	})
+
+	t.Run("directory listing links to archives", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, objectPath(fixtureObjectID, "v2", "exampl")+"/")
+		be.Equal(t, http.StatusOK, w.Code)
+		be.In(t, "exampl/?archive=zip", w.Body.String())
+		be.In(t, "exampl/?archive=tar.gz", w.Body.String())
+	})
 }
 
 func TestObjectFilesReadmeRender(t *testing.T) {
@@ -475,3 +793,512 @@ func TestObjectActionsMenu(t *testing.T) {
 		be.In(t, "Download inventory.json", body)
 	})
 }
+
+func TestListObjects(t *testing.T) {
+	h := testHandler(t)
+
+	t.Run("empty index", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, "/objects")
+		be.Equal(t, http.StatusOK, w.Code)
+		be.In(t, "No objects have been indexed", w.Body.String())
+	})
+
+	t.Run("lists indexed objects", func(t *testing.T) {
+		// index the fixture object by requesting it
+		w := doRequest(t, h, http.MethodGet, objectPath(fixtureObjectID, "head", "")+"/")
+		be.Equal(t, http.StatusOK, w.Code)
+		w = doRequest(t, h, http.MethodGet, "/objects?sort=updated&order=desc")
+		be.Equal(t, http.StatusOK, w.Code)
+		body := w.Body.String()
+		be.In(t, "Objects (1)", body)
+		be.In(t, `href="`+objectPath(fixtureObjectID, "head", "")+`/`, body)
+		be.In(t, `aria-sort="descending"`, body)
+	})
+
+	t.Run("invalid sort returns 400", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, "/objects?sort=size")
+		be.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("invalid page returns 400", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, "/objects?page=0")
+		be.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("errors are json when requested", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/objects?sort=size", nil)
+		req.Header.Set("Accept", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		be.Equal(t, http.StatusBadRequest, w.Code)
+		be.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		be.In(t, `"error"`, w.Body.String())
+	})
+
+	t.Run("page out of range returns 404", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, "/objects?page=5")
+		be.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestSearch(t *testing.T) {
+	h := testHandler(t)
+	// index the fixture object by requesting it
+	w := doRequest(t, h, http.MethodGet, objectPath(fixtureObjectID, "head", "")+"/")
+	be.Equal(t, http.StatusOK, w.Code)
+
+	t.Run("search form without query", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, "/search")
+		be.Equal(t, http.StatusOK, w.Code)
+		be.In(t, `name="q"`, w.Body.String())
+	})
+
+	t.Run("substring search", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, "/search?q="+url.QueryEscape("123/AB"))
+		be.Equal(t, http.StatusOK, w.Code)
+		be.In(t, fixtureObjectID, w.Body.String())
+	})
+
+	t.Run("case-sensitive substring search", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, "/search?matchcase=1&q="+url.QueryEscape("123/AB"))
+		be.Equal(t, http.StatusOK, w.Code)
+		be.In(t, "No objects found", w.Body.String())
+	})
+
+	t.Run("prefix search", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, "/search?mode=prefix&q=123")
+		be.Equal(t, http.StatusOK, w.Code)
+		be.In(t, "No objects found", w.Body.String())
+		w = doRequest(t, h, http.MethodGet, "/search?mode=prefix&q=ark")
+		be.Equal(t, http.StatusOK, w.Code)
+		be.In(t, fixtureObjectID, w.Body.String())
+	})
+
+	t.Run("invalid mode returns 400", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, "/search?mode=regex&q=ark")
+		be.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("lookup redirects to existing object", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, "/search?lookup=1&q="+url.QueryEscape(fixtureObjectID))
+		be.Equal(t, http.StatusFound, w.Code)
+		be.Equal(t, objectPath(fixtureObjectID, "head", "")+"/", w.Header().Get("Location"))
+	})
+
+	t.Run("lookup with typo shows results", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, "/search?lookup=1&q="+url.QueryEscape("ark:123/ab"))
+		be.Equal(t, http.StatusOK, w.Code)
+		be.In(t, fixtureObjectID, w.Body.String())
+	})
+
+	t.Run("suggestions", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, "/search/suggest?q=abc")
+		be.Equal(t, http.StatusOK, w.Code)
+		be.Equal(t, `<option value="ark:123/abc"></option>`, w.Body.String())
+	})
+
+	t.Run("message search", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, "/search?in=message&q="+url.QueryEscape("one file"))
+		be.Equal(t, http.StatusOK, w.Code)
+		body := w.Body.String()
+		be.In(t, "An version with one file", body)
+		be.In(t, historyPath(fixtureObjectID, "v1"), body)
+	})
+
+	t.Run("user search", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, "/search?in=user&q=seth")
+		be.Equal(t, http.StatusOK, w.Code)
+		be.In(t, historyPath(fixtureObjectID, "v2"), w.Body.String())
+		w = doRequest(t, h, http.MethodGet, "/search?in=user&q=nobody")
+		be.Equal(t, http.StatusOK, w.Code)
+		be.In(t, "No versions found", w.Body.String())
+	})
+
+	t.Run("path search", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, "/search?in=path&head=1&q=justfile")
+		be.Equal(t, http.StatusOK, w.Code)
+		be.In(t, "exampl/folder/justfile", w.Body.String())
+	})
+
+	t.Run("invalid field returns 400", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, "/search?in=inventory&q=ark")
+		be.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("errors are json when requested", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/search?in=inventory&q=ark", nil)
+		req.Header.Set("Accept", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		be.Equal(t, http.StatusBadRequest, w.Code)
+		be.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		be.In(t, `"error"`, w.Body.String())
+	})
+}
+
+func TestContentNegotiation(t *testing.T) {
+	h := testHandler(t)
+	doAccept := func(t *testing.T, path, accept string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("accept header", func(t *testing.T) {
+		tests := []struct {
+			accept   string
+			wantJSON bool
+		}{
+			{"", false},
+			{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", false},
+			{"*/*", false},
+			{"application/json", true},
+			{"application/json, text/plain, */*", true},
+			{"text/html;q=0.5, application/json", true},
+			{"text/html, application/json", false},
+			{"application/json;q=0", false},
+		}
+		for _, tt := range tests {
+			t.Run(tt.accept, func(t *testing.T) {
+				w := doAccept(t, historyPath(fixtureObjectID, ""), tt.accept)
+				be.Equal(t, http.StatusOK, w.Code)
+				be.Equal(t, "Accept", w.Header().Get("Vary"))
+				isJSON := w.Header().Get("Content-Type") == "application/json"
+				be.Equal(t, tt.wantJSON, isJSON)
+			})
+		}
+	})
+
+	t.Run("object files", func(t *testing.T) {
+		w := doAccept(t, objectPath(fixtureObjectID, "head", "exampl")+"/", "application/json")
+		be.Equal(t, http.StatusOK, w.Code)
+		be.Equal(t, "Accept", w.Header().Get("Vary"))
+		var page struct {
+			ObjectID   string `json:"object_id"`
+			VersionRef string `json:"version_ref"`
+			Version    string `json:"version"`
+			Path       string `json:"path"`
+			Entries    []struct {
+				Name  string `json:"name"`
+				IsDir bool   `json:"is_dir"`
+			} `json:"entries"`
+		}
+		be.NilErr(t, json.Unmarshal(w.Body.Bytes(), &page))
+		be.Equal(t, fixtureObjectID, page.ObjectID)
+		be.Equal(t, "head", page.VersionRef)
+		be.Equal(t, "v2", page.Version)
+		be.Equal(t, "exampl", page.Path)
+		be.Equal(t, 2, len(page.Entries))
+		be.Equal(t, "..", page.Entries[0].Name)
+		be.Equal(t, "folder", page.Entries[1].Name)
+	})
+
+	t.Run("object history", func(t *testing.T) {
+		w := doAccept(t, historyPath(fixtureObjectID, ""), "application/json")
+		be.Equal(t, http.StatusOK, w.Code)
+		var page struct {
+			ObjectID string `json:"object_id"`
+			Versions []struct {
+				Version  string `json:"version"`
+				UserName string `json:"user_name"`
+			} `json:"versions"`
+		}
+		be.NilErr(t, json.Unmarshal(w.Body.Bytes(), &page))
+		be.Equal(t, 2, len(page.Versions))
+		be.Equal(t, "v2", page.Versions[0].Version)
+		be.Equal(t, "A Person", page.Versions[1].UserName)
+	})
+
+	t.Run("version changes", func(t *testing.T) {
+		w := doAccept(t, historyPath(fixtureObjectID, "v1"), "application/json")
+		be.Equal(t, http.StatusOK, w.Code)
+		be.Equal(t, "Accept", w.Header().Get("Vary"))
+		var page struct {
+			Version struct {
+				Version string `json:"version"`
+			} `json:"version"`
+			NextVersion string `json:"next_version"`
+			PrevVersion string `json:"prev_version"`
+			FileTree    struct {
+				Children []struct {
+					Name    string `json:"name"`
+					ModType string `json:"mod_type"`
+				} `json:"children"`
+			} `json:"file_tree"`
+		}
+		be.NilErr(t, json.Unmarshal(w.Body.Bytes(), &page))
+		be.Equal(t, "v1", page.Version.Version)
+		be.Equal(t, "v2", page.NextVersion)
+		be.Equal(t, "", page.PrevVersion)
+		be.Equal(t, 1, len(page.FileTree.Children))
+		be.Equal(t, "a_file.txt", page.FileTree.Children[0].Name)
+		be.Equal(t, "added", page.FileTree.Children[0].ModType)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		w := doAccept(t, historyPath(fixtureObjectID, "v99"), "application/json")
+		be.Equal(t, http.StatusNotFound, w.Code)
+		be.Equal(t, "Accept", w.Header().Get("Vary"))
+		be.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		be.In(t, `"status": 404`, w.Body.String())
+		w = doAccept(t, historyPath(fixtureObjectID, "v99"), "text/html")
+		be.Equal(t, http.StatusNotFound, w.Code)
+		be.In(t, "text/plain", w.Header().Get("Content-Type"))
+	})
+
+	t.Run("file downloads ignore accept", func(t *testing.T) {
+		w := doAccept(t, objectPath(fixtureObjectID, "head", "a_file.txt"), "application/json")
+		be.Equal(t, http.StatusOK, w.Code)
+		be.Equal(t, "", w.Header().Get("Vary"))
+	})
+}
+
+func TestFileDiff(t *testing.T) {
+	objID := "diff-object"
+	v1 := map[string][]byte{
+		"metadata.json": []byte("{\n  \"title\": \"Old\",\n  \"year\": 2020\n}\n"),
+		"image.png":     {0x89, 'P', 'N', 'G', 0, 0, 0, 1},
+		"removed.txt":   []byte("gone\n"),
+	}
+	v2 := map[string][]byte{
+		"metadata.json": []byte("{\n  \"title\": \"New\",\n  \"year\": 2020\n}\n"),
+		"image.png":     {0x89, 'P', 'N', 'G', 0, 0, 0, 2},
+		"added.txt":     []byte("new file\n"),
+	}
+	h := testHandlerWithVersions(t, objID, v1, v2)
+	diffPath := func(version, name string) string {
+		return historyPath(objID, version) + "/diff/" + name
+	}
+
+	t.Run("modified text file", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, diffPath("v2", "metadata.json"))
+		be.Equal(t, http.StatusOK, w.Code)
+		body := w.Body.String()
+		be.In(t, "@@ -1,4 +1,4 @@", body)
+		be.In(t, `class="diff-delete"`, body)
+		be.In(t, `class="diff-add"`, body)
+		be.In(t, `<span class="tok-key">&#34;title&#34;</span>`, body)
+		be.In(t, `<span class="tok-string">&#34;New&#34;</span>`, body)
+		be.In(t, `href="`+diffPath("v2", "metadata.json")+`?view=split"`, body)
+	})
+
+	t.Run("side-by-side view", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, diffPath("v2", "metadata.json")+"?view=split")
+		be.Equal(t, http.StatusOK, w.Code)
+		be.In(t, `class="diff-split"`, w.Body.String())
+	})
+
+	t.Run("json", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, diffPath("v2", "metadata.json"), nil)
+		req.Header.Set("Accept", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		be.Equal(t, http.StatusOK, w.Code)
+		var diff struct {
+			From    string `json:"from"`
+			To      string `json:"to"`
+			Added   int    `json:"added"`
+			Deleted int    `json:"deleted"`
+			Hunks   []struct {
+				Lines []struct {
+					Op   string `json:"op"`
+					Text string `json:"text"`
+				} `json:"lines"`
+			} `json:"hunks"`
+		}
+		be.NilErr(t, json.Unmarshal(w.Body.Bytes(), &diff))
+		be.Equal(t, "v1", diff.From)
+		be.Equal(t, "v2", diff.To)
+		be.Equal(t, 1, diff.Added)
+		be.Equal(t, 1, diff.Deleted)
+		be.Equal(t, 1, len(diff.Hunks))
+		be.Equal(t, "delete", diff.Hunks[0].Lines[1].Op)
+		be.Equal(t, `  "title": "Old",`, diff.Hunks[0].Lines[1].Text)
+	})
+
+	t.Run("added and deleted files", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, diffPath("v2", "added.txt"))
+		be.Equal(t, http.StatusOK, w.Code)
+		be.In(t, "@@ -0,0 +1,1 @@", w.Body.String())
+		w = doRequest(t, h, http.MethodGet, diffPath("v2", "removed.txt"))
+		be.Equal(t, http.StatusOK, w.Code)
+		be.In(t, "@@ -1,1 +0,0 @@", w.Body.String())
+		w = doRequest(t, h, http.MethodGet, diffPath("v1", "removed.txt"))
+		be.Equal(t, http.StatusOK, w.Code)
+		be.In(t, "<span class=\"version-num\">empty</span>", w.Body.String())
+	})
+
+	t.Run("binary file", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, diffPath("v2", "image.png"))
+		be.Equal(t, http.StatusOK, w.Code)
+		body := w.Body.String()
+		be.In(t, "Binary files aren't compared line-by-line", body)
+		be.In(t, `class="digest"`, body)
+	})
+
+	t.Run("version changes link to diffs", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, historyPath(objID, "v2"))
+		be.Equal(t, http.StatusOK, w.Code)
+		be.In(t, `href="`+diffPath("v2", "metadata.json")+`"`, w.Body.String())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, diffPath("v2", "missing.txt"))
+		be.Equal(t, http.StatusNotFound, w.Code)
+		w = doRequest(t, h, http.MethodGet, diffPath("v3", "metadata.json"))
+		be.Equal(t, http.StatusNotFound, w.Code)
+		w = doRequest(t, h, http.MethodGet, diffPath("head", "metadata.json"))
+		be.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestCompareVersions(t *testing.T) {
+	objID := "compare-object"
+	h := testHandlerWithVersions(t, objID,
+		map[string][]byte{"a.txt": []byte("one\n"), "b.txt": []byte("b\n")},
+		map[string][]byte{"a.txt": []byte("two\n"), "b.txt": []byte("b\n")},
+		map[string][]byte{"a.txt": []byte("three\n"), "c.txt": []byte("c\n")},
+	)
+	comparePath := "/history/" + objID + "/compare"
+
+	t.Run("default comparison", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, comparePath)
+		be.Equal(t, http.StatusFound, w.Code)
+		be.Equal(t, comparePath+"/v2...v3", w.Header().Get("Location"))
+	})
+
+	t.Run("version picker form", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, comparePath+"?from=v1&to=v3")
+		be.Equal(t, http.StatusFound, w.Code)
+		be.Equal(t, comparePath+"/v1...v3", w.Header().Get("Location"))
+		w = doRequest(t, h, http.MethodGet, comparePath+"?from=v001&to=v03")
+		be.Equal(t, http.StatusFound, w.Code)
+		be.Equal(t, comparePath+"/v1...v3", w.Header().Get("Location"))
+		w = doRequest(t, h, http.MethodGet, comparePath+"?from=bad&to=v3")
+		be.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("versions use the object's padding", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, comparePath+"/v001...v3")
+		be.Equal(t, http.StatusMovedPermanently, w.Code)
+		be.Equal(t, comparePath+"/v1...v3", w.Header().Get("Location"))
+	})
+
+	t.Run("compare versions", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, comparePath+"/v1...v3")
+		be.Equal(t, http.StatusOK, w.Code)
+		body := w.Body.String()
+		// picker selects compared versions
+		be.In(t, `<option value="v1" selected>`, body)
+		be.In(t, `<option value="v3" selected>`, body)
+		// diff links and content links for each version
+		be.In(t, `href="/history/`+objID+`/v3/diff/a.txt?from=v1"`, body)
+		be.In(t, `href="/object/`+objID+`/v1/a.txt?preview"`, body)
+		be.In(t, `href="/object/`+objID+`/v3/a.txt?preview"`, body)
+		be.In(t, "b.txt", body)
+		be.In(t, "c.txt", body)
+		be.In(t, `href="`+comparePath+`/v3...v1"`, body)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, comparePath+"/v1...v3", nil)
+		req.Header.Set("Accept", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		be.Equal(t, http.StatusOK, w.Code)
+		var page struct {
+			From     string `json:"from"`
+			To       string `json:"to"`
+			FileTree struct {
+				Children []struct {
+					Name    string `json:"name"`
+					ModType string `json:"mod_type"`
+				} `json:"children"`
+			} `json:"file_tree"`
+		}
+		be.NilErr(t, json.Unmarshal(w.Body.Bytes(), &page))
+		be.Equal(t, "v1", page.From)
+		be.Equal(t, "v3", page.To)
+		be.Equal(t, 3, len(page.FileTree.Children))
+	})
+
+	t.Run("diff between versions", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, "/history/"+objID+"/v3/diff/a.txt?from=v1")
+		be.Equal(t, http.StatusOK, w.Code)
+		body := w.Body.String()
+		be.In(t, "<code>one</code>", body)
+		be.In(t, "<code>three</code>", body)
+		be.In(t, `href="/history/`+objID+`/v3/diff/a.txt?from=v1&amp;view=split"`, body)
+		be.In(t, `href="`+comparePath+`/v1...v3"`, body)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			path   string
+			status int
+		}{
+			{comparePath + "/v1..v3", http.StatusBadRequest},
+			{comparePath + "/v1...head", http.StatusBadRequest},
+			{comparePath + "/v1...v4", http.StatusNotFound},
+			{"/history/nonexistent/compare/v1...v2", http.StatusNotFound},
+			{"/history/nonexistent/compare", http.StatusNotFound},
+			{"/history/" + objID + "/v3/diff/a.txt?from=vX", http.StatusBadRequest},
+			{"/history/" + objID + "/v3/diff/a.txt?from=v9", http.StatusNotFound},
+		}
+		for _, tt := range tests {
+			w := doRequest(t, h, http.MethodGet, tt.path)
+			be.Equal(t, tt.status, w.Code)
+		}
+	})
+}
+
+func TestRenamedFiles(t *testing.T) {
+	objID := "renamed-object"
+	h := testHandlerWithVersions(t, objID,
+		map[string][]byte{"old/notes.txt": []byte("line 1\nline 2\n"), "keep.txt": []byte("keep\n")},
+		map[string][]byte{"new/notes.txt": []byte("line 1\nline 2\n"), "keep.txt": []byte("keep\n"), "copy.txt": []byte("keep\n")},
+	)
+
+	t.Run("version changes", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, "/history/"+objID+"/v2")
+		be.Equal(t, http.StatusOK, w.Code)
+		body := w.Body.String()
+		be.In(t, "renamed from old/notes.txt", body)
+		be.In(t, "copied from keep.txt", body)
+		be.In(t, `href="#file-renamed"`, body)
+		be.In(t, `href="#file-copied"`, body)
+		// older version's content is at the source path
+		be.In(t, `href="/object/`+objID+`/v1/old/notes.txt?preview"`, body)
+		be.In(t, `href="/object/`+objID+`/v1/keep.txt?preview"`, body)
+		be.NotIn(t, `href="/object/`+objID+`/v1/new/notes.txt?preview"`, body)
+	})
+
+	t.Run("diff with source file", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, "/history/"+objID+"/v2/diff/new/notes.txt")
+		be.Equal(t, http.StatusOK, w.Code)
+		body := w.Body.String()
+		be.In(t, "from old/notes.txt", body)
+		be.In(t, "The file's content didn't change.", body)
+	})
+
+	t.Run("api", func(t *testing.T) {
+		var changes struct {
+			Changes []struct {
+				Path   string `json:"path"`
+				Type   string `json:"type"`
+				Source string `json:"source"`
+			} `json:"changes"`
+		}
+		decodeAPI(t, h, apiPath("objects", objID, "versions", "v2", "changes"), http.StatusOK, &changes)
+		be.Equal(t, 2, len(changes.Changes))
+		be.Equal(t, "copy.txt", changes.Changes[0].Path)
+		be.Equal(t, "copied", changes.Changes[0].Type)
+		be.Equal(t, "keep.txt", changes.Changes[0].Source)
+		be.Equal(t, "new/notes.txt", changes.Changes[1].Path)
+		be.Equal(t, "renamed", changes.Changes[1].Type)
+		be.Equal(t, "old/notes.txt", changes.Changes[1].Source)
+	})
+}
@@ -0,0 +1,18 @@
+package template
+
+import "context"
+
+type uploadsKey struct{}
+
+// WithUploads returns a copy of ctx for rendering pages with links to the
+// upload form.
+func WithUploads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, uploadsKey{}, true)
+}
+
+// uploadsEnabled reports whether pages rendered with ctx link to the upload
+// form.
+func uploadsEnabled(ctx context.Context) bool {
+	enabled, _ := ctx.Value(uploadsKey{}).(bool)
+	return enabled
+}
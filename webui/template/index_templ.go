@@ -8,8 +8,11 @@ package template
 import "github.com/a-h/templ"
 import templruntime "github.com/a-h/templ/runtime"
 
+import "github.com/srerickson/ocfl-services/webui/utils"
+
 // Index layout is used by the index route (no object ID). It just displays
-// a form for looking an object using an ID.
+// a form for looking an object using an ID, with suggestions for matching
+// IDs as the user types.
 func Index() templ.Component {
 	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
@@ -43,7 +46,33 @@ func Index() templ.Component {
 				}()
 			}
 			ctx = templ.InitializeContext(ctx)
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<div class=\"object-lookup\"><h1>Find an object</h1><form id=\"searchForm\" role=\"search\" aria-label=\"Object search\"><label for=\"objectId\" class=\"visually-hidden\">Object ID</label> <input type=\"text\" id=\"objectId\" name=\"objectId\" placeholder=\"Object ID\" class=\"\" required aria-required=\"true\" aria-describedby=\"objectId-desc\"> <span id=\"objectId-desc\" class=\"visually-hidden\">Enter the unique identifier for the OCFL object you want to find</span> <button type=\"submit\" aria-label=\"Search for object\">→</button></form></div><script>\n\t\t\tdocument.getElementById('searchForm').addEventListener('submit', function(e) {\n\t\t\t\te.preventDefault();\n\t\t\t\tconst objectId = document.getElementById('objectId').value;\n\t\t\t\tconst encodedId = encodeURIComponent(objectId);\n\t\t\t\twindow.location.href = `/object/${encodedId}/head/`;\n\t\t\t});\n\t\t</script>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<div class=\"object-lookup\"><h1>Find an object</h1><form id=\"searchForm\" action=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var3 templ.SafeURL
+			templ_7745c5c3_Var3, templ_7745c5c3_Err = templ.JoinURLErrs(utils.LinkRootPath(ctx, "/search"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `webui/template/index.templ`, Line: 12, Col: 68}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var3))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "\" method=\"get\" role=\"search\" aria-label=\"Object search\"><label for=\"objectId\" class=\"visually-hidden\">Object ID</label> <input type=\"hidden\" name=\"lookup\" value=\"1\"> <input type=\"text\" id=\"objectId\" name=\"q\" placeholder=\"Object ID\" class=\"\" required aria-required=\"true\" aria-describedby=\"objectId-desc\" autocomplete=\"off\" list=\"objectId-suggestions\" hx-get=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var4 string
+			templ_7745c5c3_Var4, templ_7745c5c3_Err = templ.JoinStringErrs(string(utils.LinkRootPath(ctx, "/search/suggest")))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `webui/template/index.templ`, Line: 26, Col: 64}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var4))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 3, "\" hx-trigger=\"input changed delay:200ms\" hx-target=\"#objectId-suggestions\"> <datalist id=\"objectId-suggestions\"></datalist> <span id=\"objectId-desc\" class=\"visually-hidden\">Enter the unique identifier for the OCFL object you want to find</span> <button type=\"submit\" aria-label=\"Search for object\">→</button></form></div>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
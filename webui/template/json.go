@@ -0,0 +1,66 @@
+package template
+
+import (
+	"encoding/json"
+
+	"github.com/srerickson/ocfl-go"
+)
+
+// Page data types are also used for JSON responses. ocfl.VNum doesn't
+// implement json.Marshaler, so types with version numbers encode them as
+// strings ("v1", "v002") here.
+
+func (p *ObjectFiles) MarshalJSON() ([]byte, error) {
+	type alias ObjectFiles
+	return json.Marshal(&struct {
+		*alias
+		Version string `json:"version"`
+	}{alias: (*alias)(p), Version: vnumString(p.VNum)})
+}
+
+func (v *VersionBrief) MarshalJSON() ([]byte, error) {
+	type alias VersionBrief
+	return json.Marshal(&struct {
+		*alias
+		Version string `json:"version"`
+	}{alias: (*alias)(v), Version: vnumString(v.VNum)})
+}
+
+func (p *VersionChanges) MarshalJSON() ([]byte, error) {
+	type alias VersionChanges
+	return json.Marshal(&struct {
+		*alias
+		NextVersion string `json:"next_version,omitempty"`
+		PrevVersion string `json:"prev_version,omitempty"`
+	}{
+		alias:       (*alias)(p),
+		NextVersion: vnumString(p.NextVNum),
+		PrevVersion: vnumString(p.PrevVNum),
+	})
+}
+
+// vnumString returns vn as a string, or "" if vn is the zero value.
+func vnumString(vn ocfl.VNum) string {
+	if vn.IsZero() {
+		return ""
+	}
+	return vn.String()
+}
+
+func (p *FileDiff) MarshalJSON() ([]byte, error) {
+	type alias FileDiff
+	return json.Marshal(&struct {
+		*alias
+		From string `json:"from"`
+		To   string `json:"to"`
+	}{alias: (*alias)(p), From: vnumString(p.FromVNum), To: vnumString(p.ToVNum)})
+}
+
+func (p *VersionCompare) MarshalJSON() ([]byte, error) {
+	type alias VersionCompare
+	return json.Marshal(&struct {
+		*alias
+		From string `json:"from"`
+		To   string `json:"to"`
+	}{alias: (*alias)(p), From: vnumString(p.FromVNum), To: vnumString(p.ToVNum)})
+}
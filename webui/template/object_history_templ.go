@@ -15,16 +15,16 @@ import (
 )
 
 type ObjectHistory struct {
-	ObjectID string
-	Versions []*VersionBrief
+	ObjectID string          `json:"object_id"`
+	Versions []*VersionBrief `json:"versions"`
 }
 
 type VersionBrief struct {
-	VNum     ocfl.VNum
-	Message  string
-	UserName string
-	UserAddr string
-	Created  time.Time
+	VNum     ocfl.VNum `json:"-"` // encoded as "version"
+	Message  string    `json:"message"`
+	UserName string    `json:"user_name"`
+	UserAddr string    `json:"user_address"`
+	Created  time.Time `json:"created"`
 }
 
 func ObjectHistoryPage(page *ObjectHistory) templ.Component {
@@ -118,9 +118,9 @@ func objectVersionRow(objID string, entry *VersionBrief) templ.Component {
 			return templ_7745c5c3_Err
 		}
 		var templ_7745c5c3_Var4 templ.SafeURL
-		templ_7745c5c3_Var4, templ_7745c5c3_Err = templ.JoinURLErrs(utils.LinkVersionChanges(objID, entry.VNum.String()))
+		templ_7745c5c3_Var4, templ_7745c5c3_Err = templ.JoinURLErrs(utils.LinkVersionChanges(ctx, objID, entry.VNum.String()))
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `webui/template/object_history.templ`, Line: 49, Col: 65}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `webui/template/object_history.templ`, Line: 49, Col: 70}
 		}
 		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var4))
 		if templ_7745c5c3_Err != nil {
@@ -183,9 +183,9 @@ func objectVersionRow(objID string, entry *VersionBrief) templ.Component {
 			return templ_7745c5c3_Err
 		}
 		var templ_7745c5c3_Var9 templ.SafeURL
-		templ_7745c5c3_Var9, templ_7745c5c3_Err = templ.JoinURLErrs(utils.LinkObjectFiles(objID, entry.VNum.String(), ".", true))
+		templ_7745c5c3_Var9, templ_7745c5c3_Err = templ.JoinURLErrs(utils.LinkObjectFiles(ctx, objID, entry.VNum.String(), ".", true))
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `webui/template/object_history.templ`, Line: 61, Col: 73}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `webui/template/object_history.templ`, Line: 61, Col: 78}
 		}
 		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var9))
 		if templ_7745c5c3_Err != nil {
@@ -0,0 +1,287 @@
+package utils
+
+import (
+	"path"
+	"strings"
+	"unicode"
+)
+
+// Syntax highlighting languages
+const (
+	LangJSON = "json"
+	LangXML  = "xml"
+	LangYAML = "yaml"
+)
+
+// Token classes
+const (
+	TokenKey     = "tok-key"
+	TokenString  = "tok-string"
+	TokenNumber  = "tok-number"
+	TokenKeyword = "tok-keyword" // true, false, null
+	TokenComment = "tok-comment"
+	TokenTag     = "tok-tag"  // XML element names and brackets
+	TokenAttr    = "tok-attr" // XML attribute names
+)
+
+// Token is a span of text in a highlighted line. Class is empty for text that
+// isn't highlighted.
+type Token struct {
+	Class string
+	Text  string
+}
+
+// HighlightLanguage returns the syntax highlighting language for a file with
+// the given name and media type, or an empty string if the file's syntax
+// isn't supported.
+func HighlightLanguage(name string, mediaType string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".json", ".jsonld", ".geojson":
+		return LangJSON
+	case ".xml", ".xsd", ".xsl", ".xslt", ".rdf", ".html", ".htm", ".svg":
+		return LangXML
+	case ".yaml", ".yml", ".cff":
+		return LangYAML
+	}
+	base, _, _ := strings.Cut(mediaType, ";")
+	base = strings.TrimSpace(base)
+	switch {
+	case base == "application/json", strings.HasSuffix(base, "+json"):
+		return LangJSON
+	case base == "application/xml", base == "text/xml", base == "text/html",
+		strings.HasSuffix(base, "+xml"):
+		return LangXML
+	case base == "application/yaml", base == "text/yaml":
+		return LangYAML
+	}
+	return ""
+}
+
+// HighlightLine splits a line of text into tokens for syntax highlighting.
+// Lines are highlighted independently, so constructs that span lines (e.g.,
+// multi-line XML comments) are only highlighted on their first line. If lang
+// isn't supported, the line is returned as a single token.
+func HighlightLine(lang string, line string) []Token {
+	var tokens []Token
+	switch lang {
+	case LangJSON:
+		tokens = highlightJSON(line)
+	case LangXML:
+		tokens = highlightXML(line)
+	case LangYAML:
+		tokens = highlightYAML(line)
+	default:
+		return []Token{{Text: line}}
+	}
+	return mergeTokens(tokens)
+}
+
+func highlightJSON(line string) []Token {
+	var tokens []Token
+	for i := 0; i < len(line); {
+		c := line[i]
+		switch {
+		case c == '"':
+			end := quotedEnd(line, i)
+			class := TokenString
+			if strings.HasPrefix(strings.TrimLeft(line[end:], " \t"), ":") {
+				class = TokenKey
+			}
+			tokens = append(tokens, Token{Class: class, Text: line[i:end]})
+			i = end
+		case c == '-' || isDigit(c):
+			end := i + 1
+			for end < len(line) && strings.IndexByte("0123456789.eE+-", line[end]) >= 0 {
+				end++
+			}
+			tokens = append(tokens, Token{Class: TokenNumber, Text: line[i:end]})
+			i = end
+		case isWordStart(c):
+			end := wordEnd(line, i)
+			class := ""
+			switch line[i:end] {
+			case "true", "false", "null":
+				class = TokenKeyword
+			}
+			tokens = append(tokens, Token{Class: class, Text: line[i:end]})
+			i = end
+		default:
+			tokens = append(tokens, Token{Text: line[i : i+1]})
+			i++
+		}
+	}
+	return tokens
+}
+
+func highlightXML(line string) []Token {
+	var tokens []Token
+	for i := 0; i < len(line); {
+		switch {
+		case strings.HasPrefix(line[i:], "<!--"):
+			end := len(line)
+			if n := strings.Index(line[i+4:], "-->"); n >= 0 {
+				end = i + 4 + n + 3
+			}
+			tokens = append(tokens, Token{Class: TokenComment, Text: line[i:end]})
+			i = end
+		case line[i] == '<':
+			i = xmlTag(line, i, &tokens)
+		default:
+			end := strings.IndexByte(line[i:], '<')
+			if end < 0 {
+				end = len(line)
+			} else {
+				end += i
+			}
+			tokens = append(tokens, Token{Text: line[i:end]})
+			i = end
+		}
+	}
+	return tokens
+}
+
+// xmlTag adds tokens for the tag starting at line[i] and returns the index
+// after the tag (or the end of the line).
+func xmlTag(line string, i int, tokens *[]Token) int {
+	// bracket and element name: "<", "</", "<?", "<!"
+	end := i + 1
+	if end < len(line) && strings.IndexByte("/?!", line[end]) >= 0 {
+		end++
+	}
+	for end < len(line) && !unicode.IsSpace(rune(line[end])) && strings.IndexByte("/>?", line[end]) < 0 {
+		end++
+	}
+	*tokens = append(*tokens, Token{Class: TokenTag, Text: line[i:end]})
+	i = end
+	for i < len(line) {
+		c := line[i]
+		switch {
+		case c == '>' || (c == '/' || c == '?') && i+1 < len(line) && line[i+1] == '>':
+			end := strings.IndexByte(line[i:], '>') + i + 1
+			*tokens = append(*tokens, Token{Class: TokenTag, Text: line[i:end]})
+			return end
+		case c == '"' || c == '\'':
+			end := quotedEnd(line, i)
+			*tokens = append(*tokens, Token{Class: TokenString, Text: line[i:end]})
+			i = end
+		case c == '=' || unicode.IsSpace(rune(c)):
+			*tokens = append(*tokens, Token{Text: line[i : i+1]})
+			i++
+		default:
+			end := i + 1
+			for end < len(line) && !unicode.IsSpace(rune(line[end])) && strings.IndexByte("=/>?\"'", line[end]) < 0 {
+				end++
+			}
+			*tokens = append(*tokens, Token{Class: TokenAttr, Text: line[i:end]})
+			i = end
+		}
+	}
+	return i
+}
+
+func highlightYAML(line string) []Token {
+	var tokens []Token
+	rest := line
+	// indentation and sequence markers
+	indent := len(rest) - len(strings.TrimLeft(rest, " \t"))
+	for strings.HasPrefix(rest[indent:], "- ") {
+		indent += 2
+		indent += len(rest[indent:]) - len(strings.TrimLeft(rest[indent:], " \t"))
+	}
+	tokens = append(tokens, Token{Text: rest[:indent]})
+	rest = rest[indent:]
+	if strings.HasPrefix(rest, "#") {
+		return append(tokens, Token{Class: TokenComment, Text: rest})
+	}
+	// mapping key
+	if key, _, ok := strings.Cut(rest, ":"); ok && !strings.ContainsAny(key, "\"'#{[") {
+		after := rest[len(key)+1:]
+		if after == "" || after[0] == ' ' || after[0] == '\t' {
+			tokens = append(tokens, Token{Class: TokenKey, Text: key}, Token{Text: ":"})
+			rest = after
+		}
+	}
+	// value and trailing comment
+	for i := 0; i < len(rest); {
+		c := rest[i]
+		switch {
+		case c == '#' && (i == 0 || rest[i-1] == ' ' || rest[i-1] == '\t'):
+			return append(tokens, Token{Class: TokenComment, Text: rest[i:]})
+		case c == '"' || c == '\'':
+			end := quotedEnd(rest, i)
+			tokens = append(tokens, Token{Class: TokenString, Text: rest[i:end]})
+			i = end
+		case c == ' ' || c == '\t':
+			tokens = append(tokens, Token{Text: rest[i : i+1]})
+			i++
+		default:
+			// scalar ends at a comment or the end of the line
+			end := len(rest)
+			if n := strings.Index(rest[i:], " #"); n >= 0 {
+				end = i + n
+			}
+			value := strings.TrimRight(rest[i:end], " \t")
+			tokens = append(tokens, Token{Class: yamlScalarClass(value), Text: value})
+			i += len(value)
+		}
+	}
+	return tokens
+}
+
+// yamlScalarClass returns the token class for an unquoted YAML scalar.
+func yamlScalarClass(value string) string {
+	switch strings.ToLower(value) {
+	case "true", "false", "yes", "no", "null", "~":
+		return TokenKeyword
+	}
+	if value != "" && (isDigit(value[0]) || value[0] == '-' && len(value) > 1 && isDigit(value[1])) {
+		if strings.Trim(value, "0123456789.-+eE_:") == "" {
+			return TokenNumber
+		}
+	}
+	return ""
+}
+
+// quotedEnd returns the index after the quoted string starting at s[i], or
+// len(s) if the string isn't terminated.
+func quotedEnd(s string, i int) int {
+	quote := s[i]
+	for j := i + 1; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			if quote == '"' {
+				j++
+			}
+		case quote:
+			return j + 1
+		}
+	}
+	return len(s)
+}
+
+func wordEnd(s string, i int) int {
+	for i < len(s) && (isWordStart(s[i]) || isDigit(s[i])) {
+		i++
+	}
+	return i
+}
+
+func isDigit(c byte) bool     { return c >= '0' && c <= '9' }
+func isWordStart(c byte) bool { return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' }
+
+// mergeTokens joins adjacent tokens with the same class and drops empty
+// tokens.
+func mergeTokens(tokens []Token) []Token {
+	merged := make([]Token, 0, len(tokens))
+	for _, tok := range tokens {
+		if tok.Text == "" {
+			continue
+		}
+		if n := len(merged); n > 0 && merged[n-1].Class == tok.Class {
+			merged[n-1].Text += tok.Text
+			continue
+		}
+		merged = append(merged, tok)
+	}
+	return merged
+}
@@ -1,14 +1,70 @@
 package utils
 
 import (
+	"context"
 	"io/fs"
 	"net/url"
 	"path"
+	"strconv"
+	"strings"
 
 	"github.com/a-h/templ"
 )
 
-func LinkObjectFiles(objID string, version string, logicalPath string, isDir bool) templ.SafeURL {
+// RootsPath is the URL path under which storage roots are served when there
+// are several: a root's pages are under RootsPath + name.
+const RootsPath = "/r/"
+
+type rootKey struct{}
+
+// rootInfo is the storage root for rendering pages.
+type rootInfo struct {
+	name string // empty if the root is the only one
+	path string // URL path prefix for the root's pages
+}
+
+// WithRoot returns a copy of ctx for rendering pages of a storage root. Links
+// built with ctx are prefixed with path, the URL path that the root is served
+// under: for example, "/r/main". If the root is the only one, it is served at
+// "/" and name and path are empty.
+func WithRoot(ctx context.Context, name string, path string) context.Context {
+	return context.WithValue(ctx, rootKey{}, rootInfo{name: name, path: path})
+}
+
+// InRoot reports whether ctx is for rendering pages of a storage root. It
+// returns false for the list of storage roots.
+func InRoot(ctx context.Context) bool {
+	_, ok := ctx.Value(rootKey{}).(rootInfo)
+	return ok
+}
+
+// RootName returns the name of the storage root for ctx. It is empty if the
+// root is the only one.
+func RootName(ctx context.Context) string {
+	root, _ := ctx.Value(rootKey{}).(rootInfo)
+	return root.name
+}
+
+// RootPath returns the URL path prefix for pages of the storage root for ctx.
+// It is empty if the root is the only one.
+func RootPath(ctx context.Context) string {
+	root, _ := ctx.Value(rootKey{}).(rootInfo)
+	return root.path
+}
+
+// LinkRootPath returns a link to p, an absolute path in the URL space of the
+// storage root for ctx.
+func LinkRootPath(ctx context.Context, p string) templ.SafeURL {
+	return templ.URL(RootPath(ctx) + p)
+}
+
+// LinkRoot returns a link to the home page of the named storage root, when
+// there are several.
+func LinkRoot(name string) templ.SafeURL {
+	return templ.URL(RootsPath + url.PathEscape(name) + "/")
+}
+
+func LinkObjectFiles(ctx context.Context, objID string, version string, logicalPath string, isDir bool) templ.SafeURL {
 	if objID == "" {
 		return ""
 	}
@@ -16,23 +72,156 @@ func LinkObjectFiles(objID string, version string, logicalPath string, isDir boo
 		version = "head"
 	}
 	objectPath := "/object/" + url.PathEscape(objID) + "/" + version + "/"
-	if fs.ValidPath(logicalPath) {
+	// "." is the version's root directory, which is objectPath
+	if fs.ValidPath(logicalPath) && logicalPath != "." {
 		objectPath += path.Clean(logicalPath)
 		if isDir {
 			objectPath += "/" // trailing slash for directories
 		}
 	}
-	return templ.URL(objectPath)
+	return LinkRootPath(ctx, objectPath)
 }
 
-func LinkObjectHistory(objID string) templ.SafeURL {
-	return templ.URL("/history/" + url.PathEscape(objID))
+// LinkObjectArchive returns a link for downloading a directory in an object
+// version as an archive in the given format ("zip" or "tar.gz").
+func LinkObjectArchive(ctx context.Context, objID string, version string, dir string, format string) templ.SafeURL {
+	link := LinkObjectFiles(ctx, objID, version, dir, true)
+	if link == "" {
+		return ""
+	}
+	return templ.URL(string(link) + "?archive=" + url.QueryEscape(format))
+}
+
+func LinkObjectHistory(ctx context.Context, objID string) templ.SafeURL {
+	return LinkRootPath(ctx, "/history/"+url.PathEscape(objID))
 }
 
-func LinkVersionChanges(objID string, version string) templ.SafeURL {
-	return templ.URL("/history/" + url.PathEscape(objID) + "/" + version)
+func LinkVersionChanges(ctx context.Context, objID string, version string) templ.SafeURL {
+	return LinkRootPath(ctx, "/history/"+url.PathEscape(objID)+"/"+version)
+}
+
+// LinkFileDiff returns a link to the line-level changes to a file between two
+// object versions. If fromVersion is empty, the file is compared with the
+// previous version.
+func LinkFileDiff(ctx context.Context, objID string, fromVersion string, toVersion string, logicalPath string) templ.SafeURL {
+	if !fs.ValidPath(logicalPath) || logicalPath == "." {
+		return ""
+	}
+	link := "/history/" + url.PathEscape(objID) + "/" + toVersion + "/diff/" + escapePath(logicalPath)
+	if fromVersion != "" {
+		link += "?from=" + url.QueryEscape(fromVersion)
+	}
+	return LinkRootPath(ctx, link)
+}
+
+// LinkCompareVersions returns a link to the changes between two object
+// versions. If either version is empty, the link is to the version picker's
+// default comparison.
+func LinkCompareVersions(ctx context.Context, objID string, fromVersion string, toVersion string) templ.SafeURL {
+	link := "/history/" + url.PathEscape(objID) + "/compare"
+	if fromVersion == "" || toVersion == "" {
+		return LinkRootPath(ctx, link)
+	}
+	return LinkRootPath(ctx, link+"/"+fromVersion+"..."+toVersion)
+}
+
+// escapePath escapes each element of a slash-separated path.
+func escapePath(name string) string {
+	elems := strings.Split(name, "/")
+	for i, e := range elems {
+		elems[i] = url.PathEscape(e)
+	}
+	return strings.Join(elems, "/")
+}
+
+func LinkObjectInventory(ctx context.Context, objID string) templ.SafeURL {
+	return LinkRootPath(ctx, "/inventory/"+url.PathEscape(objID))
+}
+
+// LinkValidation returns a link to the object's validation report.
+func LinkValidation(ctx context.Context, objID string) templ.SafeURL {
+	return LinkRootPath(ctx, "/validation/"+url.PathEscape(objID))
+}
+
+// LinkObjectList returns a link to a page of the object list, sorted by the
+// given field.
+func LinkObjectList(ctx context.Context, sort string, desc bool, page int) templ.SafeURL {
+	query := url.Values{}
+	if sort != "" {
+		query.Set("sort", sort)
+	}
+	if desc {
+		query.Set("order", "desc")
+	}
+	if page > 1 {
+		query.Set("page", strconv.Itoa(page))
+	}
+	if len(query) == 0 {
+		return LinkRootPath(ctx, "/objects")
+	}
+	return LinkRootPath(ctx, "/objects?"+query.Encode())
+}
+
+// LinkSearch returns a link to a page of object ID search results.
+func LinkSearch(ctx context.Context, query string, mode string, matchCase bool, page int) templ.SafeURL {
+	vals := url.Values{}
+	vals.Set("q", query)
+	if mode != "" {
+		vals.Set("mode", mode)
+	}
+	if matchCase {
+		vals.Set("matchcase", "1")
+	}
+	if page > 1 {
+		vals.Set("page", strconv.Itoa(page))
+	}
+	return LinkRootPath(ctx, "/search?"+vals.Encode())
+}
+
+// LinkContentSearch returns a link to a page of version message, user name, or
+// path search results.
+func LinkContentSearch(ctx context.Context, query string, field string, headOnly bool, page int) templ.SafeURL {
+	vals := url.Values{}
+	vals.Set("q", query)
+	vals.Set("in", field)
+	if headOnly {
+		vals.Set("head", "1")
+	}
+	if page > 1 {
+		vals.Set("page", strconv.Itoa(page))
+	}
+	return LinkRootPath(ctx, "/search?"+vals.Encode())
+}
+
+// LinkUpload returns a link to the form for uploading files to a new version
+// of the object. If objID is empty, the form is for a new object.
+func LinkUpload(ctx context.Context, objID string) templ.SafeURL {
+	if objID == "" {
+		return LinkRootPath(ctx, "/upload")
+	}
+	return LinkRootPath(ctx, "/upload?id="+url.QueryEscape(objID))
+}
+
+// LinkDrafts returns a link to the list of the principal's drafts. If objID
+// isn't empty, the form for opening a draft is filled in with it.
+func LinkDrafts(ctx context.Context, objID string) templ.SafeURL {
+	if objID == "" {
+		return LinkRootPath(ctx, "/drafts")
+	}
+	return LinkRootPath(ctx, "/drafts?id="+url.QueryEscape(objID))
+}
+
+// LinkDraft returns a link to a directory in the draft's state.
+func LinkDraft(ctx context.Context, draftID int64, dir string) templ.SafeURL {
+	link := "/draft/" + strconv.FormatInt(draftID, 10) + "/"
+	if fs.ValidPath(dir) && dir != "." {
+		link += escapePath(path.Clean(dir)) + "/"
+	}
+	return LinkRootPath(ctx, link)
 }
 
-func LinkObjectInventory(objID string) templ.SafeURL {
-	return templ.URL("/inventory/" + url.PathEscape(objID))
+// LinkDraftAction returns the link for a draft form's action: "files",
+// "rename", "remove", "commit", or "discard".
+func LinkDraftAction(ctx context.Context, draftID int64, action string) templ.SafeURL {
+	return LinkRootPath(ctx, "/draft/"+strconv.FormatInt(draftID, 10)+"/"+action)
 }
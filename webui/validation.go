@@ -0,0 +1,187 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/srerickson/ocfl-services/access"
+	"github.com/srerickson/ocfl-services/webui/template"
+	"github.com/srerickson/ocfl-services/webui/utils"
+)
+
+// default and max number of reports in an API list of invalid objects
+const (
+	apiInvalidObjectLimit    = 50
+	apiInvalidObjectMaxLimit = 1000
+)
+
+type apiValidation struct {
+	ObjectID    string                  `json:"object_id"`
+	StoragePath string                  `json:"storage_path"`
+	Valid       bool                    `json:"valid"`
+	ValidatedAt time.Time               `json:"validated_at"`
+	Messages    []*apiValidationMessage `json:"messages,omitempty"` // omitted from lists of invalid objects
+}
+
+type apiValidationMessage struct {
+	Level   string `json:"level"`          // "error" or "warning"
+	Code    string `json:"code,omitempty"` // OCFL validation code; omitted if not known
+	Message string `json:"message"`
+}
+
+type apiInvalidObjects struct {
+	Objects []*apiValidation `json:"objects"`
+}
+
+// HandleGetValidation renders the object's most recent validation report.
+// Admins are shown a form for validating the object again.
+func HandleGetValidation(svc *access.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		id := r.PathValue("id")
+		varyAccept(w)
+		report, err := svc.ObjectValidation(ctx, id)
+		if err != nil {
+			httpError(w, r, err.Error(), validationErrorStatus(r, svc, err))
+			return
+		}
+		page := &template.ObjectValidation{
+			ObjectID:    id,
+			CanValidate: svc.IsAdmin(ctx),
+		}
+		if report != nil {
+			page.Validated = true
+			page.Valid = report.Valid
+			page.ValidatedAt = report.ValidatedAt
+			for _, msg := range report.Messages {
+				m := &template.ValidationMessage{Code: msg.Code, Message: msg.Message}
+				switch msg.Level {
+				case access.ValidationError:
+					page.Errors = append(page.Errors, m)
+				case access.ValidationWarning:
+					page.Warnings = append(page.Warnings, m)
+				}
+			}
+		}
+		renderPage(w, r, page, template.ObjectValidationPage(page))
+	}
+}
+
+// HandleValidateObject validates the object and redirects to its report.
+func HandleValidateObject(svc *access.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		if _, err := svc.ValidateObject(r.Context(), id); err != nil {
+			http.Error(w, err.Error(), validationErrorStatus(r, svc, err))
+			return
+		}
+		http.Redirect(w, r, string(utils.LinkValidation(r.Context(), id)), http.StatusSeeOther)
+	}
+}
+
+func HandleAPIGetObjectValidation(svc *access.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		report, err := svc.ObjectValidation(r.Context(), id)
+		if err != nil {
+			writeAPIError(w, validationErrorStatus(r, svc, err), err.Error())
+			return
+		}
+		if report == nil {
+			writeAPIError(w, http.StatusNotFound, fmt.Sprintf("object %q hasn't been validated", id))
+			return
+		}
+		writeJSON(w, http.StatusOK, newAPIValidation(report))
+	}
+}
+
+func HandleAPIValidateObject(svc *access.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report, err := svc.ValidateObject(r.Context(), r.PathValue("id"))
+		if err != nil {
+			writeAPIError(w, validationErrorStatus(r, svc, err), err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, newAPIValidation(report))
+	}
+}
+
+func HandleAPIListInvalidObjects(svc *access.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit := apiInvalidObjectLimit
+		if val := r.URL.Query().Get("limit"); val != "" {
+			var err error
+			limit, err = strconv.Atoi(val)
+			if err != nil || limit < 1 || limit > apiInvalidObjectMaxLimit {
+				writeAPIError(w, http.StatusBadRequest, fmt.Sprintf("invalid limit: %q", val))
+				return
+			}
+		}
+		reports, err := svc.InvalidObjects(r.Context(), limit)
+		if err != nil {
+			apiServiceError(w, r, svc, err)
+			return
+		}
+		result := &apiInvalidObjects{Objects: make([]*apiValidation, len(reports))}
+		for i, report := range reports {
+			result.Objects[i] = newAPIValidation(report)
+		}
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+// HandleAPIValidateRoot starts validating all objects in the storage root and
+// responds without waiting for validation to finish. Validation continues if
+// the client disconnects.
+func HandleAPIValidateRoot(svc *access.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !svc.IsAdmin(r.Context()) {
+			writeAPIError(w, http.StatusForbidden, "admin permission is required")
+			return
+		}
+		// keep the principal but not the request's cancellation
+		ctx := context.WithoutCancel(r.Context())
+		go func() {
+			if _, err := svc.ValidateRoot(ctx); err != nil {
+				svc.Logger().Error("validating storage root: " + err.Error())
+			}
+		}()
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+func newAPIValidation(report *access.ValidationReport) *apiValidation {
+	v := &apiValidation{
+		ObjectID:    report.ObjectID,
+		StoragePath: report.StoragePath,
+		Valid:       report.Valid,
+		ValidatedAt: report.ValidatedAt,
+	}
+	for _, msg := range report.Messages {
+		v.Messages = append(v.Messages, &apiValidationMessage{
+			Level:   string(msg.Level),
+			Code:    msg.Code,
+			Message: msg.Message,
+		})
+	}
+	return v
+}
+
+// validationErrorStatus returns the http status code for errors from
+// validating objects or reading their reports. Unexpected errors are logged.
+func validationErrorStatus(r *http.Request, svc *access.Service, err error) int {
+	switch {
+	case errors.Is(err, access.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, access.ErrNotFound):
+		return http.StatusNotFound
+	}
+	svc.Logger().LogAttrs(r.Context(), slog.LevelError, err.Error(),
+		slog.String("path", r.URL.Path))
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,123 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/be"
+	ocflfs "github.com/srerickson/ocfl-go/fs"
+	"github.com/srerickson/ocfl-services/access"
+	"github.com/srerickson/ocfl-services/access/sqlite"
+	"github.com/srerickson/ocfl-services/internal/testutil"
+	server "github.com/srerickson/ocfl-services/webui"
+	"github.com/srerickson/ocfl-services/webui/auth"
+)
+
+func TestValidation(t *testing.T) {
+	ctx := t.Context()
+	db, err := sqlite.NewDB(filepath.Join(t.TempDir(), "test.db"))
+	be.NilErr(t, err)
+	t.Cleanup(func() { db.Close() })
+	root := testutil.FixtureRootCopy(t, filepath.Join("..", "testdata"))
+	policy := &access.Policy{
+		Admins: access.Subjects{Principals: []string{"token:admin"}},
+		Rules:  []access.Rule{{Effect: access.Allow}},
+	}
+	svc := access.NewService(root, db, "test", nil, access.WithPolicy(policy))
+	tokens, err := auth.ParseTokens(strings.NewReader("admin s3cr3t\nreader r3ad3r"))
+	be.NilErr(t, err)
+	h := server.New(svc, server.WithAuthenticators(tokens))
+	escapedID := url.PathEscape(fixtureObjectID)
+	pagePath := "/validation/" + escapedID
+
+	do := func(method, path, token string, accept string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		if accept != "" {
+			r.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+	type report struct {
+		ObjectID    string    `json:"object_id"`
+		Valid       bool      `json:"valid"`
+		ValidatedAt time.Time `json:"validated_at"`
+		Messages    []struct {
+			Level   string `json:"level"`
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"messages"`
+	}
+
+	t.Run("not validated", func(t *testing.T) {
+		w := do(http.MethodGet, pagePath, "r3ad3r", "")
+		be.Equal(t, http.StatusOK, w.Code)
+		be.In(t, "hasn't been validated", w.Body.String())
+		be.NotIn(t, "Validate Now", w.Body.String())
+		w = do(http.MethodGet, pagePath, "s3cr3t", "")
+		be.In(t, "Validate Now", w.Body.String())
+		w = do(http.MethodGet, apiPath("objects", escapedID, "validation"), "r3ad3r", "")
+		be.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("requires admin", func(t *testing.T) {
+		w := do(http.MethodPost, pagePath, "r3ad3r", "")
+		be.Equal(t, http.StatusForbidden, w.Code)
+		w = do(http.MethodPost, apiPath("objects", escapedID, "validation"), "r3ad3r", "")
+		be.Equal(t, http.StatusForbidden, w.Code)
+		w = do(http.MethodPost, apiPath("validation"), "r3ad3r", "")
+		be.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("validate from page", func(t *testing.T) {
+		w := do(http.MethodPost, pagePath, "s3cr3t", "")
+		be.Equal(t, http.StatusSeeOther, w.Code)
+		be.Equal(t, pagePath, w.Header().Get("Location"))
+		w = do(http.MethodGet, pagePath, "r3ad3r", "application/json")
+		be.Equal(t, http.StatusOK, w.Code)
+		var page struct {
+			Validated bool `json:"validated"`
+			Valid     bool `json:"valid"`
+		}
+		be.NilErr(t, json.Unmarshal(w.Body.Bytes(), &page))
+		be.True(t, page.Validated)
+		be.True(t, page.Valid)
+	})
+
+	t.Run("invalid object", func(t *testing.T) {
+		obj, err := svc.SyncObject(ctx, fixtureObjectID)
+		be.NilErr(t, err)
+		_, err = ocflfs.Write(ctx, root.FS(), path.Join(obj.StoragePath(), "v2/content/README.md"), strings.NewReader("corrupt"))
+		be.NilErr(t, err)
+		w := do(http.MethodPost, apiPath("objects", escapedID, "validation"), "s3cr3t", "")
+		be.Equal(t, http.StatusOK, w.Code)
+		var body report
+		be.NilErr(t, json.Unmarshal(w.Body.Bytes(), &body))
+		be.Equal(t, fixtureObjectID, body.ObjectID)
+		be.False(t, body.Valid)
+		be.True(t, len(body.Messages) > 0)
+		be.Equal(t, "error", body.Messages[0].Level)
+
+		w = do(http.MethodGet, apiPath("validation"), "r3ad3r", "")
+		be.Equal(t, http.StatusOK, w.Code)
+		var list struct {
+			Objects []report `json:"objects"`
+		}
+		be.NilErr(t, json.Unmarshal(w.Body.Bytes(), &list))
+		be.Equal(t, 1, len(list.Objects))
+		be.Equal(t, fixtureObjectID, list.Objects[0].ObjectID)
+
+		w = do(http.MethodGet, pagePath, "r3ad3r", "")
+		be.In(t, "The object is invalid", w.Body.String())
+		be.In(t, "<h3>Errors</h3>", w.Body.String())
+	})
+}